@@ -1,29 +1,93 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"glofox/internal/auth"
+	"glofox/internal/config"
 	"glofox/internal/constants"
 	"glofox/internal/handlers"
+	"glofox/internal/payments"
 	"glofox/internal/repository"
 	"glofox/internal/services"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	// Embed the time zone database so that studios can use any IANA zone on hosts without one
+	_ "time/tzdata"
 )
 
 func main() {
 
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
 	// Initialize repositories
-	classRepo := repository.NewClassRepo()
-	bookingRepo := repository.NewBookingRepo()
+	store, err := newStorage(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s storage: %v", cfg.Storage, err)
+	}
 
-	// Initialize service
-	service := services.NewClassService(classRepo, bookingRepo)
+	// Initialize the services of the studios, the time zone was validated when the config was loaded
+	location, _ := time.LoadLocation(cfg.TimeZone)
+	provider := payments.New(cfg.Payments)
+	studios := services.NewStudios(store.studios, provider, cfg.Payments.Timeout, location, cfg.DailyBookingLimit)
+
+	// Retry the payments the provider did not answer and refund cancelled bookings
+	if provider != nil {
+		go func() {
+			for range time.Tick(constants.PaymentSettleInterval) {
+				studios.SettlePayments()
+			}
+		}()
+	}
 
 	// Initialize handler
-	handler := handlers.NewClassHandler(service)
+	handler := handlers.NewStudioHandler(studios)
+
+	// Initialize authentication, which is only turned off for local development
+	var authenticator *auth.Authenticator
+	if cfg.Auth.Mode == constants.AuthDisabled {
+		log.Printf("Authentication is disabled, every request is accepted")
+	} else if authenticator, err = auth.New(cfg.Auth); err != nil {
+		log.Fatalf("Failed to initialize authentication: %v", err)
+	}
 
 	// Set up router with handler
-	router := handlers.SetupRouter(handler)
+	router := handlers.SetupRouter(handler, handlers.RouterOptions{
+		IdempotencyRepo: func(studioID string) (repository.IdempotencyRepository, error) {
+			repos, err := store.studios.Studio(studioID)
+			return repos.Idempotency, err
+		},
+		IdempotencyTTL: cfg.IdempotencyTTL,
+		Authenticator:  authenticator,
+		Payments:       provider,
+	})
+
+	server := &http.Server{Addr: constants.APIServerPort, Handler: router}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to run server: %v", err)
+		}
+	}()
 
-	if err := router.Run(constants.APIServerPort); err != nil {
-		log.Fatalf("Failed to run server: %v", err)
+	// Wait for a shutdown signal so the storage can be flushed before exiting
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Failed to shut down server: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		log.Printf("Failed to close storage: %v", err)
 	}
 }
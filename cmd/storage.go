@@ -0,0 +1,55 @@
+package main
+
+import (
+	"glofox/internal/config"
+	"glofox/internal/constants"
+	"glofox/internal/repository"
+)
+
+// storage holds the repositories of every studio in the configured storage backend
+type storage struct {
+	studios repository.Studios
+	close   func() error
+}
+
+// Close releases the resources held by the storage backend
+func (s storage) Close() error {
+	if s.close == nil {
+		return nil
+	}
+	return s.close()
+}
+
+// newStorage opens the storage backend selected in cfg
+func newStorage(cfg config.Config) (storage, error) {
+	switch cfg.Storage {
+	case constants.StorageFile:
+		return newFileStorage(cfg)
+	case constants.StorageSQLite:
+		db, err := repository.OpenSQLite(cfg.SQLitePath)
+		if err != nil {
+			return storage{}, err
+		}
+		return storage{studios: repository.NewSQLStudios(db), close: db.Close}, nil
+	}
+	return storage{studios: repository.NewMemoryStudios()}, nil
+}
+
+// newFileStorage opens the file backend and restores the studios stored in it
+func newFileStorage(cfg config.Config) (storage, error) {
+	store, err := repository.OpenFileStore(repository.FileStoreConfig{
+		Dir:           cfg.DataDir,
+		Durability:    repository.DurabilityMode(cfg.Durability),
+		SyncInterval:  cfg.SyncInterval,
+		SnapshotEvery: cfg.SnapshotEvery,
+	})
+	if err != nil {
+		return storage{}, err
+	}
+	studios, err := repository.NewFileStudios(store)
+	if err != nil {
+		store.Close()
+		return storage{}, err
+	}
+	return storage{studios: studios, close: store.Close}, nil
+}
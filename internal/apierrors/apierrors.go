@@ -0,0 +1,143 @@
+package apierrors
+
+import (
+	"errors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"net/http"
+)
+
+// Error is an error reported to clients with a stable machine readable code,
+// its HTTP status and the request fields that caused it
+type Error struct {
+	Code   string
+	Status int
+	Err    error
+	Fields []models.ProblemField
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Field ties err to the request fields that caused it, named as in the JSON
+// body, query string or path
+func Field(err error, fields ...string) error {
+	problemFields := make([]models.ProblemField, 0, len(fields))
+	for _, field := range fields {
+		problemFields = append(problemFields, models.ProblemField{Field: field, Message: err.Error()})
+	}
+	return WithFields(err, problemFields...)
+}
+
+// WithFields ties err to request fields that each failed for their own reason
+func WithFields(err error, fields ...models.ProblemField) error {
+	return &Error{Err: err, Fields: fields}
+}
+
+// entry is the code and HTTP status an error is reported with
+type entry struct {
+	err    error
+	code   string
+	status int
+}
+
+// registry maps the errors of the services to codes and HTTP statuses. Codes
+// are part of the API and must not change once published.
+var registry = []entry{
+	{constants.ErrInternalServer, CodeInternal, http.StatusInternalServerError},
+	{constants.ErrInvalidReq, "invalid_request", http.StatusBadRequest},
+	{constants.ErrInvalidQuery, "invalid_query", http.StatusBadRequest},
+	{constants.ErrInvalidDate, "invalid_date", http.StatusBadRequest},
+	{constants.ErrInvalidStartDate, "invalid_date", http.StatusBadRequest},
+	{constants.ErrInvalidEndDate, "invalid_date", http.StatusBadRequest},
+	{constants.ErrInvalidStartEndDate, "invalid_date_range", http.StatusBadRequest},
+	{constants.ErrInvalidCursor, "invalid_cursor", http.StatusBadRequest},
+	{constants.ErrInvalidRecurrence, "invalid_recurrence", http.StatusBadRequest},
+	{constants.ErrNoOccurrences, "no_occurrences", http.StatusBadRequest},
+	{constants.ErrInvalidSessionTime, "invalid_session_time", http.StatusBadRequest},
+	{constants.ErrSessionRequired, "session_required", http.StatusBadRequest},
+	{constants.ErrSessionNotFound, "session_not_found", http.StatusNotFound},
+	{constants.ErrInvalidTimeZone, "invalid_time_zone", http.StatusBadRequest},
+	{constants.ErrInvalidMemberName, "invalid_member_name", http.StatusBadRequest},
+	{constants.ErrInvalidInstructorName, "invalid_instructor_name", http.StatusBadRequest},
+	{constants.ErrInvalidSubstitution, "invalid_substitution", http.StatusBadRequest},
+	{constants.ErrInvalidRoom, "invalid_room", http.StatusBadRequest},
+	{constants.ErrUnknownResource, "unknown_resource", http.StatusBadRequest},
+	{constants.ErrCancelReasonMissing, "cancel_reason_missing", http.StatusBadRequest},
+	{constants.ErrInvalidIdempotencyKey, "invalid_idempotency_key", http.StatusBadRequest},
+	{constants.ErrUnauthenticated, "unauthenticated", http.StatusUnauthorized},
+	{constants.ErrInvalidCredentials, "invalid_credentials", http.StatusUnauthorized},
+	{constants.ErrForbidden, "forbidden", http.StatusForbidden},
+	{constants.ErrInvalidStudio, "invalid_studio", http.StatusBadRequest},
+	{constants.ErrStudioNotFound, "studio_not_found", http.StatusNotFound},
+	{constants.ErrMemberSuspended, "member_suspended", http.StatusForbidden},
+	{constants.ErrClassNotFound, "class_not_found", http.StatusNotFound},
+	{constants.ErrBookingNotFound, "booking_not_found", http.StatusNotFound},
+	{constants.ErrMemberNotFound, "member_not_found", http.StatusNotFound},
+	{constants.ErrInstructorNotFound, "instructor_not_found", http.StatusNotFound},
+	{constants.ErrRoomNotFound, "room_not_found", http.StatusNotFound},
+	{constants.ErrNotOnWaitlist, "not_on_waitlist", http.StatusNotFound},
+	{constants.ErrClassAlreadyExists, "class_already_exists", http.StatusConflict},
+	{constants.ErrClassFull, "class_full", http.StatusConflict},
+	{constants.ErrAlreadyBooked, "already_booked", http.StatusConflict},
+	{constants.ErrDailyLimitReached, "daily_limit_reached", http.StatusConflict},
+	{constants.ErrAmbiguousMember, "ambiguous_member", http.StatusConflict},
+	{constants.ErrAlreadyCancelled, "already_cancelled", http.StatusConflict},
+	{constants.ErrCancellationClosed, "cancellation_closed", http.StatusConflict},
+	{constants.ErrLateCancelDenied, "late_cancel_denied", http.StatusConflict},
+	{constants.ErrAlreadyWaitlisted, "already_waitlisted", http.StatusConflict},
+	{constants.ErrSeatsAvailable, "seats_available", http.StatusConflict},
+	{constants.ErrCapacityBelowBooked, "capacity_below_booked", http.StatusConflict},
+	{constants.ErrClassHasBookings, "class_has_bookings", http.StatusConflict},
+	{constants.ErrInstructorConflict, "instructor_conflict", http.StatusConflict},
+	{constants.ErrInstructorAssigned, "instructor_assigned", http.StatusConflict},
+	{constants.ErrCapacityExceedsRoom, "capacity_exceeds_room", http.StatusConflict},
+	{constants.ErrRoomConflict, "room_conflict", http.StatusConflict},
+	{constants.ErrRoomInUse, "room_in_use", http.StatusConflict},
+	{constants.ErrUnknownSpot, "unknown_spot", http.StatusBadRequest},
+	{constants.ErrSpotTaken, "spot_taken", http.StatusConflict},
+	{constants.ErrPlanNotFound, "plan_not_found", http.StatusNotFound},
+	{constants.ErrInvalidPlan, "invalid_plan", http.StatusBadRequest},
+	{constants.ErrNoEntitlement, "no_entitlement", http.StatusPaymentRequired},
+	{constants.ErrInvalidPrice, "invalid_price", http.StatusBadRequest},
+	{constants.ErrPaymentDeclined, "payment_declined", http.StatusPaymentRequired},
+	{constants.ErrInvalidSignature, "invalid_signature", http.StatusUnauthorized},
+	{constants.ErrInvalidPaymentEvent, "invalid_payment_event", http.StatusBadRequest},
+	{constants.ErrPaymentNotFound, "payment_not_found", http.StatusNotFound},
+	{constants.ErrPromoNotFound, "promo_not_found", http.StatusNotFound},
+	{constants.ErrInvalidPromo, "invalid_promo", http.StatusBadRequest},
+	{constants.ErrPromoExists, "promo_exists", http.StatusConflict},
+	{constants.ErrPromoNotApplicable, "promo_not_applicable", http.StatusUnprocessableEntity},
+	{constants.ErrPromoExhausted, "promo_exhausted", http.StatusConflict},
+	{constants.ErrPromoRedeemed, "promo_redeemed", http.StatusConflict},
+	{constants.ErrIdempotencyKeyInProgress, "idempotency_key_in_progress", http.StatusConflict},
+	{constants.ErrVersionMismatch, "version_mismatch", http.StatusPreconditionFailed},
+	{constants.ErrIdempotencyKeyReused, "idempotency_key_reused", http.StatusUnprocessableEntity},
+	{constants.ErrIfMatchRequired, "if_match_required", http.StatusPreconditionRequired},
+}
+
+// CodeInternal is the code of errors missing from the registry
+const CodeInternal = "internal_error"
+
+// From returns the code, HTTP status and fields err is reported with. Errors
+// missing from the registry are internal errors, their message is not shown
+// to clients.
+func From(err error) *Error {
+	apiErr := &Error{Code: CodeInternal, Status: http.StatusInternalServerError, Err: constants.ErrInternalServer}
+	var fieldErr *Error
+	if errors.As(err, &fieldErr) {
+		apiErr.Fields = fieldErr.Fields
+	}
+	for _, e := range registry {
+		if errors.Is(err, e.err) {
+			apiErr.Code, apiErr.Status, apiErr.Err = e.code, e.status, err
+			break
+		}
+	}
+	return apiErr
+}
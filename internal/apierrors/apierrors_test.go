@@ -0,0 +1,53 @@
+package apierrors
+
+import (
+	"errors"
+	"fmt"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrom(t *testing.T) {
+	apiErr := From(constants.ErrClassNotFound)
+	assert.Equal(t, "class_not_found", apiErr.Code)
+	assert.Equal(t, http.StatusNotFound, apiErr.Status)
+	assert.Empty(t, apiErr.Fields)
+
+	// Wrapped errors keep the code of their sentinel and their own message
+	err := fmt.Errorf("%w: unknown weekday %q", constants.ErrInvalidRecurrence, "XX")
+	apiErr = From(err)
+	assert.Equal(t, "invalid_recurrence", apiErr.Code)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+	assert.Equal(t, err.Error(), apiErr.Error())
+
+	// Fields survive further wrapping
+	apiErr = From(fmt.Errorf("create class: %w", Field(constants.ErrInvalidStartEndDate, "start_date", "end_date")))
+	assert.Equal(t, "invalid_date_range", apiErr.Code)
+	message := constants.ErrInvalidStartEndDate.Error()
+	assert.Equal(t, []models.ProblemField{{Field: "start_date", Message: message}, {Field: "end_date", Message: message}}, apiErr.Fields)
+	assert.ErrorIs(t, apiErr, constants.ErrInvalidStartEndDate)
+
+	// Unknown errors are internal and do not leak their message
+	apiErr = From(errors.New("disk full"))
+	assert.Equal(t, CodeInternal, apiErr.Code)
+	assert.Equal(t, http.StatusInternalServerError, apiErr.Status)
+	assert.Equal(t, constants.ErrInternalServer.Error(), apiErr.Error())
+}
+
+func TestRegistry_CodesAreUnique(t *testing.T) {
+	errs := make(map[string][]error)
+	for _, e := range registry {
+		errs[e.code] = append(errs[e.code], e.err)
+	}
+	for code, codeErrs := range errs {
+		// Date parse errors share a code, the field tells them apart
+		if code == "invalid_date" {
+			continue
+		}
+		assert.Len(t, codeErrs, 1, "code %s is used by several errors", code)
+	}
+}
@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"glofox/internal/config"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Authenticator verifies the bearer tokens and API keys of requests
+type Authenticator struct {
+	secret    []byte
+	publicKey *rsa.PublicKey
+	issuer    string
+	audience  string
+	// apiKeys maps the SHA-256 hash of each key to its caller
+	apiKeys map[string]models.Principal
+	// now returns the current time, replaced in tests
+	now func() time.Time
+}
+
+// New returns an Authenticator for the configured credentials, reading the
+// RS256 public key from its PEM file
+func New(cfg config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{
+		secret:   []byte(cfg.JWTSecret),
+		issuer:   cfg.JWTIssuer,
+		audience: cfg.JWTAudience,
+		apiKeys:  make(map[string]models.Principal, len(cfg.APIKeys)),
+		now:      time.Now,
+	}
+	if cfg.JWTPublicKeyFile != "" {
+		data, err := os.ReadFile(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read JWT public key: %w", err)
+		}
+		if a.publicKey, err = ParseRSAPublicKey(data); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range cfg.APIKeys {
+		a.apiKeys[key.Hash] = models.Principal{Subject: key.Name, Role: constants.RoleStaff, StudioID: key.StudioID}
+	}
+	return a, nil
+}
+
+// ParseRSAPublicKey parses a PEM encoded PKIX or PKCS #1 RSA public key
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("JWT public key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse JWT public key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("JWT public key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash an API key is configured by
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// VerifyAPIKey returns the caller of an API key
+func (a *Authenticator) VerifyAPIKey(key string) (models.Principal, error) {
+	// Keys are looked up by hash, so lookups take the same time whatever the key
+	principal, ok := a.apiKeys[HashAPIKey(key)]
+	if !ok {
+		return models.Principal{}, fmt.Errorf("%w: unknown API key", constants.ErrInvalidCredentials)
+	}
+	return principal, nil
+}
+
+// header is the JOSE header of a token
+type header struct {
+	Algorithm string `json:"alg"`
+}
+
+// claims are the claims of a token read by the API
+type claims struct {
+	Subject   string   `json:"sub"`
+	Role      string   `json:"role"`
+	StudioID  string   `json:"studio_id"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+}
+
+// audience is the aud claim, a string or a list of strings
+type audience []string
+
+func (aud *audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*aud = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*aud = many
+	return nil
+}
+
+// VerifyToken checks the signature and claims of a compact JWT and returns its caller
+func (a *Authenticator) VerifyToken(token string) (models.Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return models.Principal{}, invalidToken("malformed token")
+	}
+	var hdr header
+	if err := decodeSegment(parts[0], &hdr); err != nil {
+		return models.Principal{}, invalidToken("malformed header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return models.Principal{}, invalidToken("malformed signature")
+	}
+	if err := a.verifySignature(hdr.Algorithm, parts[0]+"."+parts[1], signature); err != nil {
+		return models.Principal{}, err
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return models.Principal{}, invalidToken("malformed claims")
+	}
+	if err := a.checkClaims(c); err != nil {
+		return models.Principal{}, err
+	}
+	return models.Principal{Subject: c.Subject, Role: c.Role, StudioID: c.StudioID}, nil
+}
+
+// verifySignature checks the signature with the key of the algorithm. Only
+// algorithms with a configured key are accepted, so an RS256 public key can
+// never be used as an HS256 secret and unsigned tokens are refused.
+func (a *Authenticator) verifySignature(algorithm, signed string, signature []byte) error {
+	switch {
+	case algorithm == "HS256" && len(a.secret) > 0:
+		mac := hmac.New(sha256.New, a.secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return invalidToken("bad signature")
+		}
+	case algorithm == "RS256" && a.publicKey != nil:
+		digest := sha256.Sum256([]byte(signed))
+		if rsa.VerifyPKCS1v15(a.publicKey, crypto.SHA256, digest[:], signature) != nil {
+			return invalidToken("bad signature")
+		}
+	default:
+		return invalidToken(fmt.Sprintf("unsupported algorithm %q", algorithm))
+	}
+	return nil
+}
+
+// checkClaims checks the validity period, issuer and audience of a token and
+// that it names its caller
+func (a *Authenticator) checkClaims(c claims) error {
+	now := a.now()
+	if c.ExpiresAt == nil {
+		return invalidToken("token has no expiry")
+	}
+	if now.Add(-constants.JWTClockSkew).After(unixTime(*c.ExpiresAt)) {
+		return invalidToken("token expired")
+	}
+	if c.NotBefore != nil && now.Add(constants.JWTClockSkew).Before(unixTime(*c.NotBefore)) {
+		return invalidToken("token is not valid yet")
+	}
+	if a.issuer != "" && c.Issuer != a.issuer {
+		return invalidToken("unexpected issuer")
+	}
+	if a.audience != "" && !slices.Contains(c.Audience, a.audience) {
+		return invalidToken("unexpected audience")
+	}
+	if c.Subject == "" || c.StudioID == "" {
+		return invalidToken("token needs sub and studio_id claims")
+	}
+	switch c.Role {
+	case constants.RoleMember, constants.RoleInstructor, constants.RoleStaff, constants.RoleAdmin:
+	default:
+		return invalidToken(fmt.Sprintf("unknown role %q", c.Role))
+	}
+	return nil
+}
+
+// invalidToken returns ErrInvalidCredentials with the reason a token was refused
+func invalidToken(reason string) error {
+	return fmt.Errorf("%w: %s", constants.ErrInvalidCredentials, reason)
+}
+
+// decodeSegment decodes a base64url encoded JSON segment of a token
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// unixTime converts a NumericDate claim
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"glofox/internal/config"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// sign returns a compact JWT of the claims signed with key, a secret for
+// HS256 or an RSA private key for RS256
+func sign(t *testing.T, alg string, key any, claims map[string]any) string {
+	t.Helper()
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(map[string]string{"alg": alg, "typ": "JWT"}) + "." + encode(claims)
+
+	var signature []byte
+	switch alg {
+	case "HS256":
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case "RS256":
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifyToken(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "jwt.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	authenticator, err := New(config.AuthConfig{
+		Mode:             constants.AuthRequired,
+		JWTSecret:        testSecret,
+		JWTPublicKeyFile: keyFile,
+		JWTIssuer:        "glofox",
+		JWTAudience:      "api",
+	})
+	require.NoError(t, err)
+	authenticator.now = func() time.Time { return now }
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"sub":       "mb_1",
+			"role":      constants.RoleMember,
+			"studio_id": "st_1",
+			"iss":       "glofox",
+			"aud":       "api",
+			"exp":       now.Add(time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+	member := models.Principal{Subject: "mb_1", Role: constants.RoleMember, StudioID: "st_1"}
+
+	tests := []struct {
+		name      string
+		token     string
+		expected  models.Principal
+		expectErr string
+	}{
+		{
+			name:     "HS256",
+			token:    sign(t, "HS256", []byte(testSecret), claims(nil)),
+			expected: member,
+		},
+		{
+			name:     "RS256",
+			token:    sign(t, "RS256", privateKey, claims(nil)),
+			expected: member,
+		},
+		{
+			name:     "Staff With Audience List",
+			token:    sign(t, "HS256", []byte(testSecret), claims(map[string]any{"sub": "st_admin", "role": constants.RoleStaff, "aud": []string{"web", "api"}})),
+			expected: models.Principal{Subject: "st_admin", Role: constants.RoleStaff, StudioID: "st_1"},
+		},
+		{
+			name:     "Expired Within Clock Skew",
+			token:    sign(t, "HS256", []byte(testSecret), claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()})),
+			expected: member,
+		},
+		{
+			name:      "Expired",
+			token:     sign(t, "HS256", []byte(testSecret), claims(map[string]any{"exp": now.Add(-time.Hour).Unix()})),
+			expectErr: "token expired",
+		},
+		{
+			name:      "Not Valid Yet",
+			token:     sign(t, "HS256", []byte(testSecret), claims(map[string]any{"nbf": now.Add(time.Hour).Unix()})),
+			expectErr: "token is not valid yet",
+		},
+		{
+			name:      "No Expiry",
+			token:     sign(t, "HS256", []byte(testSecret), claims(map[string]any{"exp": nil})),
+			expectErr: "token has no expiry",
+		},
+		{
+			name:      "Wrong Secret",
+			token:     sign(t, "HS256", []byte("another secret of thirty-two bytes"), claims(nil)),
+			expectErr: "bad signature",
+		},
+		{
+			name:      "Public Key As HMAC Secret",
+			token:     sign(t, "HS256", der, claims(nil)),
+			expectErr: "bad signature",
+		},
+		{
+			name:      "Unsigned",
+			token:     strings.TrimSuffix(sign(t, "none", nil, claims(nil)), "."),
+			expectErr: "malformed token",
+		},
+		{
+			name:      "Algorithm None",
+			token:     sign(t, "none", nil, claims(nil)),
+			expectErr: `unsupported algorithm "none"`,
+		},
+		{
+			name:      "Wrong Issuer",
+			token:     sign(t, "HS256", []byte(testSecret), claims(map[string]any{"iss": "elsewhere"})),
+			expectErr: "unexpected issuer",
+		},
+		{
+			name:      "Wrong Audience",
+			token:     sign(t, "HS256", []byte(testSecret), claims(map[string]any{"aud": "admin"})),
+			expectErr: "unexpected audience",
+		},
+		{
+			name:      "No Studio",
+			token:     sign(t, "HS256", []byte(testSecret), claims(map[string]any{"studio_id": nil})),
+			expectErr: "token needs sub and studio_id claims",
+		},
+		{
+			name:      "Unknown Role",
+			token:     sign(t, "HS256", []byte(testSecret), claims(map[string]any{"role": "owner"})),
+			expectErr: `unknown role "owner"`,
+		},
+		{
+			name:      "Tampered Claims",
+			token:     tamper(sign(t, "HS256", []byte(testSecret), claims(nil)), claims(map[string]any{"role": constants.RoleStaff})),
+			expectErr: "bad signature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticator.VerifyToken(tt.token)
+			if tt.expectErr != "" {
+				assert.ErrorIs(t, err, constants.ErrInvalidCredentials)
+				assert.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, principal)
+		})
+	}
+}
+
+// tamper replaces the claims of a signed token, keeping its signature
+func tamper(token string, claims map[string]any) string {
+	parts := strings.Split(token, ".")
+	data, _ := json.Marshal(claims)
+	parts[1] = base64.RawURLEncoding.EncodeToString(data)
+	return strings.Join(parts, ".")
+}
+
+func TestVerifyToken_AlgorithmWithoutKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	authenticator, err := New(config.AuthConfig{Mode: constants.AuthRequired, JWTSecret: testSecret})
+	require.NoError(t, err)
+
+	// Without a configured public key RS256 tokens are refused
+	token := sign(t, "RS256", privateKey, map[string]any{"sub": "mb_1", "role": "member", "studio_id": "st_1", "exp": time.Now().Add(time.Hour).Unix()})
+	_, err = authenticator.VerifyToken(token)
+	assert.ErrorIs(t, err, constants.ErrInvalidCredentials)
+	assert.ErrorContains(t, err, `unsupported algorithm "RS256"`)
+}
+
+func TestVerifyAPIKey(t *testing.T) {
+	authenticator, err := New(config.AuthConfig{
+		Mode:    constants.AuthRequired,
+		APIKeys: []config.APIKey{{Name: "billing", StudioID: "st_1", Hash: HashAPIKey("secret-key")}},
+	})
+	require.NoError(t, err)
+
+	principal, err := authenticator.VerifyAPIKey("secret-key")
+	assert.NoError(t, err)
+	assert.Equal(t, models.Principal{Subject: "billing", Role: constants.RoleStaff, StudioID: "st_1"}, principal)
+
+	_, err = authenticator.VerifyAPIKey("other-key")
+	assert.ErrorIs(t, err, constants.ErrInvalidCredentials)
+}
+
+func TestParseRSAPublicKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&privateKey.PublicKey)})
+	key, err := ParseRSAPublicKey(pkcs1)
+	assert.NoError(t, err)
+	assert.True(t, privateKey.PublicKey.Equal(key))
+
+	_, err = ParseRSAPublicKey([]byte("not a key"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"glofox/internal/constants"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the runtime configuration of the API server
+type Config struct {
+	// Storage selects the repository backend, memory, file or sqlite
+	Storage string
+	// DataDir is where the file backend keeps its log and snapshots
+	DataDir string
+	// Durability is the fsync mode of the file backend: always, interval or none
+	Durability string
+	// SyncInterval is the fsync period of the interval durability mode
+	SyncInterval time.Duration
+	// SnapshotEvery is the number of log records after which the file backend compacts
+	SnapshotEvery int
+	// SQLitePath is the database file of the sqlite backend
+	SQLitePath string
+	// TimeZone is the IANA time zone of the studio, used by classes that do not set their own
+	TimeZone string
+	// DailyBookingLimit is the number of bookings a member may hold per day across classes, 0 means no limit
+	DailyBookingLimit int
+	// IdempotencyTTL is how long the response to an Idempotency-Key is replayed
+	IdempotencyTTL time.Duration
+	Auth           AuthConfig
+	Payments       PaymentsConfig
+}
+
+// PaymentsConfig selects the payment provider of drop-in bookings
+type PaymentsConfig struct {
+	// Provider is constants.PaymentsDisabled, PaymentsFake or PaymentsHTTP
+	Provider string
+	// URL and APIKey reach the API of the http provider
+	URL    string
+	APIKey string
+	// WebhookSecret verifies the signature of webhook events
+	WebhookSecret string
+	// Timeout is how long a call to the provider may take
+	Timeout time.Duration
+}
+
+// AuthConfig holds the credentials requests are authenticated with
+type AuthConfig struct {
+	// Mode is constants.AuthRequired or constants.AuthDisabled
+	Mode string
+	// JWTSecret verifies HS256 tokens
+	JWTSecret string
+	// JWTPublicKeyFile is a PEM file with the RSA public key verifying RS256 tokens
+	JWTPublicKeyFile string
+	// JWTIssuer and JWTAudience, when set, must match the iss and aud claims
+	JWTIssuer   string
+	JWTAudience string
+	APIKeys     []APIKey
+}
+
+// APIKey is an API key given to another service of a studio. Only the SHA-256
+// hash of the key is configured.
+type APIKey struct {
+	Name     string
+	StudioID string
+	// Hash is the hex encoded SHA-256 hash of the key
+	Hash string
+}
+
+// Load reads the configuration from the environment, unset variables fall back to the defaults
+func Load() (Config, error) {
+	cfg := Config{
+		Storage:       getEnv(constants.EnvStorage, constants.StorageMemory),
+		DataDir:       getEnv(constants.EnvDataDir, constants.DefaultDataDir),
+		Durability:    getEnv(constants.EnvDurability, constants.DefaultDurability),
+		SyncInterval:  constants.DefaultSyncInterval,
+		SnapshotEvery: constants.DefaultSnapshotEvery,
+		SQLitePath:    getEnv(constants.EnvSQLitePath, constants.DefaultSQLitePath),
+		TimeZone:      getEnv(constants.EnvTimeZone, constants.DefaultTimeZone),
+		// Members may hold any number of bookings per day unless the studio sets a limit
+		DailyBookingLimit: constants.DefaultDailyBookings,
+		IdempotencyTTL:    constants.DefaultIdempotencyTTL,
+		Auth: AuthConfig{
+			Mode:             getEnv(constants.EnvAuth, constants.AuthRequired),
+			JWTSecret:        os.Getenv(constants.EnvJWTSecret),
+			JWTPublicKeyFile: os.Getenv(constants.EnvJWTPublicKeyFile),
+			JWTIssuer:        os.Getenv(constants.EnvJWTIssuer),
+			JWTAudience:      os.Getenv(constants.EnvJWTAudience),
+		},
+		Payments: PaymentsConfig{
+			Provider:      getEnv(constants.EnvPayments, constants.PaymentsDisabled),
+			URL:           os.Getenv(constants.EnvPaymentsURL),
+			APIKey:        os.Getenv(constants.EnvPaymentsAPIKey),
+			WebhookSecret: os.Getenv(constants.EnvPaymentsWebhookSecret),
+			Timeout:       constants.DefaultPaymentsTimeout,
+		},
+	}
+
+	switch cfg.Storage {
+	case constants.StorageMemory, constants.StorageFile, constants.StorageSQLite:
+	default:
+		return Config{}, fmt.Errorf("%s: unknown storage %q", constants.EnvStorage, cfg.Storage)
+	}
+	if _, err := time.LoadLocation(cfg.TimeZone); err != nil || cfg.TimeZone == "Local" {
+		return Config{}, fmt.Errorf("%s: unknown time zone %q", constants.EnvTimeZone, cfg.TimeZone)
+	}
+	if v, ok := os.LookupEnv(constants.EnvSyncInterval); ok {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			return Config{}, fmt.Errorf("%s: invalid duration %q", constants.EnvSyncInterval, v)
+		}
+		cfg.SyncInterval = interval
+	}
+	if v, ok := os.LookupEnv(constants.EnvSnapshotEvery); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return Config{}, fmt.Errorf("%s: invalid record count %q", constants.EnvSnapshotEvery, v)
+		}
+		cfg.SnapshotEvery = n
+	}
+	if v, ok := os.LookupEnv(constants.EnvDailyBookings); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return Config{}, fmt.Errorf("%s: invalid booking count %q", constants.EnvDailyBookings, v)
+		}
+		cfg.DailyBookingLimit = n
+	}
+	if v, ok := os.LookupEnv(constants.EnvIdempotencyTTL); ok {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			return Config{}, fmt.Errorf("%s: invalid duration %q", constants.EnvIdempotencyTTL, v)
+		}
+		cfg.IdempotencyTTL = ttl
+	}
+	if err := loadAuth(&cfg.Auth); err != nil {
+		return Config{}, err
+	}
+	if err := loadPayments(&cfg.Payments); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// loadPayments checks that the selected payment provider can be reached and
+// its webhooks verified
+func loadPayments(payments *PaymentsConfig) error {
+	switch payments.Provider {
+	case constants.PaymentsDisabled, constants.PaymentsFake:
+	case constants.PaymentsHTTP:
+		if payments.URL == "" {
+			return fmt.Errorf("%s: the %s provider needs a URL", constants.EnvPaymentsURL, payments.Provider)
+		}
+		if payments.WebhookSecret == "" {
+			return fmt.Errorf("%s: the %s provider needs a webhook secret", constants.EnvPaymentsWebhookSecret, payments.Provider)
+		}
+	default:
+		return fmt.Errorf("%s: unknown provider %q", constants.EnvPayments, payments.Provider)
+	}
+	if v, ok := os.LookupEnv(constants.EnvPaymentsTimeout); ok {
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("%s: invalid duration %q", constants.EnvPaymentsTimeout, v)
+		}
+		payments.Timeout = timeout
+	}
+	return nil
+}
+
+// loadAuth parses the API keys and checks that required authentication has
+// credentials to check requests against
+func loadAuth(auth *AuthConfig) error {
+	switch auth.Mode {
+	case constants.AuthRequired, constants.AuthDisabled:
+	default:
+		return fmt.Errorf("%s: unknown mode %q", constants.EnvAuth, auth.Mode)
+	}
+	if auth.JWTSecret != "" && len(auth.JWTSecret) < constants.MinJWTSecretLength {
+		return fmt.Errorf("%s: secret must be at least %d bytes", constants.EnvJWTSecret, constants.MinJWTSecretLength)
+	}
+	if v := os.Getenv(constants.EnvAPIKeys); v != "" {
+		// Keys are listed as name:studio:sha256,name:studio:sha256
+		for _, entry := range strings.Split(v, ",") {
+			parts := strings.Split(strings.TrimSpace(entry), ":")
+			if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+				return fmt.Errorf("%s: expected name:studio:sha256, got %q", constants.EnvAPIKeys, entry)
+			}
+			hash, err := hex.DecodeString(parts[2])
+			if err != nil || len(hash) != sha256.Size {
+				return fmt.Errorf("%s: key %s needs a hex encoded SHA-256 hash", constants.EnvAPIKeys, parts[0])
+			}
+			auth.APIKeys = append(auth.APIKeys, APIKey{Name: parts[0], StudioID: parts[1], Hash: strings.ToLower(parts[2])})
+		}
+	}
+	if auth.Mode == constants.AuthRequired && auth.JWTSecret == "" && auth.JWTPublicKeyFile == "" && len(auth.APIKeys) == 0 {
+		return fmt.Errorf("no credentials configured, set %s, %s or %s, or %s=%s for local development",
+			constants.EnvJWTSecret, constants.EnvJWTPublicKeyFile, constants.EnvAPIKeys, constants.EnvAuth, constants.AuthDisabled)
+	}
+	return nil
+}
+
+// getEnv returns the value of the environment variable key or fallback when it is unset
+func getEnv(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"glofox/internal/constants"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	// Defaults, authentication is covered by TestLoad_Auth
+	t.Setenv(constants.EnvAuth, constants.AuthDisabled)
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, constants.StorageMemory, cfg.Storage)
+	assert.Equal(t, constants.DefaultSyncInterval, cfg.SyncInterval)
+	assert.Equal(t, "UTC", cfg.TimeZone)
+	assert.Zero(t, cfg.DailyBookingLimit)
+	assert.Equal(t, constants.DefaultIdempotencyTTL, cfg.IdempotencyTTL)
+
+	t.Setenv(constants.EnvStorage, constants.StorageFile)
+	t.Setenv(constants.EnvDataDir, "/var/lib/glofox")
+	t.Setenv(constants.EnvDurability, "interval")
+	t.Setenv(constants.EnvSyncInterval, "250ms")
+	t.Setenv(constants.EnvSnapshotEvery, "50")
+	t.Setenv(constants.EnvSQLitePath, "/var/lib/glofox/glofox.db")
+	t.Setenv(constants.EnvTimeZone, "Australia/Sydney")
+	t.Setenv(constants.EnvDailyBookings, "2")
+	t.Setenv(constants.EnvIdempotencyTTL, "1h")
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, Config{
+		Storage:       constants.StorageFile,
+		DataDir:       "/var/lib/glofox",
+		Durability:    "interval",
+		SyncInterval:  250 * time.Millisecond,
+		SnapshotEvery: 50,
+		SQLitePath:    "/var/lib/glofox/glofox.db",
+		TimeZone:      "Australia/Sydney",
+
+		DailyBookingLimit: 2,
+		IdempotencyTTL:    time.Hour,
+		Auth:              AuthConfig{Mode: constants.AuthDisabled},
+		Payments:          PaymentsConfig{Provider: constants.PaymentsDisabled, Timeout: constants.DefaultPaymentsTimeout},
+	}, cfg)
+
+	t.Setenv(constants.EnvTimeZone, "Moon/Tranquility")
+	_, err = Load()
+	assert.Error(t, err)
+
+	t.Setenv(constants.EnvTimeZone, "UTC")
+	t.Setenv(constants.EnvDailyBookings, "-1")
+	_, err = Load()
+	assert.Error(t, err)
+
+	t.Setenv(constants.EnvDailyBookings, "0")
+	t.Setenv(constants.EnvIdempotencyTTL, "0s")
+	_, err = Load()
+	assert.Error(t, err)
+
+	t.Setenv(constants.EnvIdempotencyTTL, "24h")
+	t.Setenv(constants.EnvSyncInterval, "soon")
+	_, err = Load()
+	assert.Error(t, err)
+
+	t.Setenv(constants.EnvSyncInterval, "1s")
+	t.Setenv(constants.EnvStorage, constants.StorageSQLite)
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, constants.StorageSQLite, cfg.Storage)
+
+	t.Setenv(constants.EnvStorage, "tape")
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func TestLoad_Auth(t *testing.T) {
+	// Authentication is required by default and needs credentials
+	_, err := Load()
+	assert.Error(t, err)
+
+	secret := strings.Repeat("s", constants.MinJWTSecretLength)
+	hash := strings.Repeat("ab", 32)
+	t.Setenv(constants.EnvJWTSecret, secret)
+	t.Setenv(constants.EnvJWTPublicKeyFile, "/etc/glofox/jwt.pem")
+	t.Setenv(constants.EnvJWTIssuer, "https://auth.example.com")
+	t.Setenv(constants.EnvJWTAudience, "glofox")
+	t.Setenv(constants.EnvAPIKeys, "billing:studio_1:"+hash+", crm:studio_2:"+strings.ToUpper(hash))
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, AuthConfig{
+		Mode:             constants.AuthRequired,
+		JWTSecret:        secret,
+		JWTPublicKeyFile: "/etc/glofox/jwt.pem",
+		JWTIssuer:        "https://auth.example.com",
+		JWTAudience:      "glofox",
+		APIKeys: []APIKey{
+			{Name: "billing", StudioID: "studio_1", Hash: hash},
+			{Name: "crm", StudioID: "studio_2", Hash: hash},
+		},
+	}, cfg.Auth)
+
+	for env, value := range map[string]string{
+		constants.EnvAuth:      "sometimes",
+		constants.EnvJWTSecret: "short",
+		constants.EnvAPIKeys:   "billing:" + hash,
+	} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, value)
+			_, err := Load()
+			assert.Error(t, err)
+		})
+	}
+	t.Setenv(constants.EnvAPIKeys, "billing:studio_1:not-a-hash")
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func TestLoad_Payments(t *testing.T) {
+	t.Setenv(constants.EnvAuth, constants.AuthDisabled)
+	t.Setenv(constants.EnvPayments, constants.PaymentsFake)
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, PaymentsConfig{Provider: constants.PaymentsFake, Timeout: constants.DefaultPaymentsTimeout}, cfg.Payments)
+
+	// The http provider needs a URL and a webhook secret
+	t.Setenv(constants.EnvPayments, constants.PaymentsHTTP)
+	_, err = Load()
+	assert.Error(t, err)
+	t.Setenv(constants.EnvPaymentsURL, "https://payments.example.com")
+	_, err = Load()
+	assert.Error(t, err)
+
+	t.Setenv(constants.EnvPaymentsAPIKey, "sk_test")
+	t.Setenv(constants.EnvPaymentsWebhookSecret, "whsec_test")
+	t.Setenv(constants.EnvPaymentsTimeout, "3s")
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, PaymentsConfig{
+		Provider:      constants.PaymentsHTTP,
+		URL:           "https://payments.example.com",
+		APIKey:        "sk_test",
+		WebhookSecret: "whsec_test",
+		Timeout:       3 * time.Second,
+	}, cfg.Payments)
+
+	for env, value := range map[string]string{
+		constants.EnvPayments:        "cash",
+		constants.EnvPaymentsTimeout: "0s",
+	} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, value)
+			_, err := Load()
+			assert.Error(t, err)
+		})
+	}
+}
@@ -1,22 +1,293 @@
 package constants
 
+import "time"
+
 const (
 	APIServerPort = ":8080"
 )
 
+// Configuration environment variables
+const (
+	EnvStorage        = "GLOFOX_STORAGE"
+	EnvDataDir        = "GLOFOX_DATA_DIR"
+	EnvDurability     = "GLOFOX_DURABILITY"
+	EnvSyncInterval   = "GLOFOX_SYNC_INTERVAL"
+	EnvSnapshotEvery  = "GLOFOX_SNAPSHOT_EVERY"
+	EnvSQLitePath     = "GLOFOX_SQLITE_PATH"
+	EnvTimeZone       = "GLOFOX_TIMEZONE"
+	EnvDailyBookings  = "GLOFOX_DAILY_BOOKING_LIMIT"
+	EnvIdempotencyTTL = "GLOFOX_IDEMPOTENCY_TTL"
+
+	EnvAuth             = "GLOFOX_AUTH"
+	EnvJWTSecret        = "GLOFOX_JWT_SECRET"
+	EnvJWTPublicKeyFile = "GLOFOX_JWT_PUBLIC_KEY_FILE"
+	EnvJWTIssuer        = "GLOFOX_JWT_ISSUER"
+	EnvJWTAudience      = "GLOFOX_JWT_AUDIENCE"
+	EnvAPIKeys          = "GLOFOX_API_KEYS"
+
+	EnvPayments              = "GLOFOX_PAYMENTS"
+	EnvPaymentsURL           = "GLOFOX_PAYMENTS_URL"
+	EnvPaymentsAPIKey        = "GLOFOX_PAYMENTS_API_KEY"
+	EnvPaymentsWebhookSecret = "GLOFOX_PAYMENTS_WEBHOOK_SECRET"
+	EnvPaymentsTimeout       = "GLOFOX_PAYMENTS_TIMEOUT"
+)
+
+// DefaultTimeZone is the time zone of the studio when none is configured
+const DefaultTimeZone = "UTC"
+
+// DefaultDailyBookings is the number of bookings a member may hold per day
+// across classes, 0 means no limit
+const DefaultDailyBookings = 0
+
+// Storage backends and their defaults
+const (
+	StorageMemory = "memory"
+	StorageFile   = "file"
+	StorageSQLite = "sqlite"
+
+	DefaultDataDir       = "data"
+	DefaultDurability    = "always"
+	DefaultSyncInterval  = time.Second
+	DefaultSnapshotEvery = 1000
+	DefaultSQLitePath    = "data/glofox.db"
+)
+
 // ENDPOINTS
 const (
-	ClassEndpoint   = "/classes"
-	BookingEndpoint = "/bookings"
+	ClassEndpoint        = "/classes"
+	ClassNameEndpoint    = ClassEndpoint + "/:name"
+	ClassSessionEndpoint = ClassNameEndpoint + "/sessions"
+	BookingEndpoint      = "/bookings"
+	BookingIDEndpoint    = BookingEndpoint + "/:id"
+
+	WaitlistEndpoint       = "/classes/:name/sessions/:date/waitlist"
+	WaitlistMemberEndpoint = WaitlistEndpoint + "/:member"
+
+	MemberEndpoint   = "/members"
+	MemberIDEndpoint = MemberEndpoint + "/:id"
+
+	InstructorEndpoint         = "/instructors"
+	InstructorIDEndpoint       = InstructorEndpoint + "/:id"
+	InstructorScheduleEndpoint = InstructorIDEndpoint + "/schedule"
+
+	RoomEndpoint            = "/rooms"
+	RoomIDEndpoint          = RoomEndpoint + "/:id"
+	RoomUtilizationEndpoint = RoomIDEndpoint + "/utilization"
+
+	PlanEndpoint             = "/plans"
+	PlanIDEndpoint           = PlanEndpoint + "/:id"
+	MemberMembershipEndpoint = MemberIDEndpoint + "/memberships"
+	MemberCreditsEndpoint    = MemberIDEndpoint + "/credits"
+
+	PaymentWebhookEndpoint = "/payments/webhook"
+
+	PromoEndpoint            = "/promo-codes"
+	PromoCodeEndpoint        = PromoEndpoint + "/:code"
+	PromoRedemptionsEndpoint = PromoCodeEndpoint + "/redemptions"
 )
 
-// ErrInvalidReq Err Messages
+// Authentication of requests
 const (
-	ErrInvalidReq = "Invalid JSON request: "
+	// AuthRequired rejects requests without valid credentials, AuthDisabled
+	// lets every request through and is meant for local development only
+	AuthRequired = "required"
+	AuthDisabled = "disabled"
+
+	HeaderAuthorization   = "Authorization"
+	HeaderAPIKey          = "X-API-Key"
+	HeaderWWWAuthenticate = "WWW-Authenticate"
+	BearerScheme          = "Bearer"
+
+	// MinJWTSecretLength is the shortest HS256 secret accepted, in bytes
+	MinJWTSecretLength = 32
+	// JWTClockSkew is how far the exp and nbf claims of a token may be off
+	JWTClockSkew = time.Minute
+
+	// ContextPrincipal is the gin context key of the authenticated caller
+	ContextPrincipal = "principal"
+)
+
+// Studios are the tenants of the API, every class, booking, waitlist and
+// member belongs to one
+const (
+	HeaderStudioID = "X-Studio-ID"
+	// DefaultStudioID is the studio of requests that name none when
+	// authentication is disabled, and of data stored before studios existed
+	DefaultStudioID = "default"
+	// MaxStudioIDLength is the longest studio ID accepted
+	MaxStudioIDLength = 64
+	// ContextStudio is the gin context key of the studio of a request
+	ContextStudio = "studio"
+	// ContextService is the gin context key of the service of the studio of a request
+	ContextService = "service"
+)
+
+// Roles of authenticated callers. API keys are used by other services of the
+// studio and act as staff.
+const (
+	RoleMember     = "member"
+	RoleInstructor = "instructor"
+	RoleStaff      = "staff"
+	RoleAdmin      = "admin"
+)
+
+// Idempotency keys of POST requests
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+	MaxIdempotencyKeyLength  = 255
+	// DefaultIdempotencyTTL is how long the response to a key is replayed
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// IdempotencyLease is how long a request being handled holds its key, so
+	// that a key is not locked until the TTL when the server dies mid-request
+	IdempotencyLease = time.Minute
+	// IdempotencyPurgeInterval is how often expired keys are deleted
+	IdempotencyPurgeInterval = 10 * time.Minute
+)
+
+// Optimistic concurrency of classes and bookings
+const (
+	HeaderETag    = "ETag"
+	HeaderIfMatch = "If-Match"
+	// AnyVersion is the version of If-Match: *, it matches every version
+	AnyVersion = 0
+)
+
+// RFC 7807 problem details of error responses
+const (
+	ContentTypeProblem = "application/problem+json"
+	// ProblemTypePrefix is followed by the error code in the type of a problem
+	ProblemTypePrefix = "urn:glofox:problem:"
 )
 
 // General
 const (
 	SuccessMsg = "success"
 	DateFormat = "2006-01-02"
+	TimeFormat = "15:04"
+)
+
+// Request validation limits
+const (
+	// MaxNameLength is the longest class name accepted, in characters
+	MaxNameLength = 64
+	// MaxClassCapacity is the largest capacity of a class
+	MaxClassCapacity = 500
+)
+
+// Pagination
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Booking statuses. Pending bookings hold their seat until the payment of
+// the drop-in price is authorized.
+const (
+	BookingStatusPending    = "pending"
+	BookingStatusBooked     = "booked"
+	BookingStatusWaitlisted = "waitlisted"
+	BookingStatusCancelled  = "cancelled"
 )
+
+// Overflow policies of PATCH /classes/:name when the capacity drops below the
+// bookings of a session
+const (
+	OverflowReject   = "reject"
+	OverflowWaitlist = "waitlist"
+)
+
+// CancelReasonCapacityReduced is recorded on bookings moved to the waitlist
+// because the capacity of their class was reduced
+const CancelReasonCapacityReduced = "class capacity was reduced"
+
+// Payment providers of drop-in bookings. PaymentsFake authorizes in process
+// and is meant for local development only.
+const (
+	PaymentsDisabled = "disabled"
+	PaymentsFake     = "fake"
+	PaymentsHTTP     = "http"
+
+	// DefaultPaymentsTimeout is how long a call to the provider may take
+	DefaultPaymentsTimeout = 10 * time.Second
+	// PaymentSettleInterval is how often payments left pending or unfinished
+	// by a failed provider call are retried
+	PaymentSettleInterval = 30 * time.Second
+	// HeaderPaymentSignature is the hex encoded HMAC-SHA256 of the body of a
+	// webhook, keyed with the webhook secret
+	HeaderPaymentSignature = "X-Payment-Signature"
+	// ContextPaymentEvent is the gin context key of a verified webhook event
+	ContextPaymentEvent = "payment_event"
+)
+
+// Payment statuses. A payment is authorized before its booking is confirmed
+// and captured right after, payments of bookings cancelled in time are
+// refunded.
+const (
+	PaymentPending       = "pending"
+	PaymentAuthorized    = "authorized"
+	PaymentCaptured      = "captured"
+	PaymentDeclined      = "declined"
+	PaymentRefundPending = "refund_pending"
+	PaymentRefunded      = "refunded"
+)
+
+// Types of the webhook events of the payment provider
+const (
+	PaymentEventAuthorized = "payment.authorized"
+	PaymentEventDeclined   = "payment.declined"
+	PaymentEventCaptured   = "payment.captured"
+	PaymentEventRefunded   = "payment.refunded"
+)
+
+// Reasons recorded on pending bookings cancelled because they were not paid
+const (
+	CancelReasonPaymentDeclined = "payment was declined"
+	CancelReasonPaymentExpired  = "payment was not confirmed before the class started"
+)
+
+// Member statuses, suspended members cannot book
+const (
+	MemberStatusActive    = "active"
+	MemberStatusSuspended = "suspended"
+)
+
+// Kinds of membership plans. Weekly and monthly plans allow a number of
+// classes per calendar week or month, packs a number of classes until they expire.
+const (
+	PlanUnlimited = "unlimited"
+	PlanWeekly    = "weekly"
+	PlanMonthly   = "monthly"
+	PlanPack      = "pack"
+)
+
+// Kinds of promo codes. Percentage and fixed codes discount the drop-in price
+// of a class, first class codes make the first booking of a member free.
+const (
+	PromoPercentage = "percentage"
+	PromoFixed      = "fixed"
+	PromoFirstClass = "first_class"
+)
+
+// Reasons of credit ledger entries
+const (
+	CreditGrant   = "grant"
+	CreditBooking = "booking"
+	CreditRefund  = "refund"
+)
+
+// Cancellation policy defaults, applied when a class does not set its own
+const (
+	DefaultFreeCancelHours = 12
+	DefaultAllowLateCancel = true
+)
+
+// Recurrence frequencies of a class
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+)
+
+// DefaultSessionMinutes is the duration of a session that does not set one
+const DefaultSessionMinutes = 60
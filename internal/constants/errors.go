@@ -4,10 +4,105 @@ import "errors"
 
 var (
 	ErrInternalServer      = errors.New("internal server error")
-	ErrInvalidDate         = errors.New("invalid date format, expected YYYY-MM-DD")
+	ErrInvalidReq          = errors.New("invalid JSON request")
+	ErrInvalidQuery        = errors.New("invalid query parameters")
+	ErrInvalidDate         = errors.New("invalid date format, expected YYYY-MM-DD or RFC 3339")
 	ErrInvalidStartEndDate = errors.New("start date cannot be after end date")
-	ErrInvalidStartDate    = errors.New("invalid start date format, expected YYYY-MM-DD")
-	ErrInvalidEndDate      = errors.New("invalid end date format, expected YYYY-MM-DD")
+	ErrInvalidStartDate    = errors.New("invalid start date format, expected YYYY-MM-DD or RFC 3339")
+	ErrInvalidEndDate      = errors.New("invalid end date format, expected YYYY-MM-DD or RFC 3339")
 	ErrClassNotFound       = errors.New("class not found")
 	ErrClassAlreadyExists  = errors.New("class already exists")
+	ErrClassFull           = errors.New("class is full for the given date")
+	ErrBookingNotFound     = errors.New("booking not found")
+	ErrAlreadyCancelled    = errors.New("booking is already cancelled")
+	ErrCancellationClosed  = errors.New("booking cannot be cancelled after the class has started")
+	ErrLateCancelDenied    = errors.New("late cancellation is not allowed for this class")
+	ErrAlreadyWaitlisted   = errors.New("member is already on the waitlist")
+	ErrNotOnWaitlist       = errors.New("member is not on the waitlist")
+	ErrSeatsAvailable      = errors.New("class has seats available, book it instead")
+	ErrInvalidCursor       = errors.New("invalid pagination cursor")
+	ErrInvalidRecurrence   = errors.New("invalid recurrence")
+	ErrNoOccurrences       = errors.New("recurrence has no dates between start and end date")
+	ErrInvalidSessionTime  = errors.New("invalid session time")
+	ErrSessionRequired     = errors.New("class runs several sessions on this date, include the start time")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrInvalidTimeZone     = errors.New("invalid time zone, expected an IANA name such as Europe/Dublin")
+	ErrMemberNotFound      = errors.New("member not found")
+	ErrInvalidMemberName   = errors.New("member name cannot be blank")
+	ErrMemberSuspended     = errors.New("member is suspended")
+	ErrAmbiguousMember     = errors.New("several members share this name, use member_id instead")
+	ErrAlreadyBooked       = errors.New("member already has a booking for this session")
+	ErrDailyLimitReached   = errors.New("member has reached the daily booking limit")
+	ErrCapacityBelowBooked = errors.New("capacity is below the number of bookings of a session")
+	ErrClassHasBookings    = errors.New("class has upcoming bookings, use force=true with a reason to cancel them")
+	ErrCancelReasonMissing = errors.New("a reason is required to cancel bookings")
+)
+
+// Instructor errors
+var (
+	ErrInstructorNotFound    = errors.New("instructor not found")
+	ErrInvalidInstructorName = errors.New("instructor name cannot be blank")
+	ErrInvalidSubstitution   = errors.New("invalid substitution")
+	ErrInstructorConflict    = errors.New("instructor is already teaching another class at this time")
+	ErrInstructorAssigned    = errors.New("instructor is assigned to classes, reassign them first")
+)
+
+// Room errors
+var (
+	ErrRoomNotFound        = errors.New("room not found")
+	ErrInvalidRoom         = errors.New("invalid room")
+	ErrUnknownResource     = errors.New("the room has no equipment of this name")
+	ErrCapacityExceedsRoom = errors.New("class capacity exceeds the capacity of its room or equipment")
+	ErrRoomConflict        = errors.New("room is already used by another class at this time")
+	ErrRoomInUse           = errors.New("room is used by classes, move them first")
+	ErrUnknownSpot         = errors.New("the seat layout of the class has no spot of this label")
+	ErrSpotTaken           = errors.New("spot is already booked for this session")
+)
+
+// Membership errors
+var (
+	ErrPlanNotFound  = errors.New("plan not found")
+	ErrInvalidPlan   = errors.New("invalid plan")
+	ErrNoEntitlement = errors.New("member has no membership or credit covering this class, buy a plan first")
+)
+
+// Payment errors
+var (
+	ErrInvalidPrice        = errors.New("invalid price, expected an amount in minor units and a 3 letter currency code")
+	ErrPaymentDeclined     = errors.New("payment was declined")
+	ErrInvalidSignature    = errors.New("invalid webhook signature")
+	ErrInvalidPaymentEvent = errors.New("invalid payment event")
+	ErrPaymentNotFound     = errors.New("no booking has this payment")
+)
+
+// Promo code errors
+var (
+	ErrPromoNotFound      = errors.New("promo code not found")
+	ErrInvalidPromo       = errors.New("invalid promo code")
+	ErrPromoExists        = errors.New("promo code already exists")
+	ErrPromoNotApplicable = errors.New("promo code does not apply to this booking")
+	ErrPromoExhausted     = errors.New("promo code has no redemptions left")
+	ErrPromoRedeemed      = errors.New("member already redeemed this promo code")
+)
+
+// Optimistic concurrency errors
+var (
+	ErrIfMatchRequired = errors.New("If-Match header with the ETag of the resource is required")
+	ErrVersionMismatch = errors.New("resource was changed by another request, fetch it again and retry")
+)
+
+// Authentication and authorization errors
+var (
+	ErrUnauthenticated    = errors.New("authentication required, send a bearer token or an API key")
+	ErrInvalidCredentials = errors.New("invalid or expired credentials")
+	ErrForbidden          = errors.New("not allowed")
+	ErrInvalidStudio      = errors.New("invalid studio ID, expected up to 64 lowercase letters, digits, _ or -")
+	ErrStudioNotFound     = errors.New("studio not found")
+)
+
+// Idempotency-Key errors
+var (
+	ErrInvalidIdempotencyKey    = errors.New("idempotency key must be between 1 and 255 characters")
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
 )
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"glofox/internal/auth"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/policy"
+	"glofox/internal/services"
+	"glofox/internal/utils"
+	"net/http"
+	"strings"
+)
+
+// Authenticate returns a middleware that resolves the caller of each request
+// from its bearer token or API key and rejects requests without valid credentials
+func Authenticate(authenticator *auth.Authenticator) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal, err := authenticate(authenticator, ctx.Request)
+		if err != nil {
+			ctx.Header(constants.HeaderWWWAuthenticate, constants.BearerScheme)
+			utils.WriteProblem(ctx, err)
+			ctx.Abort()
+			return
+		}
+		ctx.Set(constants.ContextPrincipal, principal)
+		ctx.Next()
+	}
+}
+
+// authenticate verifies the API key of a request, or else its bearer token
+func authenticate(authenticator *auth.Authenticator, req *http.Request) (models.Principal, error) {
+	if key := req.Header.Get(constants.HeaderAPIKey); key != "" {
+		return authenticator.VerifyAPIKey(key)
+	}
+	scheme, token, _ := strings.Cut(req.Header.Get(constants.HeaderAuthorization), " ")
+	token = strings.TrimSpace(token)
+	if !strings.EqualFold(scheme, constants.BearerScheme) || token == "" {
+		return models.Principal{}, constants.ErrUnauthenticated
+	}
+	return authenticator.VerifyToken(token)
+}
+
+// principalFrom returns the authenticated caller of the request, false when
+// authentication is disabled
+func principalFrom(ctx *gin.Context) (models.Principal, bool) {
+	value, ok := ctx.Get(constants.ContextPrincipal)
+	if !ok {
+		return models.Principal{}, false
+	}
+	principal, ok := value.(models.Principal)
+	return principal, ok
+}
+
+// callerMemberID returns the member ID of a caller authenticated as a member,
+// which requests acting on a member default to
+func callerMemberID(ctx *gin.Context) string {
+	if principal, ok := principalFrom(ctx); ok && principal.Role == constants.RoleMember {
+		return principal.Subject
+	}
+	return ""
+}
+
+// serviceFor returns the service of the studio of the request resolved by
+// ResolveStudio as its caller may use it, unchecked when authentication is disabled
+func (h *ClassHandler) serviceFor(ctx *gin.Context) services.IService {
+	service := ctx.MustGet(constants.ContextService).(services.IService)
+	principal, ok := principalFrom(ctx)
+	if !ok {
+		return service
+	}
+	return policy.New(service, principal)
+}
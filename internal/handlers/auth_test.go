@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"glofox/internal/auth"
+	"glofox/internal/config"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+// hs256Token returns a token for the caller signed with testJWTSecret
+func hs256Token(t *testing.T, principal models.Principal) string {
+	t.Helper()
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encode(map[string]any{
+		"sub":       principal.Subject,
+		"role":      principal.Role,
+		"studio_id": principal.StudioID,
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	})
+	mac := hmac.New(sha256.New, []byte(testJWTSecret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator, err := auth.New(config.AuthConfig{
+		Mode:      constants.AuthRequired,
+		JWTSecret: testJWTSecret,
+		APIKeys:   []config.APIKey{{Name: "billing", StudioID: "st_1", Hash: auth.HashAPIKey("secret-key")}},
+	})
+	require.NoError(t, err)
+	member := models.Principal{Subject: "mb_1", Role: constants.RoleMember, StudioID: "st_1"}
+	staff := models.Principal{Subject: "st_admin", Role: constants.RoleStaff, StudioID: "st_1"}
+	result := models.BookingResult{
+		Status:  constants.BookingStatusBooked,
+		Booking: &models.Booking{ID: "bk_1", ClassName: "Yoga", MemberID: "mb_1", MemberName: "Alice"},
+	}
+
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		jsonInput      string
+		mockSetup      func(*MockClassService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "No Credentials",
+			jsonInput:      `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`,
+			mockSetup:      func(m *MockClassService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "unauthenticated",
+		},
+		{
+			name:           "Other Scheme",
+			headers:        map[string]string{constants.HeaderAuthorization: "Basic YWxpY2U6cGFzcw=="},
+			jsonInput:      `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`,
+			mockSetup:      func(m *MockClassService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "unauthenticated",
+		},
+		{
+			name:           "Invalid Token",
+			headers:        map[string]string{constants.HeaderAuthorization: "Bearer not.a.token"},
+			jsonInput:      `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`,
+			mockSetup:      func(m *MockClassService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "invalid_credentials",
+		},
+		{
+			name:           "Unknown API Key",
+			headers:        map[string]string{constants.HeaderAPIKey: "other-key"},
+			jsonInput:      `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`,
+			mockSetup:      func(m *MockClassService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "invalid_credentials",
+		},
+		{
+			name:      "API Key",
+			headers:   map[string]string{constants.HeaderAPIKey: "secret-key"},
+			jsonInput: `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`,
+			mockSetup: func(m *MockClassService) {
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberID: "mb_1", Date: "2025-06-10"}).Return(result, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:      "Member Books For Themselves",
+			headers:   map[string]string{constants.HeaderAuthorization: "Bearer " + hs256Token(t, member)},
+			jsonInput: `{"class_name":"Yoga","date":"2025-06-10"}`,
+			mockSetup: func(m *MockClassService) {
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberID: "mb_1", Date: "2025-06-10"}).Return(result, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Member Names Another Member",
+			headers:        map[string]string{constants.HeaderAuthorization: "Bearer " + hs256Token(t, member)},
+			jsonInput:      `{"class_name":"Yoga","name":"Bob","date":"2025-06-10"}`,
+			mockSetup:      func(m *MockClassService) {},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "forbidden",
+		},
+		{
+			name:           "Staff Names No Member",
+			headers:        map[string]string{constants.HeaderAuthorization: "Bearer " + hs256Token(t, staff)},
+			jsonInput:      `{"class_name":"Yoga","date":"2025-06-10"}`,
+			mockSetup:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockClassService)
+			tt.mockSetup(mockService)
+			router := SetupRouter(NewClassHandler(mockService), RouterOptions{Authenticator: authenticator})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/bookings", bytes.NewBufferString(tt.jsonInput))
+			req.Header.Set("Content-Type", "application/json")
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var problem models.Problem
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+				assert.Equal(t, tt.expectedCode, problem.Code)
+			}
+			if tt.expectedStatus == http.StatusUnauthorized {
+				assert.Equal(t, constants.BearerScheme, w.Header().Get(constants.HeaderWWWAuthenticate))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthenticate_IdempotencyKeysPerCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator, err := auth.New(config.AuthConfig{Mode: constants.AuthRequired, JWTSecret: testJWTSecret})
+	require.NoError(t, err)
+	result := models.BookingResult{Status: constants.BookingStatusBooked, Booking: &models.Booking{ID: "bk_1"}}
+	mockService := new(MockClassService)
+	mockService.On("BookClass", mock.Anything).Return(result, nil)
+	router := SetupRouter(NewClassHandler(mockService), RouterOptions{Authenticator: authenticator, IdempotencyRepo: idempotencyRepos(repository.NewMemoryStudios()), IdempotencyTTL: time.Hour})
+
+	post := func(principal models.Principal) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/bookings", bytes.NewBufferString(`{"class_name":"Yoga","date":"2025-06-10"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(constants.HeaderIdempotencyKey, "key-1")
+		req.Header.Set(constants.HeaderAuthorization, "Bearer "+hs256Token(t, principal))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// A key reused by another member is refused instead of replaying the booking of the first
+	assert.Equal(t, http.StatusCreated, post(models.Principal{Subject: "mb_1", Role: constants.RoleMember, StudioID: "st_1"}).Code)
+	w := post(models.Principal{Subject: "mb_2", Role: constants.RoleMember, StudioID: "st_1"})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	mockService.AssertNumberOfCalls(t, "BookClass", 1)
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"glofox/internal/constants"
@@ -13,33 +12,121 @@ import (
 
 // ClassHandler handles HTTP requests
 type ClassHandler struct {
-	service services.IService
+	studios services.IStudios
 }
 
+// NewClassHandler creates a handler serving every studio with one service
 func NewClassHandler(service services.IService) IHandler {
-	return &ClassHandler{service: service}
+	return NewStudioHandler(singleService{service: service})
+}
+
+// NewStudioHandler creates a handler serving each studio with its own service
+func NewStudioHandler(studios services.IStudios) IHandler {
+	registerValidators()
+	return &ClassHandler{studios: studios}
 }
 
 // CreateBooking handles POST /bookings
 func (h *ClassHandler) CreateBooking(ctx *gin.Context) {
 	var req models.BookingRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		utils.HandleErrorResp(ctx, http.StatusBadRequest, err, constants.ErrInvalidReq)
+	if !decodeJSON(ctx, &req) {
+		return
+	}
+	// Members authenticated with their own token book for themselves
+	if req.MemberID == "" && req.MemberName == "" {
+		req.MemberID = callerMemberID(ctx)
+	}
+	if !validate(ctx, &req) {
 		return
 	}
 
-	err := h.service.BookClass(req.ClassName, req.MemberName, req.Date)
+	deprecatedMemberName(ctx, req.MemberID)
+	result, err := h.serviceFor(ctx).BookClass(req)
 	if err != nil {
-		statusCode := http.StatusBadRequest
-		if errors.Is(err, constants.ErrClassNotFound) {
-			statusCode = http.StatusNotFound
-		}
-		utils.HandleErrorResp(ctx, statusCode, err, "")
+		utils.WriteProblem(ctx, err)
 		return
 	}
 
+	member := memberLabel(req.MemberID, req.MemberName)
+	if result.Status == constants.BookingStatusWaitlisted {
+		ctx.JSON(http.StatusAccepted, models.Response{
+			Status:  constants.SuccessMsg,
+			Message: fmt.Sprintf("Class %s is full on %s, %s added to the waitlist at position %d", req.ClassName, req.Date, member, result.Position),
+			Data:    result,
+		})
+		return
+	}
+
+	if result.Booking != nil {
+		member = result.Booking.MemberName
+		setETag(ctx, result.Booking.Version)
+	}
+	if result.Status == constants.BookingStatusPending {
+		ctx.JSON(http.StatusAccepted, models.Response{
+			Status:  constants.SuccessMsg,
+			Message: fmt.Sprintf("Booking for %s on %s for class %s is pending until the payment is confirmed", member, req.Date, req.ClassName),
+			Data:    result,
+		})
+		return
+	}
 	ctx.JSON(http.StatusCreated, models.Response{
 		Status:  constants.SuccessMsg,
-		Message: fmt.Sprintf("Booking created for %s on %s for class %s", req.MemberName, req.Date, req.ClassName),
+		Message: fmt.Sprintf("Booking created for %s on %s for class %s", member, req.Date, req.ClassName),
+		Data:    result,
+	})
+}
+
+// GetBooking handles GET /bookings/:id
+func (h *ClassHandler) GetBooking(ctx *gin.Context) {
+	booking, err := h.serviceFor(ctx).GetBooking(ctx.Param("id"))
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+	setETag(ctx, booking.Version)
+	ctx.JSON(http.StatusOK, models.Response{Status: constants.SuccessMsg, Data: booking})
+}
+
+// CancelBooking handles DELETE /bookings/:id
+func (h *ClassHandler) CancelBooking(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+
+	booking, err := h.serviceFor(ctx).CancelBooking(ctx.Param("id"), version)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	setETag(ctx, booking.Version)
+	message := fmt.Sprintf("Booking %s cancelled", booking.ID)
+	if booking.LateCancel {
+		message += " (late cancellation)"
+	}
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: message,
+		Data:    booking,
+	})
+}
+
+// ListBookings handles GET /bookings
+func (h *ClassHandler) ListBookings(ctx *gin.Context) {
+	var req models.BookingListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	page, err := h.serviceFor(ctx).ListBookings(req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   page,
 	})
 }
@@ -21,9 +21,57 @@ type MockClassService struct {
 }
 
 // BookClass mocks the BookClass method
-func (m *MockClassService) BookClass(className, memberName, dateStr string) error {
-	args := m.Called(className, memberName, dateStr)
-	return args.Error(0)
+func (m *MockClassService) BookClass(req models.BookingRequest) (models.BookingResult, error) {
+	args := m.Called(req)
+	result, _ := args.Get(0).(models.BookingResult)
+	return result, args.Error(1)
+}
+
+// GetBooking mocks the GetBooking method
+func (m *MockClassService) GetBooking(id string) (models.Booking, error) {
+	args := m.Called(id)
+	booking, _ := args.Get(0).(models.Booking)
+	return booking, args.Error(1)
+}
+
+// CancelBooking mocks the CancelBooking method
+func (m *MockClassService) CancelBooking(id string, version int) (models.Booking, error) {
+	args := m.Called(id, version)
+	booking, _ := args.Get(0).(models.Booking)
+	return booking, args.Error(1)
+}
+
+// ListBookings mocks the ListBookings method
+func (m *MockClassService) ListBookings(req models.BookingListRequest) (models.Page[models.Booking], error) {
+	args := m.Called(req)
+	page, _ := args.Get(0).(models.Page[models.Booking])
+	return page, args.Error(1)
+}
+
+// assertBody checks the status and message of a response, or the problem
+// with the given code and detail of an error response
+func assertBody(t *testing.T, w *httptest.ResponseRecorder, code string, expected models.Response) {
+	t.Helper()
+	if code == "" {
+		var resp models.Response
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), "Failed to unmarshal response")
+		assert.Equal(t, expected.Status, resp.Status)
+		assert.Equal(t, expected.Message, resp.Message)
+		return
+	}
+	assertProblem(t, w, code, expected.Message)
+}
+
+// assertProblem checks that the response is a problem with the given code and detail
+func assertProblem(t *testing.T, w *httptest.ResponseRecorder, code, detail string) {
+	t.Helper()
+	assert.Equal(t, constants.ContentTypeProblem, w.Header().Get("Content-Type"))
+	var problem models.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem), "Failed to unmarshal problem")
+	assert.Equal(t, code, problem.Code)
+	assert.Equal(t, constants.ProblemTypePrefix+code, problem.Type)
+	assert.Equal(t, w.Code, problem.Status)
+	assert.Equal(t, detail, problem.Detail)
 }
 
 func TestClassHandler_CreateBooking(t *testing.T) {
@@ -37,13 +85,14 @@ func TestClassHandler_CreateBooking(t *testing.T) {
 		setupMock      func(*MockClassService)
 		expectedStatus int
 		expectedBody   models.Response
+		expectedCode   string
 		expectService  bool
 	}{
 		{
 			name:      "Happy Path",
 			jsonInput: `{"class_name":"Yoga","name":"Alice","date":"2025-06-10"}`,
 			setupMock: func(m *MockClassService) {
-				m.On("BookClass", "Yoga", "Alice", "2025-06-10").Return(nil)
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2025-06-10"}).Return(models.BookingResult{Status: constants.BookingStatusBooked}, nil)
 			},
 			expectedStatus: http.StatusCreated,
 			expectedBody: models.Response{
@@ -53,44 +102,178 @@ func TestClassHandler_CreateBooking(t *testing.T) {
 			expectService: true,
 		},
 		{
-			name:      "Invalid Date Format",
-			jsonInput: `{"class_name":"Yoga","name":"Alice","date":"2025-06-10"}`,
+			name:      "Class Full Joins Waitlist",
+			jsonInput: `{"class_name":"Yoga","name":"Alice","date":"2025-06-10","join_waitlist":true}`,
 			setupMock: func(m *MockClassService) {
-				m.On("BookClass", "Yoga", "Alice", "2025-06-10").Return(constants.ErrInvalidDate)
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2025-06-10", JoinWaitlist: true}).Return(models.BookingResult{Status: constants.BookingStatusWaitlisted, Position: 2}, nil)
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusAccepted,
 			expectedBody: models.Response{
-				Status:  "error",
-				Message: constants.ErrInvalidDate.Error(),
+				Status:  constants.SuccessMsg,
+				Message: "Class Yoga is full on 2025-06-10, Alice added to the waitlist at position 2",
 			},
 			expectService: true,
 		},
 		{
-			name:      "Class Not Found",
-			jsonInput: `{"class_name":"Yoga","name":"Alice","date":"2025-06-10"}`,
+			name:      "Payment Pending",
+			jsonInput: `{"class_name":"Yoga","name":"Alice","date":"2025-06-10","payment_method":"pm_card"}`,
 			setupMock: func(m *MockClassService) {
-				m.On("BookClass", "Yoga", "Alice", "2025-06-10").Return(constants.ErrClassNotFound)
+				pending := models.Booking{ID: "bk_1", MemberName: "Alice", Status: constants.BookingStatusPending, Version: 2}
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2025-06-10", PaymentMethod: "pm_card"}).Return(models.BookingResult{Status: constants.BookingStatusPending, Booking: &pending}, nil)
 			},
-			expectedStatus: http.StatusNotFound,
+			expectedStatus: http.StatusAccepted,
 			expectedBody: models.Response{
-				Status:  "error",
-				Message: constants.ErrClassNotFound.Error(),
+				Status:  constants.SuccessMsg,
+				Message: "Booking for Alice on 2025-06-10 for class Yoga is pending until the payment is confirmed",
 			},
 			expectService: true,
 		},
 		{
-			name:      "Invalid Date Range",
-			jsonInput: `{"class_name":"Yoga","name":"Alice","date":"2025-06-21"}`,
+			name:      "Payment Declined",
+			jsonInput: `{"class_name":"Yoga","name":"Alice","date":"2025-06-10","payment_method":"pm_declined"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2025-06-10", PaymentMethod: "pm_declined"}).Return(models.BookingResult{}, constants.ErrPaymentDeclined)
+			},
+			expectedStatus: http.StatusPaymentRequired,
+			expectedCode:   "payment_declined",
+			expectedBody:   models.Response{Message: constants.ErrPaymentDeclined.Error()},
+			expectService:  true,
+		},
+		{
+			name:      "Invalid Date Format",
+			jsonInput: `{"class_name":"Yoga","name":"Alice","date":"2025-06-10"}`,
 			setupMock: func(m *MockClassService) {
-				m.On("BookClass", "Yoga", "Alice", "2025-06-21").Return(fmt.Errorf("date 2025-06-21 is not valid for class Yoga"))
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2025-06-10"}).Return(models.BookingResult{}, constants.ErrInvalidDate)
 			},
 			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_date",
+			expectedBody:   models.Response{Message: constants.ErrInvalidDate.Error()},
+			expectService:  true,
+		},
+		{
+			name:      "Class Not Found",
+			jsonInput: `{"class_name":"Yoga","name":"Alice","date":"2025-06-10"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2025-06-10"}).Return(models.BookingResult{}, constants.ErrClassNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "class_not_found",
+			expectedBody:   models.Response{Message: constants.ErrClassNotFound.Error()},
+			expectService:  true,
+		},
+		{
+			name:      "Class Full",
+			jsonInput: `{"class_name":"Yoga","name":"Alice","date":"2025-06-10"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2025-06-10"}).Return(models.BookingResult{}, constants.ErrClassFull)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "class_full",
+			expectedBody:   models.Response{Message: constants.ErrClassFull.Error()},
+			expectService:  true,
+		},
+		{
+			name:      "Already Booked",
+			jsonInput: `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberID: "mb_1", Date: "2025-06-10"}).Return(models.BookingResult{}, constants.ErrAlreadyBooked)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "already_booked",
+			expectedBody:   models.Response{Message: constants.ErrAlreadyBooked.Error()},
+			expectService:  true,
+		},
+		{
+			name:      "Spot Taken",
+			jsonInput: `{"class_name":"Spin","member_id":"mb_1","date":"2025-06-10","spot":"12"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("BookClass", models.BookingRequest{ClassName: "Spin", MemberID: "mb_1", Date: "2025-06-10", Spot: "12"}).Return(models.BookingResult{}, constants.ErrSpotTaken)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "spot_taken",
+			expectedBody:   models.Response{Message: constants.ErrSpotTaken.Error()},
+			expectService:  true,
+		},
+		{
+			name:      "No Entitlement",
+			jsonInput: `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberID: "mb_1", Date: "2025-06-10"}).Return(models.BookingResult{}, constants.ErrNoEntitlement)
+			},
+			expectedStatus: http.StatusPaymentRequired,
+			expectedCode:   "no_entitlement",
+			expectedBody:   models.Response{Message: constants.ErrNoEntitlement.Error()},
+			expectService:  true,
+		},
+		{
+			name:      "Daily Limit Reached",
+			jsonInput: `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberID: "mb_1", Date: "2025-06-10"}).Return(models.BookingResult{}, constants.ErrDailyLimitReached)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "daily_limit_reached",
+			expectedBody:   models.Response{Message: constants.ErrDailyLimitReached.Error()},
+			expectService:  true,
+		},
+		{
+			name:      "By Member ID",
+			jsonInput: `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberID: "mb_1", Date: "2025-06-10"}).Return(models.BookingResult{
+					Status:  constants.BookingStatusBooked,
+					Booking: &models.Booking{ID: "bk_1", MemberID: "mb_1", MemberName: "Alice"},
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
 			expectedBody: models.Response{
-				Status:  "error",
-				Message: "date 2025-06-21 is not valid for class Yoga",
+				Status:  constants.SuccessMsg,
+				Message: "Booking created for Alice on 2025-06-10 for class Yoga",
 			},
 			expectService: true,
 		},
+		{
+			name:           "Missing Member",
+			jsonInput:      `{"class_name":"Yoga","date":"2025-06-10"}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+			expectedBody:   models.Response{Message: constants.ErrInvalidReq.Error() + ": member_id is required when name is not set"},
+			expectService:  false,
+		},
+		{
+			name:      "Member Suspended",
+			jsonInput: `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberID: "mb_1", Date: "2025-06-10"}).Return(models.BookingResult{}, constants.ErrMemberSuspended)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "member_suspended",
+			expectedBody:   models.Response{Message: constants.ErrMemberSuspended.Error()},
+			expectService:  true,
+		},
+		{
+			name:      "Member Not Found",
+			jsonInput: `{"class_name":"Yoga","member_id":"mb_2","date":"2025-06-10"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberID: "mb_2", Date: "2025-06-10"}).Return(models.BookingResult{}, constants.ErrMemberNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "member_not_found",
+			expectedBody:   models.Response{Message: constants.ErrMemberNotFound.Error()},
+			expectService:  true,
+		},
+		{
+			name:      "Session Not Found",
+			jsonInput: `{"class_name":"Yoga","name":"Alice","date":"2025-06-21"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2025-06-21"}).Return(models.BookingResult{}, fmt.Errorf("%w: class Yoga does not run on 2025-06-21", constants.ErrSessionNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "session_not_found",
+			expectedBody:   models.Response{Message: "session not found: class Yoga does not run on 2025-06-21"},
+			expectService:  true,
+		},
 	}
 
 	// Run tests
@@ -104,6 +287,7 @@ func TestClassHandler_CreateBooking(t *testing.T) {
 			// Create test context and recorder
 			w := httptest.NewRecorder()
 			ctx, _ := gin.CreateTestContext(w)
+			ctx.Set(constants.ContextService, mockService)
 
 			// Create HTTP request
 			req, _ := http.NewRequest("POST", "/bookings", bytes.NewBufferString(tt.jsonInput))
@@ -117,18 +301,265 @@ func TestClassHandler_CreateBooking(t *testing.T) {
 			assert.Equal(t, tt.expectedStatus, w.Code, "Expected status %d, got %d", tt.expectedStatus, w.Code)
 
 			// Assert response body
-			var resp models.Response
-			err := json.Unmarshal(w.Body.Bytes(), &resp)
-			assert.NoError(t, err, "Failed to unmarshal response")
-			assert.Equal(t, tt.expectedBody.Status, resp.Status, "Expected status %s, got %s", tt.expectedBody.Status, resp.Status)
-			assert.Equal(t, tt.expectedBody.Message, resp.Message, "Expected message %s, got %s", tt.expectedBody.Message, resp.Message)
+			assertBody(t, w, tt.expectedCode, tt.expectedBody)
 
 			// Assert service calls
 			if tt.expectService {
-				mockService.AssertCalled(t, "BookClass", mock.Anything, mock.Anything, mock.Anything)
+				mockService.AssertCalled(t, "BookClass", mock.Anything)
 			} else {
 				mockService.AssertNotCalled(t, "BookClass")
 			}
 		})
 	}
 }
+
+func TestClassHandler_GetBooking(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		setupMock      func(*MockClassService)
+		expectedStatus int
+		expectedBody   models.Response
+		expectedCode   string
+	}{
+		{
+			name: "Happy Path",
+			setupMock: func(m *MockClassService) {
+				m.On("GetBooking", "bk_1").Return(models.Booking{ID: "bk_1", ClassName: "Yoga", Status: constants.BookingStatusBooked, Version: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.Response{Status: constants.SuccessMsg},
+		},
+		{
+			name: "Booking Not Found",
+			setupMock: func(m *MockClassService) {
+				m.On("GetBooking", "bk_1").Return(models.Booking{}, constants.ErrBookingNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "booking_not_found",
+			expectedBody:   models.Response{Message: constants.ErrBookingNotFound.Error()},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup mock service and router
+			mockService := new(MockClassService)
+			tt.setupMock(mockService)
+			router := SetupRouter(NewClassHandler(mockService), RouterOptions{})
+
+			// Create HTTP request
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/bookings/bk_1", nil)
+			router.ServeHTTP(w, req)
+
+			// Assert status code
+			assert.Equal(t, tt.expectedStatus, w.Code, "Expected status %d, got %d", tt.expectedStatus, w.Code)
+			if w.Code == http.StatusOK {
+				assert.Equal(t, `"2"`, w.Header().Get(constants.HeaderETag))
+			} else {
+				assert.Empty(t, w.Header().Get(constants.HeaderETag))
+			}
+
+			// Assert response body
+			assertBody(t, w, tt.expectedCode, tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestClassHandler_CancelBooking(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		ifMatch        string
+		setupMock      func(*MockClassService)
+		expectedStatus int
+		expectedBody   models.Response
+		expectedCode   string
+	}{
+		{
+			name:    "Happy Path",
+			ifMatch: `"1"`,
+			setupMock: func(m *MockClassService) {
+				m.On("CancelBooking", "bk_1", 1).Return(models.Booking{ID: "bk_1", Status: constants.BookingStatusCancelled, Version: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Booking bk_1 cancelled",
+			},
+		},
+		{
+			name:    "Late Cancellation",
+			ifMatch: `"1"`,
+			setupMock: func(m *MockClassService) {
+				m.On("CancelBooking", "bk_1", 1).Return(models.Booking{ID: "bk_1", Status: constants.BookingStatusCancelled, LateCancel: true, Version: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Booking bk_1 cancelled (late cancellation)",
+			},
+		},
+		{
+			name:    "Booking Not Found",
+			ifMatch: `"1"`,
+			setupMock: func(m *MockClassService) {
+				m.On("CancelBooking", "bk_1", 1).Return(models.Booking{}, constants.ErrBookingNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "booking_not_found",
+			expectedBody:   models.Response{Message: constants.ErrBookingNotFound.Error()},
+		},
+		{
+			name:    "Class Already Started",
+			ifMatch: `"1"`,
+			setupMock: func(m *MockClassService) {
+				m.On("CancelBooking", "bk_1", 1).Return(models.Booking{}, constants.ErrCancellationClosed)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "cancellation_closed",
+			expectedBody:   models.Response{Message: constants.ErrCancellationClosed.Error()},
+		},
+		{
+			name:    "Any Version",
+			ifMatch: "*",
+			setupMock: func(m *MockClassService) {
+				m.On("CancelBooking", "bk_1", constants.AnyVersion).Return(models.Booking{ID: "bk_1", Status: constants.BookingStatusCancelled, Version: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Booking bk_1 cancelled",
+			},
+		},
+		{
+			name:    "Stale Version",
+			ifMatch: `"1"`,
+			setupMock: func(m *MockClassService) {
+				m.On("CancelBooking", "bk_1", 1).Return(models.Booking{}, constants.ErrVersionMismatch)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedCode:   "version_mismatch",
+			expectedBody:   models.Response{Message: constants.ErrVersionMismatch.Error()},
+		},
+		{
+			name:           "Weak ETag",
+			ifMatch:        `W/"1"`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedCode:   "version_mismatch",
+			expectedBody:   models.Response{Message: constants.ErrVersionMismatch.Error()},
+		},
+		{
+			name:           "Missing If-Match",
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusPreconditionRequired,
+			expectedCode:   "if_match_required",
+			expectedBody:   models.Response{Message: constants.ErrIfMatchRequired.Error()},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup mock service and router
+			mockService := new(MockClassService)
+			tt.setupMock(mockService)
+			router := SetupRouter(NewClassHandler(mockService), RouterOptions{})
+
+			// Create HTTP request
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, "/bookings/bk_1", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set(constants.HeaderIfMatch, tt.ifMatch)
+			}
+			router.ServeHTTP(w, req)
+
+			// Assert status code
+			assert.Equal(t, tt.expectedStatus, w.Code, "Expected status %d, got %d", tt.expectedStatus, w.Code)
+			if w.Code == http.StatusOK {
+				assert.Equal(t, `"2"`, w.Header().Get(constants.HeaderETag))
+			}
+
+			// Assert response body
+			assertBody(t, w, tt.expectedCode, tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestClassHandler_ListBookings(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		path           string
+		setupMock      func(*MockClassService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Happy Path",
+			path: "/bookings?member=Alice&class=Yoga&from=2025-06-01&to=2025-06-20&limit=5",
+			setupMock: func(m *MockClassService) {
+				m.On("ListBookings", models.BookingListRequest{
+					ListRequest: models.ListRequest{Limit: 5},
+					MemberName:  "Alice",
+					ClassName:   "Yoga",
+					From:        "2025-06-01",
+					To:          "2025-06-20",
+				}).Return(models.Page[models.Booking]{Items: []models.Booking{{ID: "bk_1"}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"id":"bk_1"`,
+		},
+		{
+			name: "Invalid Date Range",
+			path: "/bookings?from=2025-06-20&to=2025-06-01",
+			setupMock: func(m *MockClassService) {
+				m.On("ListBookings", models.BookingListRequest{From: "2025-06-20", To: "2025-06-01"}).
+					Return(models.Page[models.Booking]{}, constants.ErrInvalidStartEndDate)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   constants.ErrInvalidStartEndDate.Error(),
+		},
+		{
+			name:           "Invalid Limit",
+			path:           "/bookings?limit=0x",
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   constants.ErrInvalidQuery.Error(),
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup mock service and router
+			mockService := new(MockClassService)
+			tt.setupMock(mockService)
+			router := SetupRouter(NewClassHandler(mockService), RouterOptions{})
+
+			// Create HTTP request
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			router.ServeHTTP(w, req)
+
+			// Assert response
+			assert.Equal(t, tt.expectedStatus, w.Code, "Expected status %d, got %d", tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
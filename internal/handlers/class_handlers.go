@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"glofox/internal/constants"
@@ -13,23 +12,128 @@ import (
 // CreateClass handles POST /classes
 func (h *ClassHandler) CreateClass(ctx *gin.Context) {
 	var req models.ClassRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		utils.HandleErrorResp(ctx, http.StatusBadRequest, err, constants.ErrInvalidReq)
+	if !bindJSON(ctx, &req) {
 		return
 	}
 
-	err := h.service.CreateClass(req.Name, req.StartDate, req.EndDate, req.Capacity)
+	class, err := h.serviceFor(ctx).CreateClass(req)
 	if err != nil {
-		statusCode := http.StatusBadRequest
-		if errors.Is(err, constants.ErrClassAlreadyExists) {
-			statusCode = http.StatusConflict
-		}
-		utils.HandleErrorResp(ctx, statusCode, err, "")
+		utils.WriteProblem(ctx, err)
 		return
 	}
 
+	setETag(ctx, class.Version)
 	ctx.JSON(http.StatusCreated, models.Response{
 		Status:  constants.SuccessMsg,
 		Message: fmt.Sprintf("Class %s created successfully", req.Name),
+		Data:    class,
+	})
+}
+
+// GetClass handles GET /classes/:name
+func (h *ClassHandler) GetClass(ctx *gin.Context) {
+	class, err := h.serviceFor(ctx).GetClass(ctx.Param("name"))
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	setETag(ctx, class.Version)
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   class,
+	})
+}
+
+// ListClasses handles GET /classes
+func (h *ClassHandler) ListClasses(ctx *gin.Context) {
+	var req models.ListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	page, err := h.serviceFor(ctx).ListClasses(req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   page,
+	})
+}
+
+// ListSessions handles GET /classes/:name/sessions
+func (h *ClassHandler) ListSessions(ctx *gin.Context) {
+	var req models.ListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	page, err := h.serviceFor(ctx).ListSessions(ctx.Param("name"), req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   page,
+	})
+}
+
+// UpdateClass handles PATCH /classes/:name
+func (h *ClassHandler) UpdateClass(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	var change models.ClassChangeRequest
+	if !bindQuery(ctx, &change) {
+		return
+	}
+	var req models.ClassUpdateRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	name := ctx.Param("name")
+	result, err := h.serviceFor(ctx).UpdateClass(name, version, req, change)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	setETag(ctx, result.Class.Version)
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Class %s updated successfully", name),
+		Data:    result,
+	})
+}
+
+// DeleteClass handles DELETE /classes/:name
+func (h *ClassHandler) DeleteClass(ctx *gin.Context) {
+	version, ok := ifMatch(ctx)
+	if !ok {
+		return
+	}
+	var change models.ClassChangeRequest
+	if !bindQuery(ctx, &change) {
+		return
+	}
+
+	name := ctx.Param("name")
+	result, err := h.serviceFor(ctx).DeleteClass(name, version, change)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Class %s deleted", name),
+		Data:    result,
 	})
 }
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"bytes"
-	"encoding/json"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -14,9 +13,45 @@ import (
 )
 
 // CreateClass mocks the CreateClass method
-func (m *MockClassService) CreateClass(name, startDateStr, endDateStr string, capacity int) error {
-	args := m.Called(name, startDateStr, endDateStr, capacity)
-	return args.Error(0)
+func (m *MockClassService) CreateClass(req models.ClassRequest) (models.Class, error) {
+	args := m.Called(req)
+	class, _ := args.Get(0).(models.Class)
+	return class, args.Error(1)
+}
+
+// GetClass mocks the GetClass method
+func (m *MockClassService) GetClass(name string) (models.Class, error) {
+	args := m.Called(name)
+	class, _ := args.Get(0).(models.Class)
+	return class, args.Error(1)
+}
+
+// ListClasses mocks the ListClasses method
+func (m *MockClassService) ListClasses(req models.ListRequest) (models.Page[models.Class], error) {
+	args := m.Called(req)
+	page, _ := args.Get(0).(models.Page[models.Class])
+	return page, args.Error(1)
+}
+
+// ListSessions mocks the ListSessions method
+func (m *MockClassService) ListSessions(className string, req models.ListRequest) (models.Page[models.Session], error) {
+	args := m.Called(className, req)
+	page, _ := args.Get(0).(models.Page[models.Session])
+	return page, args.Error(1)
+}
+
+// UpdateClass mocks the UpdateClass method
+func (m *MockClassService) UpdateClass(name string, version int, req models.ClassUpdateRequest, change models.ClassChangeRequest) (models.ClassChangeResult, error) {
+	args := m.Called(name, version, req, change)
+	result, _ := args.Get(0).(models.ClassChangeResult)
+	return result, args.Error(1)
+}
+
+// DeleteClass mocks the DeleteClass method
+func (m *MockClassService) DeleteClass(name string, version int, change models.ClassChangeRequest) (models.ClassChangeResult, error) {
+	args := m.Called(name, version, change)
+	result, _ := args.Get(0).(models.ClassChangeResult)
+	return result, args.Error(1)
 }
 
 func TestClassHandler_CreateClass(t *testing.T) {
@@ -30,13 +65,14 @@ func TestClassHandler_CreateClass(t *testing.T) {
 		setupMock      func(*MockClassService)
 		expectedStatus int
 		expectedBody   models.Response
+		expectedCode   string
 		expectService  bool
 	}{
 		{
 			name:      "Happy Path",
 			jsonInput: `{"name":"Yoga","start_date":"2025-06-01","end_date":"2025-06-20","capacity":10}`,
 			setupMock: func(m *MockClassService) {
-				m.On("CreateClass", "Yoga", "2025-06-01", "2025-06-20", 10).Return(nil)
+				m.On("CreateClass", models.ClassRequest{Name: "Yoga", StartDate: "2025-06-01", EndDate: "2025-06-20", Capacity: 10}).Return(models.Class{Name: "Yoga", Version: 1}, nil)
 			},
 			expectedStatus: http.StatusCreated,
 			expectedBody: models.Response{
@@ -49,53 +85,92 @@ func TestClassHandler_CreateClass(t *testing.T) {
 			name:      "Invalid Start Date Format",
 			jsonInput: `{"name":"Yoga","start_date":"2025-06-01T00:00:00Z","end_date":"2025-06-20","capacity":10}`,
 			setupMock: func(m *MockClassService) {
-				m.On("CreateClass", "Yoga", "2025-06-01T00:00:00Z", "2025-06-20", 10).Return(constants.ErrInvalidStartDate)
+				m.On("CreateClass", models.ClassRequest{Name: "Yoga", StartDate: "2025-06-01T00:00:00Z", EndDate: "2025-06-20", Capacity: 10}).Return(models.Class{}, constants.ErrInvalidStartDate)
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody: models.Response{
-				Status:  "error",
-				Message: constants.ErrInvalidStartDate.Error(),
-			},
-			expectService: true,
+			expectedCode:   "invalid_date",
+			expectedBody:   models.Response{Message: constants.ErrInvalidStartDate.Error()},
+			expectService:  true,
+		},
+		{
+			name:           "Invalid End Date Format",
+			jsonInput:      `{"name":"Yoga","start_date":"2025-06-01","end_date":"2025/06/20","capacity":10}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+			expectedBody:   models.Response{Message: constants.ErrInvalidReq.Error() + ": end_date must be a date as YYYY-MM-DD or RFC 3339"},
+			expectService:  false,
 		},
 		{
-			name:      "Invalid End Date Format",
-			jsonInput: `{"name":"Yoga","start_date":"2025-06-01","end_date":"2025/06/20","capacity":10}`,
+			name:      "Start Date After End Date",
+			jsonInput: `{"name":"Yoga","start_date":"2025-06-21","end_date":"2025-06-01","capacity":10}`,
 			setupMock: func(m *MockClassService) {
-				m.On("CreateClass", "Yoga", "2025-06-01", "2025/06/20", 10).Return(constants.ErrInvalidEndDate)
+				m.On("CreateClass", models.ClassRequest{Name: "Yoga", StartDate: "2025-06-21", EndDate: "2025-06-01", Capacity: 10}).Return(models.Class{}, constants.ErrInvalidStartEndDate)
 			},
 			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_date_range",
+			expectedBody:   models.Response{Message: constants.ErrInvalidStartEndDate.Error()},
+			expectService:  true,
+		},
+		{
+			name:      "With Cancellation Policy",
+			jsonInput: `{"name":"Yoga","start_date":"2025-06-01","end_date":"2025-06-20","capacity":10,"cancellation_policy":{"free_cancel_hours":24,"allow_late_cancel":false}}`,
+			setupMock: func(m *MockClassService) {
+				freeCancelHours, allowLateCancel := 24, false
+				m.On("CreateClass", models.ClassRequest{Name: "Yoga", StartDate: "2025-06-01", EndDate: "2025-06-20", Capacity: 10,
+					CancellationPolicy: &models.CancellationPolicyRequest{FreeCancelHours: &freeCancelHours, AllowLateCancel: &allowLateCancel}}).Return(models.Class{}, nil)
+			},
+			expectedStatus: http.StatusCreated,
 			expectedBody: models.Response{
-				Status:  "error",
-				Message: constants.ErrInvalidEndDate.Error(),
+				Status:  constants.SuccessMsg,
+				Message: "Class Yoga created successfully",
 			},
 			expectService: true,
 		},
 		{
-			name:      "Start Date After End Date",
-			jsonInput: `{"name":"Yoga","start_date":"2025-06-21","end_date":"2025-06-01","capacity":10}`,
+			name:      "With Recurrence",
+			jsonInput: `{"name":"Yoga","start_date":"2025-06-02","end_date":"2025-08-31","capacity":10,"recurrence":{"rrule":"FREQ=WEEKLY;BYDAY=MO,WE,FR","exclusions":["2025-08-04"]}}`,
 			setupMock: func(m *MockClassService) {
-				m.On("CreateClass", "Yoga", "2025-06-21", "2025-06-01", 10).Return(constants.ErrInvalidStartEndDate)
+				m.On("CreateClass", models.ClassRequest{Name: "Yoga", StartDate: "2025-06-02", EndDate: "2025-08-31", Capacity: 10,
+					Recurrence: &models.RecurrenceRequest{RRule: "FREQ=WEEKLY;BYDAY=MO,WE,FR", Exclusions: []string{"2025-08-04"}}}).Return(models.Class{}, nil)
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusCreated,
 			expectedBody: models.Response{
-				Status:  "error",
-				Message: constants.ErrInvalidStartEndDate.Error(),
+				Status:  constants.SuccessMsg,
+				Message: "Class Yoga created successfully",
 			},
 			expectService: true,
 		},
+		{
+			name:           "Unknown Recurrence Frequency",
+			jsonInput:      `{"name":"Yoga","start_date":"2025-06-02","end_date":"2025-08-31","capacity":10,"recurrence":{"frequency":"hourly"}}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+			expectedBody:   models.Response{Message: constants.ErrInvalidReq.Error() + ": recurrence.frequency must be one of daily, weekly, monthly"},
+			expectService:  false,
+		},
+		{
+			name:      "Recurrence Without Occurrences",
+			jsonInput: `{"name":"Yoga","start_date":"2025-06-02","end_date":"2025-06-06","capacity":10,"recurrence":{"rrule":"FREQ=WEEKLY;BYDAY=SA"}}`,
+			setupMock: func(m *MockClassService) {
+				m.On("CreateClass", mock.Anything).Return(models.Class{}, constants.ErrNoOccurrences)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "no_occurrences",
+			expectedBody:   models.Response{Message: constants.ErrNoOccurrences.Error()},
+			expectService:  true,
+		},
 		{
 			name:      "Class Already Exists",
 			jsonInput: `{"name":"Yoga","start_date":"2025-06-01","end_date":"2025-06-20","capacity":10}`,
 			setupMock: func(m *MockClassService) {
-				m.On("CreateClass", "Yoga", "2025-06-01", "2025-06-20", 10).Return(constants.ErrClassAlreadyExists)
+				m.On("CreateClass", models.ClassRequest{Name: "Yoga", StartDate: "2025-06-01", EndDate: "2025-06-20", Capacity: 10}).Return(models.Class{}, constants.ErrClassAlreadyExists)
 			},
 			expectedStatus: http.StatusConflict,
-			expectedBody: models.Response{
-				Status:  "error",
-				Message: constants.ErrClassAlreadyExists.Error(),
-			},
-			expectService: true,
+			expectedCode:   "class_already_exists",
+			expectedBody:   models.Response{Message: constants.ErrClassAlreadyExists.Error()},
+			expectService:  true,
 		},
 	}
 
@@ -110,6 +185,7 @@ func TestClassHandler_CreateClass(t *testing.T) {
 			// Create test context and recorder
 			w := httptest.NewRecorder()
 			ctx, _ := gin.CreateTestContext(w)
+			ctx.Set(constants.ContextService, mockService)
 
 			// Create HTTP request
 			req, _ := http.NewRequest("POST", "/classes", bytes.NewBufferString(tt.jsonInput))
@@ -123,18 +199,288 @@ func TestClassHandler_CreateClass(t *testing.T) {
 			assert.Equal(t, tt.expectedStatus, w.Code, "Expected status %d, got %d", tt.expectedStatus, w.Code)
 
 			// Assert response body
-			var resp models.Response
-			err := json.Unmarshal(w.Body.Bytes(), &resp)
-			assert.NoError(t, err, "Failed to unmarshal response")
-			assert.Equal(t, tt.expectedBody.Status, resp.Status, "Expected status %s, got %s", tt.expectedBody.Status, resp.Status)
-			assert.Equal(t, tt.expectedBody.Message, resp.Message, "Expected message %s, got %s", tt.expectedBody.Message, resp.Message)
+			assertBody(t, w, tt.expectedCode, tt.expectedBody)
 
 			// Assert service calls
 			if tt.expectService {
-				mockService.AssertCalled(t, "CreateClass", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				mockService.AssertCalled(t, "CreateClass", mock.Anything)
 			} else {
 				mockService.AssertNotCalled(t, "CreateClass")
 			}
 		})
 	}
 }
+
+func TestClassHandler_ReadClasses(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		path           string
+		setupMock      func(*MockClassService)
+		expectedStatus int
+		expectedBody   string
+		expectedETag   string
+	}{
+		{
+			name: "Get Class",
+			path: "/classes/Yoga",
+			setupMock: func(m *MockClassService) {
+				m.On("GetClass", "Yoga").Return(models.Class{Name: "Yoga", Capacity: 10, Version: 3}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"name":"Yoga"`,
+			expectedETag:   `"3"`,
+		},
+		{
+			name: "Get Class Not Found",
+			path: "/classes/Yoga",
+			setupMock: func(m *MockClassService) {
+				m.On("GetClass", "Yoga").Return(models.Class{}, constants.ErrClassNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   constants.ErrClassNotFound.Error(),
+		},
+		{
+			name: "List Classes",
+			path: "/classes?limit=1&cursor=abc",
+			setupMock: func(m *MockClassService) {
+				m.On("ListClasses", models.ListRequest{Cursor: "abc", Limit: 1}).
+					Return(models.Page[models.Class]{Items: []models.Class{{Name: "Yoga"}}, NextCursor: "next"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"next_cursor":"next"`,
+		},
+		{
+			name:           "List Classes Limit Too Large",
+			path:           "/classes?limit=1000",
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   constants.ErrInvalidQuery.Error(),
+		},
+		{
+			name: "List Classes Invalid Cursor",
+			path: "/classes?cursor=abc",
+			setupMock: func(m *MockClassService) {
+				m.On("ListClasses", models.ListRequest{Cursor: "abc"}).Return(models.Page[models.Class]{}, constants.ErrInvalidCursor)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   constants.ErrInvalidCursor.Error(),
+		},
+		{
+			name: "List Sessions",
+			path: "/classes/Yoga/sessions",
+			setupMock: func(m *MockClassService) {
+				m.On("ListSessions", "Yoga", models.ListRequest{}).
+					Return(models.Page[models.Session]{Items: []models.Session{{Capacity: 10, Booked: 4, Remaining: 6}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"remaining":6`,
+		},
+		{
+			name: "List Sessions Class Not Found",
+			path: "/classes/Yoga/sessions",
+			setupMock: func(m *MockClassService) {
+				m.On("ListSessions", "Yoga", models.ListRequest{}).Return(models.Page[models.Session]{}, constants.ErrClassNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   constants.ErrClassNotFound.Error(),
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup mock service and router
+			mockService := new(MockClassService)
+			tt.setupMock(mockService)
+			router := SetupRouter(NewClassHandler(mockService), RouterOptions{})
+
+			// Create HTTP request
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			router.ServeHTTP(w, req)
+
+			// Assert response
+			assert.Equal(t, tt.expectedStatus, w.Code, "Expected status %d, got %d", tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.Equal(t, tt.expectedETag, w.Header().Get(constants.HeaderETag))
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestClassHandler_ChangeClass(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+	capacity := 5
+	result := models.ClassChangeResult{
+		Class:      &models.Class{Name: "Yoga", Capacity: capacity, Version: 4},
+		Cancelled:  []models.Booking{},
+		Waitlisted: []models.Booking{{ID: "bk_2"}},
+	}
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		ifMatch        string
+		jsonInput      string
+		setupMock      func(*MockClassService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:      "Update Class",
+			method:    http.MethodPatch,
+			path:      "/classes/Yoga?overflow=waitlist",
+			ifMatch:   `"3"`,
+			jsonInput: `{"capacity":5}`,
+			setupMock: func(m *MockClassService) {
+				m.On("UpdateClass", "Yoga", 3, models.ClassUpdateRequest{Capacity: &capacity}, models.ClassChangeRequest{Overflow: constants.OverflowWaitlist}).Return(result, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"waitlisted":[{"id":"bk_2"`,
+		},
+		{
+			name:           "Update Class Invalid Capacity",
+			method:         http.MethodPatch,
+			path:           "/classes/Yoga",
+			ifMatch:        `"3"`,
+			jsonInput:      `{"capacity":0}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   constants.ErrInvalidReq.Error(),
+		},
+		{
+			name:           "Update Class Invalid Overflow",
+			method:         http.MethodPatch,
+			path:           "/classes/Yoga?overflow=drop",
+			ifMatch:        `"3"`,
+			jsonInput:      `{"capacity":5}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   constants.ErrInvalidQuery.Error(),
+		},
+		{
+			name:      "Update Class Capacity Below Bookings",
+			method:    http.MethodPatch,
+			path:      "/classes/Yoga",
+			ifMatch:   `"3"`,
+			jsonInput: `{"capacity":5}`,
+			setupMock: func(m *MockClassService) {
+				m.On("UpdateClass", "Yoga", 3, models.ClassUpdateRequest{Capacity: &capacity}, models.ClassChangeRequest{}).Return(models.ClassChangeResult{}, constants.ErrCapacityBelowBooked)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   constants.ErrCapacityBelowBooked.Error(),
+		},
+		{
+			name:      "Update Class Not Found",
+			method:    http.MethodPatch,
+			path:      "/classes/Yoga",
+			ifMatch:   `"3"`,
+			jsonInput: `{"capacity":5}`,
+			setupMock: func(m *MockClassService) {
+				m.On("UpdateClass", "Yoga", 3, models.ClassUpdateRequest{Capacity: &capacity}, models.ClassChangeRequest{}).Return(models.ClassChangeResult{}, constants.ErrClassNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   constants.ErrClassNotFound.Error(),
+		},
+		{
+			name:    "Delete Class",
+			method:  http.MethodDelete,
+			path:    "/classes/Yoga?force=true&reason=Studio+closed",
+			ifMatch: `"3"`,
+			setupMock: func(m *MockClassService) {
+				m.On("DeleteClass", "Yoga", 3, models.ClassChangeRequest{Force: true, Reason: "Studio closed"}).
+					Return(models.ClassChangeResult{Cancelled: []models.Booking{{ID: "bk_1"}}, Waitlisted: []models.Booking{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"cancelled":[{"id":"bk_1"`,
+		},
+		{
+			name:    "Delete Class With Bookings",
+			method:  http.MethodDelete,
+			path:    "/classes/Yoga",
+			ifMatch: `"3"`,
+			setupMock: func(m *MockClassService) {
+				m.On("DeleteClass", "Yoga", 3, models.ClassChangeRequest{}).Return(models.ClassChangeResult{}, constants.ErrClassHasBookings)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedBody:   constants.ErrClassHasBookings.Error(),
+		},
+		{
+			name:    "Delete Class Without Reason",
+			method:  http.MethodDelete,
+			path:    "/classes/Yoga?force=true",
+			ifMatch: `"3"`,
+			setupMock: func(m *MockClassService) {
+				m.On("DeleteClass", "Yoga", 3, models.ClassChangeRequest{Force: true}).Return(models.ClassChangeResult{}, constants.ErrCancelReasonMissing)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   constants.ErrCancelReasonMissing.Error(),
+		},
+		{
+			name:      "Update Class Stale Version",
+			method:    http.MethodPatch,
+			path:      "/classes/Yoga",
+			ifMatch:   `"3"`,
+			jsonInput: `{"capacity":5}`,
+			setupMock: func(m *MockClassService) {
+				m.On("UpdateClass", "Yoga", 3, models.ClassUpdateRequest{Capacity: &capacity}, models.ClassChangeRequest{}).Return(models.ClassChangeResult{}, constants.ErrVersionMismatch)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   constants.ErrVersionMismatch.Error(),
+		},
+		{
+			name:           "Update Class Without If-Match",
+			method:         http.MethodPatch,
+			path:           "/classes/Yoga",
+			jsonInput:      `{"capacity":5}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusPreconditionRequired,
+			expectedBody:   constants.ErrIfMatchRequired.Error(),
+		},
+		{
+			name:    "Delete Class Any Version",
+			method:  http.MethodDelete,
+			path:    "/classes/Yoga",
+			ifMatch: "*",
+			setupMock: func(m *MockClassService) {
+				m.On("DeleteClass", "Yoga", constants.AnyVersion, models.ClassChangeRequest{}).Return(models.ClassChangeResult{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Class Yoga deleted",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup mock service and router
+			mockService := new(MockClassService)
+			tt.setupMock(mockService)
+			router := SetupRouter(NewClassHandler(mockService), RouterOptions{})
+
+			// Create HTTP request
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.jsonInput))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.ifMatch != "" {
+				req.Header.Set(constants.HeaderIfMatch, tt.ifMatch)
+			}
+			router.ServeHTTP(w, req)
+
+			// Assert response
+			assert.Equal(t, tt.expectedStatus, w.Code, "Expected status %d, got %d", tt.expectedStatus, w.Code)
+			if tt.method == http.MethodPatch && w.Code == http.StatusOK {
+				assert.Equal(t, `"4"`, w.Header().Get(constants.HeaderETag))
+			}
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
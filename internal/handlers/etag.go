@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"glofox/internal/constants"
+	"glofox/internal/utils"
+	"strconv"
+	"strings"
+)
+
+// setETag returns the version of a class or booking as its ETag
+func setETag(ctx *gin.Context, version int) {
+	ctx.Header(constants.HeaderETag, strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatch returns the version required by the If-Match header of the request,
+// or constants.AnyVersion for If-Match: *. It writes the error response and
+// returns false when the header is missing or cannot match any version.
+func ifMatch(ctx *gin.Context) (int, bool) {
+	header := strings.TrimSpace(ctx.GetHeader(constants.HeaderIfMatch))
+	if header == "" {
+		utils.WriteProblem(ctx, constants.ErrIfMatchRequired)
+		return 0, false
+	}
+	if header == "*" {
+		return constants.AnyVersion, true
+	}
+
+	// If-Match uses the strong comparison, so weak and malformed tags never match
+	if tag, err := strconv.Unquote(header); err == nil && strings.HasPrefix(header, `"`) {
+		if version, err := strconv.Atoi(tag); err == nil && version > 0 {
+			return version, true
+		}
+	}
+	utils.WriteProblem(ctx, constants.ErrVersionMismatch)
+	return 0, false
+}
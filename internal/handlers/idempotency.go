@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/repository"
+	"glofox/internal/utils"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// idempotency replays the stored response of POST requests retried with the
+// same Idempotency-Key
+type idempotency struct {
+	repos func(studioID string) (repository.IdempotencyRepository, error)
+	ttl   time.Duration
+	// now returns the current time, replaced in tests
+	now func() time.Time
+
+	purgeMu sync.Mutex
+	// Key: studio ID
+	lastPurge map[string]time.Time
+}
+
+// Idempotency returns a middleware that stores the first response to each
+// Idempotency-Key of a POST request for ttl and replays it on retries. Keys
+// are stored in the repository of the studio of the request.
+func Idempotency(repos func(studioID string) (repository.IdempotencyRepository, error), ttl time.Duration) gin.HandlerFunc {
+	return (&idempotency{repos: repos, ttl: ttl, now: time.Now, lastPurge: make(map[string]time.Time)}).handle
+}
+
+func (m *idempotency) handle(ctx *gin.Context) {
+	key := ctx.GetHeader(constants.HeaderIdempotencyKey)
+	if ctx.Request.Method != http.MethodPost || key == "" {
+		ctx.Next()
+		return
+	}
+	if len(key) > constants.MaxIdempotencyKeyLength {
+		utils.WriteProblem(ctx, constants.ErrInvalidIdempotencyKey)
+		ctx.Abort()
+		return
+	}
+
+	// Read the body so that it can be fingerprinted, then put it back for the handler
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		utils.WriteProblem(ctx, fmt.Errorf("%w: %v", constants.ErrInvalidReq, err))
+		ctx.Abort()
+		return
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	studioID := studioFrom(ctx)
+	repo, err := m.repos(studioID)
+	if err != nil {
+		log.Printf("Failed to open idempotency keys of studio %s: %v", studioID, err)
+		utils.WriteProblem(ctx, constants.ErrInternalServer)
+		ctx.Abort()
+		return
+	}
+	now := m.now().UTC()
+	m.purge(studioID, repo, now)
+	record := models.IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint(ctx, body),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(constants.IdempotencyLease),
+	}
+	existing, reserved, err := repo.Reserve(record)
+	if err != nil {
+		log.Printf("Failed to reserve idempotency key %q: %v", key, err)
+		utils.WriteProblem(ctx, constants.ErrInternalServer)
+		ctx.Abort()
+		return
+	}
+	if !reserved {
+		switch {
+		case existing.Fingerprint != record.Fingerprint:
+			utils.WriteProblem(ctx, constants.ErrIdempotencyKeyReused)
+		case existing.StatusCode == 0:
+			utils.WriteProblem(ctx, constants.ErrIdempotencyKeyInProgress)
+		default:
+			ctx.Header(constants.HeaderIdempotentReplayed, "true")
+			ctx.Data(existing.StatusCode, existing.ContentType, existing.Body)
+		}
+		ctx.Abort()
+		return
+	}
+
+	// Release the key when the handler panics so that the client can retry
+	completed := false
+	defer func() {
+		if !completed {
+			release(repo, key)
+		}
+	}()
+
+	recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+	ctx.Writer = recorder
+	ctx.Next()
+	ctx.Writer = recorder.ResponseWriter
+
+	// Server errors are not stored, a retry may succeed
+	if status := recorder.Status(); status < http.StatusInternalServerError {
+		record.StatusCode = status
+		record.ContentType = recorder.Header().Get("Content-Type")
+		record.Body = recorder.body.Bytes()
+		record.ExpiresAt = m.now().UTC().Add(m.ttl)
+		if err := repo.Complete(record); err != nil {
+			log.Printf("Failed to store response of idempotency key %q: %v", key, err)
+			return
+		}
+		completed = true
+	}
+}
+
+// release frees a key whose request did not complete
+func release(repo repository.IdempotencyRepository, key string) {
+	if err := repo.Release(key); err != nil {
+		log.Printf("Failed to release idempotency key %q: %v", key, err)
+	}
+}
+
+// purge deletes the expired keys of a studio at most once per purge interval
+func (m *idempotency) purge(studioID string, repo repository.IdempotencyRepository, now time.Time) {
+	m.purgeMu.Lock()
+	defer m.purgeMu.Unlock()
+
+	if now.Sub(m.lastPurge[studioID]) < constants.IdempotencyPurgeInterval {
+		return
+	}
+	m.lastPurge[studioID] = now
+	if err := repo.Purge(now); err != nil {
+		log.Printf("Failed to purge expired idempotency keys of studio %s: %v", studioID, err)
+	}
+}
+
+// fingerprint identifies a request by its caller, method, URL and body, so
+// that a key reused by another caller never replays their response. JSON
+// bodies are compacted so that retries differing only in whitespace match.
+func fingerprint(ctx *gin.Context, body []byte) string {
+	var compact bytes.Buffer
+	if json.Compact(&compact, body) == nil {
+		body = compact.Bytes()
+	}
+	req := ctx.Request
+	hash := sha256.New()
+	if principal, ok := principalFrom(ctx); ok {
+		hash.Write([]byte(principal.StudioID + " " + principal.Role + " " + principal.Subject + "\n"))
+	}
+	hash.Write([]byte(req.Method + " " + req.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder copies the response body as it is written
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"bytes"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// postWithKey sends a POST request with an Idempotency-Key to the router
+func postWithKey(router *gin.Engine, path, key, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(constants.HeaderIdempotencyKey, key)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// idempotencyRepos returns the idempotency repositories of in-memory studios
+func idempotencyRepos(studios repository.Studios) func(studioID string) (repository.IdempotencyRepository, error) {
+	return func(studioID string) (repository.IdempotencyRepository, error) {
+		repos, err := studios.Studio(studioID)
+		return repos.Idempotency, err
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	booking := models.BookingRequest{ClassName: "Yoga", MemberID: "mb_1", Date: "2025-06-10"}
+	result := models.BookingResult{
+		Status:  constants.BookingStatusBooked,
+		Booking: &models.Booking{ID: "bk_1", ClassName: "Yoga", MemberID: "mb_1", MemberName: "Alice"},
+	}
+	newRouter := func(mockService *MockClassService) (*gin.Engine, repository.IdempotencyRepository) {
+		studios := repository.NewMemoryStudios()
+		router := SetupRouter(NewClassHandler(mockService), RouterOptions{IdempotencyRepo: idempotencyRepos(studios), IdempotencyTTL: time.Hour})
+		repos, err := studios.Studio(constants.DefaultStudioID)
+		require.NoError(t, err)
+		return router, repos.Idempotency
+	}
+
+	t.Run("Retry Replays Response", func(t *testing.T) {
+		mockService := new(MockClassService)
+		mockService.On("BookClass", booking).Return(result, nil).Once()
+		router, _ := newRouter(mockService)
+
+		first := postWithKey(router, "/bookings", "key-1", `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`)
+		assert.Equal(t, http.StatusCreated, first.Code)
+		assert.Empty(t, first.Header().Get(constants.HeaderIdempotentReplayed))
+
+		// Whitespace does not change the payload
+		retry := postWithKey(router, "/bookings", "key-1", `{"class_name": "Yoga", "member_id": "mb_1", "date": "2025-06-10"}`)
+		assert.Equal(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, "true", retry.Header().Get(constants.HeaderIdempotentReplayed))
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+		mockService.AssertNumberOfCalls(t, "BookClass", 1)
+	})
+
+	t.Run("Client Errors Are Replayed", func(t *testing.T) {
+		mockService := new(MockClassService)
+		mockService.On("BookClass", booking).Return(models.BookingResult{}, constants.ErrClassFull).Once()
+		router, _ := newRouter(mockService)
+
+		for i := 0; i < 2; i++ {
+			w := postWithKey(router, "/bookings", "key-1", `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`)
+			assert.Equal(t, http.StatusConflict, w.Code)
+		}
+		mockService.AssertNumberOfCalls(t, "BookClass", 1)
+	})
+
+	t.Run("Different Payload", func(t *testing.T) {
+		mockService := new(MockClassService)
+		mockService.On("BookClass", booking).Return(result, nil).Once()
+		router, _ := newRouter(mockService)
+
+		postWithKey(router, "/bookings", "key-1", `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`)
+		w := postWithKey(router, "/bookings", "key-1", `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-11"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		// The same key on another endpoint is a different payload too
+		w = postWithKey(router, "/members", "key-1", `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		assertProblem(t, w, "idempotency_key_reused", constants.ErrIdempotencyKeyReused.Error())
+		mockService.AssertNumberOfCalls(t, "BookClass", 1)
+	})
+
+	t.Run("Request In Progress", func(t *testing.T) {
+		mockService := new(MockClassService)
+		router, repo := newRouter(mockService)
+		body := `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/bookings", nil)
+		now := time.Now().UTC()
+		_, _, err := repo.Reserve(models.IdempotencyRecord{
+			Key:         "key-1",
+			Fingerprint: fingerprint(ctx, []byte(body)),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(time.Minute),
+		})
+		assert.NoError(t, err)
+
+		w := postWithKey(router, "/bookings", "key-1", body)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		mockService.AssertNotCalled(t, "BookClass", mock.Anything)
+	})
+
+	t.Run("Server Error Releases Key", func(t *testing.T) {
+		mockService := new(MockClassService)
+		mockService.On("BookClass", booking).Run(func(mock.Arguments) { panic("boom") }).Once()
+		mockService.On("BookClass", booking).Return(result, nil).Once()
+		router, _ := newRouter(mockService)
+
+		w := postWithKey(router, "/bookings", "key-1", `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		w = postWithKey(router, "/bookings", "key-1", `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get(constants.HeaderIdempotentReplayed))
+		mockService.AssertNumberOfCalls(t, "BookClass", 2)
+	})
+
+	t.Run("Without Key", func(t *testing.T) {
+		mockService := new(MockClassService)
+		mockService.On("BookClass", booking).Return(result, nil).Twice()
+		router, _ := newRouter(mockService)
+
+		for i := 0; i < 2; i++ {
+			w := postWithKey(router, "/bookings", "", `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`)
+			assert.Equal(t, http.StatusCreated, w.Code)
+		}
+		mockService.AssertNumberOfCalls(t, "BookClass", 2)
+	})
+
+	t.Run("Keys Are Per Studio", func(t *testing.T) {
+		mockService := new(MockClassService)
+		mockService.On("BookClass", booking).Return(result, nil).Twice()
+		router, _ := newRouter(mockService)
+
+		for _, studioID := range []string{"studio_a", "studio_b", "studio_a"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/bookings", bytes.NewBufferString(`{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(constants.HeaderIdempotencyKey, "key-1")
+			req.Header.Set(constants.HeaderStudioID, studioID)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusCreated, w.Code)
+		}
+		// The retry in studio_a is replayed, the first request of studio_b is not
+		mockService.AssertNumberOfCalls(t, "BookClass", 2)
+	})
+
+	t.Run("Key Too Long", func(t *testing.T) {
+		mockService := new(MockClassService)
+		router, _ := newRouter(mockService)
+
+		w := postWithKey(router, "/bookings", strings.Repeat("k", constants.MaxIdempotencyKeyLength+1), `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "BookClass", mock.Anything)
+	})
+}
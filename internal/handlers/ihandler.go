@@ -4,6 +4,49 @@ import "github.com/gin-gonic/gin"
 
 // IHandler defines functions in handlers
 type IHandler interface {
+	ResolveStudio(ctx *gin.Context)
 	CreateClass(ctx *gin.Context)
+	GetClass(ctx *gin.Context)
+	ListClasses(ctx *gin.Context)
+	UpdateClass(ctx *gin.Context)
+	DeleteClass(ctx *gin.Context)
+	ListSessions(ctx *gin.Context)
 	CreateBooking(ctx *gin.Context)
+	GetBooking(ctx *gin.Context)
+	CancelBooking(ctx *gin.Context)
+	ListBookings(ctx *gin.Context)
+	JoinWaitlist(ctx *gin.Context)
+	LeaveWaitlist(ctx *gin.Context)
+	GetWaitlistPosition(ctx *gin.Context)
+	CreateMember(ctx *gin.Context)
+	GetMember(ctx *gin.Context)
+	ListMembers(ctx *gin.Context)
+	UpdateMember(ctx *gin.Context)
+	DeleteMember(ctx *gin.Context)
+	CreateInstructor(ctx *gin.Context)
+	GetInstructor(ctx *gin.Context)
+	ListInstructors(ctx *gin.Context)
+	UpdateInstructor(ctx *gin.Context)
+	DeleteInstructor(ctx *gin.Context)
+	GetInstructorSchedule(ctx *gin.Context)
+	CreateRoom(ctx *gin.Context)
+	GetRoom(ctx *gin.Context)
+	ListRooms(ctx *gin.Context)
+	UpdateRoom(ctx *gin.Context)
+	DeleteRoom(ctx *gin.Context)
+	GetRoomUtilization(ctx *gin.Context)
+	CreatePlan(ctx *gin.Context)
+	GetPlan(ctx *gin.Context)
+	ListPlans(ctx *gin.Context)
+	UpdatePlan(ctx *gin.Context)
+	DeletePlan(ctx *gin.Context)
+	CreatePromoCode(ctx *gin.Context)
+	GetPromoCode(ctx *gin.Context)
+	ListPromoCodes(ctx *gin.Context)
+	UpdatePromoCode(ctx *gin.Context)
+	DeletePromoCode(ctx *gin.Context)
+	GetPromoRedemptions(ctx *gin.Context)
+	PurchaseMembership(ctx *gin.Context)
+	GetMemberCredits(ctx *gin.Context)
+	HandlePaymentWebhook(ctx *gin.Context)
 }
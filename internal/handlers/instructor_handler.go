@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/utils"
+	"net/http"
+)
+
+// CreateInstructor handles POST /instructors
+func (h *ClassHandler) CreateInstructor(ctx *gin.Context) {
+	var req models.InstructorRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	instructor, err := h.serviceFor(ctx).CreateInstructor(req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Instructor %s created successfully", instructor.ID),
+		Data:    instructor,
+	})
+}
+
+// GetInstructor handles GET /instructors/:id
+func (h *ClassHandler) GetInstructor(ctx *gin.Context) {
+	instructor, err := h.serviceFor(ctx).GetInstructor(ctx.Param("id"))
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   instructor,
+	})
+}
+
+// ListInstructors handles GET /instructors
+func (h *ClassHandler) ListInstructors(ctx *gin.Context) {
+	var req models.ListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	page, err := h.serviceFor(ctx).ListInstructors(req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   page,
+	})
+}
+
+// UpdateInstructor handles PUT /instructors/:id
+func (h *ClassHandler) UpdateInstructor(ctx *gin.Context) {
+	var req models.InstructorRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	instructor, err := h.serviceFor(ctx).UpdateInstructor(ctx.Param("id"), req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Instructor %s updated successfully", instructor.ID),
+		Data:    instructor,
+	})
+}
+
+// DeleteInstructor handles DELETE /instructors/:id
+func (h *ClassHandler) DeleteInstructor(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if err := h.serviceFor(ctx).DeleteInstructor(id); err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Instructor %s deleted", id),
+	})
+}
+
+// GetInstructorSchedule handles GET /instructors/:id/schedule
+func (h *ClassHandler) GetInstructorSchedule(ctx *gin.Context) {
+	var req models.ScheduleRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	schedule, err := h.serviceFor(ctx).InstructorSchedule(ctx.Param("id"), req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   schedule,
+	})
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"bytes"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// CreateInstructor mocks the CreateInstructor method
+func (m *MockClassService) CreateInstructor(req models.InstructorRequest) (models.Instructor, error) {
+	args := m.Called(req)
+	instructor, _ := args.Get(0).(models.Instructor)
+	return instructor, args.Error(1)
+}
+
+// GetInstructor mocks the GetInstructor method
+func (m *MockClassService) GetInstructor(id string) (models.Instructor, error) {
+	args := m.Called(id)
+	instructor, _ := args.Get(0).(models.Instructor)
+	return instructor, args.Error(1)
+}
+
+// ListInstructors mocks the ListInstructors method
+func (m *MockClassService) ListInstructors(req models.ListRequest) (models.Page[models.Instructor], error) {
+	args := m.Called(req)
+	page, _ := args.Get(0).(models.Page[models.Instructor])
+	return page, args.Error(1)
+}
+
+// UpdateInstructor mocks the UpdateInstructor method
+func (m *MockClassService) UpdateInstructor(id string, req models.InstructorRequest) (models.Instructor, error) {
+	args := m.Called(id, req)
+	instructor, _ := args.Get(0).(models.Instructor)
+	return instructor, args.Error(1)
+}
+
+// DeleteInstructor mocks the DeleteInstructor method
+func (m *MockClassService) DeleteInstructor(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// InstructorSchedule mocks the InstructorSchedule method
+func (m *MockClassService) InstructorSchedule(id string, req models.ScheduleRequest) (models.Schedule, error) {
+	args := m.Called(id, req)
+	schedule, _ := args.Get(0).(models.Schedule)
+	return schedule, args.Error(1)
+}
+
+func TestClassHandler_Instructors(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	maya := models.Instructor{ID: "in_1", Name: "Maya", Email: "maya@example.com"}
+	week := time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC)
+	schedule := models.Schedule{
+		InstructorID: "in_1",
+		From:         week,
+		To:           week.AddDate(0, 0, 7),
+		Sessions:     []models.ScheduledSession{{ClassName: "Yoga", Date: week.Add(7 * time.Hour)}},
+	}
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		jsonInput      string
+		setupMock      func(*MockClassService)
+		expectedStatus int
+		expectedBody   models.Response
+		expectedCode   string
+	}{
+		{
+			name:      "Create Happy Path",
+			method:    http.MethodPost,
+			path:      "/instructors",
+			jsonInput: `{"name":"Maya","email":"maya@example.com"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("CreateInstructor", models.InstructorRequest{Name: "Maya", Email: "maya@example.com"}).Return(maya, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Instructor in_1 created successfully",
+			},
+		},
+		{
+			name:           "Create Without Name",
+			method:         http.MethodPost,
+			path:           "/instructors",
+			jsonInput:      `{"email":"maya@example.com"}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+			expectedBody:   models.Response{Message: constants.ErrInvalidReq.Error() + ": name is required"},
+		},
+		{
+			name:   "Get Not Found",
+			method: http.MethodGet,
+			path:   "/instructors/in_2",
+			setupMock: func(m *MockClassService) {
+				m.On("GetInstructor", "in_2").Return(models.Instructor{}, constants.ErrInstructorNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "instructor_not_found",
+			expectedBody:   models.Response{Message: constants.ErrInstructorNotFound.Error()},
+		},
+		{
+			name:   "List Happy Path",
+			method: http.MethodGet,
+			path:   "/instructors?limit=10",
+			setupMock: func(m *MockClassService) {
+				m.On("ListInstructors", models.ListRequest{Limit: 10}).Return(models.Page[models.Instructor]{Items: []models.Instructor{maya}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.Response{Status: constants.SuccessMsg},
+		},
+		{
+			name:      "Update Happy Path",
+			method:    http.MethodPut,
+			path:      "/instructors/in_1",
+			jsonInput: `{"name":"Maya"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("UpdateInstructor", "in_1", models.InstructorRequest{Name: "Maya"}).Return(maya, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Instructor in_1 updated successfully",
+			},
+		},
+		{
+			name:   "Delete Assigned Instructor",
+			method: http.MethodDelete,
+			path:   "/instructors/in_1",
+			setupMock: func(m *MockClassService) {
+				m.On("DeleteInstructor", "in_1").Return(constants.ErrInstructorAssigned)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "instructor_assigned",
+			expectedBody:   models.Response{Message: constants.ErrInstructorAssigned.Error()},
+		},
+		{
+			name:   "Schedule Happy Path",
+			method: http.MethodGet,
+			path:   "/instructors/in_1/schedule?from=2025-06-09",
+			setupMock: func(m *MockClassService) {
+				m.On("InstructorSchedule", "in_1", models.ScheduleRequest{From: "2025-06-09"}).Return(schedule, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.Response{Status: constants.SuccessMsg},
+		},
+		{
+			name:           "Schedule Invalid From",
+			method:         http.MethodGet,
+			path:           "/instructors/in_1/schedule?from=next-week",
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_query",
+			expectedBody:   models.Response{Message: constants.ErrInvalidQuery.Error() + ": from must be a date as YYYY-MM-DD or RFC 3339"},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup mock service and router
+			mockService := new(MockClassService)
+			tt.setupMock(mockService)
+			router := SetupRouter(NewClassHandler(mockService), RouterOptions{})
+
+			// Create HTTP request
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.jsonInput))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			// Assert status code
+			assert.Equal(t, tt.expectedStatus, w.Code, "Expected status %d, got %d", tt.expectedStatus, w.Code)
+
+			// Assert response body
+			assertBody(t, w, tt.expectedCode, tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/utils"
+	"net/http"
+)
+
+// CreateMember handles POST /members
+func (h *ClassHandler) CreateMember(ctx *gin.Context) {
+	var req models.MemberRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	member, err := h.serviceFor(ctx).CreateMember(req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Member %s created successfully", member.ID),
+		Data:    member,
+	})
+}
+
+// GetMember handles GET /members/:id
+func (h *ClassHandler) GetMember(ctx *gin.Context) {
+	member, err := h.serviceFor(ctx).GetMember(ctx.Param("id"))
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   member,
+	})
+}
+
+// ListMembers handles GET /members
+func (h *ClassHandler) ListMembers(ctx *gin.Context) {
+	var req models.ListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	page, err := h.serviceFor(ctx).ListMembers(req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   page,
+	})
+}
+
+// UpdateMember handles PUT /members/:id
+func (h *ClassHandler) UpdateMember(ctx *gin.Context) {
+	var req models.MemberRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	member, err := h.serviceFor(ctx).UpdateMember(ctx.Param("id"), req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Member %s updated successfully", member.ID),
+		Data:    member,
+	})
+}
+
+// DeleteMember handles DELETE /members/:id
+func (h *ClassHandler) DeleteMember(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if err := h.serviceFor(ctx).DeleteMember(id); err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Member %s deleted", id),
+	})
+}
+
+// deprecatedMemberName flags requests that identify the member by name only
+func deprecatedMemberName(ctx *gin.Context, memberID string) {
+	if memberID == "" {
+		ctx.Header("Deprecation", "true")
+	}
+}
+
+// memberLabel names the member of a request in response messages
+func memberLabel(memberID, memberName string) string {
+	if memberName != "" {
+		return memberName
+	}
+	return memberID
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"bytes"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// CreateMember mocks the CreateMember method
+func (m *MockClassService) CreateMember(req models.MemberRequest) (models.Member, error) {
+	args := m.Called(req)
+	member, _ := args.Get(0).(models.Member)
+	return member, args.Error(1)
+}
+
+// GetMember mocks the GetMember method
+func (m *MockClassService) GetMember(id string) (models.Member, error) {
+	args := m.Called(id)
+	member, _ := args.Get(0).(models.Member)
+	return member, args.Error(1)
+}
+
+// ListMembers mocks the ListMembers method
+func (m *MockClassService) ListMembers(req models.ListRequest) (models.Page[models.Member], error) {
+	args := m.Called(req)
+	page, _ := args.Get(0).(models.Page[models.Member])
+	return page, args.Error(1)
+}
+
+// UpdateMember mocks the UpdateMember method
+func (m *MockClassService) UpdateMember(id string, req models.MemberRequest) (models.Member, error) {
+	args := m.Called(id, req)
+	member, _ := args.Get(0).(models.Member)
+	return member, args.Error(1)
+}
+
+// DeleteMember mocks the DeleteMember method
+func (m *MockClassService) DeleteMember(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestClassHandler_Members(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	alice := models.Member{ID: "mb_1", Name: "Alice", Email: "alice@example.com", Status: constants.MemberStatusActive}
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		jsonInput      string
+		setupMock      func(*MockClassService)
+		expectedStatus int
+		expectedBody   models.Response
+		expectedCode   string
+	}{
+		{
+			name:      "Create Happy Path",
+			method:    http.MethodPost,
+			path:      "/members",
+			jsonInput: `{"name":"Alice","email":"alice@example.com"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("CreateMember", models.MemberRequest{Name: "Alice", Email: "alice@example.com"}).Return(alice, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Member mb_1 created successfully",
+			},
+		},
+		{
+			name:           "Create Invalid Email",
+			method:         http.MethodPost,
+			path:           "/members",
+			jsonInput:      `{"name":"Alice","email":"alice"}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+			expectedBody:   models.Response{Message: constants.ErrInvalidReq.Error() + ": email must be an email address"},
+		},
+		{
+			name:           "Create Unknown Status",
+			method:         http.MethodPost,
+			path:           "/members",
+			jsonInput:      `{"name":"Alice","status":"banned"}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+			expectedBody:   models.Response{Message: constants.ErrInvalidReq.Error() + ": status must be one of active, suspended"},
+		},
+		{
+			name:   "Get Happy Path",
+			method: http.MethodGet,
+			path:   "/members/mb_1",
+			setupMock: func(m *MockClassService) {
+				m.On("GetMember", "mb_1").Return(alice, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.Response{Status: constants.SuccessMsg},
+		},
+		{
+			name:   "Get Not Found",
+			method: http.MethodGet,
+			path:   "/members/mb_2",
+			setupMock: func(m *MockClassService) {
+				m.On("GetMember", "mb_2").Return(models.Member{}, constants.ErrMemberNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "member_not_found",
+			expectedBody:   models.Response{Message: constants.ErrMemberNotFound.Error()},
+		},
+		{
+			name:   "List Happy Path",
+			method: http.MethodGet,
+			path:   "/members?limit=10",
+			setupMock: func(m *MockClassService) {
+				m.On("ListMembers", models.ListRequest{Limit: 10}).Return(models.Page[models.Member]{Items: []models.Member{alice}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.Response{Status: constants.SuccessMsg},
+		},
+		{
+			name:      "Update Suspends Member",
+			method:    http.MethodPut,
+			path:      "/members/mb_1",
+			jsonInput: `{"name":"Alice","status":"suspended"}`,
+			setupMock: func(m *MockClassService) {
+				suspended := alice
+				suspended.Status = constants.MemberStatusSuspended
+				m.On("UpdateMember", "mb_1", models.MemberRequest{Name: "Alice", Status: constants.MemberStatusSuspended}).Return(suspended, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Member mb_1 updated successfully",
+			},
+		},
+		{
+			name:      "Update Not Found",
+			method:    http.MethodPut,
+			path:      "/members/mb_2",
+			jsonInput: `{"name":"Bob"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("UpdateMember", "mb_2", models.MemberRequest{Name: "Bob"}).Return(models.Member{}, constants.ErrMemberNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "member_not_found",
+			expectedBody:   models.Response{Message: constants.ErrMemberNotFound.Error()},
+		},
+		{
+			name:   "Delete Happy Path",
+			method: http.MethodDelete,
+			path:   "/members/mb_1",
+			setupMock: func(m *MockClassService) {
+				m.On("DeleteMember", "mb_1").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Member mb_1 deleted",
+			},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup mock service and router
+			mockService := new(MockClassService)
+			tt.setupMock(mockService)
+			router := SetupRouter(NewClassHandler(mockService), RouterOptions{})
+
+			// Create HTTP request
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.jsonInput))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			// Assert status code
+			assert.Equal(t, tt.expectedStatus, w.Code, "Expected status %d, got %d", tt.expectedStatus, w.Code)
+
+			// Assert response body
+			assertBody(t, w, tt.expectedCode, tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestClassHandler_CreateBooking_DeprecatedMemberName(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockClassService)
+	mockService.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2025-06-10"}).Return(models.BookingResult{Status: constants.BookingStatusBooked}, nil)
+	mockService.On("BookClass", models.BookingRequest{ClassName: "Yoga", MemberID: "mb_1", Date: "2025-06-10"}).Return(models.BookingResult{Status: constants.BookingStatusBooked}, nil)
+	router := SetupRouter(NewClassHandler(mockService), RouterOptions{})
+
+	// Booking by name still works but is flagged as deprecated
+	for body, deprecated := range map[string]string{
+		`{"class_name":"Yoga","name":"Alice","date":"2025-06-10"}`:     "true",
+		`{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`: "",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/bookings", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code, body)
+		assert.Equal(t, deprecated, w.Header().Get("Deprecation"), body)
+	}
+}
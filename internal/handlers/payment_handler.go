@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/payments"
+	"glofox/internal/utils"
+	"io"
+	"net/http"
+)
+
+// PaymentWebhook returns a middleware that verifies the signature of the
+// webhooks of the payment provider. The provider authenticates with the
+// signature instead of credentials, and the event names the studio of the
+// booking it is about.
+func PaymentWebhook(provider payments.PaymentProvider) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			utils.WriteProblem(ctx, fmt.Errorf("%w: %v", constants.ErrInvalidReq, err))
+			ctx.Abort()
+			return
+		}
+		event, err := provider.VerifyWebhook(payload, ctx.GetHeader(constants.HeaderPaymentSignature))
+		if err == nil && !validStudioID(event.StudioID) {
+			err = constants.ErrInvalidStudio
+		}
+		if err != nil {
+			utils.WriteProblem(ctx, err)
+			ctx.Abort()
+			return
+		}
+		ctx.Set(constants.ContextPaymentEvent, event)
+		ctx.Set(constants.ContextStudio, event.StudioID)
+		ctx.Next()
+	}
+}
+
+// HandlePaymentWebhook handles POST /payments/webhook
+func (h *ClassHandler) HandlePaymentWebhook(ctx *gin.Context) {
+	event, ok := ctx.MustGet(constants.ContextPaymentEvent).(models.PaymentEvent)
+	if !ok {
+		utils.WriteProblem(ctx, constants.ErrInvalidPaymentEvent)
+		return
+	}
+
+	booking, err := h.serviceFor(ctx).ApplyPaymentEvent(event)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Payment %s of booking %s is %s", event.PaymentID, booking.ID, booking.Payment.Status),
+		Data:    booking,
+	})
+}
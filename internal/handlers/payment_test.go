@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"glofox/internal/auth"
+	"glofox/internal/config"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/payments"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ApplyPaymentEvent mocks the ApplyPaymentEvent method
+func (m *MockClassService) ApplyPaymentEvent(event models.PaymentEvent) (models.Booking, error) {
+	args := m.Called(event)
+	booking, _ := args.Get(0).(models.Booking)
+	return booking, args.Error(1)
+}
+
+func TestClassHandler_PaymentWebhook(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	const secret = "whsec_test"
+	authenticator, err := auth.New(config.AuthConfig{Mode: constants.AuthRequired, JWTSecret: testJWTSecret})
+	require.NoError(t, err)
+	signed := func(payload string) (string, string) {
+		return payload, payments.Sign([]byte(secret), []byte(payload))
+	}
+	event := `{"id":"ev_1","type":"payment.captured","payment_id":"pay_1","authorization_id":"au_1","studio_id":"st_1","booking_id":"bk_1"}`
+	captured := models.Booking{ID: "bk_1", Status: constants.BookingStatusBooked, Payment: &models.Payment{ID: "pay_1", Status: constants.PaymentCaptured}}
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		payload        string
+		signature      string
+		setupMock      func(*MockClassService)
+		expectedStatus int
+		expectedBody   models.Response
+		expectedCode   string
+	}{
+		{
+			name: "Happy Path",
+			setupMock: func(m *MockClassService) {
+				m.On("ApplyPaymentEvent", models.PaymentEvent{
+					ID: "ev_1", Type: constants.PaymentEventCaptured, PaymentID: "pay_1", AuthorizationID: "au_1", StudioID: "st_1", BookingID: "bk_1",
+				}).Return(captured, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Payment pay_1 of booking bk_1 is captured",
+			},
+		},
+		{
+			name:           "Invalid Signature",
+			signature:      payments.Sign([]byte("whsec_other"), []byte(event)),
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "invalid_signature",
+			expectedBody:   models.Response{Message: constants.ErrInvalidSignature.Error()},
+		},
+		{
+			name:           "Missing Booking",
+			payload:        `{"id":"ev_1","type":"payment.captured","payment_id":"pay_1","studio_id":"st_1"}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_payment_event",
+			expectedBody:   models.Response{Message: constants.ErrInvalidPaymentEvent.Error() + ": payment_id, studio_id and booking_id are required"},
+		},
+		{
+			name:           "Invalid Studio",
+			payload:        `{"id":"ev_1","type":"payment.captured","payment_id":"pay_1","studio_id":"../st_1","booking_id":"bk_1"}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_studio",
+			expectedBody:   models.Response{Message: constants.ErrInvalidStudio.Error()},
+		},
+		{
+			name: "Unknown Payment",
+			setupMock: func(m *MockClassService) {
+				m.On("ApplyPaymentEvent", mock.Anything).Return(models.Booking{}, constants.ErrPaymentNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "payment_not_found",
+			expectedBody:   models.Response{Message: constants.ErrPaymentNotFound.Error()},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The provider signs its webhooks instead of authenticating
+			mockService := new(MockClassService)
+			tt.setupMock(mockService)
+			router := SetupRouter(NewClassHandler(mockService), RouterOptions{Authenticator: authenticator, Payments: payments.NewFake(secret)})
+
+			payload, signature := signed(event)
+			if tt.payload != "" {
+				payload, signature = signed(tt.payload)
+			}
+			if tt.signature != "" {
+				signature = tt.signature
+			}
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, constants.PaymentWebhookEndpoint, bytes.NewBufferString(payload))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(constants.HeaderPaymentSignature, signature)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, "Expected status %d, got %d", tt.expectedStatus, w.Code)
+			assertBody(t, w, tt.expectedCode, tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestClassHandler_PaymentWebhookDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := SetupRouter(NewClassHandler(new(MockClassService)), RouterOptions{})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, constants.PaymentWebhookEndpoint, bytes.NewBufferString(`{}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
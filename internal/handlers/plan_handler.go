@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/utils"
+	"net/http"
+)
+
+// CreatePlan handles POST /plans
+func (h *ClassHandler) CreatePlan(ctx *gin.Context) {
+	var req models.PlanRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	plan, err := h.serviceFor(ctx).CreatePlan(req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Plan %s created successfully", plan.ID),
+		Data:    plan,
+	})
+}
+
+// GetPlan handles GET /plans/:id
+func (h *ClassHandler) GetPlan(ctx *gin.Context) {
+	plan, err := h.serviceFor(ctx).GetPlan(ctx.Param("id"))
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   plan,
+	})
+}
+
+// ListPlans handles GET /plans
+func (h *ClassHandler) ListPlans(ctx *gin.Context) {
+	var req models.ListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	page, err := h.serviceFor(ctx).ListPlans(req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   page,
+	})
+}
+
+// UpdatePlan handles PUT /plans/:id
+func (h *ClassHandler) UpdatePlan(ctx *gin.Context) {
+	var req models.PlanRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	plan, err := h.serviceFor(ctx).UpdatePlan(ctx.Param("id"), req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Plan %s updated successfully", plan.ID),
+		Data:    plan,
+	})
+}
+
+// DeletePlan handles DELETE /plans/:id
+func (h *ClassHandler) DeletePlan(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if err := h.serviceFor(ctx).DeletePlan(id); err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Plan %s deleted", id),
+	})
+}
+
+// PurchaseMembership handles POST /members/:id/memberships
+func (h *ClassHandler) PurchaseMembership(ctx *gin.Context) {
+	var req models.MembershipRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	membership, err := h.serviceFor(ctx).PurchaseMembership(ctx.Param("id"), req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Membership %s of %s created successfully", membership.ID, membership.PlanName),
+		Data:    membership,
+	})
+}
+
+// GetMemberCredits handles GET /members/:id/credits
+func (h *ClassHandler) GetMemberCredits(ctx *gin.Context) {
+	credits, err := h.serviceFor(ctx).MemberCredits(ctx.Param("id"))
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   credits,
+	})
+}
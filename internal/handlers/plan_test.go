@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"bytes"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// CreatePlan mocks the CreatePlan method
+func (m *MockClassService) CreatePlan(req models.PlanRequest) (models.Plan, error) {
+	args := m.Called(req)
+	plan, _ := args.Get(0).(models.Plan)
+	return plan, args.Error(1)
+}
+
+// GetPlan mocks the GetPlan method
+func (m *MockClassService) GetPlan(id string) (models.Plan, error) {
+	args := m.Called(id)
+	plan, _ := args.Get(0).(models.Plan)
+	return plan, args.Error(1)
+}
+
+// ListPlans mocks the ListPlans method
+func (m *MockClassService) ListPlans(req models.ListRequest) (models.Page[models.Plan], error) {
+	args := m.Called(req)
+	page, _ := args.Get(0).(models.Page[models.Plan])
+	return page, args.Error(1)
+}
+
+// UpdatePlan mocks the UpdatePlan method
+func (m *MockClassService) UpdatePlan(id string, req models.PlanRequest) (models.Plan, error) {
+	args := m.Called(id, req)
+	plan, _ := args.Get(0).(models.Plan)
+	return plan, args.Error(1)
+}
+
+// DeletePlan mocks the DeletePlan method
+func (m *MockClassService) DeletePlan(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// PurchaseMembership mocks the PurchaseMembership method
+func (m *MockClassService) PurchaseMembership(memberID string, req models.MembershipRequest) (models.Membership, error) {
+	args := m.Called(memberID, req)
+	membership, _ := args.Get(0).(models.Membership)
+	return membership, args.Error(1)
+}
+
+// MemberCredits mocks the MemberCredits method
+func (m *MockClassService) MemberCredits(id string) (models.Credits, error) {
+	args := m.Called(id)
+	credits, _ := args.Get(0).(models.Credits)
+	return credits, args.Error(1)
+}
+
+func TestClassHandler_Plans(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	plan := models.Plan{ID: "pl_1", Name: "10 Class Pack", Kind: constants.PlanPack, Classes: 10, ValidDays: 90}
+	planReq := models.PlanRequest{Name: "10 Class Pack", Kind: constants.PlanPack, Classes: 10, ValidDays: 90}
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	membership := models.Membership{ID: "ms_1", PlanID: "pl_1", PlanName: "10 Class Pack", Kind: constants.PlanPack, Classes: 10, StartsAt: now}
+	credits := models.Credits{
+		MemberID: "mb_1",
+		Balances: []models.CreditBalance{{Membership: membership, Remaining: 9}},
+		Entries:  []models.CreditEntry{{ID: "cr_1", MemberID: "mb_1", MembershipID: "ms_1", Amount: 10, Reason: constants.CreditGrant}},
+	}
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		jsonInput      string
+		setupMock      func(*MockClassService)
+		expectedStatus int
+		expectedBody   models.Response
+		expectedCode   string
+	}{
+		{
+			name:      "Create Happy Path",
+			method:    http.MethodPost,
+			path:      "/plans",
+			jsonInput: `{"name":"10 Class Pack","kind":"pack","classes":10,"valid_days":90}`,
+			setupMock: func(m *MockClassService) {
+				m.On("CreatePlan", planReq).Return(plan, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Plan pl_1 created successfully",
+			},
+		},
+		{
+			name:           "Create Unknown Kind",
+			method:         http.MethodPost,
+			path:           "/plans",
+			jsonInput:      `{"name":"Daily","kind":"daily"}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+			expectedBody:   models.Response{Message: constants.ErrInvalidReq.Error() + ": kind must be one of unlimited, weekly, monthly, pack"},
+		},
+		{
+			name:      "Create Pack Without Expiry",
+			method:    http.MethodPost,
+			path:      "/plans",
+			jsonInput: `{"name":"10 Class Pack","kind":"pack","classes":10}`,
+			setupMock: func(m *MockClassService) {
+				m.On("CreatePlan", models.PlanRequest{Name: "10 Class Pack", Kind: constants.PlanPack, Classes: 10}).Return(models.Plan{}, constants.ErrInvalidPlan)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_plan",
+			expectedBody:   models.Response{Message: constants.ErrInvalidPlan.Error()},
+		},
+		{
+			name:   "Get Not Found",
+			method: http.MethodGet,
+			path:   "/plans/pl_2",
+			setupMock: func(m *MockClassService) {
+				m.On("GetPlan", "pl_2").Return(models.Plan{}, constants.ErrPlanNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "plan_not_found",
+			expectedBody:   models.Response{Message: constants.ErrPlanNotFound.Error()},
+		},
+		{
+			name:   "List Happy Path",
+			method: http.MethodGet,
+			path:   "/plans?limit=10",
+			setupMock: func(m *MockClassService) {
+				m.On("ListPlans", models.ListRequest{Limit: 10}).Return(models.Page[models.Plan]{Items: []models.Plan{plan}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.Response{Status: constants.SuccessMsg},
+		},
+		{
+			name:      "Update Happy Path",
+			method:    http.MethodPut,
+			path:      "/plans/pl_1",
+			jsonInput: `{"name":"10 Class Pack","kind":"pack","classes":10,"valid_days":90}`,
+			setupMock: func(m *MockClassService) {
+				m.On("UpdatePlan", "pl_1", planReq).Return(plan, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Plan pl_1 updated successfully",
+			},
+		},
+		{
+			name:   "Delete Happy Path",
+			method: http.MethodDelete,
+			path:   "/plans/pl_1",
+			setupMock: func(m *MockClassService) {
+				m.On("DeletePlan", "pl_1").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Plan pl_1 deleted",
+			},
+		},
+		{
+			name:      "Purchase Happy Path",
+			method:    http.MethodPost,
+			path:      "/members/mb_1/memberships",
+			jsonInput: `{"plan_id":"pl_1"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("PurchaseMembership", "mb_1", models.MembershipRequest{PlanID: "pl_1"}).Return(membership, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Membership ms_1 of 10 Class Pack created successfully",
+			},
+		},
+		{
+			name:           "Purchase Invalid Start",
+			method:         http.MethodPost,
+			path:           "/members/mb_1/memberships",
+			jsonInput:      `{"plan_id":"pl_1","starts_at":"June"}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+			expectedBody:   models.Response{Message: constants.ErrInvalidReq.Error() + ": starts_at must be a date as YYYY-MM-DD or RFC 3339"},
+		},
+		{
+			name:   "Credits Happy Path",
+			method: http.MethodGet,
+			path:   "/members/mb_1/credits",
+			setupMock: func(m *MockClassService) {
+				m.On("MemberCredits", "mb_1").Return(credits, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.Response{Status: constants.SuccessMsg},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup mock service and router
+			mockService := new(MockClassService)
+			tt.setupMock(mockService)
+			router := SetupRouter(NewClassHandler(mockService), RouterOptions{})
+
+			// Create HTTP request
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.jsonInput))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			// Assert status code
+			assert.Equal(t, tt.expectedStatus, w.Code, "Expected status %d, got %d", tt.expectedStatus, w.Code)
+
+			// Assert response body
+			assertBody(t, w, tt.expectedCode, tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
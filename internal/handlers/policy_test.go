@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"glofox/internal/auth"
+	"glofox/internal/config"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// serviceArity is the number of arguments of each IService method
+var serviceArity = map[string]int{
+	"CreateClass": 1, "GetClass": 1, "UpdateClass": 4, "DeleteClass": 3, "ListClasses": 1, "ListSessions": 2,
+	"BookClass": 1, "GetBooking": 1, "CancelBooking": 2, "ListBookings": 1,
+	"JoinWaitlist": 3, "LeaveWaitlist": 3, "WaitlistPosition": 3,
+	"CreateMember": 1, "GetMember": 1, "ListMembers": 1, "UpdateMember": 2, "DeleteMember": 1,
+	"CreateInstructor": 1, "GetInstructor": 1, "ListInstructors": 1, "UpdateInstructor": 2, "DeleteInstructor": 1,
+	"InstructorSchedule": 2,
+	"CreateRoom":         1, "GetRoom": 1, "ListRooms": 1, "UpdateRoom": 2, "DeleteRoom": 1, "RoomUtilization": 2,
+	"CreatePlan": 1, "GetPlan": 1, "ListPlans": 1, "UpdatePlan": 2, "DeletePlan": 1,
+	"CreatePromoCode": 1, "GetPromoCode": 1, "ListPromoCodes": 1, "UpdatePromoCode": 2, "DeletePromoCode": 1, "PromoRedemptions": 1,
+	"PurchaseMembership": 2, "MemberCredits": 1, "ApplyPaymentEvent": 1,
+}
+
+// newPolicyService returns a mock service where in_1 teaches Yoga and
+// substitutes one session of Pilates, bk_1 is a booking of mb_1 and every
+// other call succeeds
+func newPolicyService() *MockClassService {
+	m := new(MockClassService)
+	m.On("GetClass", "Yoga").Return(models.Class{Name: "Yoga", InstructorID: "in_1"}, nil).Maybe()
+	m.On("GetClass", "Boxing").Return(models.Class{Name: "Boxing", InstructorID: "in_2"}, nil).Maybe()
+	m.On("GetClass", "Pilates").Return(models.Class{Name: "Pilates", InstructorID: "in_2", Substitutions: []models.Substitution{{InstructorID: "in_1"}}}, nil).Maybe()
+	m.On("GetBooking", "bk_1").Return(models.Booking{ID: "bk_1", ClassName: "Yoga", MemberID: "mb_1"}, nil).Maybe()
+	m.On("GetBooking", "bk_2").Return(models.Booking{ID: "bk_2", ClassName: "Boxing", MemberID: "mb_2"}, nil).Maybe()
+	m.On("UpdateClass", mockArgs("UpdateClass")...).Return(models.ClassChangeResult{Class: &models.Class{Name: "Yoga"}}, nil).Maybe()
+	for method := range serviceArity {
+		m.On(method, mockArgs(method)...).Return(nil, nil).Maybe()
+	}
+	return m
+}
+
+// policyCase is a request to a route and the roles allowed to send it
+type policyCase struct {
+	name    string
+	route   string
+	method  string
+	path    string
+	body    string
+	call    string
+	allowed []string
+}
+
+func TestPolicy_Routes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator, err := auth.New(config.AuthConfig{Mode: constants.AuthRequired, JWTSecret: testJWTSecret})
+	require.NoError(t, err)
+	callers := map[string]models.Principal{
+		constants.RoleAdmin:      {Subject: "ad_1", Role: constants.RoleAdmin, StudioID: "st_1"},
+		constants.RoleStaff:      {Subject: "sf_1", Role: constants.RoleStaff, StudioID: "st_1"},
+		constants.RoleInstructor: {Subject: "in_1", Role: constants.RoleInstructor, StudioID: "st_1"},
+		constants.RoleMember:     {Subject: "mb_1", Role: constants.RoleMember, StudioID: "st_1"},
+	}
+	staff := []string{constants.RoleAdmin, constants.RoleStaff}
+	members := []string{constants.RoleAdmin, constants.RoleStaff, constants.RoleMember}
+	everyone := []string{constants.RoleAdmin, constants.RoleStaff, constants.RoleInstructor, constants.RoleMember}
+	booking := `{"class_name":"Yoga","member_id":"mb_1","date":"2025-06-10"}`
+
+	tests := []policyCase{
+		{"Create Class", constants.ClassEndpoint, http.MethodPost, "/classes", `{"name":"Yoga","start_date":"2025-06-01","end_date":"2025-06-20","capacity":10}`, "CreateClass", staff},
+		{"List Classes", constants.ClassEndpoint, http.MethodGet, "/classes", "", "ListClasses", everyone},
+		{"Get Class", constants.ClassNameEndpoint, http.MethodGet, "/classes/Yoga", "", "GetClass", everyone},
+		{"Update Class", constants.ClassNameEndpoint, http.MethodPatch, "/classes/Yoga", `{"capacity":8}`, "UpdateClass", staff},
+		{"Delete Class", constants.ClassNameEndpoint, http.MethodDelete, "/classes/Yoga", "", "DeleteClass", staff},
+		{"List Sessions", constants.ClassSessionEndpoint, http.MethodGet, "/classes/Yoga/sessions", "", "ListSessions", everyone},
+		{"Book Self", constants.BookingEndpoint, http.MethodPost, "/bookings", booking, "BookClass", members},
+		{"Book Other Member", constants.BookingEndpoint, http.MethodPost, "/bookings", `{"class_name":"Yoga","member_id":"mb_2","date":"2025-06-10"}`, "BookClass", staff},
+		{"Book By Name", constants.BookingEndpoint, http.MethodPost, "/bookings", `{"class_name":"Yoga","name":"Alice","date":"2025-06-10"}`, "BookClass", staff},
+		{"Get Own Booking", constants.BookingIDEndpoint, http.MethodGet, "/bookings/bk_1", "", "GetBooking", everyone},
+		{"Get Other Booking", constants.BookingIDEndpoint, http.MethodGet, "/bookings/bk_2", "", "GetBooking", staff},
+		{"Cancel Own Booking", constants.BookingIDEndpoint, http.MethodDelete, "/bookings/bk_1", "", "CancelBooking", members},
+		{"Cancel Other Booking", constants.BookingIDEndpoint, http.MethodDelete, "/bookings/bk_2", "", "CancelBooking", staff},
+		{"List Bookings", constants.BookingEndpoint, http.MethodGet, "/bookings", "", "ListBookings", members},
+		{"Roster Of Taught Class", constants.BookingEndpoint, http.MethodGet, "/bookings?class=Yoga", "", "ListBookings", everyone},
+		{"Roster Of Other Class", constants.BookingEndpoint, http.MethodGet, "/bookings?class=Boxing", "", "ListBookings", members},
+		{"Roster Of Substituted Class", constants.BookingEndpoint, http.MethodGet, "/bookings?class=Pilates", "", "ListBookings", everyone},
+		{"Bookings Of Other Member", constants.BookingEndpoint, http.MethodGet, "/bookings?member_id=mb_2", "", "ListBookings", staff},
+		{"Join Waitlist", constants.WaitlistEndpoint, http.MethodPost, "/classes/Yoga/sessions/2025-06-10/waitlist", `{"member_id":"mb_1"}`, "JoinWaitlist", members},
+		{"Waitlist Other Member", constants.WaitlistEndpoint, http.MethodPost, "/classes/Yoga/sessions/2025-06-10/waitlist", `{"member_id":"mb_2"}`, "JoinWaitlist", staff},
+		{"Own Waitlist Position", constants.WaitlistMemberEndpoint, http.MethodGet, "/classes/Yoga/sessions/2025-06-10/waitlist/mb_1", "", "WaitlistPosition", everyone},
+		{"Other Waitlist Position", constants.WaitlistMemberEndpoint, http.MethodGet, "/classes/Boxing/sessions/2025-06-10/waitlist/mb_2", "", "WaitlistPosition", staff},
+		{"Leave Waitlist", constants.WaitlistMemberEndpoint, http.MethodDelete, "/classes/Yoga/sessions/2025-06-10/waitlist/mb_1", "", "LeaveWaitlist", members},
+		{"Remove Other From Waitlist", constants.WaitlistMemberEndpoint, http.MethodDelete, "/classes/Yoga/sessions/2025-06-10/waitlist/mb_2", "", "LeaveWaitlist", staff},
+		{"Create Member", constants.MemberEndpoint, http.MethodPost, "/members", `{"name":"Alice"}`, "CreateMember", staff},
+		{"List Members", constants.MemberEndpoint, http.MethodGet, "/members", "", "ListMembers", staff},
+		{"Get Own Member", constants.MemberIDEndpoint, http.MethodGet, "/members/mb_1", "", "GetMember", members},
+		{"Get Other Member", constants.MemberIDEndpoint, http.MethodGet, "/members/mb_2", "", "GetMember", staff},
+		{"Update Member", constants.MemberIDEndpoint, http.MethodPut, "/members/mb_1", `{"name":"Alice"}`, "UpdateMember", staff},
+		{"Delete Member", constants.MemberIDEndpoint, http.MethodDelete, "/members/mb_1", "", "DeleteMember", staff},
+		{"Create Instructor", constants.InstructorEndpoint, http.MethodPost, "/instructors", `{"name":"Maya"}`, "CreateInstructor", staff},
+		{"List Instructors", constants.InstructorEndpoint, http.MethodGet, "/instructors", "", "ListInstructors", everyone},
+		{"Get Instructor", constants.InstructorIDEndpoint, http.MethodGet, "/instructors/in_2", "", "GetInstructor", everyone},
+		{"Update Instructor", constants.InstructorIDEndpoint, http.MethodPut, "/instructors/in_1", `{"name":"Maya"}`, "UpdateInstructor", staff},
+		{"Delete Instructor", constants.InstructorIDEndpoint, http.MethodDelete, "/instructors/in_1", "", "DeleteInstructor", staff},
+		{"Own Schedule", constants.InstructorScheduleEndpoint, http.MethodGet, "/instructors/in_1/schedule", "", "InstructorSchedule", []string{constants.RoleAdmin, constants.RoleStaff, constants.RoleInstructor}},
+		{"Other Schedule", constants.InstructorScheduleEndpoint, http.MethodGet, "/instructors/in_2/schedule", "", "InstructorSchedule", staff},
+		{"Create Room", constants.RoomEndpoint, http.MethodPost, "/rooms", `{"name":"Studio 1","capacity":20}`, "CreateRoom", staff},
+		{"List Rooms", constants.RoomEndpoint, http.MethodGet, "/rooms", "", "ListRooms", everyone},
+		{"Get Room", constants.RoomIDEndpoint, http.MethodGet, "/rooms/rm_1", "", "GetRoom", everyone},
+		{"Update Room", constants.RoomIDEndpoint, http.MethodPut, "/rooms/rm_1", `{"name":"Studio 1","capacity":20}`, "UpdateRoom", staff},
+		{"Delete Room", constants.RoomIDEndpoint, http.MethodDelete, "/rooms/rm_1", "", "DeleteRoom", staff},
+		{"Room Utilization", constants.RoomUtilizationEndpoint, http.MethodGet, "/rooms/rm_1/utilization", "", "RoomUtilization", staff},
+		{"Create Plan", constants.PlanEndpoint, http.MethodPost, "/plans", `{"name":"Unlimited","kind":"unlimited"}`, "CreatePlan", staff},
+		{"List Plans", constants.PlanEndpoint, http.MethodGet, "/plans", "", "ListPlans", everyone},
+		{"Get Plan", constants.PlanIDEndpoint, http.MethodGet, "/plans/pl_1", "", "GetPlan", everyone},
+		{"Update Plan", constants.PlanIDEndpoint, http.MethodPut, "/plans/pl_1", `{"name":"Unlimited","kind":"unlimited"}`, "UpdatePlan", staff},
+		{"Delete Plan", constants.PlanIDEndpoint, http.MethodDelete, "/plans/pl_1", "", "DeletePlan", staff},
+		{"Create Promo Code", constants.PromoEndpoint, http.MethodPost, "/promo-codes", `{"code":"WELCOME","kind":"first_class"}`, "CreatePromoCode", staff},
+		{"List Promo Codes", constants.PromoEndpoint, http.MethodGet, "/promo-codes", "", "ListPromoCodes", staff},
+		{"Get Promo Code", constants.PromoCodeEndpoint, http.MethodGet, "/promo-codes/WELCOME", "", "GetPromoCode", staff},
+		{"Update Promo Code", constants.PromoCodeEndpoint, http.MethodPut, "/promo-codes/WELCOME", `{"code":"WELCOME","kind":"first_class"}`, "UpdatePromoCode", staff},
+		{"Delete Promo Code", constants.PromoCodeEndpoint, http.MethodDelete, "/promo-codes/WELCOME", "", "DeletePromoCode", staff},
+		{"Promo Redemptions", constants.PromoRedemptionsEndpoint, http.MethodGet, "/promo-codes/WELCOME/redemptions", "", "PromoRedemptions", staff},
+		{"Purchase Membership", constants.MemberMembershipEndpoint, http.MethodPost, "/members/mb_1/memberships", `{"plan_id":"pl_1"}`, "PurchaseMembership", staff},
+		{"Own Credits", constants.MemberCreditsEndpoint, http.MethodGet, "/members/mb_1/credits", "", "MemberCredits", members},
+		{"Other Credits", constants.MemberCreditsEndpoint, http.MethodGet, "/members/mb_2/credits", "", "MemberCredits", staff},
+	}
+
+	// Every route of the router is covered
+	router := SetupRouter(NewClassHandler(newPolicyService()), RouterOptions{Authenticator: authenticator})
+	for _, route := range router.Routes() {
+		assert.True(t, slices.ContainsFunc(tests, func(tt policyCase) bool {
+			return tt.method == route.Method && tt.route == route.Path
+		}), "route %s %s has no policy test", route.Method, route.Path)
+	}
+
+	for _, tt := range tests {
+		for _, role := range everyone {
+			t.Run(tt.name+"/"+role, func(t *testing.T) {
+				mockService := newPolicyService()
+				router := SetupRouter(NewClassHandler(mockService), RouterOptions{Authenticator: authenticator})
+
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set(constants.HeaderIfMatch, "*")
+				req.Header.Set(constants.HeaderAuthorization, "Bearer "+hs256Token(t, callers[role]))
+				router.ServeHTTP(w, req)
+
+				if slices.Contains(tt.allowed, role) {
+					assert.Less(t, w.Code, 300, w.Body.String())
+					mockService.AssertCalled(t, tt.call, mockArgs(tt.call)...)
+					return
+				}
+				assert.Equal(t, http.StatusForbidden, w.Code)
+				var problem models.Problem
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+				assert.Equal(t, "forbidden", problem.Code)
+				// The policy reads a booking to find its member and class
+				if tt.call != "GetBooking" {
+					mockService.AssertNotCalled(t, tt.call, mockArgs(tt.call)...)
+				}
+			})
+		}
+	}
+}
+
+// mockArgs matches any arguments of a service method
+func mockArgs(method string) []any {
+	args := make([]any, serviceArity[method])
+	for i := range args {
+		args[i] = mock.Anything
+	}
+	return args
+}
+
+func TestPolicy_MemberListsOwnBookings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator, err := auth.New(config.AuthConfig{Mode: constants.AuthRequired, JWTSecret: testJWTSecret})
+	require.NoError(t, err)
+	mockService := new(MockClassService)
+	expected := models.BookingListRequest{MemberID: "mb_1", ClassName: "Yoga"}
+	mockService.On("ListBookings", expected).Return(models.Page[models.Booking]{Items: []models.Booking{}}, nil)
+	router := SetupRouter(NewClassHandler(mockService), RouterOptions{Authenticator: authenticator})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/bookings?class=Yoga", nil)
+	req.Header.Set(constants.HeaderAuthorization, "Bearer "+hs256Token(t, models.Principal{Subject: "mb_1", Role: constants.RoleMember, StudioID: "st_1"}))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
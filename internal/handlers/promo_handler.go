@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/utils"
+	"net/http"
+)
+
+// CreatePromoCode handles POST /promo-codes
+func (h *ClassHandler) CreatePromoCode(ctx *gin.Context) {
+	var req models.PromoCodeRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	promo, err := h.serviceFor(ctx).CreatePromoCode(req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Promo code %s created successfully", promo.Code),
+		Data:    promo,
+	})
+}
+
+// GetPromoCode handles GET /promo-codes/:code
+func (h *ClassHandler) GetPromoCode(ctx *gin.Context) {
+	promo, err := h.serviceFor(ctx).GetPromoCode(ctx.Param("code"))
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   promo,
+	})
+}
+
+// ListPromoCodes handles GET /promo-codes
+func (h *ClassHandler) ListPromoCodes(ctx *gin.Context) {
+	var req models.ListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	page, err := h.serviceFor(ctx).ListPromoCodes(req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   page,
+	})
+}
+
+// UpdatePromoCode handles PUT /promo-codes/:code
+func (h *ClassHandler) UpdatePromoCode(ctx *gin.Context) {
+	var req models.PromoCodeRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	promo, err := h.serviceFor(ctx).UpdatePromoCode(ctx.Param("code"), req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Promo code %s updated successfully", promo.Code),
+		Data:    promo,
+	})
+}
+
+// DeletePromoCode handles DELETE /promo-codes/:code
+func (h *ClassHandler) DeletePromoCode(ctx *gin.Context) {
+	code := ctx.Param("code")
+	if err := h.serviceFor(ctx).DeletePromoCode(code); err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Promo code %s deleted", code),
+	})
+}
+
+// GetPromoRedemptions handles GET /promo-codes/:code/redemptions
+func (h *ClassHandler) GetPromoRedemptions(ctx *gin.Context) {
+	report, err := h.serviceFor(ctx).PromoRedemptions(ctx.Param("code"))
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   report,
+	})
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"bytes"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// CreatePromoCode mocks the CreatePromoCode method
+func (m *MockClassService) CreatePromoCode(req models.PromoCodeRequest) (models.PromoCode, error) {
+	args := m.Called(req)
+	promo, _ := args.Get(0).(models.PromoCode)
+	return promo, args.Error(1)
+}
+
+// GetPromoCode mocks the GetPromoCode method
+func (m *MockClassService) GetPromoCode(code string) (models.PromoCode, error) {
+	args := m.Called(code)
+	promo, _ := args.Get(0).(models.PromoCode)
+	return promo, args.Error(1)
+}
+
+// ListPromoCodes mocks the ListPromoCodes method
+func (m *MockClassService) ListPromoCodes(req models.ListRequest) (models.Page[models.PromoCode], error) {
+	args := m.Called(req)
+	page, _ := args.Get(0).(models.Page[models.PromoCode])
+	return page, args.Error(1)
+}
+
+// UpdatePromoCode mocks the UpdatePromoCode method
+func (m *MockClassService) UpdatePromoCode(code string, req models.PromoCodeRequest) (models.PromoCode, error) {
+	args := m.Called(code, req)
+	promo, _ := args.Get(0).(models.PromoCode)
+	return promo, args.Error(1)
+}
+
+// DeletePromoCode mocks the DeletePromoCode method
+func (m *MockClassService) DeletePromoCode(code string) error {
+	args := m.Called(code)
+	return args.Error(0)
+}
+
+// PromoRedemptions mocks the PromoRedemptions method
+func (m *MockClassService) PromoRedemptions(code string) (models.PromoReport, error) {
+	args := m.Called(code)
+	report, _ := args.Get(0).(models.PromoReport)
+	return report, args.Error(1)
+}
+
+func TestClassHandler_PromoCodes(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	promo := models.PromoCode{Code: "SUMMER", Kind: constants.PromoPercentage, Percent: 20, MaxRedemptions: 50}
+	promoReq := models.PromoCodeRequest{Code: "summer", Kind: constants.PromoPercentage, Percent: 20, MaxRedemptions: 50}
+	remaining := 49
+	report := models.PromoReport{PromoCode: promo, Redemptions: 1, Remaining: &remaining, Discounts: map[string]int64{"EUR": 300}}
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		jsonInput      string
+		setupMock      func(*MockClassService)
+		expectedStatus int
+		expectedBody   models.Response
+		expectedCode   string
+	}{
+		{
+			name:      "Create Happy Path",
+			method:    http.MethodPost,
+			path:      "/promo-codes",
+			jsonInput: `{"code":"summer","kind":"percentage","percent":20,"max_redemptions":50}`,
+			setupMock: func(m *MockClassService) {
+				m.On("CreatePromoCode", promoReq).Return(promo, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Promo code SUMMER created successfully",
+			},
+		},
+		{
+			name:           "Create Unknown Kind",
+			method:         http.MethodPost,
+			path:           "/promo-codes",
+			jsonInput:      `{"code":"SUMMER","kind":"free"}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+			expectedBody:   models.Response{Message: constants.ErrInvalidReq.Error() + ": kind must be one of percentage, fixed, first_class"},
+		},
+		{
+			name:      "Create Duplicate",
+			method:    http.MethodPost,
+			path:      "/promo-codes",
+			jsonInput: `{"code":"summer","kind":"percentage","percent":20,"max_redemptions":50}`,
+			setupMock: func(m *MockClassService) {
+				m.On("CreatePromoCode", promoReq).Return(models.PromoCode{}, constants.ErrPromoExists)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "promo_exists",
+			expectedBody:   models.Response{Message: constants.ErrPromoExists.Error()},
+		},
+		{
+			name:   "Get Not Found",
+			method: http.MethodGet,
+			path:   "/promo-codes/WINTER",
+			setupMock: func(m *MockClassService) {
+				m.On("GetPromoCode", "WINTER").Return(models.PromoCode{}, constants.ErrPromoNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "promo_not_found",
+			expectedBody:   models.Response{Message: constants.ErrPromoNotFound.Error()},
+		},
+		{
+			name:   "List Happy Path",
+			method: http.MethodGet,
+			path:   "/promo-codes?limit=10",
+			setupMock: func(m *MockClassService) {
+				m.On("ListPromoCodes", models.ListRequest{Limit: 10}).Return(models.Page[models.PromoCode]{Items: []models.PromoCode{promo}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.Response{Status: constants.SuccessMsg},
+		},
+		{
+			name:      "Update Happy Path",
+			method:    http.MethodPut,
+			path:      "/promo-codes/SUMMER",
+			jsonInput: `{"code":"summer","kind":"percentage","percent":20,"max_redemptions":50}`,
+			setupMock: func(m *MockClassService) {
+				m.On("UpdatePromoCode", "SUMMER", promoReq).Return(promo, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Promo code SUMMER updated successfully",
+			},
+		},
+		{
+			name:   "Delete Happy Path",
+			method: http.MethodDelete,
+			path:   "/promo-codes/SUMMER",
+			setupMock: func(m *MockClassService) {
+				m.On("DeletePromoCode", "SUMMER").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Promo code SUMMER deleted",
+			},
+		},
+		{
+			name:   "Redemptions Happy Path",
+			method: http.MethodGet,
+			path:   "/promo-codes/SUMMER/redemptions",
+			setupMock: func(m *MockClassService) {
+				m.On("PromoRedemptions", "SUMMER").Return(report, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.Response{Status: constants.SuccessMsg},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup mock service and router
+			mockService := new(MockClassService)
+			tt.setupMock(mockService)
+			router := SetupRouter(NewClassHandler(mockService), RouterOptions{})
+
+			// Create HTTP request
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.jsonInput))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			// Assert status code
+			assert.Equal(t, tt.expectedStatus, w.Code, "Expected status %d, got %d", tt.expectedStatus, w.Code)
+
+			// Assert response body
+			assertBody(t, w, tt.expectedCode, tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
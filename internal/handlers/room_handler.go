@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/utils"
+	"net/http"
+)
+
+// CreateRoom handles POST /rooms
+func (h *ClassHandler) CreateRoom(ctx *gin.Context) {
+	var req models.RoomRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	room, err := h.serviceFor(ctx).CreateRoom(req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Room %s created successfully", room.ID),
+		Data:    room,
+	})
+}
+
+// GetRoom handles GET /rooms/:id
+func (h *ClassHandler) GetRoom(ctx *gin.Context) {
+	room, err := h.serviceFor(ctx).GetRoom(ctx.Param("id"))
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   room,
+	})
+}
+
+// ListRooms handles GET /rooms
+func (h *ClassHandler) ListRooms(ctx *gin.Context) {
+	var req models.ListRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	page, err := h.serviceFor(ctx).ListRooms(req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   page,
+	})
+}
+
+// UpdateRoom handles PUT /rooms/:id
+func (h *ClassHandler) UpdateRoom(ctx *gin.Context) {
+	var req models.RoomRequest
+	if !bindJSON(ctx, &req) {
+		return
+	}
+
+	room, err := h.serviceFor(ctx).UpdateRoom(ctx.Param("id"), req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Room %s updated successfully", room.ID),
+		Data:    room,
+	})
+}
+
+// DeleteRoom handles DELETE /rooms/:id
+func (h *ClassHandler) DeleteRoom(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if err := h.serviceFor(ctx).DeleteRoom(id); err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("Room %s deleted", id),
+	})
+}
+
+// GetRoomUtilization handles GET /rooms/:id/utilization
+func (h *ClassHandler) GetRoomUtilization(ctx *gin.Context) {
+	var req models.ScheduleRequest
+	if !bindQuery(ctx, &req) {
+		return
+	}
+
+	utilization, err := h.serviceFor(ctx).RoomUtilization(ctx.Param("id"), req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   utilization,
+	})
+}
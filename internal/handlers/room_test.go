@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bytes"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// CreateRoom mocks the CreateRoom method
+func (m *MockClassService) CreateRoom(req models.RoomRequest) (models.Room, error) {
+	args := m.Called(req)
+	room, _ := args.Get(0).(models.Room)
+	return room, args.Error(1)
+}
+
+// GetRoom mocks the GetRoom method
+func (m *MockClassService) GetRoom(id string) (models.Room, error) {
+	args := m.Called(id)
+	room, _ := args.Get(0).(models.Room)
+	return room, args.Error(1)
+}
+
+// ListRooms mocks the ListRooms method
+func (m *MockClassService) ListRooms(req models.ListRequest) (models.Page[models.Room], error) {
+	args := m.Called(req)
+	page, _ := args.Get(0).(models.Page[models.Room])
+	return page, args.Error(1)
+}
+
+// UpdateRoom mocks the UpdateRoom method
+func (m *MockClassService) UpdateRoom(id string, req models.RoomRequest) (models.Room, error) {
+	args := m.Called(id, req)
+	room, _ := args.Get(0).(models.Room)
+	return room, args.Error(1)
+}
+
+// DeleteRoom mocks the DeleteRoom method
+func (m *MockClassService) DeleteRoom(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// RoomUtilization mocks the RoomUtilization method
+func (m *MockClassService) RoomUtilization(id string, req models.ScheduleRequest) (models.RoomUtilization, error) {
+	args := m.Called(id, req)
+	utilization, _ := args.Get(0).(models.RoomUtilization)
+	return utilization, args.Error(1)
+}
+
+func TestClassHandler_Rooms(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	room := models.Room{ID: "rm_1", Name: "Studio 1", Capacity: 20, Resources: []models.Resource{{Name: "Bikes", Quantity: 12}}}
+	roomReq := models.RoomRequest{Name: "Studio 1", Capacity: 20, Resources: []models.ResourceRequest{{Name: "Bikes", Quantity: 12}}}
+	week := time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC)
+	utilization := models.RoomUtilization{
+		RoomID:    "rm_1",
+		From:      week,
+		To:        week.AddDate(0, 0, 7),
+		Seats:     20,
+		Booked:    5,
+		Occupancy: 0.25,
+		Sessions:  []models.RoomSession{{ClassName: "Spin", Date: week.Add(7 * time.Hour), Capacity: 12, Booked: 5}},
+	}
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		jsonInput      string
+		setupMock      func(*MockClassService)
+		expectedStatus int
+		expectedBody   models.Response
+		expectedCode   string
+	}{
+		{
+			name:      "Create Happy Path",
+			method:    http.MethodPost,
+			path:      "/rooms",
+			jsonInput: `{"name":"Studio 1","capacity":20,"resources":[{"name":"Bikes","quantity":12}]}`,
+			setupMock: func(m *MockClassService) {
+				m.On("CreateRoom", roomReq).Return(room, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Room rm_1 created successfully",
+			},
+		},
+		{
+			name:           "Create Without Capacity",
+			method:         http.MethodPost,
+			path:           "/rooms",
+			jsonInput:      `{"name":"Studio 1"}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+			expectedBody:   models.Response{Message: constants.ErrInvalidReq.Error() + ": capacity is required"},
+		},
+		{
+			name:   "Get Not Found",
+			method: http.MethodGet,
+			path:   "/rooms/rm_2",
+			setupMock: func(m *MockClassService) {
+				m.On("GetRoom", "rm_2").Return(models.Room{}, constants.ErrRoomNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "room_not_found",
+			expectedBody:   models.Response{Message: constants.ErrRoomNotFound.Error()},
+		},
+		{
+			name:   "List Happy Path",
+			method: http.MethodGet,
+			path:   "/rooms?limit=10",
+			setupMock: func(m *MockClassService) {
+				m.On("ListRooms", models.ListRequest{Limit: 10}).Return(models.Page[models.Room]{Items: []models.Room{room}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.Response{Status: constants.SuccessMsg},
+		},
+		{
+			name:      "Update Below Class Capacity",
+			method:    http.MethodPut,
+			path:      "/rooms/rm_1",
+			jsonInput: `{"name":"Studio 1","capacity":20,"resources":[{"name":"Bikes","quantity":12}]}`,
+			setupMock: func(m *MockClassService) {
+				m.On("UpdateRoom", "rm_1", roomReq).Return(models.Room{}, constants.ErrCapacityExceedsRoom)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "capacity_exceeds_room",
+			expectedBody:   models.Response{Message: constants.ErrCapacityExceedsRoom.Error()},
+		},
+		{
+			name:   "Delete Room In Use",
+			method: http.MethodDelete,
+			path:   "/rooms/rm_1",
+			setupMock: func(m *MockClassService) {
+				m.On("DeleteRoom", "rm_1").Return(constants.ErrRoomInUse)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "room_in_use",
+			expectedBody:   models.Response{Message: constants.ErrRoomInUse.Error()},
+		},
+		{
+			name:   "Utilization Happy Path",
+			method: http.MethodGet,
+			path:   "/rooms/rm_1/utilization?from=2025-06-09",
+			setupMock: func(m *MockClassService) {
+				m.On("RoomUtilization", "rm_1", models.ScheduleRequest{From: "2025-06-09"}).Return(utilization, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   models.Response{Status: constants.SuccessMsg},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup mock service and router
+			mockService := new(MockClassService)
+			tt.setupMock(mockService)
+			router := SetupRouter(NewClassHandler(mockService), RouterOptions{})
+
+			// Create HTTP request
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.jsonInput))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			// Assert status code
+			assert.Equal(t, tt.expectedStatus, w.Code, "Expected status %d, got %d", tt.expectedStatus, w.Code)
+
+			// Assert response body
+			assertBody(t, w, tt.expectedCode, tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
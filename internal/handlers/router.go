@@ -2,20 +2,95 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
+	"glofox/internal/auth"
 	"glofox/internal/constants"
+	"glofox/internal/payments"
+	"glofox/internal/repository"
+	"time"
 )
 
+// RouterOptions configures the middleware installed by SetupRouter
+type RouterOptions struct {
+	// IdempotencyRepo returns the repository storing the responses replayed
+	// for Idempotency-Key retries in a studio, nil disables the header
+	IdempotencyRepo func(studioID string) (repository.IdempotencyRepository, error)
+	// IdempotencyTTL is how long a stored response is replayed
+	IdempotencyTTL time.Duration
+	// Authenticator verifies the credentials of every request, nil disables
+	// authentication
+	Authenticator *auth.Authenticator
+	// Payments verifies the webhooks of the payment provider, nil disables
+	// the webhook endpoint
+	Payments payments.PaymentProvider
+}
+
 // SetupRouter configures the Gin router with handlers
-func SetupRouter(handler IHandler) *gin.Engine {
+func SetupRouter(handler IHandler, opts RouterOptions) *gin.Engine {
 	router := gin.Default()
 	// Middleware to handle panics and recover
 	router.Use(gin.Recovery())
 	// Middleware for logging requests
 	router.Use(gin.Logger())
+	// The provider signs its webhooks instead of presenting credentials, so
+	// the endpoint is registered before authentication
+	if opts.Payments != nil {
+		router.POST(constants.PaymentWebhookEndpoint, PaymentWebhook(opts.Payments), handler.ResolveStudio, handler.HandlePaymentWebhook)
+	}
+	// Middleware rejecting requests without valid credentials
+	if opts.Authenticator != nil {
+		router.Use(Authenticate(opts.Authenticator))
+	}
+	// Middleware resolving the studio every request acts in
+	router.Use(handler.ResolveStudio)
+	// Middleware replaying the response of retried POST requests
+	if opts.IdempotencyRepo != nil {
+		router.Use(Idempotency(opts.IdempotencyRepo, opts.IdempotencyTTL))
+	}
 
 	// Define API endpoints
 	router.POST(constants.ClassEndpoint, handler.CreateClass)
+	router.GET(constants.ClassEndpoint, handler.ListClasses)
+	router.GET(constants.ClassNameEndpoint, handler.GetClass)
+	router.PATCH(constants.ClassNameEndpoint, handler.UpdateClass)
+	router.DELETE(constants.ClassNameEndpoint, handler.DeleteClass)
+	router.GET(constants.ClassSessionEndpoint, handler.ListSessions)
 	router.POST(constants.BookingEndpoint, handler.CreateBooking)
+	router.GET(constants.BookingEndpoint, handler.ListBookings)
+	router.GET(constants.BookingIDEndpoint, handler.GetBooking)
+	router.DELETE(constants.BookingIDEndpoint, handler.CancelBooking)
+	router.POST(constants.WaitlistEndpoint, handler.JoinWaitlist)
+	router.GET(constants.WaitlistMemberEndpoint, handler.GetWaitlistPosition)
+	router.DELETE(constants.WaitlistMemberEndpoint, handler.LeaveWaitlist)
+	router.POST(constants.MemberEndpoint, handler.CreateMember)
+	router.GET(constants.MemberEndpoint, handler.ListMembers)
+	router.GET(constants.MemberIDEndpoint, handler.GetMember)
+	router.PUT(constants.MemberIDEndpoint, handler.UpdateMember)
+	router.DELETE(constants.MemberIDEndpoint, handler.DeleteMember)
+	router.POST(constants.InstructorEndpoint, handler.CreateInstructor)
+	router.GET(constants.InstructorEndpoint, handler.ListInstructors)
+	router.GET(constants.InstructorIDEndpoint, handler.GetInstructor)
+	router.PUT(constants.InstructorIDEndpoint, handler.UpdateInstructor)
+	router.DELETE(constants.InstructorIDEndpoint, handler.DeleteInstructor)
+	router.GET(constants.InstructorScheduleEndpoint, handler.GetInstructorSchedule)
+	router.POST(constants.RoomEndpoint, handler.CreateRoom)
+	router.GET(constants.RoomEndpoint, handler.ListRooms)
+	router.GET(constants.RoomIDEndpoint, handler.GetRoom)
+	router.PUT(constants.RoomIDEndpoint, handler.UpdateRoom)
+	router.DELETE(constants.RoomIDEndpoint, handler.DeleteRoom)
+	router.GET(constants.RoomUtilizationEndpoint, handler.GetRoomUtilization)
+	router.POST(constants.PlanEndpoint, handler.CreatePlan)
+	router.GET(constants.PlanEndpoint, handler.ListPlans)
+	router.GET(constants.PlanIDEndpoint, handler.GetPlan)
+	router.PUT(constants.PlanIDEndpoint, handler.UpdatePlan)
+	router.DELETE(constants.PlanIDEndpoint, handler.DeletePlan)
+	router.POST(constants.PromoEndpoint, handler.CreatePromoCode)
+	router.GET(constants.PromoEndpoint, handler.ListPromoCodes)
+	router.GET(constants.PromoCodeEndpoint, handler.GetPromoCode)
+	router.PUT(constants.PromoCodeEndpoint, handler.UpdatePromoCode)
+	router.DELETE(constants.PromoCodeEndpoint, handler.DeletePromoCode)
+	router.GET(constants.PromoRedemptionsEndpoint, handler.GetPromoRedemptions)
+	router.POST(constants.MemberMembershipEndpoint, handler.PurchaseMembership)
+	router.GET(constants.MemberCreditsEndpoint, handler.GetMemberCredits)
 
 	return router
 }
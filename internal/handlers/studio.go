@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"glofox/internal/constants"
+	"glofox/internal/services"
+	"glofox/internal/utils"
+	"log"
+	"strings"
+)
+
+// ResolveStudio is the middleware resolving the studio of each request and
+// its service. An authenticated caller acts in the studio of their
+// credentials, which the X-Studio-ID header may repeat but not change, and
+// which is created on first use. Without authentication the header picks one
+// of the studios that exist, and defaults to DefaultStudioID. Webhooks act in
+// the studio named by their event, which must exist too.
+func (h *ClassHandler) ResolveStudio(ctx *gin.Context) {
+	studioID, create, err := requestStudio(ctx)
+	if err == nil && !validStudioID(studioID) {
+		err = constants.ErrInvalidStudio
+	}
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		ctx.Abort()
+		return
+	}
+	if !create && studioID != constants.DefaultStudioID {
+		exists, err := h.studios.Exists(studioID)
+		if err != nil {
+			log.Printf("Failed to look up studio %s: %v", studioID, err)
+			utils.WriteProblem(ctx, constants.ErrInternalServer)
+			ctx.Abort()
+			return
+		}
+		if !exists {
+			utils.WriteProblem(ctx, fmt.Errorf("%w: %s", constants.ErrStudioNotFound, studioID))
+			ctx.Abort()
+			return
+		}
+	}
+	service, err := h.studios.Service(studioID)
+	if err != nil {
+		log.Printf("Failed to open studio %s: %v", studioID, err)
+		utils.WriteProblem(ctx, constants.ErrInternalServer)
+		ctx.Abort()
+		return
+	}
+	ctx.Set(constants.ContextStudio, studioID)
+	ctx.Set(constants.ContextService, service)
+	ctx.Next()
+}
+
+// requestStudio returns the studio a request acts in, and whether the
+// request may create it, see ResolveStudio
+func requestStudio(ctx *gin.Context) (string, bool, error) {
+	header := strings.TrimSpace(ctx.GetHeader(constants.HeaderStudioID))
+	if principal, ok := principalFrom(ctx); ok {
+		if header != "" && header != principal.StudioID {
+			return "", false, fmt.Errorf("%w: credentials of studio %s cannot act in studio %s", constants.ErrForbidden, principal.StudioID, header)
+		}
+		return principal.StudioID, true, nil
+	}
+	// PaymentWebhook sets the studio named by the event
+	if studioID := ctx.GetString(constants.ContextStudio); studioID != "" {
+		return studioID, false, nil
+	}
+	if header == "" {
+		return constants.DefaultStudioID, false, nil
+	}
+	return header, false, nil
+}
+
+// validStudioID reports whether a studio ID is up to MaxStudioIDLength
+// lowercase letters, digits, _ or -, starting with a letter or digit. Studio
+// IDs name stored collections, so they never contain separators.
+func validStudioID(studioID string) bool {
+	if studioID == "" || len(studioID) > constants.MaxStudioIDLength || studioID[0] == '_' || studioID[0] == '-' {
+		return false
+	}
+	for _, c := range studioID {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// studioFrom returns the studio of the request resolved by ResolveStudio
+func studioFrom(ctx *gin.Context) string {
+	if studioID := ctx.GetString(constants.ContextStudio); studioID != "" {
+		return studioID
+	}
+	return constants.DefaultStudioID
+}
+
+// singleService serves every studio with the same service
+type singleService struct {
+	service services.IService
+}
+
+// Service returns the shared service
+func (s singleService) Service(string) (services.IService, error) {
+	return s.service, nil
+}
+
+// Exists reports that every studio exists
+func (s singleService) Exists(string) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"glofox/internal/auth"
+	"glofox/internal/config"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockStudios serves each studio with its own mock service
+type mockStudios map[string]*MockClassService
+
+// Service mocks the Service method
+func (m mockStudios) Service(studioID string) (services.IService, error) {
+	service, exists := m[studioID]
+	if !exists {
+		return nil, errors.New("disk full")
+	}
+	return service, nil
+}
+
+// Exists mocks the Exists method
+func (m mockStudios) Exists(studioID string) (bool, error) {
+	_, exists := m[studioID]
+	return exists, nil
+}
+
+func TestStudio(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator, err := auth.New(config.AuthConfig{Mode: constants.AuthRequired, JWTSecret: testJWTSecret})
+	require.NoError(t, err)
+	staff := "Bearer " + hs256Token(t, models.Principal{Subject: "sf_1", Role: constants.RoleStaff, StudioID: "st_1"})
+	broken := "Bearer " + hs256Token(t, models.Principal{Subject: "sf_9", Role: constants.RoleStaff, StudioID: "st_9"})
+
+	tests := []struct {
+		name           string
+		authenticated  bool
+		headers        map[string]string
+		expectedStatus int
+		expectedCode   string
+		expectedStudio string
+	}{
+		{
+			name:           "Default Studio",
+			expectedStatus: http.StatusOK,
+			expectedStudio: constants.DefaultStudioID,
+		},
+		{
+			name:           "Studio Header",
+			headers:        map[string]string{constants.HeaderStudioID: "st_2"},
+			expectedStatus: http.StatusOK,
+			expectedStudio: "st_2",
+		},
+		{
+			name:           "Unknown Studio Header",
+			headers:        map[string]string{constants.HeaderStudioID: "st_9"},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "studio_not_found",
+		},
+		{
+			name:           "Invalid Studio Header",
+			headers:        map[string]string{constants.HeaderStudioID: "../st_2"},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_studio",
+		},
+		{
+			name:           "Studio Header Too Long",
+			headers:        map[string]string{constants.HeaderStudioID: strings.Repeat("s", constants.MaxStudioIDLength+1)},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_studio",
+		},
+		{
+			name:           "Studio Of Credentials",
+			authenticated:  true,
+			headers:        map[string]string{constants.HeaderAuthorization: staff},
+			expectedStatus: http.StatusOK,
+			expectedStudio: "st_1",
+		},
+		{
+			name:           "Header Repeats Studio Of Credentials",
+			authenticated:  true,
+			headers:        map[string]string{constants.HeaderAuthorization: staff, constants.HeaderStudioID: "st_1"},
+			expectedStatus: http.StatusOK,
+			expectedStudio: "st_1",
+		},
+		{
+			name:           "Header Names Another Studio",
+			authenticated:  true,
+			headers:        map[string]string{constants.HeaderAuthorization: staff, constants.HeaderStudioID: "st_2"},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "forbidden",
+		},
+		{
+			name:           "Studio Of Credentials Fails To Open",
+			authenticated:  true,
+			headers:        map[string]string{constants.HeaderAuthorization: broken},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "internal_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			studios := mockStudios{}
+			for _, studioID := range []string{constants.DefaultStudioID, "st_1", "st_2"} {
+				studios[studioID] = new(MockClassService)
+			}
+			if tt.expectedStudio != "" {
+				studios[tt.expectedStudio].On("GetClass", "Yoga").Return(models.Class{Name: "Yoga", Version: 1}, nil)
+			}
+			opts := RouterOptions{}
+			if tt.authenticated {
+				opts.Authenticator = authenticator
+			}
+			router := SetupRouter(NewStudioHandler(studios), opts)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/classes/Yoga", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var problem models.Problem
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+				assert.Equal(t, tt.expectedCode, problem.Code)
+			}
+			// Only the service of the studio of the request is called
+			for _, mockService := range studios {
+				mockService.AssertExpectations(t)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"glofox/internal/apierrors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/utils"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// namePattern is the character set of class names. Names are used in URL
+// paths, so path and query delimiters are not allowed.
+var namePattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} '&.,()+_-]*$`)
+
+var registerValidatorsOnce sync.Once
+
+// registerValidators names validation errors after the JSON or query fields
+// and adds the custom rules used in the binding tags of the request models
+func registerValidators() {
+	registerValidatorsOnce.Do(func() {
+		engine, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		engine.RegisterTagNameFunc(requestFieldName)
+		// RegisterValidation only fails for an empty tag or a nil function
+		_ = engine.RegisterValidation("date", validateDate)
+		_ = engine.RegisterValidation("name", validateName)
+		_ = engine.RegisterValidation("capacity", validateCapacity)
+	})
+}
+
+// requestFieldName returns the name of a field in the JSON body or query string
+func requestFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// validateDate accepts the date and time formats of utils.ParseDateTime
+func validateDate(fl validator.FieldLevel) bool {
+	_, _, err := utils.ParseDateTime(fl.Field().String(), time.UTC)
+	return err == nil
+}
+
+// validateName accepts names of up to constants.MaxNameLength characters of namePattern
+func validateName(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	return utf8.RuneCountInString(name) <= constants.MaxNameLength && namePattern.MatchString(name)
+}
+
+// validateCapacity accepts capacities between 1 and constants.MaxClassCapacity
+func validateCapacity(fl validator.FieldLevel) bool {
+	capacity := fl.Field().Int()
+	return capacity >= 1 && capacity <= constants.MaxClassCapacity
+}
+
+// bindJSON binds the JSON body of the request into obj. It writes the problem
+// listing the invalid fields and returns false when the body is not valid.
+func bindJSON(ctx *gin.Context, obj any) bool {
+	return decodeJSON(ctx, obj) && validate(ctx, obj)
+}
+
+// decodeJSON decodes the JSON body of the request into obj without validating
+// it, for handlers that fill in defaults first
+func decodeJSON(ctx *gin.Context, obj any) bool {
+	if ctx.Request.Body == nil {
+		utils.WriteProblem(ctx, fmt.Errorf("%w: missing body", constants.ErrInvalidReq))
+		return false
+	}
+	if err := json.NewDecoder(ctx.Request.Body).Decode(obj); err != nil {
+		utils.WriteProblem(ctx, invalidRequest(constants.ErrInvalidReq, obj, err))
+		return false
+	}
+	return true
+}
+
+// validate checks the binding rules of a decoded JSON body like bindJSON
+func validate(ctx *gin.Context, obj any) bool {
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		utils.WriteProblem(ctx, invalidRequest(constants.ErrInvalidReq, obj, err))
+		return false
+	}
+	return true
+}
+
+// bindQuery binds the query string of the request into obj like bindJSON
+func bindQuery(ctx *gin.Context, obj any) bool {
+	if err := ctx.ShouldBindQuery(obj); err != nil {
+		utils.WriteProblem(ctx, invalidRequest(constants.ErrInvalidQuery, obj, err))
+		return false
+	}
+	return true
+}
+
+// invalidRequest wraps a binding error of obj into sentinel with a problem
+// field for each invalid field
+func invalidRequest(sentinel error, obj any, err error) error {
+	var fields []models.ProblemField
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fieldErr := range validationErrs {
+			fields = append(fields, problemField(obj, fieldErr))
+		}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		fields = append(fields, models.ProblemField{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("must be a %s", jsonTypeName(typeErr.Type)),
+		})
+	default:
+		return fmt.Errorf("%w: %v", sentinel, err)
+	}
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, field.Field+" "+field.Message)
+	}
+	return apierrors.WithFields(fmt.Errorf("%w: %s", sentinel, strings.Join(messages, "; ")), fields...)
+}
+
+// problemField translates a failed validation rule
+func problemField(obj any, fieldErr validator.FieldError) models.ProblemField {
+	field := models.ProblemField{Field: fieldPath(obj, fieldErr), Rule: fieldErr.Tag()}
+	param := fieldErr.Param()
+
+	switch fieldErr.Tag() {
+	case "required":
+		field.Message = "is required"
+	case "required_without":
+		field.Message = fmt.Sprintf("is required when %s is not set", siblingName(obj, param))
+	case "oneof":
+		field.Allowed = strings.Fields(param)
+		field.Message = "must be one of " + strings.Join(field.Allowed, ", ")
+	case "gt":
+		field.Message = "must be greater than " + param
+	case "gte", "min":
+		field.Message = "must be at least " + param
+	case "lt":
+		field.Message = "must be less than " + param
+	case "lte", "max":
+		field.Message = "must be at most " + param
+	case "email":
+		field.Message = "must be an email address"
+	case "date":
+		field.Message = "must be a date as YYYY-MM-DD or RFC 3339"
+	case "name":
+		field.Message = fmt.Sprintf("must be 1 to %d letters, digits, spaces or ' & . , ( ) + _ -, starting with a letter or digit", constants.MaxNameLength)
+	case "capacity":
+		field.Message = fmt.Sprintf("must be between 1 and %d", constants.MaxClassCapacity)
+	default:
+		field.Message = fmt.Sprintf("failed the %s rule", fieldErr.Tag())
+	}
+	return field
+}
+
+// fieldPath returns the JSON path of the field that failed validation. The
+// namespace of the error starts with the name of the request struct and
+// includes embedded structs, which are flattened in JSON and query strings.
+func fieldPath(obj any, fieldErr validator.FieldError) string {
+	names := strings.Split(fieldErr.Namespace(), ".")[1:]
+	goNames := strings.Split(fieldErr.StructNamespace(), ".")[1:]
+	typ := reflect.TypeOf(obj)
+	path := make([]string, 0, len(names))
+	for i, name := range names {
+		for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array || typ.Kind() == reflect.Map {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct {
+			path = append(path, names[i:]...)
+			break
+		}
+		goName, _, _ := strings.Cut(goNames[i], "[")
+		field, ok := typ.FieldByName(goName)
+		if !ok {
+			path = append(path, names[i:]...)
+			break
+		}
+		if !field.Anonymous {
+			path = append(path, name)
+		}
+		typ = field.Type
+	}
+	return strings.Join(path, ".")
+}
+
+// siblingName returns the request name of a top level field of obj, which
+// rules such as required_without refer to by its Go name
+func siblingName(obj any, goName string) string {
+	typ := reflect.TypeOf(obj)
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Struct {
+		if field, ok := typ.FieldByName(goName); ok {
+			return requestFieldName(field)
+		}
+	}
+	return goName
+}
+
+// jsonTypeName names a Go type the way JSON clients know it
+func jsonTypeName(typ reflect.Type) string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "whole number"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	}
+	return "object"
+}
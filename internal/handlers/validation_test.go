@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidation_FieldErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		jsonInput      string
+		expectedFields []models.ProblemField
+	}{
+		{
+			name:      "Missing Class Fields",
+			method:    http.MethodPost,
+			path:      "/classes",
+			jsonInput: `{"name":"Yoga"}`,
+			expectedFields: []models.ProblemField{
+				{Field: "start_date", Rule: "required", Message: "is required"},
+				{Field: "end_date", Rule: "required", Message: "is required"},
+				{Field: "capacity", Rule: "required", Message: "is required"},
+			},
+		},
+		{
+			name:      "Class Dates",
+			method:    http.MethodPost,
+			path:      "/classes",
+			jsonInput: `{"name":"Yoga","start_date":"01/06/2025","end_date":"2025-06-20T25:00:00Z","capacity":10}`,
+			expectedFields: []models.ProblemField{
+				{Field: "start_date", Rule: "date", Message: "must be a date as YYYY-MM-DD or RFC 3339"},
+				{Field: "end_date", Rule: "date", Message: "must be a date as YYYY-MM-DD or RFC 3339"},
+			},
+		},
+		{
+			name:      "Class Name Charset",
+			method:    http.MethodPost,
+			path:      "/classes",
+			jsonInput: `{"name":"Yoga/Pilates","start_date":"2025-06-01","end_date":"2025-06-20","capacity":10}`,
+			expectedFields: []models.ProblemField{
+				{Field: "name", Rule: "name", Message: "must be 1 to 64 letters, digits, spaces or ' & . , ( ) + _ -, starting with a letter or digit"},
+			},
+		},
+		{
+			name:      "Class Name Length",
+			method:    http.MethodPost,
+			path:      "/classes",
+			jsonInput: `{"name":"` + strings.Repeat("a", constants.MaxNameLength+1) + `","start_date":"2025-06-01","end_date":"2025-06-20","capacity":10}`,
+			expectedFields: []models.ProblemField{
+				{Field: "name", Rule: "name", Message: "must be 1 to 64 letters, digits, spaces or ' & . , ( ) + _ -, starting with a letter or digit"},
+			},
+		},
+		{
+			name:      "Capacity Upper Bound",
+			method:    http.MethodPost,
+			path:      "/classes",
+			jsonInput: `{"name":"Yoga","start_date":"2025-06-01","end_date":"2025-06-20","capacity":501}`,
+			expectedFields: []models.ProblemField{
+				{Field: "capacity", Rule: "capacity", Message: "must be between 1 and 500"},
+			},
+		},
+		{
+			name:      "Capacity Type",
+			method:    http.MethodPost,
+			path:      "/classes",
+			jsonInput: `{"name":"Yoga","start_date":"2025-06-01","end_date":"2025-06-20","capacity":"ten"}`,
+			expectedFields: []models.ProblemField{
+				{Field: "capacity", Rule: "type", Message: "must be a whole number"},
+			},
+		},
+		{
+			name:      "Nested Fields",
+			method:    http.MethodPost,
+			path:      "/classes",
+			jsonInput: `{"name":"Yoga","start_date":"2025-06-01","end_date":"2025-06-20","capacity":10,"recurrence":{"frequency":"hourly"},"session_times":[{"start":"07:00"},{"duration_minutes":30}]}`,
+			expectedFields: []models.ProblemField{
+				{Field: "recurrence.frequency", Rule: "oneof", Allowed: []string{"daily", "weekly", "monthly"}, Message: "must be one of daily, weekly, monthly"},
+				{Field: "session_times[1].start", Rule: "required", Message: "is required"},
+			},
+		},
+		{
+			name:      "Class Update",
+			method:    http.MethodPatch,
+			path:      "/classes/Yoga",
+			jsonInput: `{"end_date":"June 20th","capacity":0}`,
+			expectedFields: []models.ProblemField{
+				{Field: "end_date", Rule: "date", Message: "must be a date as YYYY-MM-DD or RFC 3339"},
+				{Field: "capacity", Rule: "capacity", Message: "must be between 1 and 500"},
+			},
+		},
+		{
+			name:      "Booking",
+			method:    http.MethodPost,
+			path:      "/bookings",
+			jsonInput: `{"class_name":"Yoga?","date":"tomorrow"}`,
+			expectedFields: []models.ProblemField{
+				{Field: "class_name", Rule: "name", Message: "must be 1 to 64 letters, digits, spaces or ' & . , ( ) + _ -, starting with a letter or digit"},
+				{Field: "member_id", Rule: "required_without", Message: "is required when name is not set"},
+				{Field: "date", Rule: "date", Message: "must be a date as YYYY-MM-DD or RFC 3339"},
+			},
+		},
+		{
+			name:   "Query",
+			method: http.MethodGet,
+			path:   "/bookings?limit=500",
+			expectedFields: []models.ProblemField{
+				{Field: "limit", Rule: "lte", Message: "must be at most 100"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockClassService)
+			router := SetupRouter(NewClassHandler(mockService), RouterOptions{})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.jsonInput))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(constants.HeaderIfMatch, "*")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, constants.ContentTypeProblem, w.Header().Get("Content-Type"))
+			var problem models.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Contains(t, []string{"invalid_request", "invalid_query"}, problem.Code)
+			assert.Equal(t, tt.expectedFields, problem.Errors)
+			// The detail names every invalid field too
+			for _, field := range tt.expectedFields {
+				assert.Contains(t, problem.Detail, field.Field+" "+field.Message)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
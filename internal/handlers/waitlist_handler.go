@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/utils"
+	"net/http"
+)
+
+// JoinWaitlist handles POST /classes/:name/sessions/:date/waitlist
+func (h *ClassHandler) JoinWaitlist(ctx *gin.Context) {
+	var req models.WaitlistRequest
+	if !decodeJSON(ctx, &req) {
+		return
+	}
+	if req.MemberID == "" && req.MemberName == "" {
+		req.MemberID = callerMemberID(ctx)
+	}
+	if !validate(ctx, &req) {
+		return
+	}
+
+	deprecatedMemberName(ctx, req.MemberID)
+	className, date := ctx.Param("name"), ctx.Param("date")
+	position, err := h.serviceFor(ctx).JoinWaitlist(className, date, req)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("%s added to the waitlist for class %s on %s at position %d", position.MemberName, className, date, position.Position),
+		Data:    position,
+	})
+}
+
+// LeaveWaitlist handles DELETE /classes/:name/sessions/:date/waitlist/:member
+func (h *ClassHandler) LeaveWaitlist(ctx *gin.Context) {
+	className, date, member := ctx.Param("name"), ctx.Param("date"), ctx.Param("member")
+	if err := h.serviceFor(ctx).LeaveWaitlist(className, member, date); err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status:  constants.SuccessMsg,
+		Message: fmt.Sprintf("%s removed from the waitlist for class %s on %s", member, className, date),
+	})
+}
+
+// GetWaitlistPosition handles GET /classes/:name/sessions/:date/waitlist/:member
+func (h *ClassHandler) GetWaitlistPosition(ctx *gin.Context) {
+	className, date, member := ctx.Param("name"), ctx.Param("date"), ctx.Param("member")
+	position, err := h.serviceFor(ctx).WaitlistPosition(className, member, date)
+	if err != nil {
+		utils.WriteProblem(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Response{
+		Status: constants.SuccessMsg,
+		Data:   position,
+	})
+}
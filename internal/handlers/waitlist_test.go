@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"bytes"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// JoinWaitlist mocks the JoinWaitlist method
+func (m *MockClassService) JoinWaitlist(className, dateStr string, req models.WaitlistRequest) (models.WaitlistPosition, error) {
+	args := m.Called(className, dateStr, req)
+	position, _ := args.Get(0).(models.WaitlistPosition)
+	return position, args.Error(1)
+}
+
+// LeaveWaitlist mocks the LeaveWaitlist method
+func (m *MockClassService) LeaveWaitlist(className, member, dateStr string) error {
+	args := m.Called(className, member, dateStr)
+	return args.Error(0)
+}
+
+// WaitlistPosition mocks the WaitlistPosition method
+func (m *MockClassService) WaitlistPosition(className, member, dateStr string) (models.WaitlistPosition, error) {
+	args := m.Called(className, member, dateStr)
+	position, _ := args.Get(0).(models.WaitlistPosition)
+	return position, args.Error(1)
+}
+
+func TestClassHandler_Waitlist(t *testing.T) {
+	// Set Gin to test mode
+	gin.SetMode(gin.TestMode)
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		jsonInput      string
+		setupMock      func(*MockClassService)
+		expectedStatus int
+		expectedBody   models.Response
+		expectedCode   string
+	}{
+		{
+			name:      "Join Happy Path",
+			method:    http.MethodPost,
+			path:      "/classes/Yoga/sessions/2025-06-10/waitlist",
+			jsonInput: `{"member_id":"mb_1"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("JoinWaitlist", "Yoga", "2025-06-10", models.WaitlistRequest{MemberID: "mb_1"}).Return(models.WaitlistPosition{MemberID: "mb_1", MemberName: "Alice", Position: 3}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Alice added to the waitlist for class Yoga on 2025-06-10 at position 3",
+			},
+		},
+		{
+			name:      "Join With Seats Available",
+			method:    http.MethodPost,
+			path:      "/classes/Yoga/sessions/2025-06-10/waitlist",
+			jsonInput: `{"name":"Alice"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("JoinWaitlist", "Yoga", "2025-06-10", models.WaitlistRequest{MemberName: "Alice"}).Return(models.WaitlistPosition{}, constants.ErrSeatsAvailable)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "seats_available",
+			expectedBody:   models.Response{Message: constants.ErrSeatsAvailable.Error()},
+		},
+		{
+			name:           "Join Missing Name",
+			method:         http.MethodPost,
+			path:           "/classes/Yoga/sessions/2025-06-10/waitlist",
+			jsonInput:      `{}`,
+			setupMock:      func(m *MockClassService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+			expectedBody:   models.Response{Message: constants.ErrInvalidReq.Error() + ": member_id is required when name is not set"},
+		},
+		{
+			name:   "Position Happy Path",
+			method: http.MethodGet,
+			path:   "/classes/Yoga/sessions/2025-06-10/waitlist/Alice",
+			setupMock: func(m *MockClassService) {
+				m.On("WaitlistPosition", "Yoga", "Alice", "2025-06-10").Return(models.WaitlistPosition{MemberID: "mb_1", MemberName: "Alice", Position: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: models.Response{
+				Status: constants.SuccessMsg,
+			},
+		},
+		{
+			name:   "Position Not On Waitlist",
+			method: http.MethodGet,
+			path:   "/classes/Yoga/sessions/2025-06-10/waitlist/Alice",
+			setupMock: func(m *MockClassService) {
+				m.On("WaitlistPosition", "Yoga", "Alice", "2025-06-10").Return(models.WaitlistPosition{}, constants.ErrNotOnWaitlist)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "not_on_waitlist",
+			expectedBody:   models.Response{Message: constants.ErrNotOnWaitlist.Error()},
+		},
+		{
+			name:      "Join Suspended Member",
+			method:    http.MethodPost,
+			path:      "/classes/Yoga/sessions/2025-06-10/waitlist",
+			jsonInput: `{"member_id":"mb_1"}`,
+			setupMock: func(m *MockClassService) {
+				m.On("JoinWaitlist", "Yoga", "2025-06-10", models.WaitlistRequest{MemberID: "mb_1"}).Return(models.WaitlistPosition{}, constants.ErrMemberSuspended)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "member_suspended",
+			expectedBody:   models.Response{Message: constants.ErrMemberSuspended.Error()},
+		},
+		{
+			name:   "Leave Happy Path",
+			method: http.MethodDelete,
+			path:   "/classes/Yoga/sessions/2025-06-10/waitlist/Alice",
+			setupMock: func(m *MockClassService) {
+				m.On("LeaveWaitlist", "Yoga", "Alice", "2025-06-10").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: models.Response{
+				Status:  constants.SuccessMsg,
+				Message: "Alice removed from the waitlist for class Yoga on 2025-06-10",
+			},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup mock service and router
+			mockService := new(MockClassService)
+			tt.setupMock(mockService)
+			router := SetupRouter(NewClassHandler(mockService), RouterOptions{})
+
+			// Create HTTP request
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.jsonInput))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			// Assert status code
+			assert.Equal(t, tt.expectedStatus, w.Code, "Expected status %d, got %d", tt.expectedStatus, w.Code)
+
+			// Assert response body
+			assertBody(t, w, tt.expectedCode, tt.expectedBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -6,35 +6,703 @@ import (
 
 // Class represents a class with its details
 type Class struct {
-	Name      string
-	StartDate time.Time
-	EndDate   time.Time
-	Capacity  int
+	Name               string             `json:"name"`
+	StartDate          time.Time          `json:"start_date"`
+	EndDate            time.Time          `json:"end_date"`
+	Capacity           int                `json:"capacity"`
+	CancellationPolicy CancellationPolicy `json:"cancellation_policy"`
+	Recurrence         Recurrence         `json:"recurrence"`
+	// TimeZone is the IANA zone the dates and session times of the class are
+	// in, classes created before time zones existed are in UTC
+	TimeZone string `json:"time_zone,omitempty"`
+	// SessionTimes are the sessions the class runs on each of its dates,
+	// classes without any run one session starting at midnight
+	SessionTimes []SessionTime `json:"session_times,omitempty"`
+	Description  string        `json:"description,omitempty"`
+	// InstructorID is the user ID of the instructor teaching the class, who
+	// may view its rosters
+	InstructorID string `json:"instructor_id,omitempty"`
+	// Substitutions assign other instructors to single sessions, sorted by session
+	Substitutions []Substitution `json:"substitutions,omitempty"`
+	// RoomID is the room the class runs in, its capacity caps the class
+	RoomID string `json:"room_id,omitempty"`
+	// Resource is the equipment of the room each attendee uses, its quantity
+	// caps the class
+	Resource string `json:"resource,omitempty"`
+	// DropIn is the price members without a membership covering the class
+	// pay to book a session, nil when they cannot
+	DropIn *Price `json:"drop_in,omitempty"`
+	// Version is incremented by every change and returned as the ETag
+	Version int `json:"version"`
 }
 
-// Booking represents a booking for a class on a specific date
+// Price is an amount of money in the minor unit of its currency, like cents
+type Price struct {
+	Amount int64 `json:"amount"`
+	// Currency is an ISO 4217 code in upper case
+	Currency string `json:"currency"`
+}
+
+// Substitution assigns another instructor to one session of a class
+type Substitution struct {
+	// Session is the instant the session starts at
+	Session      time.Time `json:"session"`
+	InstructorID string    `json:"instructor_id"`
+}
+
+// SessionTime is one session a class runs on each of its dates
+type SessionTime struct {
+	// Start is the time of day the session starts at, as HH:MM
+	Start           string `json:"start"`
+	DurationMinutes int    `json:"duration_minutes"`
+}
+
+// Recurrence decides on which dates between StartDate and EndDate a class
+// runs. The zero value runs every day.
+type Recurrence struct {
+	// Frequency is daily, weekly or monthly
+	Frequency string `json:"frequency"`
+	// Interval runs the class every Interval days, weeks or months
+	Interval int `json:"interval"`
+	// Weekdays are the RRULE day codes (MO, TU, ...) a weekly class runs on,
+	// when empty it runs on the weekday of StartDate
+	Weekdays []string `json:"weekdays,omitempty"`
+	// Exclusions are dates on which the class does not run, such as public holidays
+	Exclusions []time.Time `json:"exclusions,omitempty"`
+}
+
+// Session represents a single session of a class with its seat usage
+type Session struct {
+	// Date is the instant the session starts at
+	Date time.Time `json:"date"`
+	// End is unset for sessions without a start time
+	End       *time.Time `json:"end,omitempty"`
+	Capacity  int        `json:"capacity"`
+	Booked    int        `json:"booked"`
+	Remaining int        `json:"remaining"`
+	// Spots is the seat layout of the room of the class, if it has one
+	Spots []SessionSpot `json:"spots,omitempty"`
+}
+
+// CancellationPolicy decides how late a booking for a class may be cancelled.
+// Cancelling more than FreeCancelHours before the class starts is free, after
+// that it is a late cancellation (if allowed), and after the start it is refused.
+type CancellationPolicy struct {
+	FreeCancelHours int  `json:"free_cancel_hours"`
+	AllowLateCancel bool `json:"allow_late_cancel"`
+}
+
+// Member represents a member of the studio
+type Member struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Email  string `json:"email,omitempty"`
+	Phone  string `json:"phone,omitempty"`
+	Status string `json:"status"`
+	// Memberships are the plans the member bought, including expired ones
+	Memberships []Membership `json:"memberships,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	// Version is incremented by every change
+	Version int `json:"version"`
+}
+
+// Plan is a membership plan sold by the studio
+type Plan struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Kind is constants.PlanUnlimited, PlanWeekly, PlanMonthly or PlanPack
+	Kind string `json:"kind"`
+	// Classes is the number of classes per week or month, or in a pack
+	Classes int `json:"classes,omitempty"`
+	// ValidDays is how long a membership of the plan lasts, 0 for plans
+	// that do not expire. Packs always expire.
+	ValidDays int       `json:"valid_days,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Membership is a plan bought by a member. The terms of the plan are copied,
+// so changing the plan does not change the memberships sold before.
+type Membership struct {
+	ID       string    `json:"id"`
+	PlanID   string    `json:"plan_id"`
+	PlanName string    `json:"plan_name"`
+	Kind     string    `json:"kind"`
+	Classes  int       `json:"classes,omitempty"`
+	StartsAt time.Time `json:"starts_at"`
+	// ExpiresAt is unset for memberships that do not expire
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// CreditEntry is an entry of the credit ledger of a member. Packs are granted
+// their classes as credits, and every booking charged to a weekly, monthly or
+// pack membership debits one credit, refunded when it is cancelled in time.
+type CreditEntry struct {
+	ID           string `json:"id"`
+	MemberID     string `json:"member_id"`
+	MembershipID string `json:"membership_id"`
+	// Amount is positive for grants and refunds, negative for debits
+	Amount int `json:"amount"`
+	// Reason is constants.CreditGrant, CreditBooking or CreditRefund
+	Reason    string `json:"reason"`
+	BookingID string `json:"booking_id,omitempty"`
+	// Session is the start of the booked session of debits and refunds
+	Session   *time.Time `json:"session,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// PromoCode is a code members send with a booking for a discount
+type PromoCode struct {
+	// Code is stored in upper case, members may send it in any case
+	Code string `json:"code"`
+	// Kind is constants.PromoPercentage, PromoFixed or PromoFirstClass
+	Kind string `json:"kind"`
+	// Percent is the discount of percentage codes
+	Percent int `json:"percent,omitempty"`
+	// Amount is the discount of fixed codes, in minor units of Currency
+	Amount   int64  `json:"amount,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	// MaxRedemptions caps the bookings redeeming the code that are not
+	// cancelled, 0 means no cap
+	MaxRedemptions int `json:"max_redemptions,omitempty"`
+	// ClassNames are the classes the code applies to, every class when empty
+	ClassNames []string `json:"class_names,omitempty"`
+	// ValidFrom and ValidUntil are the first and last calendar day of the
+	// sessions the code applies to, unset for no bound
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Promo is the promo code a booking redeemed and the discount it got off the
+// drop-in price of the class
+type Promo struct {
+	Code     string `json:"code"`
+	Discount int64  `json:"discount"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// PromoReport lists the bookings that redeemed a promo code. Redemptions
+// counts the bookings that are not cancelled, Discounts sums their discount
+// per currency.
+type PromoReport struct {
+	PromoCode   PromoCode        `json:"promo_code"`
+	Redemptions int              `json:"redemptions"`
+	Remaining   *int             `json:"remaining,omitempty"`
+	Discounts   map[string]int64 `json:"discounts"`
+	Bookings    []Booking        `json:"bookings"`
+}
+
+// Credits are the classes a member may still book with each of their
+// memberships, and their credit ledger
+type Credits struct {
+	MemberID string          `json:"member_id"`
+	Balances []CreditBalance `json:"balances"`
+	Entries  []CreditEntry   `json:"entries"`
+}
+
+// CreditBalance is what is left of a membership that is active now. Remaining
+// counts the classes left this week or month for weekly and monthly plans.
+type CreditBalance struct {
+	Membership
+	Unlimited bool `json:"unlimited,omitempty"`
+	Remaining int  `json:"remaining"`
+}
+
+// Instructor represents an instructor of the studio, tokens issued to them
+// carry their ID as the subject
+type Instructor struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Room represents a room of the studio and the equipment kept in it
+type Room struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Capacity  int        `json:"capacity"`
+	Resources []Resource `json:"resources,omitempty"`
+	// Spots is the seat layout of the room, members of its classes book one spot each
+	Spots     []Spot    `json:"spots,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Resource is equipment of a room, like bikes or reformers
+type Resource struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+}
+
+// Spot is a place in the seat layout of a room, like bike 12
+type Spot struct {
+	Label  string `json:"label"`
+	Row    int    `json:"row"`
+	Column int    `json:"column"`
+}
+
+// SessionSpot is a spot of a session and whether it is booked
+type SessionSpot struct {
+	Spot
+	Taken bool `json:"taken"`
+}
+
+// Booking represents a booking for a session of a class
 type Booking struct {
-	MemberName string    `json:"name"`
-	Date       time.Time `json:"date"`
+	ID        string `json:"id"`
+	ClassName string `json:"class_name"`
+	// MemberID is empty for bookings made before members were registered
+	MemberID   string `json:"member_id,omitempty"`
+	MemberName string `json:"name"`
+	// Date is the instant the booked session starts at
+	Date        time.Time  `json:"date"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LateCancel  bool       `json:"late_cancel,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	// CancelReason is set when the studio cancelled the booking
+	CancelReason string `json:"cancel_reason,omitempty"`
+	// Spot is the label of the booked spot in classes held in a room with a seat layout
+	Spot string `json:"spot,omitempty"`
+	// MembershipID is the membership the booking was charged a credit of,
+	// empty for bookings covered by an unlimited plan
+	MembershipID string `json:"membership_id,omitempty"`
+	// Payment is the payment of the drop-in price of the class, the booking
+	// is pending until it is authorized
+	Payment *Payment `json:"payment,omitempty"`
+	// Promo is the promo code the booking redeemed
+	Promo *Promo `json:"promo,omitempty"`
+	// Version is incremented by every change and returned as the ETag
+	Version int `json:"version"`
+}
+
+// Payment is the payment of a drop-in booking at the payment provider
+type Payment struct {
+	// ID identifies the payment at the provider, which authorizes it at
+	// most once however often it is retried
+	ID string `json:"id"`
+	// AuthorizationID is the reference of the authorization at the provider,
+	// empty until the provider confirmed it
+	AuthorizationID string `json:"authorization_id,omitempty"`
+	Amount          int64  `json:"amount"`
+	Currency        string `json:"currency"`
+	// Method is the token of the payment method of the member, issued by the provider
+	Method string `json:"method"`
+	// Status is constants.PaymentPending, PaymentAuthorized, PaymentCaptured,
+	// PaymentDeclined, PaymentRefundPending or PaymentRefunded
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PaymentEvent is a webhook event of the payment provider about a payment
+type PaymentEvent struct {
+	ID string `json:"id"`
+	// Type is constants.PaymentEventAuthorized, PaymentEventDeclined,
+	// PaymentEventCaptured or PaymentEventRefunded
+	Type            string `json:"type"`
+	PaymentID       string `json:"payment_id"`
+	AuthorizationID string `json:"authorization_id,omitempty"`
+	// StudioID and BookingID are the metadata the payment was authorized with
+	StudioID  string    `json:"studio_id"`
+	BookingID string    `json:"booking_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// Subject is the member ID of members, the user ID of staff and the name
+	// of API keys
+	Subject string `json:"sub"`
+	// Role is constants.RoleMember or constants.RoleStaff
+	Role     string `json:"role"`
+	StudioID string `json:"studio_id"`
 }
 
 // ClassRequest represents the JSON request for /classes
 type ClassRequest struct {
+	Name      string `json:"name" binding:"required,name"`
+	StartDate string `json:"start_date" binding:"required,date"`
+	EndDate   string `json:"end_date" binding:"required,date"`
+	Capacity  int    `json:"capacity" binding:"required,capacity"`
+	// TimeZone is optional and defaults to the time zone of the studio
+	TimeZone string `json:"time_zone"`
+	// CancellationPolicy is optional, unset fields fall back to the defaults
+	CancellationPolicy *CancellationPolicyRequest `json:"cancellation_policy"`
+	// Recurrence is optional, classes without one run every day
+	Recurrence *RecurrenceRequest `json:"recurrence"`
+	// SessionTimes is optional, see Class.SessionTimes
+	SessionTimes []SessionTimeRequest `json:"session_times" binding:"omitempty,dive"`
+	Description  string               `json:"description"`
+	InstructorID string               `json:"instructor_id"`
+	// Substitutions is optional, see Class.Substitutions
+	Substitutions []SubstitutionRequest `json:"substitutions"`
+	// RoomID and Resource are optional, see Class.RoomID and Class.Resource
+	RoomID   string `json:"room_id"`
+	Resource string `json:"resource"`
+	// DropIn is optional, see Class.DropIn
+	DropIn *PriceRequest `json:"drop_in"`
+}
+
+// PriceRequest represents a price in a ClassRequest, an amount of 0 means no price
+type PriceRequest struct {
+	Amount   int64  `json:"amount" binding:"gte=0"`
+	Currency string `json:"currency"`
+}
+
+// ClassUpdateRequest represents the JSON request for PATCH /classes/:name,
+// fields that are not set are kept
+type ClassUpdateRequest struct {
+	StartDate   *string `json:"start_date" binding:"omitempty,date"`
+	EndDate     *string `json:"end_date" binding:"omitempty,date"`
+	Capacity    *int    `json:"capacity" binding:"omitempty,capacity"`
+	Description *string `json:"description"`
+	// InstructorID reassigns the class, an empty ID unassigns it
+	InstructorID *string `json:"instructor_id"`
+	// Substitutions replaces the substitutions of the class
+	Substitutions *[]SubstitutionRequest `json:"substitutions"`
+	// RoomID moves the class to another room, an empty ID takes it out of its room
+	RoomID *string `json:"room_id"`
+	// Resource changes the equipment attendees use, an empty name uses none
+	Resource *string `json:"resource"`
+	// DropIn changes the drop-in price, an amount of 0 stops drop-ins
+	DropIn *PriceRequest `json:"drop_in"`
+}
+
+// ClassChangeRequest represents the query parameters of PATCH and DELETE
+// /classes/:name that decide what happens to affected bookings
+type ClassChangeRequest struct {
+	// Force cancels the bookings of sessions the change removes
+	Force  bool   `form:"force"`
+	Reason string `form:"reason"`
+	// Overflow is reject or waitlist, see constants.OverflowReject
+	Overflow string `form:"overflow" binding:"omitempty,oneof=reject waitlist"`
+}
+
+// ClassChangeResult reports the class after a change and the bookings it affected
+type ClassChangeResult struct {
+	Class *Class `json:"class,omitempty"`
+	// Cancelled lists the bookings cancelled by the change
+	Cancelled []Booking `json:"cancelled"`
+	// Waitlisted lists the bookings moved to the waitlist because the capacity was reduced
+	Waitlisted []Booking `json:"waitlisted"`
+}
+
+// SessionTimeRequest represents a session time in a ClassRequest
+type SessionTimeRequest struct {
+	Start string `json:"start" binding:"required"`
+	// DurationMinutes defaults to constants.DefaultSessionMinutes
+	DurationMinutes int `json:"duration_minutes" binding:"omitempty,gte=1"`
+}
+
+// SubstitutionRequest represents a substitution in a ClassRequest
+type SubstitutionRequest struct {
+	// Session is the date of the session, with its start time when the class
+	// runs several sessions a day
+	Session      string `json:"session"`
+	InstructorID string `json:"instructor_id"`
+}
+
+// CancellationPolicyRequest represents the cancellation policy in a ClassRequest
+type CancellationPolicyRequest struct {
+	FreeCancelHours *int  `json:"free_cancel_hours" binding:"omitempty,gte=0"`
+	AllowLateCancel *bool `json:"allow_late_cancel"`
+}
+
+// RecurrenceRequest represents the recurrence in a ClassRequest, either as an
+// RRULE (FREQ, INTERVAL and BYDAY are supported) or as separate fields
+type RecurrenceRequest struct {
+	RRule      string   `json:"rrule"`
+	Frequency  string   `json:"frequency" binding:"omitempty,oneof=daily weekly monthly"`
+	Interval   int      `json:"interval" binding:"omitempty,gte=1"`
+	Weekdays   []string `json:"weekdays"`
+	Exclusions []string `json:"exclusions"`
+}
+
+// InstructorRequest represents the JSON request for /instructors
+type InstructorRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+// ScheduleRequest represents the query parameters of GET
+// /instructors/:id/schedule and GET /rooms/:id/utilization
+type ScheduleRequest struct {
+	// From is the first date of the week, today in the time zone of the studio by default
+	From string `form:"from" binding:"omitempty,date"`
+}
+
+// Schedule lists the sessions an instructor teaches in a week
+type Schedule struct {
+	InstructorID string             `json:"instructor_id"`
+	From         time.Time          `json:"from"`
+	To           time.Time          `json:"to"`
+	Sessions     []ScheduledSession `json:"sessions"`
+}
+
+// ScheduledSession is a session in a Schedule
+type ScheduledSession struct {
+	ClassName string     `json:"class_name"`
+	Date      time.Time  `json:"date"`
+	End       *time.Time `json:"end,omitempty"`
+	// Substitute is set when the instructor stands in for the instructor of the class
+	Substitute bool `json:"substitute,omitempty"`
+}
+
+// RoomRequest represents the JSON request for /rooms
+type RoomRequest struct {
+	Name      string            `json:"name" binding:"required"`
+	Capacity  int               `json:"capacity" binding:"required,gte=1"`
+	Resources []ResourceRequest `json:"resources" binding:"omitempty,dive"`
+	Spots     []SpotRequest     `json:"spots" binding:"omitempty,dive"`
+}
+
+// PlanRequest represents the JSON request for /plans
+type PlanRequest struct {
 	Name      string `json:"name" binding:"required"`
-	StartDate string `json:"start_date" binding:"required"`
-	EndDate   string `json:"end_date" binding:"required"`
-	Capacity  int    `json:"capacity" binding:"required,gt=0"`
+	Kind      string `json:"kind" binding:"required,oneof=unlimited weekly monthly pack"`
+	Classes   int    `json:"classes" binding:"gte=0"`
+	ValidDays int    `json:"valid_days" binding:"gte=0"`
+}
+
+// PromoCodeRequest represents the JSON request for /promo-codes
+type PromoCodeRequest struct {
+	Code           string   `json:"code" binding:"required"`
+	Kind           string   `json:"kind" binding:"required,oneof=percentage fixed first_class"`
+	Percent        int      `json:"percent" binding:"gte=0,lte=100"`
+	Amount         int64    `json:"amount" binding:"gte=0"`
+	Currency       string   `json:"currency"`
+	MaxRedemptions int      `json:"max_redemptions" binding:"gte=0"`
+	ClassNames     []string `json:"class_names"`
+	ValidFrom      string   `json:"valid_from" binding:"omitempty,date"`
+	ValidUntil     string   `json:"valid_until" binding:"omitempty,date"`
+}
+
+// MembershipRequest represents the JSON request for POST /members/:id/memberships
+type MembershipRequest struct {
+	PlanID string `json:"plan_id" binding:"required"`
+	// StartsAt is a date or instant, now when empty
+	StartsAt string `json:"starts_at" binding:"omitempty,date"`
+}
+
+// SpotRequest represents a spot of the seat layout in a RoomRequest
+type SpotRequest struct {
+	Label  string `json:"label" binding:"required"`
+	Row    int    `json:"row" binding:"required,gte=1"`
+	Column int    `json:"column" binding:"required,gte=1"`
+}
+
+// ResourceRequest represents the equipment in a RoomRequest
+type ResourceRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,gte=1"`
+}
+
+// RoomUtilization reports how a room is used in a week
+type RoomUtilization struct {
+	RoomID string    `json:"room_id"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	// ScheduledMinutes is the time classes hold the room
+	ScheduledMinutes int `json:"scheduled_minutes"`
+	// Seats is the capacity of the room summed over its sessions, Booked
+	// the seats booked in them
+	Seats  int `json:"seats"`
+	Booked int `json:"booked"`
+	// Occupancy is Booked divided by Seats, 0 without sessions
+	Occupancy float64       `json:"occupancy"`
+	Sessions  []RoomSession `json:"sessions"`
+}
+
+// RoomSession is a session in a RoomUtilization
+type RoomSession struct {
+	ClassName string     `json:"class_name"`
+	Date      time.Time  `json:"date"`
+	End       *time.Time `json:"end,omitempty"`
+	Capacity  int        `json:"capacity"`
+	Booked    int        `json:"booked"`
+}
+
+// MemberRequest represents the JSON request for /members
+type MemberRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"omitempty,email"`
+	Phone string `json:"phone"`
+	// Status defaults to active for new members and is kept on update when empty
+	Status string `json:"status" binding:"omitempty,oneof=active suspended"`
 }
 
 // BookingRequest represents the JSON request for /bookings
 type BookingRequest struct {
-	ClassName  string `json:"class_name" binding:"required"`
-	MemberName string `json:"name" binding:"required"`
-	Date       string `json:"date" binding:"required"`
+	ClassName string `json:"class_name" binding:"required,name"`
+	MemberID  string `json:"member_id" binding:"required_without=MemberName"`
+	// Deprecated: MemberName looks the member up by name, use MemberID
+	MemberName string `json:"name"`
+	Date       string `json:"date" binding:"required,date"`
+	// JoinWaitlist puts the member on the waitlist when the class is full
+	JoinWaitlist bool `json:"join_waitlist"`
+	// Spot picks a spot of the seat layout, a free one is assigned when empty
+	Spot string `json:"spot"`
+	// PaymentMethod is a payment method token of the provider, members
+	// without a membership covering the class pay its drop-in price with it
+	PaymentMethod string `json:"payment_method"`
+	// PromoCode is redeemed for a discount on the drop-in price, or a free
+	// first class
+	PromoCode string `json:"promo_code"`
+}
+
+// BookingResult tells the member whether they got a seat or a waitlist position
+type BookingResult struct {
+	Status   string   `json:"status"`
+	Position int      `json:"position,omitempty"`
+	Booking  *Booking `json:"booking,omitempty"`
+}
+
+// WaitlistRequest represents the JSON request for joining a waitlist
+type WaitlistRequest struct {
+	MemberID string `json:"member_id" binding:"required_without=MemberName"`
+	// Deprecated: MemberName looks the member up by name, use MemberID
+	MemberName string `json:"name"`
+}
+
+// WaitlistPosition represents a member's place on a waitlist
+type WaitlistPosition struct {
+	MemberID   string `json:"member_id"`
+	MemberName string `json:"name"`
+	Position   int    `json:"position"`
+}
+
+// ListRequest represents the pagination query parameters of list endpoints
+type ListRequest struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,gte=1,lte=100"`
+}
+
+// BookingListRequest represents the query parameters for GET /bookings
+type BookingListRequest struct {
+	ListRequest
+	MemberID   string `form:"member_id"`
+	MemberName string `form:"member"`
+	ClassName  string `form:"class"`
+	From       string `form:"from"`
+	To         string `form:"to"`
+}
+
+// BookingFilter selects bookings from the repository, zero values match everything
+type BookingFilter struct {
+	MemberID   string
+	MemberName string
+	ClassName  string
+	From       time.Time
+	To         time.Time
+	// PaymentStatuses keeps the bookings with a payment in one of the statuses
+	PaymentStatuses []string
+	// PromoCode keeps the bookings that redeemed the code
+	PromoCode string
+	// After resumes the listing after the given position
+	After *BookingCursor
+	Limit int
+}
+
+// BookingLimits are enforced by the booking repository together with the insert
+type BookingLimits struct {
+	// Capacity is the number of seats of the session
+	Capacity int
+	// PerDay caps the active bookings a member holds in sessions starting
+	// between DayStart and DayEnd, 0 means no cap
+	PerDay   int
+	DayStart time.Time
+	DayEnd   time.Time
+	// Spots are the labels of the seat layout of the session in order, the
+	// first free one is assigned to bookings without a spot. Empty when the
+	// session has no layout.
+	Spots []string
+	// Charge debits a credit of a membership together with the insert, nil
+	// for bookings covered by an unlimited plan
+	Charge *Charge
+	// Promo is the promo code the booking redeems, checked together with the insert
+	Promo *PromoLimit
+}
+
+// PromoLimit is a promo code redeemed by a booking. A member redeems a code
+// once among their bookings that are not cancelled.
+type PromoLimit struct {
+	Code string
+	// MaxRedemptions caps the bookings redeeming the code that are not
+	// cancelled, 0 means no cap
+	MaxRedemptions int
+	// FirstClass requires the member to hold no other booking that is not cancelled
+	FirstClass bool
+}
+
+// Charge is the membership a booking debits a credit of. The credits of a
+// membership are the sum of its ledger entries, plus Allowance for weekly and
+// monthly plans, whose entries are only summed for sessions between From and To.
+type Charge struct {
+	MembershipID string
+	Allowance    int
+	From         time.Time
+	To           time.Time
+}
+
+// BookingCursor is the position of a booking in the (date, class name, ID) sort order
+type BookingCursor struct {
+	Date      time.Time `json:"date"`
+	ClassName string    `json:"class_name"`
+	ID        string    `json:"id"`
+}
+
+// Page is one page of a cursor paginated listing
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // Response represents the JSON response
 type Response struct {
-	Status  string `json:"status"`
-	Message string `json:"message,omitempty"`
+	Status  string      `json:"status"`
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Problem is an RFC 7807 error response. Code is stable and meant for
+// clients to match on, Detail is for humans.
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Code     string         `json:"code"`
+	Errors   []ProblemField `json:"errors,omitempty"`
+}
+
+// ProblemField is a request field that caused a problem
+type ProblemField struct {
+	// Field is the JSON path of the field, such as session_times[0].start
+	Field string `json:"field"`
+	// Rule is the validation rule the value broke, such as required or oneof
+	Rule string `json:"rule,omitempty"`
+	// Allowed lists the accepted values of oneof rules
+	Allowed []string `json:"allowed,omitempty"`
+	Message string   `json:"message"`
+}
+
+// IdempotencyRecord is the stored outcome of a request sent with an Idempotency-Key
+type IdempotencyRecord struct {
+	Key string `json:"key"`
+	// Fingerprint identifies the method, path and body of the request
+	Fingerprint string `json:"fingerprint"`
+	// StatusCode is 0 while the first request with the key is being handled
+	StatusCode  int       `json:"status_code"`
+	ContentType string    `json:"content_type,omitempty"`
+	Body        []byte    `json:"body,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	// ExpiresAt is when the key can be reused, a pending request holds it for a short lease only
+	ExpiresAt time.Time `json:"expires_at"`
 }
@@ -0,0 +1,187 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/utils"
+	"sync"
+	"time"
+)
+
+// Payment method tokens the fake provider treats specially, every other
+// token is authorized
+const (
+	// MethodDeclined is always declined
+	MethodDeclined = "pm_declined"
+	// MethodTimeout is authorized, but the first call authorizing it times
+	// out as if the response was lost
+	MethodTimeout = "pm_timeout"
+)
+
+// ErrUnavailable is returned by the fake provider while it is unavailable
+var ErrUnavailable = errors.New("payment provider is unavailable")
+
+// FakePayment is a payment held by the fake provider
+type FakePayment struct {
+	AuthorizeRequest
+	AuthorizationID string
+	// Status is constants.PaymentAuthorized, PaymentCaptured, PaymentDeclined or PaymentRefunded
+	Status string
+	// Attempts counts the calls authorizing the payment
+	Attempts int
+}
+
+// Fake is an in-process PaymentProvider for tests and local development
+type Fake struct {
+	secret []byte
+	// Key: payment ID
+	payments map[string]*FakePayment
+	// Key: authorization ID, Value: payment ID
+	authorizations map[string]string
+	unavailable    bool
+	mu             sync.Mutex
+}
+
+// NewFake creates a fake provider signing its webhook events with secret
+func NewFake(secret string) *Fake {
+	return &Fake{
+		secret:         []byte(secret),
+		payments:       make(map[string]*FakePayment),
+		authorizations: make(map[string]string),
+	}
+}
+
+// Authorize authorizes a payment once, retries return the first outcome
+func (fake *Fake) Authorize(ctx context.Context, req AuthorizeRequest) (string, error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	if err := fake.available(ctx); err != nil {
+		return "", err
+	}
+	payment, exists := fake.payments[req.PaymentID]
+	if !exists {
+		payment = &FakePayment{AuthorizeRequest: req, Status: constants.PaymentDeclined}
+		if req.Method != MethodDeclined {
+			payment.AuthorizationID = utils.NewID("au_")
+			payment.Status = constants.PaymentAuthorized
+			fake.authorizations[payment.AuthorizationID] = req.PaymentID
+		}
+		fake.payments[req.PaymentID] = payment
+	}
+	payment.Attempts++
+	if payment.Method == MethodTimeout && payment.Attempts == 1 {
+		return "", fmt.Errorf("authorize %s: %w", req.PaymentID, context.DeadlineExceeded)
+	}
+	if payment.Status == constants.PaymentDeclined {
+		return "", constants.ErrPaymentDeclined
+	}
+	return payment.AuthorizationID, nil
+}
+
+// Capture takes the amount of an authorization, capturing it again does nothing
+func (fake *Fake) Capture(ctx context.Context, authorizationID string) error {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	payment, err := fake.authorization(ctx, authorizationID)
+	if err != nil {
+		return err
+	}
+	switch payment.Status {
+	case constants.PaymentAuthorized:
+		payment.Status = constants.PaymentCaptured
+	case constants.PaymentRefunded:
+		return fmt.Errorf("authorization %s was refunded", authorizationID)
+	}
+	return nil
+}
+
+// Refund returns the amount of an authorization, refunding it again does nothing
+func (fake *Fake) Refund(ctx context.Context, authorizationID string) error {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	payment, err := fake.authorization(ctx, authorizationID)
+	if err != nil {
+		return err
+	}
+	payment.Status = constants.PaymentRefunded
+	return nil
+}
+
+// VerifyWebhook checks the signature of a webhook and returns its event
+func (fake *Fake) VerifyWebhook(payload []byte, signature string) (models.PaymentEvent, error) {
+	return verifyWebhook(fake.secret, payload, signature)
+}
+
+// Payment returns a payment held by the fake
+func (fake *Fake) Payment(paymentID string) (FakePayment, bool) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	payment, exists := fake.payments[paymentID]
+	if !exists {
+		return FakePayment{}, false
+	}
+	return *payment, true
+}
+
+// SetUnavailable makes every call fail with ErrUnavailable without effect
+// until it is called with false
+func (fake *Fake) SetUnavailable(unavailable bool) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	fake.unavailable = unavailable
+}
+
+// Webhook returns the signed webhook the provider sends about a payment
+func (fake *Fake) Webhook(paymentID, eventType string) (payload []byte, signature string, err error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	payment, exists := fake.payments[paymentID]
+	if !exists {
+		return nil, "", fmt.Errorf("unknown payment %s", paymentID)
+	}
+	payload, err = json.Marshal(models.PaymentEvent{
+		ID:              utils.NewID("ev_"),
+		Type:            eventType,
+		PaymentID:       paymentID,
+		AuthorizationID: payment.AuthorizationID,
+		StudioID:        payment.StudioID,
+		BookingID:       payment.BookingID,
+		CreatedAt:       time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, Sign(fake.secret, payload), nil
+}
+
+// available fails calls while the fake is unavailable or the caller gave
+// up, the caller must hold the lock
+func (fake *Fake) available(ctx context.Context) error {
+	if fake.unavailable {
+		return ErrUnavailable
+	}
+	return ctx.Err()
+}
+
+// authorization returns the payment of an authorization, the caller must
+// hold the lock
+func (fake *Fake) authorization(ctx context.Context, authorizationID string) (*FakePayment, error) {
+	if err := fake.available(ctx); err != nil {
+		return nil, err
+	}
+	paymentID, exists := fake.authorizations[authorizationID]
+	if !exists {
+		return nil, fmt.Errorf("unknown authorization %s", authorizationID)
+	}
+	return fake.payments[paymentID], nil
+}
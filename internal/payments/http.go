@@ -0,0 +1,110 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"glofox/internal/config"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Paths of the provider API called by HTTPProvider and served by the stub server
+const (
+	authorizationsPath = "/v1/authorizations"
+	capturePath        = "/capture"
+	refundPath         = "/refund"
+)
+
+// HTTPProvider calls the API of a payment provider over HTTP
+type HTTPProvider struct {
+	baseURL string
+	apiKey  string
+	secret  []byte
+	client  *http.Client
+}
+
+// NewHTTPProvider creates a provider calling the API at cfg.URL, giving up
+// on calls after cfg.Timeout
+func NewHTTPProvider(cfg config.PaymentsConfig) *HTTPProvider {
+	return &HTTPProvider{
+		baseURL: strings.TrimRight(cfg.URL, "/"),
+		apiKey:  cfg.APIKey,
+		secret:  []byte(cfg.WebhookSecret),
+		client:  &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// authorization is the response of the provider to an authorization
+type authorization struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// Authorize authorizes a payment, the payment ID is its idempotency key
+func (p *HTTPProvider) Authorize(ctx context.Context, req AuthorizeRequest) (string, error) {
+	var auth authorization
+	if err := p.post(ctx, authorizationsPath, req.PaymentID, req, &auth); err != nil {
+		return "", err
+	}
+	return auth.ID, nil
+}
+
+// Capture takes the amount of an authorization
+func (p *HTTPProvider) Capture(ctx context.Context, authorizationID string) error {
+	return p.post(ctx, authorizationsPath+"/"+url.PathEscape(authorizationID)+capturePath, authorizationID+capturePath, nil, nil)
+}
+
+// Refund returns the amount of an authorization
+func (p *HTTPProvider) Refund(ctx context.Context, authorizationID string) error {
+	return p.post(ctx, authorizationsPath+"/"+url.PathEscape(authorizationID)+refundPath, authorizationID+refundPath, nil, nil)
+}
+
+// VerifyWebhook checks the signature of a webhook and returns its event
+func (p *HTTPProvider) VerifyWebhook(payload []byte, signature string) (models.PaymentEvent, error) {
+	return verifyWebhook(p.secret, payload, signature)
+}
+
+// post sends a request with an idempotency key and decodes the response
+// into out. A 402 response is a declined payment.
+func (p *HTTPProvider) post(ctx context.Context, path, idempotencyKey string, in, out interface{}) error {
+	var body io.Reader = http.NoBody
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(constants.HeaderAuthorization, constants.BearerScheme+" "+p.apiKey)
+	req.Header.Set(constants.HeaderIdempotencyKey, idempotencyKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("payment provider: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPaymentRequired:
+		return constants.ErrPaymentDeclined
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("payment provider: %s %s returned %s", req.Method, path, resp.Status)
+	case out == nil:
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("payment provider: invalid response: %w", err)
+	}
+	return nil
+}
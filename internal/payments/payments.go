@@ -0,0 +1,107 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"glofox/internal/config"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+)
+
+// PaymentProvider takes the drop-in price of bookings. Calls are keyed by
+// the payment ID and the authorization ID, so retrying a call whose outcome
+// is unknown, like after a timeout, never charges a member twice.
+type PaymentProvider interface {
+	// Authorize reserves the amount of a payment and returns the ID of the
+	// authorization. It returns constants.ErrPaymentDeclined when the
+	// provider refused the payment, any other error leaves the outcome unknown.
+	Authorize(ctx context.Context, req AuthorizeRequest) (string, error)
+	// Capture takes the amount of an authorization
+	Capture(ctx context.Context, authorizationID string) error
+	// Refund returns the amount of an authorization, releasing it when it
+	// was not captured
+	Refund(ctx context.Context, authorizationID string) error
+	// VerifyWebhook checks the signature of a webhook and returns its event
+	VerifyWebhook(payload []byte, signature string) (models.PaymentEvent, error)
+}
+
+// AuthorizeRequest is a payment to authorize. The studio and booking are
+// metadata returned in the webhook events of the payment.
+type AuthorizeRequest struct {
+	PaymentID string `json:"payment_id"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	Method    string `json:"payment_method"`
+	StudioID  string `json:"studio_id"`
+	BookingID string `json:"booking_id"`
+}
+
+// New returns the configured provider, nil when payments are disabled
+func New(cfg config.PaymentsConfig) PaymentProvider {
+	switch cfg.Provider {
+	case constants.PaymentsFake:
+		return NewFake(cfg.WebhookSecret)
+	case constants.PaymentsHTTP:
+		return NewHTTPProvider(cfg)
+	}
+	return nil
+}
+
+// ForStudio returns a provider authorizing payments for a studio, so that
+// their webhook events name it. It returns nil when provider is nil.
+func ForStudio(provider PaymentProvider, studioID string) PaymentProvider {
+	if provider == nil {
+		return nil
+	}
+	return studioProvider{PaymentProvider: provider, studioID: studioID}
+}
+
+// studioProvider sets the studio of the payments it authorizes
+type studioProvider struct {
+	PaymentProvider
+	studioID string
+}
+
+// Authorize authorizes a payment of the studio
+func (p studioProvider) Authorize(ctx context.Context, req AuthorizeRequest) (string, error) {
+	req.StudioID = p.studioID
+	return p.PaymentProvider.Authorize(ctx, req)
+}
+
+// Sign returns the signature of a webhook payload, the hex encoded
+// HMAC-SHA256 of the payload keyed with the webhook secret
+func Sign(secret, payload []byte) string {
+	return hex.EncodeToString(mac(secret, payload))
+}
+
+// mac returns the HMAC-SHA256 of a payload
+func mac(secret, payload []byte) []byte {
+	hash := hmac.New(sha256.New, secret)
+	hash.Write(payload)
+	return hash.Sum(nil)
+}
+
+// verifyWebhook checks the signature of a webhook payload and decodes its event
+func verifyWebhook(secret, payload []byte, signature string) (models.PaymentEvent, error) {
+	sum, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(sum, mac(secret, payload)) {
+		return models.PaymentEvent{}, constants.ErrInvalidSignature
+	}
+	var event models.PaymentEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return models.PaymentEvent{}, fmt.Errorf("%w: %v", constants.ErrInvalidPaymentEvent, err)
+	}
+	switch event.Type {
+	case constants.PaymentEventAuthorized, constants.PaymentEventDeclined, constants.PaymentEventCaptured, constants.PaymentEventRefunded:
+	default:
+		return models.PaymentEvent{}, fmt.Errorf("%w: unknown type %q", constants.ErrInvalidPaymentEvent, event.Type)
+	}
+	if event.PaymentID == "" || event.StudioID == "" || event.BookingID == "" {
+		return models.PaymentEvent{}, fmt.Errorf("%w: payment_id, studio_id and booking_id are required", constants.ErrInvalidPaymentEvent)
+	}
+	return event, nil
+}
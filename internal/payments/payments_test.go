@@ -0,0 +1,132 @@
+package payments
+
+import (
+	"context"
+	"glofox/internal/config"
+	"glofox/internal/constants"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "whsec_test"
+
+func testRequest(paymentID, method string) AuthorizeRequest {
+	return AuthorizeRequest{PaymentID: paymentID, Amount: 1500, Currency: "EUR", Method: method, StudioID: "studio_1", BookingID: "bk_" + paymentID}
+}
+
+func TestFake(t *testing.T) {
+	testProvider(t, NewFake(testSecret), func(provider PaymentProvider) *Fake { return provider.(*Fake) })
+}
+
+func TestHTTPProvider(t *testing.T) {
+	fake := NewFake(testSecret)
+	server := httptest.NewServer(NewStubServer(fake, "sk_test"))
+	defer server.Close()
+
+	provider := NewHTTPProvider(config.PaymentsConfig{URL: server.URL + "/", APIKey: "sk_test", WebhookSecret: testSecret, Timeout: 100 * time.Millisecond})
+	testProvider(t, provider, func(PaymentProvider) *Fake { return fake })
+
+	// Calls with another API key are rejected
+	wrongKey := NewHTTPProvider(config.PaymentsConfig{URL: server.URL, APIKey: "sk_other", Timeout: time.Second})
+	_, err := wrongKey.Authorize(context.Background(), testRequest("pay_4", "pm_card"))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, constants.ErrPaymentDeclined)
+}
+
+// testProvider runs the behaviour shared by the fake and the HTTP provider
+func testProvider(t *testing.T, provider PaymentProvider, fakeOf func(PaymentProvider) *Fake) {
+	ctx := context.Background()
+	fake := fakeOf(provider)
+
+	t.Run("Authorize Capture Refund", func(t *testing.T) {
+		id, err := provider.Authorize(ctx, testRequest("pay_1", "pm_card"))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, id)
+
+		// Retries return the first authorization
+		again, err := provider.Authorize(ctx, testRequest("pay_1", "pm_card"))
+		assert.NoError(t, err)
+		assert.Equal(t, id, again)
+
+		assert.NoError(t, provider.Capture(ctx, id))
+		payment, _ := fake.Payment("pay_1")
+		assert.Equal(t, constants.PaymentCaptured, payment.Status)
+		assert.Equal(t, 2, payment.Attempts)
+
+		assert.NoError(t, provider.Refund(ctx, id))
+		payment, _ = fake.Payment("pay_1")
+		assert.Equal(t, constants.PaymentRefunded, payment.Status)
+		assert.Error(t, provider.Capture(ctx, id))
+		assert.Error(t, provider.Refund(ctx, "au_unknown"))
+	})
+
+	t.Run("Declined", func(t *testing.T) {
+		_, err := provider.Authorize(ctx, testRequest("pay_2", MethodDeclined))
+		assert.ErrorIs(t, err, constants.ErrPaymentDeclined)
+		_, err = provider.Authorize(ctx, testRequest("pay_2", MethodDeclined))
+		assert.ErrorIs(t, err, constants.ErrPaymentDeclined)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		// The first call times out after the payment was authorized, the retry learns the outcome
+		_, err := provider.Authorize(ctx, testRequest("pay_3", MethodTimeout))
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, constants.ErrPaymentDeclined)
+		payment, _ := fake.Payment("pay_3")
+		assert.Equal(t, constants.PaymentAuthorized, payment.Status)
+
+		id, err := provider.Authorize(ctx, testRequest("pay_3", MethodTimeout))
+		assert.NoError(t, err)
+		assert.Equal(t, payment.AuthorizationID, id)
+	})
+
+	t.Run("Unavailable", func(t *testing.T) {
+		fake.SetUnavailable(true)
+		_, err := provider.Authorize(ctx, testRequest("pay_5", "pm_card"))
+		assert.Error(t, err)
+		_, exists := fake.Payment("pay_5")
+		assert.False(t, exists)
+
+		fake.SetUnavailable(false)
+		_, err = provider.Authorize(ctx, testRequest("pay_5", "pm_card"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Webhook", func(t *testing.T) {
+		payload, signature, err := fake.Webhook("pay_1", constants.PaymentEventRefunded)
+		assert.NoError(t, err)
+		event, err := provider.VerifyWebhook(payload, signature)
+		assert.NoError(t, err)
+		assert.Equal(t, constants.PaymentEventRefunded, event.Type)
+		assert.Equal(t, "studio_1", event.StudioID)
+		assert.Equal(t, "bk_pay_1", event.BookingID)
+
+		_, err = provider.VerifyWebhook(append(payload, ' '), signature)
+		assert.ErrorIs(t, err, constants.ErrInvalidSignature)
+		_, err = provider.VerifyWebhook(payload, "not hex")
+		assert.ErrorIs(t, err, constants.ErrInvalidSignature)
+
+		unknown := []byte(`{"type":"payment.lost","payment_id":"pay_1","studio_id":"studio_1","booking_id":"bk_1"}`)
+		_, err = provider.VerifyWebhook(unknown, Sign([]byte(testSecret), unknown))
+		assert.ErrorIs(t, err, constants.ErrInvalidPaymentEvent)
+	})
+}
+
+func TestForStudio(t *testing.T) {
+	assert.Nil(t, ForStudio(nil, "studio_1"))
+
+	fake := NewFake(testSecret)
+	_, err := ForStudio(fake, "studio_2").Authorize(context.Background(), testRequest("pay_1", "pm_card"))
+	assert.NoError(t, err)
+	payment, _ := fake.Payment("pay_1")
+	assert.Equal(t, "studio_2", payment.StudioID)
+}
+
+func TestNew(t *testing.T) {
+	assert.Nil(t, New(config.PaymentsConfig{Provider: constants.PaymentsDisabled}))
+	assert.IsType(t, &Fake{}, New(config.PaymentsConfig{Provider: constants.PaymentsFake}))
+	assert.IsType(t, &HTTPProvider{}, New(config.PaymentsConfig{Provider: constants.PaymentsHTTP, URL: "http://localhost"}))
+}
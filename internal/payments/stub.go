@@ -0,0 +1,89 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"glofox/internal/constants"
+	"net/http"
+)
+
+// NewStubServer returns a handler serving the provider API that HTTPProvider
+// calls, backed by fake, so that tests can run against real HTTP round
+// trips. Requests must carry apiKey as their bearer token. Calls the fake
+// times out are held until the client gives up.
+func NewStubServer(fake *Fake, apiKey string) http.Handler {
+	stub := &stubServer{fake: fake, apiKey: apiKey}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+authorizationsPath, stub.authorize)
+	mux.HandleFunc("POST "+authorizationsPath+"/{id}"+capturePath, stub.capture)
+	mux.HandleFunc("POST "+authorizationsPath+"/{id}"+refundPath, stub.refund)
+	return stub.authenticate(mux)
+}
+
+// stubServer serves the provider API
+type stubServer struct {
+	fake   *Fake
+	apiKey string
+}
+
+// authenticate rejects requests without the API key of the stub
+func (stub *stubServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(constants.HeaderAuthorization) != constants.BearerScheme+" "+stub.apiKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorize handles POST /v1/authorizations
+func (stub *stubServer) authorize(w http.ResponseWriter, r *http.Request) {
+	var req AuthorizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PaymentID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	id, err := stub.fake.Authorize(r.Context(), req)
+	if err != nil {
+		stub.fail(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(authorization{ID: id, Status: constants.PaymentAuthorized})
+}
+
+// capture handles POST /v1/authorizations/{id}/capture
+func (stub *stubServer) capture(w http.ResponseWriter, r *http.Request) {
+	if err := stub.fake.Capture(r.Context(), r.PathValue("id")); err != nil {
+		stub.fail(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// refund handles POST /v1/authorizations/{id}/refund
+func (stub *stubServer) refund(w http.ResponseWriter, r *http.Request) {
+	if err := stub.fake.Refund(r.Context(), r.PathValue("id")); err != nil {
+		stub.fail(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// fail writes the status of an error of the fake
+func (stub *stubServer) fail(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, constants.ErrPaymentDeclined):
+		w.WriteHeader(http.StatusPaymentRequired)
+	case errors.Is(err, context.DeadlineExceeded):
+		// The response is lost, the client times out
+		<-r.Context().Done()
+	case errors.Is(err, ErrUnavailable):
+		w.WriteHeader(http.StatusServiceUnavailable)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
@@ -0,0 +1,516 @@
+package policy
+
+import (
+	"fmt"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/services"
+	"slices"
+)
+
+// Permission is an action a role may take. Actions on members come in an
+// Any form and narrower forms checked against the caller.
+type Permission string
+
+const (
+	// ReadClasses allows reading classes and their sessions
+	ReadClasses Permission = "classes:read"
+	// WriteClasses allows creating, changing and deleting classes
+	WriteClasses Permission = "classes:write"
+	// BookAny allows booking, cancelling and waitlisting for any member
+	BookAny Permission = "bookings:write:any"
+	// BookSelf allows booking, cancelling and waitlisting for the caller
+	BookSelf Permission = "bookings:write:self"
+	// ReadRostersAny allows reading the bookings and waitlists of every class
+	ReadRostersAny Permission = "rosters:read:any"
+	// ReadRostersTaught allows reading the bookings and waitlists of the
+	// classes the caller teaches
+	ReadRostersTaught Permission = "rosters:read:taught"
+	// ReadRostersSelf allows reading the bookings and waitlist positions of the caller
+	ReadRostersSelf Permission = "rosters:read:self"
+	// ReadMembersAny allows reading every member
+	ReadMembersAny Permission = "members:read:any"
+	// ReadMembersSelf allows reading the member of the caller
+	ReadMembersSelf Permission = "members:read:self"
+	// WriteMembers allows creating, changing and deleting members
+	WriteMembers Permission = "members:write"
+	// WriteInstructors allows creating, changing and deleting instructors
+	WriteInstructors Permission = "instructors:write"
+	// ReadSchedulesAny allows reading the schedule of every instructor
+	ReadSchedulesAny Permission = "schedules:read:any"
+	// ReadSchedulesSelf allows reading the schedule of the caller
+	ReadSchedulesSelf Permission = "schedules:read:self"
+	// WriteRooms allows creating, changing and deleting rooms
+	WriteRooms Permission = "rooms:write"
+	// ReadReports allows reading reports on the use of the studio
+	ReadReports Permission = "reports:read"
+	// WritePlans allows creating, changing and deleting membership plans
+	WritePlans Permission = "plans:write"
+	// WritePromos allows reading, creating, changing and deleting promo codes
+	WritePromos Permission = "promos:write"
+)
+
+// staffPermissions are held by staff and admins
+var staffPermissions = []Permission{
+	ReadClasses, WriteClasses, BookAny, ReadRostersAny, ReadMembersAny, WriteMembers,
+	WriteInstructors, ReadSchedulesAny, WriteRooms, ReadReports, WritePlans, WritePromos,
+}
+
+// rolePermissions is the permission table of the roles of authenticated callers
+var rolePermissions = map[string][]Permission{
+	constants.RoleAdmin:      staffPermissions,
+	constants.RoleStaff:      staffPermissions,
+	constants.RoleInstructor: {ReadClasses, ReadRostersTaught, ReadSchedulesSelf},
+	constants.RoleMember:     {ReadClasses, BookSelf, ReadRostersSelf, ReadMembersSelf},
+}
+
+// Allows reports whether a role holds a permission, unknown roles hold none
+func Allows(role string, permission Permission) bool {
+	return slices.Contains(rolePermissions[role], permission)
+}
+
+// Service checks the permissions of its caller before calling the wrapped
+// service. It is created for each request.
+type Service struct {
+	next   services.IService
+	caller models.Principal
+}
+
+// New returns the service as the caller may use it
+func New(next services.IService, caller models.Principal) *Service {
+	return &Service{next: next, caller: caller}
+}
+
+var _ services.IService = (*Service)(nil)
+
+// require checks that the caller holds one of the permissions
+func (s *Service) require(action string, permissions ...Permission) error {
+	for _, permission := range permissions {
+		if Allows(s.caller.Role, permission) {
+			return nil
+		}
+	}
+	return s.forbidden(action)
+}
+
+// forbidden returns ErrForbidden naming the role of the caller and the action
+func (s *Service) forbidden(action string) error {
+	return fmt.Errorf("%w: %s may not %s", constants.ErrForbidden, s.caller.Role, action)
+}
+
+// isSelf reports whether a member or instructor ID is the caller. Members
+// acting for themselves are identified by ID, names are not unique.
+func (s *Service) isSelf(id string) bool {
+	return id != "" && id == s.caller.Subject
+}
+
+// teaches reports whether the caller is the instructor of a class or
+// substitutes for one of its sessions
+func (s *Service) teaches(className string) bool {
+	class, err := s.next.GetClass(className)
+	if err != nil || s.caller.Subject == "" {
+		return false
+	}
+	if class.InstructorID == s.caller.Subject {
+		return true
+	}
+	for _, substitution := range class.Substitutions {
+		if substitution.InstructorID == s.caller.Subject {
+			return true
+		}
+	}
+	return false
+}
+
+// requireBooking checks that the caller may book, cancel or waitlist for a member
+func (s *Service) requireBooking(action, memberID string) error {
+	if Allows(s.caller.Role, BookAny) || (Allows(s.caller.Role, BookSelf) && s.isSelf(memberID)) {
+		return nil
+	}
+	if Allows(s.caller.Role, BookSelf) {
+		return s.forbidden(action + " for other members")
+	}
+	return s.forbidden(action)
+}
+
+// requireRoster checks that the caller may read the roster of a member in a class
+func (s *Service) requireRoster(action, className, memberID string) error {
+	role := s.caller.Role
+	if Allows(role, ReadRostersAny) ||
+		(Allows(role, ReadRostersSelf) && s.isSelf(memberID)) ||
+		(Allows(role, ReadRostersTaught) && s.teaches(className)) {
+		return nil
+	}
+	return s.forbidden(action)
+}
+
+// CreateClass requires WriteClasses
+func (s *Service) CreateClass(req models.ClassRequest) (models.Class, error) {
+	if err := s.require("create classes", WriteClasses); err != nil {
+		return models.Class{}, err
+	}
+	return s.next.CreateClass(req)
+}
+
+// GetClass requires ReadClasses
+func (s *Service) GetClass(name string) (models.Class, error) {
+	if err := s.require("read classes", ReadClasses); err != nil {
+		return models.Class{}, err
+	}
+	return s.next.GetClass(name)
+}
+
+// UpdateClass requires WriteClasses
+func (s *Service) UpdateClass(name string, version int, req models.ClassUpdateRequest, change models.ClassChangeRequest) (models.ClassChangeResult, error) {
+	if err := s.require("change classes", WriteClasses); err != nil {
+		return models.ClassChangeResult{}, err
+	}
+	return s.next.UpdateClass(name, version, req, change)
+}
+
+// DeleteClass requires WriteClasses
+func (s *Service) DeleteClass(name string, version int, change models.ClassChangeRequest) (models.ClassChangeResult, error) {
+	if err := s.require("delete classes", WriteClasses); err != nil {
+		return models.ClassChangeResult{}, err
+	}
+	return s.next.DeleteClass(name, version, change)
+}
+
+// ListClasses requires ReadClasses
+func (s *Service) ListClasses(req models.ListRequest) (models.Page[models.Class], error) {
+	if err := s.require("read classes", ReadClasses); err != nil {
+		return models.Page[models.Class]{}, err
+	}
+	return s.next.ListClasses(req)
+}
+
+// ListSessions requires ReadClasses
+func (s *Service) ListSessions(className string, req models.ListRequest) (models.Page[models.Session], error) {
+	if err := s.require("read classes", ReadClasses); err != nil {
+		return models.Page[models.Session]{}, err
+	}
+	return s.next.ListSessions(className, req)
+}
+
+// BookClass requires BookAny, or BookSelf for the caller
+func (s *Service) BookClass(req models.BookingRequest) (models.BookingResult, error) {
+	if err := s.requireBooking("book", req.MemberID); err != nil {
+		return models.BookingResult{}, err
+	}
+	return s.next.BookClass(req)
+}
+
+// GetBooking requires ReadRostersAny, ReadRostersSelf for bookings of the
+// caller or ReadRostersTaught for bookings of classes the caller teaches
+func (s *Service) GetBooking(id string) (models.Booking, error) {
+	booking, err := s.next.GetBooking(id)
+	if err != nil {
+		return models.Booking{}, err
+	}
+	if err := s.requireRoster("read this booking", booking.ClassName, booking.MemberID); err != nil {
+		return models.Booking{}, err
+	}
+	return booking, nil
+}
+
+// CancelBooking requires BookAny, or BookSelf for bookings of the caller
+func (s *Service) CancelBooking(id string, version int) (models.Booking, error) {
+	if !Allows(s.caller.Role, BookAny) {
+		booking, err := s.next.GetBooking(id)
+		if err != nil {
+			return models.Booking{}, err
+		}
+		if err := s.requireBooking("cancel bookings", booking.MemberID); err != nil {
+			return models.Booking{}, err
+		}
+	}
+	return s.next.CancelBooking(id, version)
+}
+
+// ListBookings requires ReadRostersAny, ReadRostersTaught filtered by a class
+// the caller teaches, or ReadRostersSelf. Members without a member filter see
+// their own bookings.
+func (s *Service) ListBookings(req models.BookingListRequest) (models.Page[models.Booking], error) {
+	role := s.caller.Role
+	switch {
+	case Allows(role, ReadRostersAny):
+	case Allows(role, ReadRostersTaught) && req.ClassName != "" && s.teaches(req.ClassName):
+	case Allows(role, ReadRostersSelf) && req.MemberName == "" && (req.MemberID == "" || s.isSelf(req.MemberID)):
+		req.MemberID = s.caller.Subject
+	default:
+		return models.Page[models.Booking]{}, s.forbidden("read these bookings")
+	}
+	return s.next.ListBookings(req)
+}
+
+// JoinWaitlist requires BookAny, or BookSelf for the caller
+func (s *Service) JoinWaitlist(className, dateStr string, req models.WaitlistRequest) (models.WaitlistPosition, error) {
+	if err := s.requireBooking("join waitlists", req.MemberID); err != nil {
+		return models.WaitlistPosition{}, err
+	}
+	return s.next.JoinWaitlist(className, dateStr, req)
+}
+
+// LeaveWaitlist requires BookAny, or BookSelf for the caller
+func (s *Service) LeaveWaitlist(className, member, dateStr string) error {
+	if err := s.requireBooking("leave waitlists", member); err != nil {
+		return err
+	}
+	return s.next.LeaveWaitlist(className, member, dateStr)
+}
+
+// WaitlistPosition requires ReadRostersAny, ReadRostersSelf for the caller or
+// ReadRostersTaught for classes the caller teaches
+func (s *Service) WaitlistPosition(className, member, dateStr string) (models.WaitlistPosition, error) {
+	if err := s.requireRoster("read this waitlist", className, member); err != nil {
+		return models.WaitlistPosition{}, err
+	}
+	return s.next.WaitlistPosition(className, member, dateStr)
+}
+
+// CreateMember requires WriteMembers
+func (s *Service) CreateMember(req models.MemberRequest) (models.Member, error) {
+	if err := s.require("create members", WriteMembers); err != nil {
+		return models.Member{}, err
+	}
+	return s.next.CreateMember(req)
+}
+
+// GetMember requires ReadMembersAny, or ReadMembersSelf for the caller
+func (s *Service) GetMember(id string) (models.Member, error) {
+	if !Allows(s.caller.Role, ReadMembersAny) && !(Allows(s.caller.Role, ReadMembersSelf) && s.isSelf(id)) {
+		return models.Member{}, s.forbidden("read other members")
+	}
+	return s.next.GetMember(id)
+}
+
+// ListMembers requires ReadMembersAny
+func (s *Service) ListMembers(req models.ListRequest) (models.Page[models.Member], error) {
+	if err := s.require("list members", ReadMembersAny); err != nil {
+		return models.Page[models.Member]{}, err
+	}
+	return s.next.ListMembers(req)
+}
+
+// UpdateMember requires WriteMembers
+func (s *Service) UpdateMember(id string, req models.MemberRequest) (models.Member, error) {
+	if err := s.require("change members", WriteMembers); err != nil {
+		return models.Member{}, err
+	}
+	return s.next.UpdateMember(id, req)
+}
+
+// DeleteMember requires WriteMembers
+func (s *Service) DeleteMember(id string) error {
+	if err := s.require("delete members", WriteMembers); err != nil {
+		return err
+	}
+	return s.next.DeleteMember(id)
+}
+
+// CreateInstructor requires WriteInstructors
+func (s *Service) CreateInstructor(req models.InstructorRequest) (models.Instructor, error) {
+	if err := s.require("create instructors", WriteInstructors); err != nil {
+		return models.Instructor{}, err
+	}
+	return s.next.CreateInstructor(req)
+}
+
+// GetInstructor requires ReadClasses, instructors are part of the timetable
+func (s *Service) GetInstructor(id string) (models.Instructor, error) {
+	if err := s.require("read instructors", ReadClasses); err != nil {
+		return models.Instructor{}, err
+	}
+	return s.next.GetInstructor(id)
+}
+
+// ListInstructors requires ReadClasses
+func (s *Service) ListInstructors(req models.ListRequest) (models.Page[models.Instructor], error) {
+	if err := s.require("read instructors", ReadClasses); err != nil {
+		return models.Page[models.Instructor]{}, err
+	}
+	return s.next.ListInstructors(req)
+}
+
+// UpdateInstructor requires WriteInstructors
+func (s *Service) UpdateInstructor(id string, req models.InstructorRequest) (models.Instructor, error) {
+	if err := s.require("change instructors", WriteInstructors); err != nil {
+		return models.Instructor{}, err
+	}
+	return s.next.UpdateInstructor(id, req)
+}
+
+// DeleteInstructor requires WriteInstructors
+func (s *Service) DeleteInstructor(id string) error {
+	if err := s.require("delete instructors", WriteInstructors); err != nil {
+		return err
+	}
+	return s.next.DeleteInstructor(id)
+}
+
+// InstructorSchedule requires ReadSchedulesAny, or ReadSchedulesSelf for the caller
+func (s *Service) InstructorSchedule(id string, req models.ScheduleRequest) (models.Schedule, error) {
+	if !Allows(s.caller.Role, ReadSchedulesAny) && !(Allows(s.caller.Role, ReadSchedulesSelf) && s.isSelf(id)) {
+		return models.Schedule{}, s.forbidden("read the schedules of other instructors")
+	}
+	return s.next.InstructorSchedule(id, req)
+}
+
+// CreateRoom requires WriteRooms
+func (s *Service) CreateRoom(req models.RoomRequest) (models.Room, error) {
+	if err := s.require("create rooms", WriteRooms); err != nil {
+		return models.Room{}, err
+	}
+	return s.next.CreateRoom(req)
+}
+
+// GetRoom requires ReadClasses, rooms are part of the timetable
+func (s *Service) GetRoom(id string) (models.Room, error) {
+	if err := s.require("read rooms", ReadClasses); err != nil {
+		return models.Room{}, err
+	}
+	return s.next.GetRoom(id)
+}
+
+// ListRooms requires ReadClasses
+func (s *Service) ListRooms(req models.ListRequest) (models.Page[models.Room], error) {
+	if err := s.require("read rooms", ReadClasses); err != nil {
+		return models.Page[models.Room]{}, err
+	}
+	return s.next.ListRooms(req)
+}
+
+// UpdateRoom requires WriteRooms
+func (s *Service) UpdateRoom(id string, req models.RoomRequest) (models.Room, error) {
+	if err := s.require("change rooms", WriteRooms); err != nil {
+		return models.Room{}, err
+	}
+	return s.next.UpdateRoom(id, req)
+}
+
+// DeleteRoom requires WriteRooms
+func (s *Service) DeleteRoom(id string) error {
+	if err := s.require("delete rooms", WriteRooms); err != nil {
+		return err
+	}
+	return s.next.DeleteRoom(id)
+}
+
+// RoomUtilization requires ReadReports
+func (s *Service) RoomUtilization(id string, req models.ScheduleRequest) (models.RoomUtilization, error) {
+	if err := s.require("read room utilization", ReadReports); err != nil {
+		return models.RoomUtilization{}, err
+	}
+	return s.next.RoomUtilization(id, req)
+}
+
+// CreatePlan requires WritePlans
+func (s *Service) CreatePlan(req models.PlanRequest) (models.Plan, error) {
+	if err := s.require("create plans", WritePlans); err != nil {
+		return models.Plan{}, err
+	}
+	return s.next.CreatePlan(req)
+}
+
+// GetPlan requires ReadClasses, plans are offered to every member
+func (s *Service) GetPlan(id string) (models.Plan, error) {
+	if err := s.require("read plans", ReadClasses); err != nil {
+		return models.Plan{}, err
+	}
+	return s.next.GetPlan(id)
+}
+
+// ListPlans requires ReadClasses
+func (s *Service) ListPlans(req models.ListRequest) (models.Page[models.Plan], error) {
+	if err := s.require("read plans", ReadClasses); err != nil {
+		return models.Page[models.Plan]{}, err
+	}
+	return s.next.ListPlans(req)
+}
+
+// UpdatePlan requires WritePlans
+func (s *Service) UpdatePlan(id string, req models.PlanRequest) (models.Plan, error) {
+	if err := s.require("change plans", WritePlans); err != nil {
+		return models.Plan{}, err
+	}
+	return s.next.UpdatePlan(id, req)
+}
+
+// DeletePlan requires WritePlans
+func (s *Service) DeletePlan(id string) error {
+	if err := s.require("delete plans", WritePlans); err != nil {
+		return err
+	}
+	return s.next.DeletePlan(id)
+}
+
+// CreatePromoCode requires WritePromos
+func (s *Service) CreatePromoCode(req models.PromoCodeRequest) (models.PromoCode, error) {
+	if err := s.require("create promo codes", WritePromos); err != nil {
+		return models.PromoCode{}, err
+	}
+	return s.next.CreatePromoCode(req)
+}
+
+// GetPromoCode requires WritePromos, members redeem codes they were given
+// without reading them
+func (s *Service) GetPromoCode(code string) (models.PromoCode, error) {
+	if err := s.require("read promo codes", WritePromos); err != nil {
+		return models.PromoCode{}, err
+	}
+	return s.next.GetPromoCode(code)
+}
+
+// ListPromoCodes requires WritePromos
+func (s *Service) ListPromoCodes(req models.ListRequest) (models.Page[models.PromoCode], error) {
+	if err := s.require("read promo codes", WritePromos); err != nil {
+		return models.Page[models.PromoCode]{}, err
+	}
+	return s.next.ListPromoCodes(req)
+}
+
+// UpdatePromoCode requires WritePromos
+func (s *Service) UpdatePromoCode(code string, req models.PromoCodeRequest) (models.PromoCode, error) {
+	if err := s.require("change promo codes", WritePromos); err != nil {
+		return models.PromoCode{}, err
+	}
+	return s.next.UpdatePromoCode(code, req)
+}
+
+// DeletePromoCode requires WritePromos
+func (s *Service) DeletePromoCode(code string) error {
+	if err := s.require("delete promo codes", WritePromos); err != nil {
+		return err
+	}
+	return s.next.DeletePromoCode(code)
+}
+
+// PromoRedemptions requires ReadReports
+func (s *Service) PromoRedemptions(code string) (models.PromoReport, error) {
+	if err := s.require("read promo code redemptions", ReadReports); err != nil {
+		return models.PromoReport{}, err
+	}
+	return s.next.PromoRedemptions(code)
+}
+
+// PurchaseMembership requires WriteMembers, memberships are sold by the studio
+func (s *Service) PurchaseMembership(memberID string, req models.MembershipRequest) (models.Membership, error) {
+	if err := s.require("sell memberships", WriteMembers); err != nil {
+		return models.Membership{}, err
+	}
+	return s.next.PurchaseMembership(memberID, req)
+}
+
+// MemberCredits requires ReadMembersAny, or ReadMembersSelf for the caller
+func (s *Service) MemberCredits(id string) (models.Credits, error) {
+	if !Allows(s.caller.Role, ReadMembersAny) && !(Allows(s.caller.Role, ReadMembersSelf) && s.isSelf(id)) {
+		return models.Credits{}, s.forbidden("read the credits of other members")
+	}
+	return s.next.MemberCredits(id)
+}
+
+// ApplyPaymentEvent is denied to every caller, payment events come from the
+// provider through the signed webhook, which uses the service directly
+func (s *Service) ApplyPaymentEvent(event models.PaymentEvent) (models.Booking, error) {
+	return models.Booking{}, s.forbidden("report payment events")
+}
@@ -0,0 +1,49 @@
+package policy
+
+import (
+	"glofox/internal/constants"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		role       string
+		permission Permission
+		expected   bool
+	}{
+		{constants.RoleAdmin, WriteClasses, true},
+		{constants.RoleStaff, WriteClasses, true},
+		{constants.RoleStaff, BookAny, true},
+		{constants.RoleInstructor, ReadRostersTaught, true},
+		{constants.RoleInstructor, ReadRostersAny, false},
+		{constants.RoleInstructor, BookSelf, false},
+		{constants.RoleInstructor, ReadSchedulesSelf, true},
+		{constants.RoleInstructor, WriteInstructors, false},
+		{constants.RoleStaff, ReadSchedulesAny, true},
+		{constants.RoleStaff, WriteRooms, true},
+		{constants.RoleInstructor, ReadReports, false},
+		{constants.RoleStaff, WritePlans, true},
+		{constants.RoleMember, WritePlans, false},
+		{constants.RoleStaff, WritePromos, true},
+		{constants.RoleMember, WritePromos, false},
+		{constants.RoleMember, BookSelf, true},
+		{constants.RoleMember, BookAny, false},
+		{constants.RoleMember, WriteClasses, false},
+		{"owner", ReadClasses, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role+"/"+string(tt.permission), func(t *testing.T) {
+			assert.Equal(t, tt.expected, Allows(tt.role, tt.permission))
+		})
+	}
+}
+
+func TestRolePermissions_KnownRoles(t *testing.T) {
+	// Every role a token may carry has an entry in the table
+	for _, role := range []string{constants.RoleAdmin, constants.RoleStaff, constants.RoleInstructor, constants.RoleMember} {
+		assert.NotEmpty(t, rolePermissions[role], role)
+	}
+}
@@ -1,40 +1,577 @@
 package repository
 
 import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
 	"glofox/internal/utils"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 )
 
 type BookingRepository interface {
-	Create(className, memberName string, date time.Time) error
+	Create(booking models.Booking, limits models.BookingLimits) (models.Booking, error)
+	GetByID(id string) (models.Booking, bool)
+	// Cancel cancels the booking when its stored version is version
+	Cancel(id string, version int, cancelledAt time.Time, lateCancel bool, reason string) (models.Booking, error)
+	Count(className string, date time.Time) int
+	Query(filter models.BookingFilter) []models.Booking
+	// AddCredit appends an entry to the credit ledger of a member
+	AddCredit(entry models.CreditEntry) error
+	// Credits returns the credit ledger of a member, oldest entry first
+	Credits(memberID string) []models.CreditEntry
+	// Balance returns the credits a member has left with the membership of a
+	// charge, Create refuses to charge it when none are left
+	Balance(memberID string, charge models.Charge) int
+	// UpdatePayment stores the payment of a booking when its stored version
+	// is version, see settlePayment
+	UpdatePayment(id string, version int, payment models.Payment) (models.Booking, error)
+	// DeleteByClass removes the bookings of a deleted class, see BookingRepo.DeleteByClass
+	DeleteByClass(className string) error
 }
 
 // BookingRepo manages the in-memory booking data
 type BookingRepo struct {
-	// Key: class name, Sub-key: date, Value: list of member names
-	bookings map[string]map[time.Time][]string
-	mu       sync.RWMutex
+	// Key: booking ID, holds active and cancelled bookings
+	bookings map[string]models.Booking
+	// Key: class name, Sub-key: date, Value: IDs of pending and booked bookings
+	sessions map[string]map[time.Time][]string
+	// Key: memberKey, Value: IDs of pending and booked bookings
+	members map[string][]string
+	// Key: promo code, Value: IDs of pending and booked bookings redeeming it
+	redemptions map[string][]string
+	// credits is the credit ledger of every member, in the order of entry
+	credits []models.CreditEntry
+	mu      sync.RWMutex
 }
 
 // NewBookingRepo creates a new BookingRepo
 func NewBookingRepo() *BookingRepo {
 	return &BookingRepo{
-		bookings: make(map[string]map[time.Time][]string),
+		bookings:    make(map[string]models.Booking),
+		sessions:    make(map[string]map[time.Time][]string),
+		members:     make(map[string][]string),
+		redemptions: make(map[string][]string),
 	}
 }
 
-// Create for creating a new booking
-func (bookingRepo *BookingRepo) Create(className, memberName string, date time.Time) error {
+// Create for creating a new booking of the class, member and date of
+// booking, the duplicate, daily limit, capacity, spot, credit and promo code
+// checks, the insert and the debit happen under the same lock so concurrent
+// bookings cannot oversell a date, book a member or a spot twice, spend a
+// credit twice or redeem a promo code past its limits
+func (bookingRepo *BookingRepo) Create(booking models.Booking, limits models.BookingLimits) (models.Booking, error) {
 	bookingRepo.mu.Lock()
 	defer bookingRepo.mu.Unlock()
 
-	// Normalize date to midnight
-	date = utils.ToMidnightUTC(date)
+	booking, debit, err := bookingRepo.checkCreate(booking, limits)
+	if err != nil {
+		return models.Booking{}, err
+	}
+	bookingRepo.save(booking, debit)
+	return booking, nil
+}
+
+// checkCreate returns a new booking as Create stores it and the credit it
+// debits, nil when it is not charged, the caller must hold the lock
+func (bookingRepo *BookingRepo) checkCreate(booking models.Booking, limits models.BookingLimits) (models.Booking, *models.CreditEntry, error) {
+	// Sessions are keyed by their start instant in UTC
+	className, date := booking.ClassName, booking.Date.UTC()
+
+	for _, id := range bookingRepo.sessions[className][date] {
+		if sameMember(bookingRepo.bookings[id], booking) {
+			return models.Booking{}, nil, constants.ErrAlreadyBooked
+		}
+	}
+	if limits.PerDay > 0 && bookingRepo.countMemberBookings(booking, limits.DayStart, limits.DayEnd) >= limits.PerDay {
+		return models.Booking{}, nil, constants.ErrDailyLimitReached
+	}
+	if len(bookingRepo.sessions[className][date]) >= limits.Capacity {
+		return models.Booking{}, nil, constants.ErrClassFull
+	}
+	taken := make(map[string]bool)
+	for _, id := range bookingRepo.sessions[className][date] {
+		taken[bookingRepo.bookings[id].Spot] = true
+	}
+	spot, err := pickSpot(booking.Spot, limits.Spots, taken)
+	if err != nil {
+		return models.Booking{}, nil, err
+	}
+	if limits.Charge != nil && bookingRepo.balance(booking.MemberID, *limits.Charge) < 1 {
+		return models.Booking{}, nil, constants.ErrNoEntitlement
+	}
+	if limits.Promo != nil {
+		if err := bookingRepo.redeem(booking, *limits.Promo); err != nil {
+			return models.Booking{}, nil, err
+		}
+	}
+
+	booking = newBooking(booking)
+	booking.Spot = spot
+	var debit *models.CreditEntry
+	if limits.Charge != nil {
+		booking.MembershipID = limits.Charge.MembershipID
+		entry := newCredit(booking, -1, constants.CreditBooking)
+		debit = &entry
+	}
+	return booking, debit, nil
+}
+
+// balance returns the credits a member has left with the membership of a
+// charge, the caller must hold the lock
+func (bookingRepo *BookingRepo) balance(memberID string, charge models.Charge) int {
+	balance := charge.Allowance
+	for _, entry := range bookingRepo.credits {
+		if entry.MemberID == memberID && charges(entry, charge) {
+			balance += entry.Amount
+		}
+	}
+	return balance
+}
+
+// redeem checks that the member of booking may redeem a promo code, the
+// caller must hold the lock
+func (bookingRepo *BookingRepo) redeem(booking models.Booking, promo models.PromoLimit) error {
+	redemptions := bookingRepo.redemptions[promo.Code]
+	for _, id := range redemptions {
+		if sameMember(bookingRepo.bookings[id], booking) {
+			return constants.ErrPromoRedeemed
+		}
+	}
+	other := len(bookingRepo.members[memberKey(booking)]) > 0
+	return promoError(len(redemptions), other, promo)
+}
+
+// promoError returns why a promo code redeemed redemptions times cannot be
+// redeemed again by a member, other tells whether the member holds bookings
+// that are not cancelled
+func promoError(redemptions int, other bool, promo models.PromoLimit) error {
+	if promo.MaxRedemptions > 0 && redemptions >= promo.MaxRedemptions {
+		return constants.ErrPromoExhausted
+	}
+	if promo.FirstClass && other {
+		return constants.ErrPromoNotApplicable
+	}
+	return nil
+}
+
+// countMemberBookings counts the active bookings of the member of booking in
+// sessions starting in [from, to), the caller must hold the lock
+func (bookingRepo *BookingRepo) countMemberBookings(booking models.Booking, from, to time.Time) int {
+	count := 0
+	for _, id := range bookingRepo.members[memberKey(booking)] {
+		if date := bookingRepo.bookings[id].Date; !date.Before(from) && date.Before(to) {
+			count++
+		}
+	}
+	return count
+}
+
+// GetByID fetches booking by given ID
+func (bookingRepo *BookingRepo) GetByID(id string) (models.Booking, bool) {
+	bookingRepo.mu.RLock()
+	defer bookingRepo.mu.RUnlock()
+
+	booking, exists := bookingRepo.bookings[id]
+	return booking, exists
+}
+
+// Cancel marks a booking as cancelled and frees its seat. The credit of a
+// charged booking is refunded unless it is cancelled late.
+func (bookingRepo *BookingRepo) Cancel(id string, version int, cancelledAt time.Time, lateCancel bool, reason string) (models.Booking, error) {
+	bookingRepo.mu.Lock()
+	defer bookingRepo.mu.Unlock()
+
+	booking, refund, err := bookingRepo.checkCancel(id, version, cancelledAt, lateCancel, reason)
+	if err != nil {
+		return models.Booking{}, err
+	}
+	bookingRepo.save(booking, refund)
+	return booking, nil
+}
+
+// checkCancel returns a booking as Cancel stores it and the credit it
+// refunds, nil when there is none. The payment of a booking cancelled in
+// time is marked to be refunded. The caller must hold the lock.
+func (bookingRepo *BookingRepo) checkCancel(id string, version int, cancelledAt time.Time, lateCancel bool, reason string) (models.Booking, *models.CreditEntry, error) {
+	booking, exists := bookingRepo.bookings[id]
+	if !exists {
+		return models.Booking{}, nil, constants.ErrBookingNotFound
+	}
+	if booking.Version != version {
+		return models.Booking{}, nil, constants.ErrVersionMismatch
+	}
+	if booking.Status == constants.BookingStatusCancelled {
+		return models.Booking{}, nil, constants.ErrAlreadyCancelled
+	}
+
+	booking.Status = constants.BookingStatusCancelled
+	booking.LateCancel = lateCancel
+	booking.CancelledAt = &cancelledAt
+	booking.CancelReason = reason
+	booking.Payment = refundPayment(booking.Payment, lateCancel, cancelledAt)
+	booking.Version++
+
+	var refund *models.CreditEntry
+	if booking.MembershipID != "" && !lateCancel {
+		entry := newCredit(booking, 1, constants.CreditRefund)
+		refund = &entry
+	}
+	return booking, refund, nil
+}
+
+// UpdatePayment stores the payment of a booking. A pending booking is booked
+// once its payment is authorized, and cancelled when it is declined.
+func (bookingRepo *BookingRepo) UpdatePayment(id string, version int, payment models.Payment) (models.Booking, error) {
+	bookingRepo.mu.Lock()
+	defer bookingRepo.mu.Unlock()
+
+	booking, err := bookingRepo.checkPayment(id, version, payment)
+	if err != nil {
+		return models.Booking{}, err
+	}
+	bookingRepo.save(booking, nil)
+	return booking, nil
+}
+
+// checkPayment returns a booking as UpdatePayment stores it, the caller must
+// hold the lock
+func (bookingRepo *BookingRepo) checkPayment(id string, version int, payment models.Payment) (models.Booking, error) {
+	booking, exists := bookingRepo.bookings[id]
+	if !exists {
+		return models.Booking{}, constants.ErrBookingNotFound
+	}
+	if booking.Version != version {
+		return models.Booking{}, constants.ErrVersionMismatch
+	}
+	if booking.Payment == nil || booking.Payment.ID != payment.ID {
+		return models.Booking{}, constants.ErrPaymentNotFound
+	}
+	return settlePayment(booking, payment), nil
+}
+
+// DeleteByClass removes the bookings of a deleted class, so that a class
+// created later with the same name starts without bookings. Bookings with a
+// payment still to settle are kept for SettlePayments.
+func (bookingRepo *BookingRepo) DeleteByClass(className string) error {
+	bookingRepo.mu.Lock()
+	defer bookingRepo.mu.Unlock()
 
-	if _, exists := bookingRepo.bookings[className]; !exists {
-		bookingRepo.bookings[className] = make(map[time.Time][]string)
+	for id, booking := range bookingRepo.bookings {
+		if booking.ClassName == className && !unsettled(booking.Payment) {
+			bookingRepo.unindex(id)
+			delete(bookingRepo.bookings, id)
+		}
 	}
-	bookingRepo.bookings[className][date] = append(bookingRepo.bookings[className][date], memberName)
 	return nil
 }
+
+// Count returns the number of active bookings for a class on a date
+func (bookingRepo *BookingRepo) Count(className string, date time.Time) int {
+	bookingRepo.mu.RLock()
+	defer bookingRepo.mu.RUnlock()
+
+	return len(bookingRepo.sessions[className][date.UTC()])
+}
+
+// Query returns up to filter.Limit bookings matching the filter, sorted by
+// date, class name and ID so that pages are stable between calls
+func (bookingRepo *BookingRepo) Query(filter models.BookingFilter) []models.Booking {
+	bookingRepo.mu.RLock()
+	defer bookingRepo.mu.RUnlock()
+
+	var bookings []models.Booking
+	for _, booking := range bookingRepo.bookings {
+		if matchesFilter(booking, filter) {
+			bookings = append(bookings, booking)
+		}
+	}
+	sort.Slice(bookings, func(i, j int) bool {
+		return bookingBefore(bookingCursor(bookings[i]), bookingCursor(bookings[j]))
+	})
+	if filter.Limit > 0 && len(bookings) > filter.Limit {
+		bookings = bookings[:filter.Limit]
+	}
+	return bookings
+}
+
+// AddCredit appends an entry to the credit ledger of a member
+func (bookingRepo *BookingRepo) AddCredit(entry models.CreditEntry) error {
+	bookingRepo.mu.Lock()
+	defer bookingRepo.mu.Unlock()
+
+	bookingRepo.credits = append(bookingRepo.credits, entry)
+	return nil
+}
+
+// Credits returns the credit ledger of a member, oldest entry first
+func (bookingRepo *BookingRepo) Credits(memberID string) []models.CreditEntry {
+	bookingRepo.mu.RLock()
+	defer bookingRepo.mu.RUnlock()
+
+	entries := []models.CreditEntry{}
+	for _, entry := range bookingRepo.credits {
+		if entry.MemberID == memberID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Balance returns the credits a member has left with the membership of a charge
+func (bookingRepo *BookingRepo) Balance(memberID string, charge models.Charge) int {
+	bookingRepo.mu.RLock()
+	defer bookingRepo.mu.RUnlock()
+
+	return bookingRepo.balance(memberID, charge)
+}
+
+// record inserts or replaces a booking and appends the credit it debited or
+// refunded without validation, used to restore persisted state
+func (bookingRepo *BookingRepo) record(booking models.Booking, credit *models.CreditEntry) {
+	bookingRepo.mu.Lock()
+	defer bookingRepo.mu.Unlock()
+
+	// Bookings persisted before versions existed start at version 1
+	booking.Version = max(booking.Version, 1)
+	bookingRepo.save(booking, credit)
+}
+
+// save inserts or replaces a booking, indexing it unless it is cancelled, and
+// appends credit to the ledger unless it is nil, the caller must hold the lock
+func (bookingRepo *BookingRepo) save(booking models.Booking, credit *models.CreditEntry) {
+	bookingRepo.unindex(booking.ID)
+	bookingRepo.bookings[booking.ID] = booking
+	if booking.Status != constants.BookingStatusCancelled {
+		bookingRepo.index(booking)
+	}
+	if credit != nil {
+		bookingRepo.credits = append(bookingRepo.credits, *credit)
+	}
+}
+
+// index adds an active booking to the session, member and promo code
+// indexes, the caller must hold the lock
+func (bookingRepo *BookingRepo) index(booking models.Booking) {
+	if _, exists := bookingRepo.sessions[booking.ClassName]; !exists {
+		bookingRepo.sessions[booking.ClassName] = make(map[time.Time][]string)
+	}
+	bookingRepo.sessions[booking.ClassName][booking.Date] = append(bookingRepo.sessions[booking.ClassName][booking.Date], booking.ID)
+	key := memberKey(booking)
+	bookingRepo.members[key] = append(bookingRepo.members[key], booking.ID)
+	if booking.Promo != nil {
+		bookingRepo.redemptions[booking.Promo.Code] = append(bookingRepo.redemptions[booking.Promo.Code], booking.ID)
+	}
+}
+
+// unindex drops a booking from the session, member and promo code indexes,
+// the caller must hold the lock
+func (bookingRepo *BookingRepo) unindex(id string) {
+	booking, exists := bookingRepo.bookings[id]
+	if !exists {
+		return
+	}
+	if dates, exists := bookingRepo.sessions[booking.ClassName]; exists {
+		dates[booking.Date] = without(dates[booking.Date], id)
+	}
+	key := memberKey(booking)
+	if bookingRepo.members[key] = without(bookingRepo.members[key], id); len(bookingRepo.members[key]) == 0 {
+		delete(bookingRepo.members, key)
+	}
+	if booking.Promo != nil {
+		code := booking.Promo.Code
+		if bookingRepo.redemptions[code] = without(bookingRepo.redemptions[code], id); len(bookingRepo.redemptions[code]) == 0 {
+			delete(bookingRepo.redemptions, code)
+		}
+	}
+}
+
+// without returns ids less id
+func without(ids []string, id string) []string {
+	if i := indexOf(ids, id); i >= 0 {
+		return append(ids[:i:i], ids[i+1:]...)
+	}
+	return ids
+}
+
+// all returns every booking, including cancelled ones
+func (bookingRepo *BookingRepo) all() []models.Booking {
+	bookingRepo.mu.RLock()
+	defer bookingRepo.mu.RUnlock()
+
+	bookings := make([]models.Booking, 0, len(bookingRepo.bookings))
+	for _, booking := range bookingRepo.bookings {
+		bookings = append(bookings, booking)
+	}
+	return bookings
+}
+
+// allCredits returns the credit ledger of every member
+func (bookingRepo *BookingRepo) allCredits() []models.CreditEntry {
+	bookingRepo.mu.RLock()
+	defer bookingRepo.mu.RUnlock()
+
+	return append([]models.CreditEntry{}, bookingRepo.credits...)
+}
+
+// newBooking fills the ID, status and creation time of a new booking,
+// bookings paid for are pending until their payment is authorized
+func newBooking(booking models.Booking) models.Booking {
+	booking.ID = utils.NewID("bk_")
+	booking.Date = booking.Date.UTC()
+	booking.Status = constants.BookingStatusBooked
+	if booking.Payment != nil {
+		booking.Status = constants.BookingStatusPending
+	}
+	booking.CreatedAt = time.Now().UTC()
+	booking.Version = 1
+	return booking
+}
+
+// settlePayment returns a booking with its payment replaced. A pending
+// booking is booked once the payment is authorized or captured, and
+// cancelled when it is declined.
+func settlePayment(booking models.Booking, payment models.Payment) models.Booking {
+	booking.Payment = &payment
+	booking.Version++
+	if booking.Status != constants.BookingStatusPending {
+		return booking
+	}
+	switch payment.Status {
+	case constants.PaymentAuthorized, constants.PaymentCaptured:
+		booking.Status = constants.BookingStatusBooked
+	case constants.PaymentDeclined:
+		cancelledAt := payment.UpdatedAt
+		booking.Status = constants.BookingStatusCancelled
+		booking.CancelledAt = &cancelledAt
+		booking.CancelReason = constants.CancelReasonPaymentDeclined
+	}
+	return booking
+}
+
+// unsettled reports whether a payment is still to be authorized, captured or refunded
+func unsettled(payment *models.Payment) bool {
+	if payment == nil {
+		return false
+	}
+	switch payment.Status {
+	case constants.PaymentPending, constants.PaymentAuthorized, constants.PaymentRefundPending:
+		return true
+	}
+	return false
+}
+
+// refundPayment returns the payment of a booking cancelled at cancelledAt,
+// marked to be refunded unless the cancellation was late. Declined and
+// refunded payments are kept.
+func refundPayment(payment *models.Payment, lateCancel bool, cancelledAt time.Time) *models.Payment {
+	if payment == nil || lateCancel {
+		return payment
+	}
+	switch payment.Status {
+	case constants.PaymentPending, constants.PaymentAuthorized, constants.PaymentCaptured:
+		refund := *payment
+		refund.Status = constants.PaymentRefundPending
+		refund.UpdatedAt = cancelledAt
+		return &refund
+	}
+	return payment
+}
+
+// newCredit returns a ledger entry of amount credits for a booking
+func newCredit(booking models.Booking, amount int, reason string) models.CreditEntry {
+	session := booking.Date
+	return models.CreditEntry{
+		ID:           utils.NewID("cr_"),
+		MemberID:     booking.MemberID,
+		MembershipID: booking.MembershipID,
+		Amount:       amount,
+		Reason:       reason,
+		BookingID:    booking.ID,
+		Session:      &session,
+		CreatedAt:    time.Now().UTC(),
+	}
+}
+
+// charges reports whether a ledger entry counts towards the credits of the
+// membership of a charge, weekly and monthly plans only count the entries
+// of sessions in their week or month
+func charges(entry models.CreditEntry, charge models.Charge) bool {
+	if entry.MembershipID != charge.MembershipID {
+		return false
+	}
+	if charge.From.IsZero() {
+		return true
+	}
+	return entry.Session != nil && !entry.Session.Before(charge.From) && entry.Session.Before(charge.To)
+}
+
+// pickSpot returns the requested spot when it is free, or the first free spot
+// of the layout when none was requested
+func pickSpot(requested string, layout []string, taken map[string]bool) (string, error) {
+	if requested != "" {
+		if taken[requested] {
+			return "", constants.ErrSpotTaken
+		}
+		return requested, nil
+	}
+	for _, spot := range layout {
+		if !taken[spot] {
+			return spot, nil
+		}
+	}
+	if len(layout) > 0 {
+		return "", constants.ErrClassFull
+	}
+	return "", nil
+}
+
+// memberKey returns the key of the member of a booking, matching sameMember
+func memberKey(booking models.Booking) string {
+	if booking.MemberID != "" {
+		return "id:" + booking.MemberID
+	}
+	return "name:" + booking.MemberName
+}
+
+// sameMember reports whether two bookings belong to the same member, bookings
+// made before members were registered are told apart by name
+func sameMember(a, b models.Booking) bool {
+	if a.MemberID != "" || b.MemberID != "" {
+		return a.MemberID == b.MemberID
+	}
+	return a.MemberName == b.MemberName
+}
+
+// matchesFilter reports whether a booking is selected by the filter
+func matchesFilter(booking models.Booking, filter models.BookingFilter) bool {
+	switch {
+	case filter.MemberID != "" && booking.MemberID != filter.MemberID,
+		filter.MemberName != "" && booking.MemberName != filter.MemberName,
+		filter.ClassName != "" && booking.ClassName != filter.ClassName,
+		!filter.From.IsZero() && booking.Date.Before(filter.From),
+		!filter.To.IsZero() && booking.Date.After(filter.To),
+		filter.PromoCode != "" && (booking.Promo == nil || booking.Promo.Code != filter.PromoCode),
+		len(filter.PaymentStatuses) > 0 && (booking.Payment == nil || !slices.Contains(filter.PaymentStatuses, booking.Payment.Status)),
+		filter.After != nil && !bookingBefore(*filter.After, bookingCursor(booking)):
+		return false
+	}
+	return true
+}
+
+// bookingCursor returns the sort position of a booking
+func bookingCursor(booking models.Booking) models.BookingCursor {
+	return models.BookingCursor{Date: booking.Date, ClassName: booking.ClassName, ID: booking.ID}
+}
+
+// bookingBefore orders bookings by date, then class name, then ID
+func bookingBefore(a, b models.BookingCursor) bool {
+	if !a.Date.Equal(b.Date) {
+		return a.Date.Before(b.Date)
+	}
+	if a.ClassName != b.ClassName {
+		return a.ClassName < b.ClassName
+	}
+	return a.ID < b.ID
+}
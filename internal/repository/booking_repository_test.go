@@ -0,0 +1,640 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookingRepo(t *testing.T) {
+	testBookingRepository(t, func(t *testing.T) BookingRepository {
+		return NewBookingRepo()
+	})
+}
+
+// testBookingRepository runs the BookingRepository test suite against the
+// implementation returned by newRepo
+func testBookingRepository(t *testing.T, newRepo func(t *testing.T) BookingRepository) {
+	t.Run("ConcurrentCapacity", func(t *testing.T) { testBookingConcurrentCapacity(t, newRepo(t)) })
+	t.Run("CapacityIsPerSession", func(t *testing.T) { testBookingCapacityIsPerSession(t, newRepo(t)) })
+	t.Run("Duplicate", func(t *testing.T) { testBookingDuplicate(t, newRepo(t)) })
+	t.Run("ConcurrentDuplicate", func(t *testing.T) { testBookingConcurrentDuplicate(t, newRepo(t)) })
+	t.Run("DailyLimit", func(t *testing.T) { testBookingDailyLimit(t, newRepo(t)) })
+	t.Run("Spots", func(t *testing.T) { testBookingSpots(t, newRepo(t)) })
+	t.Run("ConcurrentSpot", func(t *testing.T) { testBookingConcurrentSpot(t, newRepo(t)) })
+	t.Run("SpotConflicts", func(t *testing.T) { testBookingSpotConflicts(t, newRepo(t)) })
+	t.Run("Credits", func(t *testing.T) { testBookingCredits(t, newRepo(t)) })
+	t.Run("WeeklyCredits", func(t *testing.T) { testBookingWeeklyCredits(t, newRepo(t)) })
+	t.Run("ConcurrentCredit", func(t *testing.T) { testBookingConcurrentCredit(t, newRepo(t)) })
+	t.Run("Cancel", func(t *testing.T) { testBookingCancel(t, newRepo(t)) })
+	t.Run("Payments", func(t *testing.T) { testBookingPayments(t, newRepo(t)) })
+	t.Run("Promos", func(t *testing.T) { testBookingPromos(t, newRepo(t)) })
+	t.Run("Query", func(t *testing.T) { testBookingQuery(t, newRepo(t)) })
+	t.Run("DeleteByClass", func(t *testing.T) { testBookingDeleteByClass(t, newRepo(t)) })
+}
+
+// testBooking returns a new booking of Yoga for the member
+func testBooking(memberName string, date time.Time) models.Booking {
+	return models.Booking{ClassName: "Yoga", MemberID: "mb_" + memberName, MemberName: memberName, Date: date}
+}
+
+// seats returns the limits of a session with the given capacity and no daily cap
+func seats(capacity int) models.BookingLimits {
+	return models.BookingLimits{Capacity: capacity}
+}
+
+func testBookingConcurrentCapacity(t *testing.T, repo BookingRepository) {
+	const (
+		capacity   = 10
+		goroutines = 500
+	)
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	var succeeded, full int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Release all goroutines at once to maximise contention
+			<-start
+			_, err := repo.Create(testBooking(fmt.Sprintf("member-%d", i), date), seats(capacity))
+			switch {
+			case err == nil:
+				atomic.AddInt32(&succeeded, 1)
+			case errors.Is(err, constants.ErrClassFull):
+				atomic.AddInt32(&full, 1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(capacity), succeeded, "Expected exactly %d bookings to succeed", capacity)
+	assert.Equal(t, int32(goroutines-capacity), full, "Expected the remaining bookings to be rejected as full")
+	assert.Equal(t, capacity, repo.Count("Yoga", date))
+}
+
+func testBookingCapacityIsPerSession(t *testing.T, repo BookingRepository) {
+	day1 := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC)
+
+	_, err := repo.Create(testBooking("Alice", day1), seats(1))
+	assert.NoError(t, err)
+	_, err = repo.Create(testBooking("Bob", day1), seats(1))
+	assert.ErrorIs(t, err, constants.ErrClassFull)
+	_, err = repo.Create(testBooking("Bob", day2), seats(1))
+	assert.NoError(t, err)
+	// Sessions at different times of the same day have their own seats
+	_, err = repo.Create(testBooking("Carol", day2.Add(7*time.Hour)), seats(1))
+	assert.NoError(t, err)
+	_, err = repo.Create(testBooking("Dave", day2.Add(7*time.Hour)), seats(1))
+	assert.ErrorIs(t, err, constants.ErrClassFull)
+	assert.Equal(t, 1, repo.Count("Yoga", day2))
+	assert.Equal(t, 1, repo.Count("Yoga", day2.Add(7*time.Hour)))
+}
+
+func testBookingDuplicate(t *testing.T, repo BookingRepository) {
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	booking, err := repo.Create(testBooking("Alice", date), seats(10))
+	assert.NoError(t, err)
+	_, err = repo.Create(testBooking("Alice", date), seats(10))
+	assert.ErrorIs(t, err, constants.ErrAlreadyBooked)
+	// A full session reports the duplicate rather than the missing seat
+	_, err = repo.Create(testBooking("Alice", date), seats(1))
+	assert.ErrorIs(t, err, constants.ErrAlreadyBooked)
+
+	// Another member with the same name is a different person
+	namesake := testBooking("Alice", date)
+	namesake.MemberID = "mb_other"
+	_, err = repo.Create(namesake, seats(10))
+	assert.NoError(t, err)
+
+	// Bookings made before members were registered are told apart by name
+	legacy := models.Booking{ClassName: "Yoga", MemberName: "Bob", Date: date}
+	_, err = repo.Create(legacy, seats(10))
+	assert.NoError(t, err)
+	_, err = repo.Create(legacy, seats(10))
+	assert.ErrorIs(t, err, constants.ErrAlreadyBooked)
+
+	// Cancelling frees the member to book again
+	_, err = repo.Cancel(booking.ID, booking.Version, date.Add(-24*time.Hour), false, "")
+	assert.NoError(t, err)
+	_, err = repo.Create(testBooking("Alice", date), seats(10))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, repo.Count("Yoga", date))
+}
+
+func testBookingConcurrentDuplicate(t *testing.T, repo BookingRepository) {
+	const clicks = 50
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	var succeeded, duplicate int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < clicks; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := repo.Create(testBooking("Alice", date), seats(10))
+			switch {
+			case err == nil:
+				atomic.AddInt32(&succeeded, 1)
+			case errors.Is(err, constants.ErrAlreadyBooked):
+				atomic.AddInt32(&duplicate, 1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), succeeded, "Expected exactly one booking to succeed")
+	assert.Equal(t, int32(clicks-1), duplicate)
+	assert.Equal(t, 1, repo.Count("Yoga", date))
+}
+
+func testBookingSpots(t *testing.T, repo BookingRepository) {
+	date := time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC)
+	layout := models.BookingLimits{Capacity: 3, Spots: []string{"1", "2", "3"}}
+
+	requested := testBooking("Alice", date)
+	requested.Spot = "2"
+	alice, err := repo.Create(requested, layout)
+	require.NoError(t, err)
+	assert.Equal(t, "2", alice.Spot)
+	requested = testBooking("Bob", date)
+	requested.Spot = "2"
+	_, err = repo.Create(requested, layout)
+	assert.ErrorIs(t, err, constants.ErrSpotTaken)
+
+	// Bookings without a spot get the first free one
+	bob, err := repo.Create(testBooking("Bob", date), layout)
+	require.NoError(t, err)
+	assert.Equal(t, "1", bob.Spot)
+	stored, _ := repo.GetByID(bob.ID)
+	assert.Equal(t, "1", stored.Spot)
+
+	// Cancelling frees the spot, and the same spot is free in other sessions
+	_, err = repo.Cancel(alice.ID, alice.Version, date.Add(-24*time.Hour), false, "")
+	require.NoError(t, err)
+	requested = testBooking("Carol", date)
+	requested.Spot = "2"
+	_, err = repo.Create(requested, layout)
+	assert.NoError(t, err)
+	requested.Date = date.AddDate(0, 0, 1)
+	_, err = repo.Create(requested, layout)
+	assert.NoError(t, err)
+	_, err = repo.Create(testBooking("Dave", date), layout)
+	assert.NoError(t, err)
+	_, err = repo.Create(testBooking("Erin", date), models.BookingLimits{Capacity: 10, Spots: layout.Spots})
+	assert.ErrorIs(t, err, constants.ErrClassFull)
+}
+
+func testBookingConcurrentSpot(t *testing.T, repo BookingRepository) {
+	const members = 50
+	date := time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC)
+	layout := models.BookingLimits{Capacity: members, Spots: []string{"1", "2", "3"}}
+
+	var succeeded, taken int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < members; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			booking := testBooking(fmt.Sprintf("member-%d", i), date)
+			booking.Spot = "3"
+			_, err := repo.Create(booking, layout)
+			switch {
+			case err == nil:
+				atomic.AddInt32(&succeeded, 1)
+			case errors.Is(err, constants.ErrSpotTaken):
+				atomic.AddInt32(&taken, 1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), succeeded, "Expected exactly one member to get the spot")
+	assert.Equal(t, int32(members-1), taken)
+}
+
+func testBookingSpotConflicts(t *testing.T, repo BookingRepository) {
+	date := time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC)
+	layout := models.BookingLimits{Capacity: 3, Spots: []string{"1", "2", "3"}}
+
+	// A pending booking holds its spot like a booked one
+	requested := testPaidBooking("Alice", date)
+	requested.Spot = "2"
+	_, err := repo.Create(requested, layout)
+	require.NoError(t, err)
+
+	// Another member asking for the spot is told it is taken, the member
+	// holding it is told they are booked whichever spot they ask for
+	requested = testBooking("Bob", date)
+	requested.Spot = "2"
+	_, err = repo.Create(requested, layout)
+	assert.ErrorIs(t, err, constants.ErrSpotTaken)
+	assert.NotErrorIs(t, err, constants.ErrAlreadyBooked)
+	for _, spot := range []string{"2", "3"} {
+		requested = testBooking("Alice", date)
+		requested.Spot = spot
+		_, err = repo.Create(requested, layout)
+		assert.ErrorIs(t, err, constants.ErrAlreadyBooked)
+	}
+	assert.Equal(t, 1, repo.Count("Yoga", date))
+}
+
+// testGrant returns an entry granting credits of a pack to the member
+func testGrant(memberName string, credits int) models.CreditEntry {
+	return models.CreditEntry{
+		ID:           "cr_grant_" + memberName,
+		MemberID:     "mb_" + memberName,
+		MembershipID: "ms_pack",
+		Amount:       credits,
+		Reason:       constants.CreditGrant,
+		CreatedAt:    time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func testBookingCredits(t *testing.T, repo BookingRepository) {
+	date := time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC)
+	pack := models.BookingLimits{Capacity: 10, Charge: &models.Charge{MembershipID: "ms_pack"}}
+
+	_, err := repo.Create(testBooking("Alice", date), pack)
+	assert.ErrorIs(t, err, constants.ErrNoEntitlement)
+	require.NoError(t, repo.AddCredit(testGrant("Alice", 1)))
+	assert.Equal(t, 1, repo.Balance("mb_Alice", *pack.Charge))
+
+	// The booking debits the only credit, so the next one has none left
+	booking, err := repo.Create(testBooking("Alice", date), pack)
+	require.NoError(t, err)
+	assert.Zero(t, repo.Balance("mb_Alice", *pack.Charge))
+	assert.Equal(t, "ms_pack", booking.MembershipID)
+	stored, _ := repo.GetByID(booking.ID)
+	assert.Equal(t, "ms_pack", stored.MembershipID)
+	_, err = repo.Create(testBooking("Alice", date.AddDate(0, 0, 1)), pack)
+	assert.ErrorIs(t, err, constants.ErrNoEntitlement)
+	assert.Equal(t, 1, repo.Count("Yoga", date))
+
+	// Cancelling in time refunds the credit, a late cancel does not
+	_, err = repo.Cancel(booking.ID, booking.Version, date.Add(-24*time.Hour), false, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.Balance("mb_Alice", *pack.Charge))
+	late, err := repo.Create(testBooking("Alice", date.AddDate(0, 0, 1)), pack)
+	require.NoError(t, err)
+	_, err = repo.Cancel(late.ID, late.Version, date, true, "")
+	require.NoError(t, err)
+
+	entries := repo.Credits("mb_Alice")
+	reasons := make([]string, len(entries))
+	balance := 0
+	for i, entry := range entries {
+		reasons[i] = entry.Reason
+		balance += entry.Amount
+	}
+	assert.Equal(t, []string{constants.CreditGrant, constants.CreditBooking, constants.CreditRefund, constants.CreditBooking}, reasons)
+	assert.Zero(t, balance)
+	assert.Equal(t, booking.ID, entries[1].BookingID)
+	require.NotNil(t, entries[1].Session)
+	assert.True(t, date.Equal(*entries[1].Session))
+	assert.Empty(t, repo.Credits("mb_Bob"))
+	assert.Zero(t, repo.Balance("mb_Alice", *pack.Charge))
+	assert.Zero(t, repo.Balance("mb_Bob", *pack.Charge))
+
+	// Bookings without a charge leave the ledger alone
+	_, err = repo.Create(testBooking("Bob", date), seats(10))
+	require.NoError(t, err)
+	assert.Empty(t, repo.Credits("mb_Bob"))
+}
+
+func testBookingWeeklyCredits(t *testing.T, repo BookingRepository) {
+	week := time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC)
+	weekly := func(from time.Time) models.BookingLimits {
+		return models.BookingLimits{Capacity: 10, Charge: &models.Charge{MembershipID: "ms_weekly", Allowance: 1, From: from, To: from.AddDate(0, 0, 7)}}
+	}
+
+	_, err := repo.Create(testBooking("Alice", week.Add(7*time.Hour)), weekly(week))
+	require.NoError(t, err)
+	_, err = repo.Create(testBooking("Alice", week.Add(31*time.Hour)), weekly(week))
+	assert.ErrorIs(t, err, constants.ErrNoEntitlement)
+	assert.Zero(t, repo.Balance("mb_Alice", *weekly(week).Charge))
+
+	// The allowance starts over the next week
+	next := week.AddDate(0, 0, 7)
+	assert.Equal(t, 1, repo.Balance("mb_Alice", *weekly(next).Charge))
+	_, err = repo.Create(testBooking("Alice", next.Add(7*time.Hour)), weekly(next))
+	assert.NoError(t, err)
+	assert.Zero(t, repo.Balance("mb_Alice", *weekly(next).Charge))
+}
+
+func testBookingConcurrentCredit(t *testing.T, repo BookingRepository) {
+	const sessions = 20
+	date := time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC)
+	pack := models.BookingLimits{Capacity: 10, Charge: &models.Charge{MembershipID: "ms_pack"}}
+	require.NoError(t, repo.AddCredit(testGrant("Alice", 3)))
+
+	var succeeded, refused int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < sessions; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, err := repo.Create(testBooking("Alice", date.AddDate(0, 0, i)), pack)
+			switch {
+			case err == nil:
+				atomic.AddInt32(&succeeded, 1)
+			case errors.Is(err, constants.ErrNoEntitlement):
+				atomic.AddInt32(&refused, 1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(3), succeeded, "Expected exactly the granted credits to be spent")
+	assert.Equal(t, int32(sessions-3), refused)
+	assert.Len(t, repo.Credits("mb_Alice"), 4)
+}
+
+func testBookingDailyLimit(t *testing.T, repo BookingRepository) {
+	day := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	book := func(className, memberName string, date time.Time) (models.Booking, error) {
+		booking := testBooking(memberName, date)
+		booking.ClassName = className
+		// The day is the UTC day of the session
+		dayStart := date.Truncate(24 * time.Hour)
+		return repo.Create(booking, models.BookingLimits{Capacity: 10, PerDay: 2, DayStart: dayStart, DayEnd: dayStart.AddDate(0, 0, 1)})
+	}
+
+	_, err := book("Yoga", "Alice", day.Add(7*time.Hour))
+	assert.NoError(t, err)
+	pilates, err := book("Pilates", "Alice", day.Add(18*time.Hour))
+	assert.NoError(t, err)
+	_, err = book("Boxing", "Alice", day.Add(20*time.Hour))
+	assert.ErrorIs(t, err, constants.ErrDailyLimitReached)
+
+	// Other members and other days have their own allowance
+	_, err = book("Boxing", "Bob", day.Add(20*time.Hour))
+	assert.NoError(t, err)
+	_, err = book("Boxing", "Alice", day.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+
+	// Cancelled bookings do not count towards the limit
+	_, err = repo.Cancel(pilates.ID, pilates.Version, day, false, "")
+	assert.NoError(t, err)
+	_, err = book("Boxing", "Alice", day.Add(20*time.Hour))
+	assert.NoError(t, err)
+}
+
+func testBookingCancel(t *testing.T, repo BookingRepository) {
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	cancelledAt := time.Date(2025, 6, 9, 8, 0, 0, 0, time.UTC)
+
+	booking, err := repo.Create(testBooking("Alice", date), seats(1))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, booking.ID)
+	assert.Equal(t, constants.BookingStatusBooked, booking.Status)
+	assert.Equal(t, 1, booking.Version)
+
+	_, err = repo.Cancel(booking.ID, 2, cancelledAt, true, "Instructor unwell")
+	assert.ErrorIs(t, err, constants.ErrVersionMismatch)
+	cancelled, err := repo.Cancel(booking.ID, booking.Version, cancelledAt, true, "Instructor unwell")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cancelled.Version)
+	assert.Equal(t, constants.BookingStatusCancelled, cancelled.Status)
+	assert.True(t, cancelled.LateCancel)
+	assert.Equal(t, cancelledAt, *cancelled.CancelledAt)
+	assert.Equal(t, "Instructor unwell", cancelled.CancelReason)
+
+	// The cancelled booking is kept but no longer holds a seat
+	stored, exists := repo.GetByID(booking.ID)
+	assert.True(t, exists)
+	assert.Equal(t, cancelled, stored)
+	assert.Equal(t, 0, repo.Count("Yoga", date))
+	_, err = repo.Create(testBooking("Bob", date), seats(1))
+	assert.NoError(t, err)
+
+	_, err = repo.Cancel(booking.ID, cancelled.Version, cancelledAt, false, "")
+	assert.ErrorIs(t, err, constants.ErrAlreadyCancelled)
+	_, err = repo.Cancel("missing", 1, cancelledAt, false, "")
+	assert.ErrorIs(t, err, constants.ErrBookingNotFound)
+}
+
+// testPaidBooking returns a new drop-in booking of Yoga for the member with
+// a pending payment
+func testPaidBooking(memberName string, date time.Time) models.Booking {
+	booking := testBooking(memberName, date)
+	booking.Payment = &models.Payment{ID: "pay_" + memberName, Amount: 1500, Currency: "EUR", Method: "pm_card", Status: constants.PaymentPending}
+	return booking
+}
+
+func testBookingPayments(t *testing.T, repo BookingRepository) {
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	paidAt := time.Date(2025, 6, 9, 8, 0, 0, 0, time.UTC)
+	pending := []string{constants.PaymentPending}
+
+	// A paid booking is pending and holds its seat until the payment is authorized
+	booking, err := repo.Create(testPaidBooking("Alice", date), seats(1))
+	require.NoError(t, err)
+	assert.Equal(t, constants.BookingStatusPending, booking.Status)
+	assert.Equal(t, 1, repo.Count("Yoga", date))
+	_, err = repo.Create(testBooking("Bob", date), seats(1))
+	assert.ErrorIs(t, err, constants.ErrClassFull)
+	_, err = repo.Create(testBooking("Alice", date), seats(2))
+	assert.ErrorIs(t, err, constants.ErrAlreadyBooked)
+	assert.Len(t, repo.Query(models.BookingFilter{PaymentStatuses: pending}), 1)
+
+	payment := *booking.Payment
+	payment.Status, payment.AuthorizationID, payment.UpdatedAt = constants.PaymentAuthorized, "au_1", paidAt
+	_, err = repo.UpdatePayment(booking.ID, booking.Version+1, payment)
+	assert.ErrorIs(t, err, constants.ErrVersionMismatch)
+	_, err = repo.UpdatePayment(booking.ID, booking.Version, models.Payment{ID: "pay_other"})
+	assert.ErrorIs(t, err, constants.ErrPaymentNotFound)
+	_, err = repo.UpdatePayment("missing", 1, payment)
+	assert.ErrorIs(t, err, constants.ErrBookingNotFound)
+	booked, err := repo.UpdatePayment(booking.ID, booking.Version, payment)
+	require.NoError(t, err)
+	assert.Equal(t, constants.BookingStatusBooked, booked.Status)
+	assert.Equal(t, 2, booked.Version)
+	assert.Equal(t, payment, *booked.Payment)
+	stored, _ := repo.GetByID(booking.ID)
+	assert.Equal(t, booked, stored)
+	assert.Empty(t, repo.Query(models.BookingFilter{PaymentStatuses: pending}))
+
+	// Cancelling in time marks the payment to be refunded, a late cancellation keeps it
+	cancelled, err := repo.Cancel(booking.ID, booked.Version, paidAt, false, "")
+	require.NoError(t, err)
+	assert.Equal(t, constants.PaymentRefundPending, cancelled.Payment.Status)
+	assert.Len(t, repo.Query(models.BookingFilter{PaymentStatuses: []string{constants.PaymentRefundPending, constants.PaymentAuthorized}}), 1)
+	late, err := repo.Create(testPaidBooking("Bob", date), seats(1))
+	require.NoError(t, err)
+	late, err = repo.Cancel(late.ID, late.Version, paidAt, true, "")
+	require.NoError(t, err)
+	assert.Equal(t, constants.PaymentPending, late.Payment.Status)
+
+	// A declined payment cancels its pending booking and frees the seat
+	declined, err := repo.Create(testPaidBooking("Carol", date), seats(1))
+	require.NoError(t, err)
+	payment = *declined.Payment
+	payment.Status, payment.UpdatedAt = constants.PaymentDeclined, paidAt
+	declined, err = repo.UpdatePayment(declined.ID, declined.Version, payment)
+	require.NoError(t, err)
+	assert.Equal(t, constants.BookingStatusCancelled, declined.Status)
+	assert.Equal(t, constants.CancelReasonPaymentDeclined, declined.CancelReason)
+	assert.Equal(t, paidAt, *declined.CancelledAt)
+	stored, _ = repo.GetByID(declined.ID)
+	assert.Equal(t, declined, stored)
+	assert.Zero(t, repo.Count("Yoga", date))
+	_, err = repo.Create(testBooking("Dan", date), seats(1))
+	assert.NoError(t, err)
+}
+
+func testBookingDeleteByClass(t *testing.T, repo BookingRepository) {
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	cancelledAt := time.Date(2025, 6, 9, 8, 0, 0, 0, time.UTC)
+	booked, err := repo.Create(testBooking("Alice", date), seats(10))
+	require.NoError(t, err)
+	cancelled, err := repo.Create(testBooking("Bob", date), seats(10))
+	require.NoError(t, err)
+	_, err = repo.Cancel(cancelled.ID, cancelled.Version, cancelledAt, false, "")
+	require.NoError(t, err)
+	refund, err := repo.Create(testPaidBooking("Carol", date), seats(10))
+	require.NoError(t, err)
+	refund, err = repo.Cancel(refund.ID, refund.Version, cancelledAt, false, "")
+	require.NoError(t, err)
+	pilates := testBooking("Alice", date)
+	pilates.ClassName = "Pilates"
+	pilates, err = repo.Create(pilates, seats(10))
+	require.NoError(t, err)
+
+	// Bookings with a refund still due are kept, other classes are untouched
+	require.NoError(t, repo.DeleteByClass("Yoga"))
+	assert.Equal(t, []models.Booking{refund}, repo.Query(models.BookingFilter{ClassName: "Yoga"}))
+	assert.Zero(t, repo.Count("Yoga", date))
+	_, exists := repo.GetByID(booked.ID)
+	assert.False(t, exists)
+	assert.Equal(t, []models.Booking{pilates}, repo.Query(models.BookingFilter{ClassName: "Pilates"}))
+	require.NoError(t, repo.DeleteByClass("Boxing"))
+
+	// A class created with the same name starts without bookings
+	_, err = repo.Create(testBooking("Alice", date), seats(1))
+	assert.NoError(t, err)
+}
+
+// testPromoBooking returns a new booking of Yoga for the member redeeming code
+func testPromoBooking(memberName string, date time.Time, code string) models.Booking {
+	booking := testBooking(memberName, date)
+	booking.Promo = &models.Promo{Code: code, Discount: 500, Currency: "EUR"}
+	return booking
+}
+
+func testBookingPromos(t *testing.T, repo BookingRepository) {
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC) }
+	redeem := func(limit models.PromoLimit) models.BookingLimits {
+		limits := seats(10)
+		limits.Promo = &limit
+		return limits
+	}
+	summer := models.PromoLimit{Code: "SUMMER", MaxRedemptions: 2}
+
+	alice, err := repo.Create(testPromoBooking("Alice", day(10), "SUMMER"), redeem(summer))
+	require.NoError(t, err)
+	assert.Equal(t, &models.Promo{Code: "SUMMER", Discount: 500, Currency: "EUR"}, alice.Promo)
+	stored, _ := repo.GetByID(alice.ID)
+	assert.Equal(t, alice, stored)
+
+	// A member redeems a code once, and the code runs out after its redemptions
+	_, err = repo.Create(testPromoBooking("Alice", day(11), "SUMMER"), redeem(summer))
+	assert.ErrorIs(t, err, constants.ErrPromoRedeemed)
+	_, err = repo.Create(testPromoBooking("Bob", day(10), "SUMMER"), redeem(summer))
+	require.NoError(t, err)
+	_, err = repo.Create(testPromoBooking("Carol", day(10), "SUMMER"), redeem(summer))
+	assert.ErrorIs(t, err, constants.ErrPromoExhausted)
+	assert.Len(t, repo.Query(models.BookingFilter{PromoCode: "SUMMER"}), 2)
+
+	// Cancelling a booking gives its redemption back
+	_, err = repo.Cancel(alice.ID, alice.Version, day(9), false, "")
+	require.NoError(t, err)
+	_, err = repo.Create(testPromoBooking("Carol", day(10), "SUMMER"), redeem(summer))
+	assert.NoError(t, err)
+
+	// A first class code is only redeemed by members holding no other booking
+	first := models.PromoLimit{Code: "WELCOME", FirstClass: true}
+	_, err = repo.Create(testPromoBooking("Bob", day(12), "WELCOME"), redeem(first))
+	assert.ErrorIs(t, err, constants.ErrPromoNotApplicable)
+	_, err = repo.Create(testPromoBooking("Alice", day(12), "WELCOME"), redeem(first))
+	assert.NoError(t, err)
+	assert.Len(t, repo.Query(models.BookingFilter{PromoCode: "WELCOME"}), 1)
+}
+
+func testBookingQuery(t *testing.T, repo BookingRepository) {
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC) }
+
+	for _, b := range []struct {
+		className, memberName string
+		date                  time.Time
+	}{
+		{"Yoga", "Alice", day(12)},
+		{"Yoga", "Bob", day(10)},
+		{"Pilates", "Alice", day(10)},
+		{"Yoga", "Alice", day(10)},
+		{"Yoga", "Alice", day(25)},
+	} {
+		booking := testBooking(b.memberName, b.date)
+		booking.ClassName = b.className
+		_, err := repo.Create(booking, seats(10))
+		assert.NoError(t, err)
+	}
+
+	// Results are sorted by date, then class name, then ID
+	all := repo.Query(models.BookingFilter{})
+	assert.Len(t, all, 5)
+	for i := 1; i < len(all); i++ {
+		assert.True(t, bookingBefore(bookingCursor(all[i-1]), bookingCursor(all[i])), "bookings out of order at %d", i)
+	}
+	assert.Equal(t, "Pilates", all[0].ClassName)
+
+	filtered := repo.Query(models.BookingFilter{MemberName: "Alice", ClassName: "Yoga", From: day(1), To: day(20)})
+	assert.Len(t, filtered, 2)
+	assert.Equal(t, day(10), filtered[0].Date)
+	assert.Equal(t, day(12), filtered[1].Date)
+	assert.Len(t, repo.Query(models.BookingFilter{MemberID: "mb_Bob"}), 1)
+
+	// Walking the pages with a cursor returns every booking exactly once
+	var paged []models.Booking
+	filter := models.BookingFilter{Limit: 2}
+	for {
+		page := repo.Query(filter)
+		if len(page) == 0 {
+			break
+		}
+		paged = append(paged, page...)
+		cursor := bookingCursor(page[len(page)-1])
+		filter.After = &cursor
+	}
+	assert.Equal(t, all, paged)
+}
@@ -3,12 +3,19 @@ package repository
 import (
 	"glofox/internal/constants"
 	"glofox/internal/models"
+	"sort"
 	"sync"
 )
 
 type ClassRepository interface {
-	Create(class models.Class) error
+	Create(class models.Class) (models.Class, error)
 	GetByName(name string) (models.Class, bool)
+	List(afterName string, limit int) []models.Class
+	// Update replaces the class when its stored version is class.Version and
+	// returns it with the next version
+	Update(class models.Class) (models.Class, error)
+	// Delete removes the class when its stored version is version
+	Delete(name string, version int) error
 }
 
 // ClassRepo manages the in-memory class data
@@ -24,16 +31,17 @@ func NewClassRepo() *ClassRepo {
 	}
 }
 
-// Create for creating a new class
-func (classRepo *ClassRepo) Create(class models.Class) error {
+// Create for creating a new class, the class starts at version 1
+func (classRepo *ClassRepo) Create(class models.Class) (models.Class, error) {
 	classRepo.mu.Lock()
 	defer classRepo.mu.Unlock()
 
-	if _, exists := classRepo.classes[class.Name]; exists {
-		return constants.ErrClassAlreadyExists
+	class, err := classRepo.checkCreate(class)
+	if err != nil {
+		return models.Class{}, err
 	}
 	classRepo.classes[class.Name] = class
-	return nil
+	return class, nil
 }
 
 // GetByName fetches class by given name
@@ -44,3 +52,110 @@ func (classRepo *ClassRepo) GetByName(name string) (models.Class, bool) {
 	class, exists := classRepo.classes[name]
 	return class, exists
 }
+
+// List returns up to limit classes sorted by name, starting after afterName
+func (classRepo *ClassRepo) List(afterName string, limit int) []models.Class {
+	classRepo.mu.RLock()
+	defer classRepo.mu.RUnlock()
+
+	classes := make([]models.Class, 0, len(classRepo.classes))
+	for name, class := range classRepo.classes {
+		if name > afterName {
+			classes = append(classes, class)
+		}
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		return classes[i].Name < classes[j].Name
+	})
+	if len(classes) > limit {
+		classes = classes[:limit]
+	}
+	return classes
+}
+
+// Update replaces an existing class, the version check and the write happen
+// under the same lock so concurrent updates cannot overwrite each other
+func (classRepo *ClassRepo) Update(class models.Class) (models.Class, error) {
+	classRepo.mu.Lock()
+	defer classRepo.mu.Unlock()
+
+	class, err := classRepo.checkUpdate(class)
+	if err != nil {
+		return models.Class{}, err
+	}
+	classRepo.classes[class.Name] = class
+	return class, nil
+}
+
+// Delete removes a class
+func (classRepo *ClassRepo) Delete(name string, version int) error {
+	classRepo.mu.Lock()
+	defer classRepo.mu.Unlock()
+
+	if err := classRepo.checkDelete(name, version); err != nil {
+		return err
+	}
+	delete(classRepo.classes, name)
+	return nil
+}
+
+// checkCreate returns a new class as Create stores it, the caller must hold the lock
+func (classRepo *ClassRepo) checkCreate(class models.Class) (models.Class, error) {
+	if _, exists := classRepo.classes[class.Name]; exists {
+		return models.Class{}, constants.ErrClassAlreadyExists
+	}
+	class.Version = 1
+	return class, nil
+}
+
+// checkUpdate returns a class as Update stores it, the caller must hold the lock
+func (classRepo *ClassRepo) checkUpdate(class models.Class) (models.Class, error) {
+	if err := classRepo.checkDelete(class.Name, class.Version); err != nil {
+		return models.Class{}, err
+	}
+	class.Version++
+	return class, nil
+}
+
+// checkDelete checks that the stored version of a class is version, the
+// caller must hold the lock
+func (classRepo *ClassRepo) checkDelete(name string, version int) error {
+	stored, exists := classRepo.classes[name]
+	if !exists {
+		return constants.ErrClassNotFound
+	}
+	if stored.Version != version {
+		return constants.ErrVersionMismatch
+	}
+	return nil
+}
+
+// put inserts or replaces a class without validation, used to restore persisted state
+func (classRepo *ClassRepo) put(class models.Class) {
+	classRepo.mu.Lock()
+	defer classRepo.mu.Unlock()
+
+	// Classes persisted before versions existed start at version 1
+	class.Version = max(class.Version, 1)
+	classRepo.classes[class.Name] = class
+}
+
+// remove deletes a class without validation, used to replay deletions
+func (classRepo *ClassRepo) remove(name string) {
+	classRepo.mu.Lock()
+	defer classRepo.mu.Unlock()
+
+	delete(classRepo.classes, name)
+}
+
+// all returns every class
+func (classRepo *ClassRepo) all() []models.Class {
+	classRepo.mu.RLock()
+	defer classRepo.mu.RUnlock()
+
+	classes := make([]models.Class, 0, len(classRepo.classes))
+	for _, class := range classRepo.classes {
+		classes = append(classes, class)
+	}
+	return classes
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassRepo(t *testing.T) {
+	testClassRepository(t, func(t *testing.T) ClassRepository {
+		return NewClassRepo()
+	})
+}
+
+// testClassRepository runs the ClassRepository test suite against the
+// implementation returned by newRepo
+func testClassRepository(t *testing.T, newRepo func(t *testing.T) ClassRepository) {
+	t.Run("CreateAndGet", func(t *testing.T) { testClassCreateAndGet(t, newRepo(t)) })
+	t.Run("List", func(t *testing.T) { testClassList(t, newRepo(t)) })
+	t.Run("UpdateAndDelete", func(t *testing.T) { testClassUpdateAndDelete(t, newRepo(t)) })
+	t.Run("ConcurrentUpdate", func(t *testing.T) { testClassConcurrentUpdate(t, newRepo(t)) })
+}
+
+// testClass returns a fully populated class
+func testClass() models.Class {
+	return models.Class{
+		Name:               "Yoga",
+		StartDate:          time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:            time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC),
+		Capacity:           10,
+		CancellationPolicy: models.CancellationPolicy{FreeCancelHours: 12, AllowLateCancel: true},
+		Recurrence: models.Recurrence{
+			Frequency:  "weekly",
+			Interval:   1,
+			Weekdays:   []string{"MO", "WE", "FR"},
+			Exclusions: []time.Time{time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC)},
+		},
+		TimeZone:     "Europe/Dublin",
+		SessionTimes: []models.SessionTime{{Start: "07:00", DurationMinutes: 60}, {Start: "18:00", DurationMinutes: 45}},
+		Description:  "Vinyasa flow for all levels",
+		InstructorID: "in_1",
+		Substitutions: []models.Substitution{
+			{Session: time.Date(2025, 6, 11, 6, 0, 0, 0, time.UTC), InstructorID: "in_2"},
+		},
+		RoomID:   "rm_1",
+		Resource: "Mats",
+		DropIn:   &models.Price{Amount: 1500, Currency: "EUR"},
+	}
+}
+
+func testClassCreateAndGet(t *testing.T, repo ClassRepository) {
+	class := testClass()
+	created, err := repo.Create(class)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, created.Version)
+	_, err = repo.Create(class)
+	assert.ErrorIs(t, err, constants.ErrClassAlreadyExists)
+
+	stored, exists := repo.GetByName("Yoga")
+	assert.True(t, exists)
+	assert.Equal(t, created, stored)
+	_, exists = repo.GetByName("Boxing")
+	assert.False(t, exists)
+}
+
+func testClassList(t *testing.T, repo ClassRepository) {
+	for _, name := range []string{"Yoga", "Boxing", "Pilates"} {
+		_, err := repo.Create(models.Class{Name: name, Capacity: 10})
+		assert.NoError(t, err)
+	}
+	_, err := repo.Create(models.Class{Name: "Yoga"})
+	assert.ErrorIs(t, err, constants.ErrClassAlreadyExists)
+
+	classes := repo.List("", 2)
+	assert.Equal(t, []string{"Boxing", "Pilates"}, classNames(classes))
+	classes = repo.List("Pilates", 2)
+	assert.Equal(t, []string{"Yoga"}, classNames(classes))
+	assert.Empty(t, repo.List("Yoga", 2))
+}
+
+func testClassUpdateAndDelete(t *testing.T, repo ClassRepository) {
+	class, err := repo.Create(testClass())
+	assert.NoError(t, err)
+
+	class.Capacity = 8
+	class.EndDate = time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+	class.Description = "Slow flow"
+	class.InstructorID = "in_2"
+	updated, err := repo.Update(class)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+	stored, _ := repo.GetByName("Yoga")
+	assert.Equal(t, updated, stored)
+	_, err = repo.Update(models.Class{Name: "Boxing", Version: 1})
+	assert.ErrorIs(t, err, constants.ErrClassNotFound)
+
+	// Writes based on an old version are rejected
+	class.Capacity = 4
+	_, err = repo.Update(class)
+	assert.ErrorIs(t, err, constants.ErrVersionMismatch)
+	assert.ErrorIs(t, repo.Delete("Yoga", 1), constants.ErrVersionMismatch)
+	stored, _ = repo.GetByName("Yoga")
+	assert.Equal(t, updated, stored)
+
+	assert.NoError(t, repo.Delete("Yoga", 2))
+	_, exists := repo.GetByName("Yoga")
+	assert.False(t, exists)
+	assert.ErrorIs(t, repo.Delete("Yoga", 2), constants.ErrClassNotFound)
+	// The name can be used again
+	_, err = repo.Create(testClass())
+	assert.NoError(t, err)
+}
+
+func testClassConcurrentUpdate(t *testing.T, repo ClassRepository) {
+	const writers = 20
+	class, err := repo.Create(testClass())
+	assert.NoError(t, err)
+
+	var updatedCount int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(capacity int) {
+			defer wg.Done()
+			<-start
+			change := class
+			change.Capacity = capacity
+			_, err := repo.Update(change)
+			if err == nil {
+				atomic.AddInt32(&updatedCount, 1)
+				return
+			}
+			assert.ErrorIs(t, err, constants.ErrVersionMismatch)
+		}(i + 1)
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), updatedCount, "Expected exactly one writer to update the class")
+	stored, _ := repo.GetByName("Yoga")
+	assert.Equal(t, 2, stored.Version)
+}
+
+// classNames returns the names of the classes in order
+func classNames(classes []models.Class) []string {
+	names := make([]string, 0, len(classes))
+	for _, class := range classes {
+		names = append(names, class.Name)
+	}
+	return names
+}
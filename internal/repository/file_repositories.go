@@ -0,0 +1,1117 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"log"
+	"sync"
+	"time"
+)
+
+// Collections and ops of the file-backed repositories in the write-ahead log
+const (
+	collectionClasses     = "classes"
+	collectionBookings    = "bookings"
+	collectionWaitlists   = "waitlists"
+	collectionMembers     = "members"
+	collectionInstructors = "instructors"
+	collectionRooms       = "rooms"
+	collectionPlans       = "plans"
+	collectionPromos      = "promos"
+	collectionIdempotency = "idempotency"
+
+	opPut    = "put"
+	opDelete = "delete"
+	// opBook logs a booking with the credit it debited or refunded
+	opBook = "book"
+	// opCredit logs an entry of the credit ledger
+	opCredit = "credit"
+)
+
+// FileClassRepo is a ClassRepo whose mutations are persisted in a FileStore
+type FileClassRepo struct {
+	*ClassRepo
+	store *FileStore
+	// collection is the collection of the studio of the repository
+	collection string
+	// mu orders mutations with their log records
+	mu sync.Mutex
+}
+
+// NewFileClassRepo creates the FileClassRepo of a studio and restores its classes from the store
+func NewFileClassRepo(store *FileStore, studioID string) (*FileClassRepo, error) {
+	classRepo := &FileClassRepo{ClassRepo: NewClassRepo(), store: store, collection: studioCollection(studioID, collectionClasses)}
+	if err := store.register(classRepo.collection, classRepo); err != nil {
+		return nil, err
+	}
+	return classRepo, nil
+}
+
+// Create for creating a new class
+func (classRepo *FileClassRepo) Create(class models.Class) (models.Class, error) {
+	classRepo.mu.Lock()
+	defer classRepo.mu.Unlock()
+
+	classRepo.ClassRepo.mu.RLock()
+	class, err := classRepo.ClassRepo.checkCreate(class)
+	classRepo.ClassRepo.mu.RUnlock()
+	if err != nil {
+		return models.Class{}, err
+	}
+	if err := classRepo.store.append(classRepo.collection, opPut, class); err != nil {
+		return models.Class{}, persistErr(err)
+	}
+	classRepo.ClassRepo.put(class)
+	return class, nil
+}
+
+// Update replaces an existing class
+func (classRepo *FileClassRepo) Update(class models.Class) (models.Class, error) {
+	classRepo.mu.Lock()
+	defer classRepo.mu.Unlock()
+
+	classRepo.ClassRepo.mu.RLock()
+	class, err := classRepo.ClassRepo.checkUpdate(class)
+	classRepo.ClassRepo.mu.RUnlock()
+	if err != nil {
+		return models.Class{}, err
+	}
+	if err := classRepo.store.append(classRepo.collection, opPut, class); err != nil {
+		return models.Class{}, persistErr(err)
+	}
+	classRepo.ClassRepo.put(class)
+	return class, nil
+}
+
+// Delete removes a class
+func (classRepo *FileClassRepo) Delete(name string, version int) error {
+	classRepo.mu.Lock()
+	defer classRepo.mu.Unlock()
+
+	classRepo.ClassRepo.mu.RLock()
+	err := classRepo.ClassRepo.checkDelete(name, version)
+	classRepo.ClassRepo.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := classRepo.store.append(classRepo.collection, opDelete, name); err != nil {
+		return persistErr(err)
+	}
+	classRepo.ClassRepo.remove(name)
+	return nil
+}
+
+func (classRepo *FileClassRepo) lock()   { classRepo.mu.Lock() }
+func (classRepo *FileClassRepo) unlock() { classRepo.mu.Unlock() }
+
+func (classRepo *FileClassRepo) snapshot() (json.RawMessage, error) {
+	return json.Marshal(classRepo.ClassRepo.all())
+}
+
+func (classRepo *FileClassRepo) replay(op string, data json.RawMessage) error {
+	switch op {
+	case opSnapshot:
+		var classes []models.Class
+		if err := json.Unmarshal(data, &classes); err != nil {
+			return err
+		}
+		for _, class := range classes {
+			classRepo.ClassRepo.put(class)
+		}
+	case opPut:
+		var class models.Class
+		if err := json.Unmarshal(data, &class); err != nil {
+			return err
+		}
+		classRepo.ClassRepo.put(class)
+	case opDelete:
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return err
+		}
+		classRepo.ClassRepo.remove(name)
+	default:
+		return fmt.Errorf("unknown op %q", op)
+	}
+	return nil
+}
+
+// FileBookingRepo is a BookingRepo whose mutations are persisted in a FileStore
+type FileBookingRepo struct {
+	*BookingRepo
+	store *FileStore
+	// collection is the collection of the studio of the repository
+	collection string
+	// mu orders mutations with their log records
+	mu sync.Mutex
+}
+
+// NewFileBookingRepo creates the FileBookingRepo of a studio and restores its bookings from the store
+func NewFileBookingRepo(store *FileStore, studioID string) (*FileBookingRepo, error) {
+	bookingRepo := &FileBookingRepo{BookingRepo: NewBookingRepo(), store: store, collection: studioCollection(studioID, collectionBookings)}
+	if err := store.register(bookingRepo.collection, bookingRepo); err != nil {
+		return nil, err
+	}
+	return bookingRepo, nil
+}
+
+// Create for creating a new booking, the booking and the credit it debits
+// are persisted in one record
+func (bookingRepo *FileBookingRepo) Create(booking models.Booking, limits models.BookingLimits) (models.Booking, error) {
+	bookingRepo.mu.Lock()
+	defer bookingRepo.mu.Unlock()
+
+	bookingRepo.BookingRepo.mu.RLock()
+	booking, debit, err := bookingRepo.BookingRepo.checkCreate(booking, limits)
+	bookingRepo.BookingRepo.mu.RUnlock()
+	if err != nil {
+		return models.Booking{}, err
+	}
+	if err := bookingRepo.store.append(bookingRepo.collection, opBook, bookingRecord{Booking: booking, Credit: debit}); err != nil {
+		return models.Booking{}, persistErr(err)
+	}
+	bookingRepo.BookingRepo.record(booking, debit)
+	return booking, nil
+}
+
+// Cancel marks a booking as cancelled and frees its seat, the booking and
+// the credit it refunds are persisted in one record
+func (bookingRepo *FileBookingRepo) Cancel(id string, version int, cancelledAt time.Time, lateCancel bool, reason string) (models.Booking, error) {
+	bookingRepo.mu.Lock()
+	defer bookingRepo.mu.Unlock()
+
+	bookingRepo.BookingRepo.mu.RLock()
+	booking, refund, err := bookingRepo.BookingRepo.checkCancel(id, version, cancelledAt, lateCancel, reason)
+	bookingRepo.BookingRepo.mu.RUnlock()
+	if err != nil {
+		return models.Booking{}, err
+	}
+	if err := bookingRepo.store.append(bookingRepo.collection, opBook, bookingRecord{Booking: booking, Credit: refund}); err != nil {
+		return models.Booking{}, persistErr(err)
+	}
+	bookingRepo.BookingRepo.record(booking, refund)
+	return booking, nil
+}
+
+// UpdatePayment stores the payment of a booking, see BookingRepo.UpdatePayment
+func (bookingRepo *FileBookingRepo) UpdatePayment(id string, version int, payment models.Payment) (models.Booking, error) {
+	bookingRepo.mu.Lock()
+	defer bookingRepo.mu.Unlock()
+
+	bookingRepo.BookingRepo.mu.RLock()
+	booking, err := bookingRepo.BookingRepo.checkPayment(id, version, payment)
+	bookingRepo.BookingRepo.mu.RUnlock()
+	if err != nil {
+		return models.Booking{}, err
+	}
+	if err := bookingRepo.store.append(bookingRepo.collection, opBook, bookingRecord{Booking: booking}); err != nil {
+		return models.Booking{}, persistErr(err)
+	}
+	bookingRepo.BookingRepo.record(booking, nil)
+	return booking, nil
+}
+
+// AddCredit appends an entry to the credit ledger of a member
+func (bookingRepo *FileBookingRepo) AddCredit(entry models.CreditEntry) error {
+	bookingRepo.mu.Lock()
+	defer bookingRepo.mu.Unlock()
+
+	if err := bookingRepo.store.append(bookingRepo.collection, opCredit, entry); err != nil {
+		return persistErr(err)
+	}
+	return bookingRepo.BookingRepo.AddCredit(entry)
+}
+
+// DeleteByClass removes the bookings of a deleted class, see BookingRepo.DeleteByClass
+func (bookingRepo *FileBookingRepo) DeleteByClass(className string) error {
+	bookingRepo.mu.Lock()
+	defer bookingRepo.mu.Unlock()
+
+	if err := bookingRepo.store.append(bookingRepo.collection, opDelete, className); err != nil {
+		return persistErr(err)
+	}
+	return bookingRepo.BookingRepo.DeleteByClass(className)
+}
+
+func (bookingRepo *FileBookingRepo) lock()   { bookingRepo.mu.Lock() }
+func (bookingRepo *FileBookingRepo) unlock() { bookingRepo.mu.Unlock() }
+
+// bookingRecord is a booking logged with the credit it debited or refunded
+type bookingRecord struct {
+	Booking models.Booking      `json:"booking"`
+	Credit  *models.CreditEntry `json:"credit,omitempty"`
+}
+
+// bookingSnapshot is the snapshot of the bookings and credit ledger of a studio
+type bookingSnapshot struct {
+	Bookings []models.Booking     `json:"bookings"`
+	Credits  []models.CreditEntry `json:"credits"`
+}
+
+func (bookingRepo *FileBookingRepo) snapshot() (json.RawMessage, error) {
+	return json.Marshal(bookingSnapshot{Bookings: bookingRepo.BookingRepo.all(), Credits: bookingRepo.BookingRepo.allCredits()})
+}
+
+func (bookingRepo *FileBookingRepo) replay(op string, data json.RawMessage) error {
+	switch op {
+	case opSnapshot:
+		var snapshot bookingSnapshot
+		// Snapshots taken before the credit ledger existed hold only bookings
+		if err := json.Unmarshal(data, &snapshot.Bookings); err != nil {
+			if err := json.Unmarshal(data, &snapshot); err != nil {
+				return err
+			}
+		}
+		for _, booking := range snapshot.Bookings {
+			bookingRepo.BookingRepo.record(booking, nil)
+		}
+		for _, entry := range snapshot.Credits {
+			bookingRepo.BookingRepo.AddCredit(entry)
+		}
+	case opPut:
+		var booking models.Booking
+		if err := json.Unmarshal(data, &booking); err != nil {
+			return err
+		}
+		bookingRepo.BookingRepo.record(booking, nil)
+	case opBook:
+		var record bookingRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		bookingRepo.BookingRepo.record(record.Booking, record.Credit)
+	case opCredit:
+		var entry models.CreditEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return err
+		}
+		bookingRepo.BookingRepo.AddCredit(entry)
+	case opDelete:
+		var className string
+		if err := json.Unmarshal(data, &className); err != nil {
+			return err
+		}
+		bookingRepo.BookingRepo.DeleteByClass(className)
+	default:
+		return fmt.Errorf("unknown op %q", op)
+	}
+	return nil
+}
+
+// FileWaitlistRepo is a WaitlistRepo whose mutations are persisted in a FileStore
+type FileWaitlistRepo struct {
+	*WaitlistRepo
+	store *FileStore
+	// collection is the collection of the studio of the repository
+	collection string
+	// mu orders mutations with their log records
+	mu sync.Mutex
+}
+
+// NewFileWaitlistRepo creates the FileWaitlistRepo of a studio and restores its waitlists from the store
+func NewFileWaitlistRepo(store *FileStore, studioID string) (*FileWaitlistRepo, error) {
+	waitlistRepo := &FileWaitlistRepo{WaitlistRepo: NewWaitlistRepo(), store: store, collection: studioCollection(studioID, collectionWaitlists)}
+	if err := store.register(waitlistRepo.collection, waitlistRepo); err != nil {
+		return nil, err
+	}
+	return waitlistRepo, nil
+}
+
+// Join appends a member to the waitlist and returns their 1-based position
+func (waitlistRepo *FileWaitlistRepo) Join(className, memberName string, date time.Time) (int, error) {
+	waitlistRepo.mu.Lock()
+	defer waitlistRepo.mu.Unlock()
+
+	waitlistRepo.WaitlistRepo.mu.RLock()
+	entry, err := waitlistRepo.WaitlistRepo.joined(className, memberName, date)
+	waitlistRepo.WaitlistRepo.mu.RUnlock()
+	if err != nil {
+		return 0, err
+	}
+	if err := waitlistRepo.persist(entry); err != nil {
+		return 0, err
+	}
+	return len(entry.Members), nil
+}
+
+// Leave removes a member from the waitlist
+func (waitlistRepo *FileWaitlistRepo) Leave(className, memberName string, date time.Time) error {
+	waitlistRepo.mu.Lock()
+	defer waitlistRepo.mu.Unlock()
+
+	waitlistRepo.WaitlistRepo.mu.RLock()
+	entry, err := waitlistRepo.WaitlistRepo.left(className, memberName, date)
+	waitlistRepo.WaitlistRepo.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return waitlistRepo.persist(entry)
+}
+
+// persist logs the new waitlist of a session, then applies it
+func (waitlistRepo *FileWaitlistRepo) persist(entry waitlistEntry) error {
+	if err := waitlistRepo.store.append(waitlistRepo.collection, opPut, entry); err != nil {
+		return persistErr(err)
+	}
+	waitlistRepo.WaitlistRepo.restore(entry)
+	return nil
+}
+
+func (waitlistRepo *FileWaitlistRepo) lock()   { waitlistRepo.mu.Lock() }
+func (waitlistRepo *FileWaitlistRepo) unlock() { waitlistRepo.mu.Unlock() }
+
+func (waitlistRepo *FileWaitlistRepo) snapshot() (json.RawMessage, error) {
+	return json.Marshal(waitlistRepo.WaitlistRepo.entries())
+}
+
+func (waitlistRepo *FileWaitlistRepo) replay(op string, data json.RawMessage) error {
+	switch op {
+	case opSnapshot:
+		var entries []waitlistEntry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			waitlistRepo.WaitlistRepo.restore(entry)
+		}
+	case opPut:
+		var entry waitlistEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return err
+		}
+		waitlistRepo.WaitlistRepo.restore(entry)
+	default:
+		return fmt.Errorf("unknown op %q", op)
+	}
+	return nil
+}
+
+// FileMemberRepo is a MemberRepo whose mutations are persisted in a FileStore
+type FileMemberRepo struct {
+	*MemberRepo
+	store *FileStore
+	// collection is the collection of the studio of the repository
+	collection string
+	// mu orders mutations with their log records
+	mu sync.Mutex
+}
+
+// NewFileMemberRepo creates the FileMemberRepo of a studio and restores its members from the store
+func NewFileMemberRepo(store *FileStore, studioID string) (*FileMemberRepo, error) {
+	memberRepo := &FileMemberRepo{MemberRepo: NewMemberRepo(), store: store, collection: studioCollection(studioID, collectionMembers)}
+	if err := store.register(memberRepo.collection, memberRepo); err != nil {
+		return nil, err
+	}
+	return memberRepo, nil
+}
+
+// Create for creating a new member
+func (memberRepo *FileMemberRepo) Create(member models.Member) (models.Member, error) {
+	memberRepo.mu.Lock()
+	defer memberRepo.mu.Unlock()
+
+	member = newMember(member)
+	if err := memberRepo.store.append(memberRepo.collection, opPut, member); err != nil {
+		return models.Member{}, persistErr(err)
+	}
+	memberRepo.MemberRepo.put(member)
+	return member, nil
+}
+
+// Update replaces an existing member
+func (memberRepo *FileMemberRepo) Update(member models.Member) (models.Member, error) {
+	memberRepo.mu.Lock()
+	defer memberRepo.mu.Unlock()
+
+	memberRepo.MemberRepo.mu.RLock()
+	member, err := memberRepo.MemberRepo.checkUpdate(member)
+	memberRepo.MemberRepo.mu.RUnlock()
+	if err != nil {
+		return models.Member{}, err
+	}
+	if err := memberRepo.store.append(memberRepo.collection, opPut, member); err != nil {
+		return models.Member{}, persistErr(err)
+	}
+	memberRepo.MemberRepo.put(member)
+	return member, nil
+}
+
+// Delete removes a member
+func (memberRepo *FileMemberRepo) Delete(id string) error {
+	memberRepo.mu.Lock()
+	defer memberRepo.mu.Unlock()
+
+	memberRepo.MemberRepo.mu.RLock()
+	err := memberRepo.MemberRepo.found(id)
+	memberRepo.MemberRepo.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := memberRepo.store.append(memberRepo.collection, opDelete, id); err != nil {
+		return persistErr(err)
+	}
+	memberRepo.MemberRepo.remove(id)
+	return nil
+}
+
+func (memberRepo *FileMemberRepo) lock()   { memberRepo.mu.Lock() }
+func (memberRepo *FileMemberRepo) unlock() { memberRepo.mu.Unlock() }
+
+func (memberRepo *FileMemberRepo) snapshot() (json.RawMessage, error) {
+	return json.Marshal(memberRepo.MemberRepo.all())
+}
+
+func (memberRepo *FileMemberRepo) replay(op string, data json.RawMessage) error {
+	switch op {
+	case opSnapshot:
+		var members []models.Member
+		if err := json.Unmarshal(data, &members); err != nil {
+			return err
+		}
+		for _, member := range members {
+			memberRepo.MemberRepo.put(member)
+		}
+	case opPut:
+		var member models.Member
+		if err := json.Unmarshal(data, &member); err != nil {
+			return err
+		}
+		memberRepo.MemberRepo.put(member)
+	case opDelete:
+		var id string
+		if err := json.Unmarshal(data, &id); err != nil {
+			return err
+		}
+		memberRepo.MemberRepo.remove(id)
+	default:
+		return fmt.Errorf("unknown op %q", op)
+	}
+	return nil
+}
+
+// FileInstructorRepo is an InstructorRepo whose mutations are persisted in a FileStore
+type FileInstructorRepo struct {
+	*InstructorRepo
+	store *FileStore
+	// collection is the collection of the studio of the repository
+	collection string
+	// mu orders mutations with their log records
+	mu sync.Mutex
+}
+
+// NewFileInstructorRepo creates the FileInstructorRepo of a studio and restores its instructors from the store
+func NewFileInstructorRepo(store *FileStore, studioID string) (*FileInstructorRepo, error) {
+	instructorRepo := &FileInstructorRepo{InstructorRepo: NewInstructorRepo(), store: store, collection: studioCollection(studioID, collectionInstructors)}
+	if err := store.register(instructorRepo.collection, instructorRepo); err != nil {
+		return nil, err
+	}
+	return instructorRepo, nil
+}
+
+// Create for creating a new instructor
+func (instructorRepo *FileInstructorRepo) Create(instructor models.Instructor) error {
+	instructorRepo.mu.Lock()
+	defer instructorRepo.mu.Unlock()
+
+	if err := instructorRepo.store.append(instructorRepo.collection, opPut, instructor); err != nil {
+		return persistErr(err)
+	}
+	instructorRepo.InstructorRepo.put(instructor)
+	return nil
+}
+
+// Update replaces an existing instructor
+func (instructorRepo *FileInstructorRepo) Update(instructor models.Instructor) error {
+	instructorRepo.mu.Lock()
+	defer instructorRepo.mu.Unlock()
+
+	instructorRepo.InstructorRepo.mu.RLock()
+	err := instructorRepo.InstructorRepo.found(instructor.ID)
+	instructorRepo.InstructorRepo.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := instructorRepo.store.append(instructorRepo.collection, opPut, instructor); err != nil {
+		return persistErr(err)
+	}
+	instructorRepo.InstructorRepo.put(instructor)
+	return nil
+}
+
+// Delete removes an instructor
+func (instructorRepo *FileInstructorRepo) Delete(id string) error {
+	instructorRepo.mu.Lock()
+	defer instructorRepo.mu.Unlock()
+
+	instructorRepo.InstructorRepo.mu.RLock()
+	err := instructorRepo.InstructorRepo.found(id)
+	instructorRepo.InstructorRepo.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := instructorRepo.store.append(instructorRepo.collection, opDelete, id); err != nil {
+		return persistErr(err)
+	}
+	instructorRepo.InstructorRepo.remove(id)
+	return nil
+}
+
+func (instructorRepo *FileInstructorRepo) lock()   { instructorRepo.mu.Lock() }
+func (instructorRepo *FileInstructorRepo) unlock() { instructorRepo.mu.Unlock() }
+
+func (instructorRepo *FileInstructorRepo) snapshot() (json.RawMessage, error) {
+	return json.Marshal(instructorRepo.InstructorRepo.all())
+}
+
+func (instructorRepo *FileInstructorRepo) replay(op string, data json.RawMessage) error {
+	switch op {
+	case opSnapshot:
+		var instructors []models.Instructor
+		if err := json.Unmarshal(data, &instructors); err != nil {
+			return err
+		}
+		for _, instructor := range instructors {
+			instructorRepo.InstructorRepo.put(instructor)
+		}
+	case opPut:
+		var instructor models.Instructor
+		if err := json.Unmarshal(data, &instructor); err != nil {
+			return err
+		}
+		instructorRepo.InstructorRepo.put(instructor)
+	case opDelete:
+		var id string
+		if err := json.Unmarshal(data, &id); err != nil {
+			return err
+		}
+		instructorRepo.InstructorRepo.remove(id)
+	default:
+		return fmt.Errorf("unknown op %q", op)
+	}
+	return nil
+}
+
+// FileRoomRepo is a RoomRepo whose mutations are persisted in a FileStore
+type FileRoomRepo struct {
+	*RoomRepo
+	store *FileStore
+	// collection is the collection of the studio of the repository
+	collection string
+	// mu orders mutations with their log records
+	mu sync.Mutex
+}
+
+// NewFileRoomRepo creates the FileRoomRepo of a studio and restores its rooms from the store
+func NewFileRoomRepo(store *FileStore, studioID string) (*FileRoomRepo, error) {
+	roomRepo := &FileRoomRepo{RoomRepo: NewRoomRepo(), store: store, collection: studioCollection(studioID, collectionRooms)}
+	if err := store.register(roomRepo.collection, roomRepo); err != nil {
+		return nil, err
+	}
+	return roomRepo, nil
+}
+
+// Create for creating a new room
+func (roomRepo *FileRoomRepo) Create(room models.Room) error {
+	roomRepo.mu.Lock()
+	defer roomRepo.mu.Unlock()
+
+	if err := roomRepo.store.append(roomRepo.collection, opPut, room); err != nil {
+		return persistErr(err)
+	}
+	roomRepo.RoomRepo.put(room)
+	return nil
+}
+
+// Update replaces an existing room
+func (roomRepo *FileRoomRepo) Update(room models.Room) error {
+	roomRepo.mu.Lock()
+	defer roomRepo.mu.Unlock()
+
+	roomRepo.RoomRepo.mu.RLock()
+	err := roomRepo.RoomRepo.found(room.ID)
+	roomRepo.RoomRepo.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := roomRepo.store.append(roomRepo.collection, opPut, room); err != nil {
+		return persistErr(err)
+	}
+	roomRepo.RoomRepo.put(room)
+	return nil
+}
+
+// Delete removes a room
+func (roomRepo *FileRoomRepo) Delete(id string) error {
+	roomRepo.mu.Lock()
+	defer roomRepo.mu.Unlock()
+
+	roomRepo.RoomRepo.mu.RLock()
+	err := roomRepo.RoomRepo.found(id)
+	roomRepo.RoomRepo.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := roomRepo.store.append(roomRepo.collection, opDelete, id); err != nil {
+		return persistErr(err)
+	}
+	roomRepo.RoomRepo.remove(id)
+	return nil
+}
+
+func (roomRepo *FileRoomRepo) lock()   { roomRepo.mu.Lock() }
+func (roomRepo *FileRoomRepo) unlock() { roomRepo.mu.Unlock() }
+
+func (roomRepo *FileRoomRepo) snapshot() (json.RawMessage, error) {
+	return json.Marshal(roomRepo.RoomRepo.all())
+}
+
+func (roomRepo *FileRoomRepo) replay(op string, data json.RawMessage) error {
+	switch op {
+	case opSnapshot:
+		var rooms []models.Room
+		if err := json.Unmarshal(data, &rooms); err != nil {
+			return err
+		}
+		for _, room := range rooms {
+			roomRepo.RoomRepo.put(room)
+		}
+	case opPut:
+		var room models.Room
+		if err := json.Unmarshal(data, &room); err != nil {
+			return err
+		}
+		roomRepo.RoomRepo.put(room)
+	case opDelete:
+		var id string
+		if err := json.Unmarshal(data, &id); err != nil {
+			return err
+		}
+		roomRepo.RoomRepo.remove(id)
+	default:
+		return fmt.Errorf("unknown op %q", op)
+	}
+	return nil
+}
+
+// FilePlanRepo is a PlanRepo whose mutations are persisted in a FileStore
+type FilePlanRepo struct {
+	*PlanRepo
+	store *FileStore
+	// collection is the collection of the studio of the repository
+	collection string
+	// mu orders mutations with their log records
+	mu sync.Mutex
+}
+
+// NewFilePlanRepo creates the FilePlanRepo of a studio and restores its plans from the store
+func NewFilePlanRepo(store *FileStore, studioID string) (*FilePlanRepo, error) {
+	planRepo := &FilePlanRepo{PlanRepo: NewPlanRepo(), store: store, collection: studioCollection(studioID, collectionPlans)}
+	if err := store.register(planRepo.collection, planRepo); err != nil {
+		return nil, err
+	}
+	return planRepo, nil
+}
+
+// Create for creating a new plan
+func (planRepo *FilePlanRepo) Create(plan models.Plan) error {
+	planRepo.mu.Lock()
+	defer planRepo.mu.Unlock()
+
+	if err := planRepo.store.append(planRepo.collection, opPut, plan); err != nil {
+		return persistErr(err)
+	}
+	planRepo.PlanRepo.put(plan)
+	return nil
+}
+
+// Update replaces an existing plan
+func (planRepo *FilePlanRepo) Update(plan models.Plan) error {
+	planRepo.mu.Lock()
+	defer planRepo.mu.Unlock()
+
+	planRepo.PlanRepo.mu.RLock()
+	err := planRepo.PlanRepo.found(plan.ID)
+	planRepo.PlanRepo.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := planRepo.store.append(planRepo.collection, opPut, plan); err != nil {
+		return persistErr(err)
+	}
+	planRepo.PlanRepo.put(plan)
+	return nil
+}
+
+// Delete removes a plan
+func (planRepo *FilePlanRepo) Delete(id string) error {
+	planRepo.mu.Lock()
+	defer planRepo.mu.Unlock()
+
+	planRepo.PlanRepo.mu.RLock()
+	err := planRepo.PlanRepo.found(id)
+	planRepo.PlanRepo.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := planRepo.store.append(planRepo.collection, opDelete, id); err != nil {
+		return persistErr(err)
+	}
+	planRepo.PlanRepo.remove(id)
+	return nil
+}
+
+func (planRepo *FilePlanRepo) lock()   { planRepo.mu.Lock() }
+func (planRepo *FilePlanRepo) unlock() { planRepo.mu.Unlock() }
+
+func (planRepo *FilePlanRepo) snapshot() (json.RawMessage, error) {
+	return json.Marshal(planRepo.PlanRepo.all())
+}
+
+func (planRepo *FilePlanRepo) replay(op string, data json.RawMessage) error {
+	switch op {
+	case opSnapshot:
+		var plans []models.Plan
+		if err := json.Unmarshal(data, &plans); err != nil {
+			return err
+		}
+		for _, plan := range plans {
+			planRepo.PlanRepo.put(plan)
+		}
+	case opPut:
+		var plan models.Plan
+		if err := json.Unmarshal(data, &plan); err != nil {
+			return err
+		}
+		planRepo.PlanRepo.put(plan)
+	case opDelete:
+		var id string
+		if err := json.Unmarshal(data, &id); err != nil {
+			return err
+		}
+		planRepo.PlanRepo.remove(id)
+	default:
+		return fmt.Errorf("unknown op %q", op)
+	}
+	return nil
+}
+
+// FilePromoRepo is a PromoRepo whose mutations are persisted in a FileStore
+type FilePromoRepo struct {
+	*PromoRepo
+	store *FileStore
+	// collection is the collection of the studio of the repository
+	collection string
+	// mu orders mutations with their log records
+	mu sync.Mutex
+}
+
+// NewFilePromoRepo creates the FilePromoRepo of a studio and restores its promo codes from the store
+func NewFilePromoRepo(store *FileStore, studioID string) (*FilePromoRepo, error) {
+	promoRepo := &FilePromoRepo{PromoRepo: NewPromoRepo(), store: store, collection: studioCollection(studioID, collectionPromos)}
+	if err := store.register(promoRepo.collection, promoRepo); err != nil {
+		return nil, err
+	}
+	return promoRepo, nil
+}
+
+// Create for creating a new promo code
+func (promoRepo *FilePromoRepo) Create(promo models.PromoCode) error {
+	promoRepo.mu.Lock()
+	defer promoRepo.mu.Unlock()
+
+	promoRepo.PromoRepo.mu.RLock()
+	err := promoRepo.PromoRepo.checkCreate(promo.Code)
+	promoRepo.PromoRepo.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := promoRepo.store.append(promoRepo.collection, opPut, promo); err != nil {
+		return persistErr(err)
+	}
+	promoRepo.PromoRepo.put(promo)
+	return nil
+}
+
+// Update replaces an existing promo code
+func (promoRepo *FilePromoRepo) Update(promo models.PromoCode) error {
+	promoRepo.mu.Lock()
+	defer promoRepo.mu.Unlock()
+
+	promoRepo.PromoRepo.mu.RLock()
+	err := promoRepo.PromoRepo.found(promo.Code)
+	promoRepo.PromoRepo.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := promoRepo.store.append(promoRepo.collection, opPut, promo); err != nil {
+		return persistErr(err)
+	}
+	promoRepo.PromoRepo.put(promo)
+	return nil
+}
+
+// Delete removes a promo code
+func (promoRepo *FilePromoRepo) Delete(code string) error {
+	promoRepo.mu.Lock()
+	defer promoRepo.mu.Unlock()
+
+	promoRepo.PromoRepo.mu.RLock()
+	err := promoRepo.PromoRepo.found(code)
+	promoRepo.PromoRepo.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := promoRepo.store.append(promoRepo.collection, opDelete, code); err != nil {
+		return persistErr(err)
+	}
+	promoRepo.PromoRepo.remove(code)
+	return nil
+}
+
+func (promoRepo *FilePromoRepo) lock()   { promoRepo.mu.Lock() }
+func (promoRepo *FilePromoRepo) unlock() { promoRepo.mu.Unlock() }
+
+func (promoRepo *FilePromoRepo) snapshot() (json.RawMessage, error) {
+	return json.Marshal(promoRepo.PromoRepo.all())
+}
+
+func (promoRepo *FilePromoRepo) replay(op string, data json.RawMessage) error {
+	switch op {
+	case opSnapshot:
+		var promos []models.PromoCode
+		if err := json.Unmarshal(data, &promos); err != nil {
+			return err
+		}
+		for _, promo := range promos {
+			promoRepo.PromoRepo.put(promo)
+		}
+	case opPut:
+		var promo models.PromoCode
+		if err := json.Unmarshal(data, &promo); err != nil {
+			return err
+		}
+		promoRepo.PromoRepo.put(promo)
+	case opDelete:
+		var code string
+		if err := json.Unmarshal(data, &code); err != nil {
+			return err
+		}
+		promoRepo.PromoRepo.remove(code)
+	default:
+		return fmt.Errorf("unknown op %q", op)
+	}
+	return nil
+}
+
+// FileIdempotencyRepo is an IdempotencyRepo whose mutations are persisted in a FileStore
+type FileIdempotencyRepo struct {
+	*IdempotencyRepo
+	store *FileStore
+	// collection is the collection of the studio of the repository
+	collection string
+	// mu orders mutations with their log records
+	mu sync.Mutex
+}
+
+// NewFileIdempotencyRepo creates the FileIdempotencyRepo of a studio and restores its records from the store
+func NewFileIdempotencyRepo(store *FileStore, studioID string) (*FileIdempotencyRepo, error) {
+	idempotencyRepo := &FileIdempotencyRepo{IdempotencyRepo: NewIdempotencyRepo(), store: store, collection: studioCollection(studioID, collectionIdempotency)}
+	if err := store.register(idempotencyRepo.collection, idempotencyRepo); err != nil {
+		return nil, err
+	}
+	return idempotencyRepo, nil
+}
+
+// Reserve claims the key of record for a new request
+func (idempotencyRepo *FileIdempotencyRepo) Reserve(record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	idempotencyRepo.mu.Lock()
+	defer idempotencyRepo.mu.Unlock()
+
+	idempotencyRepo.IdempotencyRepo.mu.Lock()
+	existing, held := idempotencyRepo.IdempotencyRepo.holder(record)
+	idempotencyRepo.IdempotencyRepo.mu.Unlock()
+	if held {
+		return existing, false, nil
+	}
+	if err := idempotencyRepo.store.append(idempotencyRepo.collection, opPut, record); err != nil {
+		return models.IdempotencyRecord{}, false, persistErr(err)
+	}
+	idempotencyRepo.IdempotencyRepo.put(record)
+	return record, true, nil
+}
+
+// Complete stores the response of a reserved key
+func (idempotencyRepo *FileIdempotencyRepo) Complete(record models.IdempotencyRecord) error {
+	idempotencyRepo.mu.Lock()
+	defer idempotencyRepo.mu.Unlock()
+
+	if err := idempotencyRepo.store.append(idempotencyRepo.collection, opPut, record); err != nil {
+		return persistErr(err)
+	}
+	idempotencyRepo.IdempotencyRepo.put(record)
+	return nil
+}
+
+// Release frees a reserved key so that the request can be retried
+func (idempotencyRepo *FileIdempotencyRepo) Release(key string) error {
+	idempotencyRepo.mu.Lock()
+	defer idempotencyRepo.mu.Unlock()
+
+	if _, exists := idempotencyRepo.IdempotencyRepo.get(key); !exists {
+		return nil
+	}
+	if err := idempotencyRepo.store.append(idempotencyRepo.collection, opDelete, key); err != nil {
+		return persistErr(err)
+	}
+	idempotencyRepo.IdempotencyRepo.remove(key)
+	return nil
+}
+
+func (idempotencyRepo *FileIdempotencyRepo) lock()   { idempotencyRepo.mu.Lock() }
+func (idempotencyRepo *FileIdempotencyRepo) unlock() { idempotencyRepo.mu.Unlock() }
+
+// Purge deletes the records that expired before now, the deletion is not
+// logged because Reserve ignores expired records replayed from the log
+func (idempotencyRepo *FileIdempotencyRepo) Purge(now time.Time) error {
+	idempotencyRepo.mu.Lock()
+	defer idempotencyRepo.mu.Unlock()
+
+	return idempotencyRepo.IdempotencyRepo.Purge(now)
+}
+
+func (idempotencyRepo *FileIdempotencyRepo) snapshot() (json.RawMessage, error) {
+	return json.Marshal(idempotencyRepo.IdempotencyRepo.all())
+}
+
+func (idempotencyRepo *FileIdempotencyRepo) replay(op string, data json.RawMessage) error {
+	switch op {
+	case opSnapshot:
+		var records []models.IdempotencyRecord
+		if err := json.Unmarshal(data, &records); err != nil {
+			return err
+		}
+		for _, record := range records {
+			idempotencyRepo.IdempotencyRepo.put(record)
+		}
+	case opPut:
+		var record models.IdempotencyRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		idempotencyRepo.IdempotencyRepo.put(record)
+	case opDelete:
+		var key string
+		if err := json.Unmarshal(data, &key); err != nil {
+			return err
+		}
+		idempotencyRepo.IdempotencyRepo.remove(key)
+	default:
+		return fmt.Errorf("unknown op %q", op)
+	}
+	return nil
+}
+
+// studioCollection names the collection of a studio in the store
+func studioCollection(studioID, collection string) string {
+	return studioID + "/" + collection
+}
+
+// FileStudios keeps the file-backed repositories of each studio in one FileStore
+type FileStudios struct {
+	store *FileStore
+	// Key: studio ID
+	studios map[string]Repositories
+	mu      sync.Mutex
+}
+
+// NewFileStudios creates a FileStudios and restores the repositories of every
+// studio with data in the store
+func NewFileStudios(store *FileStore) (*FileStudios, error) {
+	fileStudios := &FileStudios{store: store, studios: make(map[string]Repositories)}
+	for _, studioID := range store.studios() {
+		repos, err := fileStudios.open(studioID)
+		if err != nil {
+			return nil, fmt.Errorf("restore studio %s: %w", studioID, err)
+		}
+		fileStudios.studios[studioID] = repos
+	}
+	return fileStudios, nil
+}
+
+// Studio returns the repositories of a studio. Studios with data were opened
+// by NewFileStudios, a new one is registered with the store on first use.
+func (fileStudios *FileStudios) Studio(studioID string) (Repositories, error) {
+	fileStudios.mu.Lock()
+	defer fileStudios.mu.Unlock()
+
+	repos, exists := fileStudios.studios[studioID]
+	if !exists {
+		var err error
+		if repos, err = fileStudios.open(studioID); err != nil {
+			return Repositories{}, fmt.Errorf("open studio %s: %w", studioID, err)
+		}
+		fileStudios.studios[studioID] = repos
+	}
+	return repos, nil
+}
+
+// Exists reports whether a studio has data in the store or was created by
+// Studio since. A studio that never stored anything is not kept across restarts.
+func (fileStudios *FileStudios) Exists(studioID string) (bool, error) {
+	fileStudios.mu.Lock()
+	defer fileStudios.mu.Unlock()
+
+	_, exists := fileStudios.studios[studioID]
+	return exists, nil
+}
+
+// IDs returns the ID of every studio with data in the store or created by
+// Studio since, sorted
+func (fileStudios *FileStudios) IDs() ([]string, error) {
+	fileStudios.mu.Lock()
+	defer fileStudios.mu.Unlock()
+
+	return studioIDs(fileStudios.studios), nil
+}
+
+// open creates the repositories of a studio and registers them with the store
+func (fileStudios *FileStudios) open(studioID string) (Repositories, error) {
+	var repos Repositories
+	var err error
+	if repos.Classes, err = NewFileClassRepo(fileStudios.store, studioID); err != nil {
+		return Repositories{}, err
+	}
+	if repos.Bookings, err = NewFileBookingRepo(fileStudios.store, studioID); err != nil {
+		return Repositories{}, err
+	}
+	if repos.Waitlists, err = NewFileWaitlistRepo(fileStudios.store, studioID); err != nil {
+		return Repositories{}, err
+	}
+	if repos.Members, err = NewFileMemberRepo(fileStudios.store, studioID); err != nil {
+		return Repositories{}, err
+	}
+	if repos.Instructors, err = NewFileInstructorRepo(fileStudios.store, studioID); err != nil {
+		return Repositories{}, err
+	}
+	if repos.Rooms, err = NewFileRoomRepo(fileStudios.store, studioID); err != nil {
+		return Repositories{}, err
+	}
+	if repos.Plans, err = NewFilePlanRepo(fileStudios.store, studioID); err != nil {
+		return Repositories{}, err
+	}
+	if repos.Promos, err = NewFilePromoRepo(fileStudios.store, studioID); err != nil {
+		return Repositories{}, err
+	}
+	if repos.Idempotency, err = NewFileIdempotencyRepo(fileStudios.store, studioID); err != nil {
+		return Repositories{}, err
+	}
+	return repos, nil
+}
+
+// persistErr logs a failed log append and hides its details from callers
+func persistErr(err error) error {
+	log.Printf("Failed to persist mutation: %v", err)
+	return constants.ErrInternalServer
+}
@@ -0,0 +1,504 @@
+package repository
+
+import (
+	"fmt"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestStore opens a FileStore in dir that is closed when the test ends
+func openTestStore(t *testing.T, cfg FileStoreConfig) *FileStore {
+	store, err := OpenFileStore(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestFileClassRepo(t *testing.T) {
+	testClassRepository(t, func(t *testing.T) ClassRepository {
+		repo, err := NewFileClassRepo(openTestStore(t, FileStoreConfig{Dir: t.TempDir()}), constants.DefaultStudioID)
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestFileBookingRepo(t *testing.T) {
+	testBookingRepository(t, func(t *testing.T) BookingRepository {
+		repo, err := NewFileBookingRepo(openTestStore(t, FileStoreConfig{Dir: t.TempDir()}), constants.DefaultStudioID)
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestFileWaitlistRepo(t *testing.T) {
+	testWaitlistRepository(t, func(t *testing.T) WaitlistRepository {
+		repo, err := NewFileWaitlistRepo(openTestStore(t, FileStoreConfig{Dir: t.TempDir()}), constants.DefaultStudioID)
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestFileMemberRepo(t *testing.T) {
+	testMemberRepository(t, func(t *testing.T) MemberRepository {
+		repo, err := NewFileMemberRepo(openTestStore(t, FileStoreConfig{Dir: t.TempDir()}), constants.DefaultStudioID)
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestFileInstructorRepo(t *testing.T) {
+	testInstructorRepository(t, func(t *testing.T) InstructorRepository {
+		repo, err := NewFileInstructorRepo(openTestStore(t, FileStoreConfig{Dir: t.TempDir()}), constants.DefaultStudioID)
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestFileRoomRepo(t *testing.T) {
+	testRoomRepository(t, func(t *testing.T) RoomRepository {
+		repo, err := NewFileRoomRepo(openTestStore(t, FileStoreConfig{Dir: t.TempDir()}), constants.DefaultStudioID)
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestFilePlanRepo(t *testing.T) {
+	testPlanRepository(t, func(t *testing.T) PlanRepository {
+		repo, err := NewFilePlanRepo(openTestStore(t, FileStoreConfig{Dir: t.TempDir()}), constants.DefaultStudioID)
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestFilePromoRepo(t *testing.T) {
+	testPromoRepository(t, func(t *testing.T) PromoRepository {
+		repo, err := NewFilePromoRepo(openTestStore(t, FileStoreConfig{Dir: t.TempDir()}), constants.DefaultStudioID)
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestFileMemberRepo_ReplaysDeletes(t *testing.T) {
+	cfg := FileStoreConfig{Dir: t.TempDir()}
+	store, err := OpenFileStore(cfg)
+	require.NoError(t, err)
+	memberRepo, err := NewFileMemberRepo(store, constants.DefaultStudioID)
+	require.NoError(t, err)
+	_, err = memberRepo.Create(testMember("mb_1", "Alice"))
+	require.NoError(t, err)
+	_, err = memberRepo.Create(testMember("mb_2", "Bob"))
+	require.NoError(t, err)
+	require.NoError(t, memberRepo.Delete("mb_1"))
+	require.NoError(t, store.Close())
+
+	memberRepo, err = NewFileMemberRepo(openTestStore(t, cfg), constants.DefaultStudioID)
+	require.NoError(t, err)
+	_, exists := memberRepo.GetByID("mb_1")
+	assert.False(t, exists)
+	_, exists = memberRepo.GetByID("mb_2")
+	assert.True(t, exists)
+}
+
+func TestFileBookingRepo_ReplaysCredits(t *testing.T) {
+	for _, snapshot := range []bool{false, true} {
+		name := "LogOnly"
+		if snapshot {
+			name = "SnapshotAndLog"
+		}
+		t.Run(name, func(t *testing.T) {
+			cfg := FileStoreConfig{Dir: t.TempDir()}
+			date := time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC)
+			pack := models.BookingLimits{Capacity: 10, Charge: &models.Charge{MembershipID: "ms_pack"}}
+
+			store, err := OpenFileStore(cfg)
+			require.NoError(t, err)
+			repo, err := NewFileBookingRepo(store, constants.DefaultStudioID)
+			require.NoError(t, err)
+			require.NoError(t, repo.AddCredit(testGrant("Alice", 2)))
+			booking, err := repo.Create(testBooking("Alice", date), pack)
+			require.NoError(t, err)
+			if snapshot {
+				require.NoError(t, store.Snapshot())
+			}
+			_, err = repo.Cancel(booking.ID, booking.Version, date.Add(-24*time.Hour), false, "")
+			require.NoError(t, err)
+			entries := repo.Credits("mb_Alice")
+			require.NoError(t, store.Close())
+
+			// The ledger comes back after a restart and still guards the credits
+			repo, err = NewFileBookingRepo(openTestStore(t, cfg), constants.DefaultStudioID)
+			require.NoError(t, err)
+			assert.Equal(t, entries, repo.Credits("mb_Alice"))
+			stored, _ := repo.GetByID(booking.ID)
+			assert.Equal(t, "ms_pack", stored.MembershipID)
+			for i := 1; i <= 2; i++ {
+				_, err = repo.Create(testBooking("Alice", date.AddDate(0, 0, i)), pack)
+				require.NoError(t, err)
+			}
+			_, err = repo.Create(testBooking("Alice", date.AddDate(0, 0, 3)), pack)
+			assert.ErrorIs(t, err, constants.ErrNoEntitlement)
+		})
+	}
+}
+
+func TestFileBookingRepo_ReplaysPayments(t *testing.T) {
+	cfg := FileStoreConfig{Dir: t.TempDir()}
+	date := time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC)
+
+	store, err := OpenFileStore(cfg)
+	require.NoError(t, err)
+	repo, err := NewFileBookingRepo(store, constants.DefaultStudioID)
+	require.NoError(t, err)
+	booking, err := repo.Create(testPaidBooking("Alice", date), seats(2))
+	require.NoError(t, err)
+	payment := *booking.Payment
+	payment.Status, payment.AuthorizationID = constants.PaymentAuthorized, "au_1"
+	booked, err := repo.UpdatePayment(booking.ID, booking.Version, payment)
+	require.NoError(t, err)
+	pending, err := repo.Create(testPaidBooking("Bob", date), seats(2))
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	// Payments come back after a restart, and the pending booking still holds its seat
+	repo, err = NewFileBookingRepo(openTestStore(t, cfg), constants.DefaultStudioID)
+	require.NoError(t, err)
+	stored, _ := repo.GetByID(booking.ID)
+	assert.Equal(t, booked, stored)
+	stored, _ = repo.GetByID(pending.ID)
+	assert.Equal(t, pending, stored)
+	assert.Equal(t, 2, repo.Count("Yoga", date))
+	assert.Equal(t, []models.Booking{pending}, repo.Query(models.BookingFilter{PaymentStatuses: []string{constants.PaymentPending}}))
+}
+
+func TestFileBookingRepo_ReplaysDeletes(t *testing.T) {
+	cfg := FileStoreConfig{Dir: t.TempDir()}
+	date := time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC)
+
+	store, err := OpenFileStore(cfg)
+	require.NoError(t, err)
+	repo, err := NewFileBookingRepo(store, constants.DefaultStudioID)
+	require.NoError(t, err)
+	_, err = repo.Create(testBooking("Alice", date), seats(2))
+	require.NoError(t, err)
+	pending, err := repo.Create(testPaidBooking("Bob", date), seats(2))
+	require.NoError(t, err)
+	require.NoError(t, repo.DeleteByClass("Yoga"))
+	require.NoError(t, store.Close())
+
+	// Deleted bookings stay deleted after a restart
+	repo, err = NewFileBookingRepo(openTestStore(t, cfg), constants.DefaultStudioID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Booking{pending}, repo.Query(models.BookingFilter{}))
+	assert.Equal(t, 1, repo.Count("Yoga", date))
+}
+
+func TestFileBookingRepo_ReplaysRedemptions(t *testing.T) {
+	cfg := FileStoreConfig{Dir: t.TempDir()}
+	date := time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC)
+	limits := seats(10)
+	limits.Promo = &models.PromoLimit{Code: "SUMMER", MaxRedemptions: 2}
+
+	store, err := OpenFileStore(cfg)
+	require.NoError(t, err)
+	repo, err := NewFileBookingRepo(store, constants.DefaultStudioID)
+	require.NoError(t, err)
+	alice, err := repo.Create(testPromoBooking("Alice", date, "SUMMER"), limits)
+	require.NoError(t, err)
+	bob, err := repo.Create(testPromoBooking("Bob", date, "SUMMER"), limits)
+	require.NoError(t, err)
+	_, err = repo.Cancel(bob.ID, bob.Version, date.Add(-24*time.Hour), false, "")
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	// Redemptions are indexed again after a restart, without the cancelled one
+	repo, err = NewFileBookingRepo(openTestStore(t, cfg), constants.DefaultStudioID)
+	require.NoError(t, err)
+	_, err = repo.Create(testPromoBooking("Alice", date.AddDate(0, 0, 1), "SUMMER"), limits)
+	assert.ErrorIs(t, err, constants.ErrPromoRedeemed)
+	_, err = repo.Create(testPromoBooking("Carol", date, "SUMMER"), limits)
+	require.NoError(t, err)
+	_, err = repo.Create(testPromoBooking("Dave", date, "SUMMER"), limits)
+	assert.ErrorIs(t, err, constants.ErrPromoExhausted)
+	stored, _ := repo.GetByID(alice.ID)
+	assert.Equal(t, alice, stored)
+}
+
+func TestFileBookingRepo_KeepsStateWhenAppendFails(t *testing.T) {
+	store := openTestStore(t, FileStoreConfig{Dir: t.TempDir()})
+	repo, err := NewFileBookingRepo(store, constants.DefaultStudioID)
+	require.NoError(t, err)
+	date := time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC)
+	booked, err := repo.Create(testBooking("Alice", date), seats(2))
+	require.NoError(t, err)
+
+	// Mutations that cannot be logged are never applied
+	require.NoError(t, store.wal.Close())
+	_, err = repo.Create(testBooking("Bob", date), seats(2))
+	assert.ErrorIs(t, err, constants.ErrInternalServer)
+	_, err = repo.Cancel(booked.ID, booked.Version, date, false, "")
+	assert.ErrorIs(t, err, constants.ErrInternalServer)
+	assert.ErrorIs(t, repo.DeleteByClass("Yoga"), constants.ErrInternalServer)
+	assert.Equal(t, []models.Booking{booked}, repo.Query(models.BookingFilter{}))
+	assert.Equal(t, 1, repo.Count("Yoga", date))
+}
+
+func TestFileClassRepo_ReplaysUpdatesAndDeletes(t *testing.T) {
+	cfg := FileStoreConfig{Dir: t.TempDir()}
+	store, err := OpenFileStore(cfg)
+	require.NoError(t, err)
+	classRepo, err := NewFileClassRepo(store, constants.DefaultStudioID)
+	require.NoError(t, err)
+	created, err := classRepo.Create(testClass())
+	require.NoError(t, err)
+	created.Capacity = 4
+	updated, err := classRepo.Update(created)
+	require.NoError(t, err)
+	boxing, err := classRepo.Create(models.Class{Name: "Boxing", Capacity: 10})
+	require.NoError(t, err)
+	require.NoError(t, classRepo.Delete("Boxing", boxing.Version))
+	require.NoError(t, store.Close())
+
+	classRepo, err = NewFileClassRepo(openTestStore(t, cfg), constants.DefaultStudioID)
+	require.NoError(t, err)
+	stored, exists := classRepo.GetByName("Yoga")
+	assert.True(t, exists)
+	assert.Equal(t, updated, stored)
+	_, exists = classRepo.GetByName("Boxing")
+	assert.False(t, exists)
+}
+
+func TestFileIdempotencyRepo(t *testing.T) {
+	testIdempotencyRepository(t, func(t *testing.T) IdempotencyRepository {
+		repo, err := NewFileIdempotencyRepo(openTestStore(t, FileStoreConfig{Dir: t.TempDir()}), constants.DefaultStudioID)
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestFileIdempotencyRepo_ReplaysResponses(t *testing.T) {
+	cfg := FileStoreConfig{Dir: t.TempDir()}
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	store, err := OpenFileStore(cfg)
+	require.NoError(t, err)
+	idempotencyRepo, err := NewFileIdempotencyRepo(store, constants.DefaultStudioID)
+	require.NoError(t, err)
+	completed := testRecord("key-1", now)
+	_, _, err = idempotencyRepo.Reserve(completed)
+	require.NoError(t, err)
+	completed.StatusCode, completed.Body, completed.ExpiresAt = 201, []byte(`{"status":"success"}`), now.Add(time.Hour)
+	require.NoError(t, idempotencyRepo.Complete(completed))
+	_, _, err = idempotencyRepo.Reserve(testRecord("key-2", now))
+	require.NoError(t, err)
+	require.NoError(t, idempotencyRepo.Release("key-2"))
+	require.NoError(t, store.Close())
+
+	idempotencyRepo, err = NewFileIdempotencyRepo(openTestStore(t, cfg), constants.DefaultStudioID)
+	require.NoError(t, err)
+	existing, reserved, err := idempotencyRepo.Reserve(testRecord("key-1", now))
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, completed, existing)
+	_, reserved, err = idempotencyRepo.Reserve(testRecord("key-2", now))
+	require.NoError(t, err)
+	assert.True(t, reserved, "released key should stay released after a restart")
+}
+
+// fileRepos opens the store in dir together with all file-backed repositories
+func fileRepos(t *testing.T, cfg FileStoreConfig) (*FileStore, *FileClassRepo, *FileBookingRepo, *FileWaitlistRepo) {
+	store, err := OpenFileStore(cfg)
+	require.NoError(t, err)
+	classRepo, err := NewFileClassRepo(store, constants.DefaultStudioID)
+	require.NoError(t, err)
+	bookingRepo, err := NewFileBookingRepo(store, constants.DefaultStudioID)
+	require.NoError(t, err)
+	waitlistRepo, err := NewFileWaitlistRepo(store, constants.DefaultStudioID)
+	require.NoError(t, err)
+	return store, classRepo, bookingRepo, waitlistRepo
+}
+
+func TestFileStore_ReplaysAfterRestart(t *testing.T) {
+	for _, snapshot := range []bool{false, true} {
+		name := "LogOnly"
+		if snapshot {
+			name = "SnapshotAndLog"
+		}
+		t.Run(name, func(t *testing.T) {
+			cfg := FileStoreConfig{Dir: t.TempDir(), Durability: DurabilityAlways}
+			date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+			class := models.Class{Name: "Yoga", StartDate: date, EndDate: date, Capacity: 2}
+
+			store, classRepo, bookingRepo, waitlistRepo := fileRepos(t, cfg)
+			class, err := classRepo.Create(class)
+			require.NoError(t, err)
+			alice, err := bookingRepo.Create(testBooking("Alice", date), seats(2))
+			require.NoError(t, err)
+			if snapshot {
+				// Later mutations land in the log on top of the snapshot
+				require.NoError(t, store.Snapshot())
+			}
+			bob, err := bookingRepo.Create(testBooking("Bob", date), seats(2))
+			require.NoError(t, err)
+			_, err = waitlistRepo.Join("Yoga", "Carol", date)
+			require.NoError(t, err)
+			_, err = waitlistRepo.Join("Yoga", "Dave", date)
+			require.NoError(t, err)
+			require.NoError(t, waitlistRepo.Leave("Yoga", "Carol", date))
+			cancelled, err := bookingRepo.Cancel(alice.ID, alice.Version, date.Add(-time.Hour), true, "")
+			require.NoError(t, err)
+			require.NoError(t, store.Close())
+
+			// Everything comes back after a restart
+			store, classRepo, bookingRepo, waitlistRepo = fileRepos(t, cfg)
+			defer store.Close()
+
+			stored, exists := classRepo.GetByName("Yoga")
+			assert.True(t, exists)
+			assert.Equal(t, class, stored)
+
+			storedAlice, _ := bookingRepo.GetByID(alice.ID)
+			assert.Equal(t, cancelled.Status, storedAlice.Status)
+			assert.True(t, storedAlice.LateCancel)
+			storedBob, _ := bookingRepo.GetByID(bob.ID)
+			assert.Equal(t, constants.BookingStatusBooked, storedBob.Status)
+			assert.Equal(t, 1, bookingRepo.Count("Yoga", date))
+
+			position, err := waitlistRepo.Position("Yoga", "Dave", date)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, position)
+			_, err = waitlistRepo.Position("Yoga", "Carol", date)
+			assert.ErrorIs(t, err, constants.ErrNotOnWaitlist)
+
+			// New IDs and sequence numbers keep working after the restart
+			_, err = bookingRepo.Create(testBooking("Erin", date), seats(2))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFileStore_CompactsLog(t *testing.T) {
+	cfg := FileStoreConfig{Dir: t.TempDir(), SnapshotEvery: 5}
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	store, _, bookingRepo, _ := fileRepos(t, cfg)
+	for i := 0; i < 12; i++ {
+		_, err := bookingRepo.Create(testBooking(fmt.Sprintf("member-%d", i), date), seats(100))
+		require.NoError(t, err)
+	}
+
+	// Compaction runs in the background once enough records are logged
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(cfg.Dir, snapshotFileName))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, store.Close())
+
+	info, err := os.Stat(filepath.Join(cfg.Dir, walFileName))
+	require.NoError(t, err)
+	assert.Less(t, info.Size(), int64(12*100), "log should have been truncated by the snapshot")
+
+	store, _, bookingRepo, _ = fileRepos(t, cfg)
+	defer store.Close()
+	assert.Equal(t, 12, bookingRepo.Count("Yoga", date))
+}
+
+func TestFileStore_DiscardsTornRecord(t *testing.T) {
+	cfg := FileStoreConfig{Dir: t.TempDir(), Durability: DurabilityNone}
+	store, classRepo, _, _ := fileRepos(t, cfg)
+	_, err := classRepo.Create(models.Class{Name: "Yoga", Capacity: 10})
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	// Simulate a crash in the middle of an append
+	wal, err := os.OpenFile(filepath.Join(cfg.Dir, walFileName), os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = wal.WriteString(`{"seq":2,"collection":"classes","op":"put","data":{"na`)
+	require.NoError(t, err)
+	require.NoError(t, wal.Close())
+
+	store, classRepo, _, _ = fileRepos(t, cfg)
+	_, exists := classRepo.GetByName("Yoga")
+	assert.True(t, exists)
+	_, err = classRepo.Create(models.Class{Name: "Boxing", Capacity: 10})
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	// The record written after the torn one is not lost behind it
+	store, classRepo, _, _ = fileRepos(t, cfg)
+	defer store.Close()
+	_, exists = classRepo.GetByName("Boxing")
+	assert.True(t, exists)
+}
+
+func TestFileStore_RejectsCorruptRecord(t *testing.T) {
+	cfg := FileStoreConfig{Dir: t.TempDir()}
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.Dir, walFileName), []byte("not json\n"), 0o644))
+
+	_, err := OpenFileStore(cfg)
+	assert.Error(t, err)
+}
+
+func TestOpenFileStore_Durability(t *testing.T) {
+	_, err := OpenFileStore(FileStoreConfig{Dir: t.TempDir(), Durability: "sometimes"})
+	assert.Error(t, err)
+	_, err = OpenFileStore(FileStoreConfig{Dir: t.TempDir(), Durability: DurabilityInterval})
+	assert.Error(t, err, "interval durability needs a sync interval")
+
+	store, classRepo, _, _ := fileRepos(t, FileStoreConfig{Dir: t.TempDir(), Durability: DurabilityInterval, SyncInterval: time.Millisecond})
+	defer store.Close()
+	_, err = classRepo.Create(models.Class{Name: "Yoga", Capacity: 10})
+	assert.NoError(t, err)
+}
+
+func TestFileStudios(t *testing.T) {
+	testStudioIsolation(t, func(t *testing.T) Studios {
+		studios, err := NewFileStudios(openTestStore(t, FileStoreConfig{Dir: t.TempDir()}))
+		require.NoError(t, err)
+		return studios
+	})
+}
+
+func TestFileStudios_RestoresEveryStudio(t *testing.T) {
+	cfg := FileStoreConfig{Dir: t.TempDir()}
+	// A log written before studios existed belongs to the default studio
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.Dir, walFileName),
+		[]byte(`{"seq":1,"collection":"classes","op":"put","data":{"name":"Yoga","capacity":10,"version":1}}`+"\n"), 0o644))
+	store, err := OpenFileStore(cfg)
+	require.NoError(t, err)
+	studios, err := NewFileStudios(store)
+	require.NoError(t, err)
+	_, err = studio(t, studios, "studio_a").Classes.Create(models.Class{Name: "Boxing", Capacity: 10})
+	require.NoError(t, err)
+	require.NoError(t, store.Snapshot())
+	require.NoError(t, store.Close())
+
+	studios, err = NewFileStudios(openTestStore(t, cfg))
+	require.NoError(t, err)
+	_, exists := studio(t, studios, constants.DefaultStudioID).Classes.GetByName("Yoga")
+	assert.True(t, exists)
+	_, exists = studio(t, studios, "studio_a").Classes.GetByName("Boxing")
+	assert.True(t, exists)
+	_, exists = studio(t, studios, "studio_a").Classes.GetByName("Yoga")
+	assert.False(t, exists)
+}
+
+func TestFileStudios_OpenError(t *testing.T) {
+	store := openTestStore(t, FileStoreConfig{Dir: t.TempDir()})
+	studios, err := NewFileStudios(store)
+	require.NoError(t, err)
+	_, err = NewFileClassRepo(store, "studio_a")
+	require.NoError(t, err)
+
+	// A studio whose repositories cannot be opened is an error, not a panic
+	_, err = studios.Studio("studio_a")
+	assert.Error(t, err)
+	exists, err := studios.Exists("studio_a")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
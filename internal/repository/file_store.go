@@ -0,0 +1,406 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"glofox/internal/constants"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DurabilityMode decides when the write-ahead log is fsynced to disk
+type DurabilityMode string
+
+const (
+	// DurabilityAlways fsyncs after every mutation, nothing acknowledged is ever lost
+	DurabilityAlways DurabilityMode = "always"
+	// DurabilityInterval fsyncs in the background, a crash loses at most one interval
+	DurabilityInterval DurabilityMode = "interval"
+	// DurabilityNone leaves flushing to the operating system
+	DurabilityNone DurabilityMode = "none"
+)
+
+const (
+	walFileName      = "wal.log"
+	snapshotFileName = "snapshot.json"
+)
+
+// FileStoreConfig configures a FileStore
+type FileStoreConfig struct {
+	Dir        string
+	Durability DurabilityMode
+	// SyncInterval is the fsync period of DurabilityInterval
+	SyncInterval time.Duration
+	// SnapshotEvery compacts the log into a snapshot after this many records, 0 disables it
+	SnapshotEvery int
+}
+
+// journal is implemented by the file-backed repositories so the store can
+// snapshot and replay them
+type journal interface {
+	// lock blocks mutations of the collection while a snapshot is taken
+	lock()
+	unlock()
+	// snapshot returns the full state of the collection
+	snapshot() (json.RawMessage, error)
+	// replay applies one persisted record, either a snapshot or a logged mutation
+	replay(op string, data json.RawMessage) error
+}
+
+// walRecord is one line of the write-ahead log
+type walRecord struct {
+	Seq        uint64          `json:"seq"`
+	Collection string          `json:"collection"`
+	Op         string          `json:"op"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// snapshotFile is the on-disk snapshot, it covers every record up to LastSeq
+type snapshotFile struct {
+	LastSeq     uint64                     `json:"last_seq"`
+	Collections map[string]json.RawMessage `json:"collections"`
+}
+
+// opSnapshot is the op used to replay a collection from the snapshot
+const opSnapshot = "snapshot"
+
+// FileStore persists repositories in a directory as a write-ahead log plus a
+// periodically compacted snapshot. Every mutation is appended to the log before
+// it is applied in memory, and on startup the snapshot is loaded and the log replayed.
+type FileStore struct {
+	cfg FileStoreConfig
+
+	mu       sync.Mutex
+	wal      *os.File
+	walSize  int64
+	seq      uint64
+	pending  int
+	journals map[string]journal
+	// loaded holds the persisted state of collections until their repository registers
+	loaded map[string][]walRecord
+
+	compact chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// OpenFileStore loads the snapshot and the log from cfg.Dir, creating it if needed
+func OpenFileStore(cfg FileStoreConfig) (*FileStore, error) {
+	if cfg.Durability == "" {
+		cfg.Durability = DurabilityAlways
+	}
+	switch cfg.Durability {
+	case DurabilityAlways, DurabilityNone:
+	case DurabilityInterval:
+		if cfg.SyncInterval <= 0 {
+			return nil, fmt.Errorf("sync interval must be positive for durability mode %q", cfg.Durability)
+		}
+	default:
+		return nil, fmt.Errorf("unknown durability mode %q", cfg.Durability)
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	store := &FileStore{
+		cfg:      cfg,
+		journals: make(map[string]journal),
+		loaded:   make(map[string][]walRecord),
+		compact:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+
+	wal, err := os.OpenFile(filepath.Join(cfg.Dir, walFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open write-ahead log: %w", err)
+	}
+	info, err := wal.Stat()
+	if err != nil {
+		wal.Close()
+		return nil, fmt.Errorf("stat write-ahead log: %w", err)
+	}
+	store.wal, store.walSize = wal, info.Size()
+
+	store.wg.Add(1)
+	go store.background()
+	return store, nil
+}
+
+// load reads the snapshot and every log record newer than it
+func (store *FileStore) load() error {
+	var snapshot snapshotFile
+	b, err := os.ReadFile(filepath.Join(store.cfg.Dir, snapshotFileName))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return fmt.Errorf("read snapshot: %w", err)
+	default:
+		if err := json.Unmarshal(b, &snapshot); err != nil {
+			return fmt.Errorf("decode snapshot: %w", err)
+		}
+	}
+	store.seq = snapshot.LastSeq
+	for collection, data := range snapshot.Collections {
+		collection = scopeCollection(collection)
+		store.loaded[collection] = append(store.loaded[collection], walRecord{Seq: snapshot.LastSeq, Collection: collection, Op: opSnapshot, Data: data})
+	}
+
+	walPath := filepath.Join(store.cfg.Dir, walFileName)
+	b, err = os.ReadFile(walPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read write-ahead log: %w", err)
+	}
+
+	reader := bufio.NewReader(bytes.NewReader(b))
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				// A crash in the middle of an append leaves a partial last line
+				log.Printf("Discarding torn write-ahead log record at offset %d", offset)
+				return os.Truncate(walPath, offset)
+			}
+			return nil
+		}
+		var record walRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("corrupt write-ahead log record at offset %d: %w", offset, err)
+		}
+		offset += int64(len(line))
+		if record.Seq <= snapshot.LastSeq {
+			// Already covered by the snapshot
+			continue
+		}
+		store.seq = record.Seq
+		store.pending++
+		record.Collection = scopeCollection(record.Collection)
+		store.loaded[record.Collection] = append(store.loaded[record.Collection], record)
+	}
+}
+
+// scopeCollection returns the name of a persisted collection, collections
+// written before studios existed belong to the default studio
+func scopeCollection(collection string) string {
+	if strings.Contains(collection, "/") {
+		return collection
+	}
+	return studioCollection(constants.DefaultStudioID, collection)
+}
+
+// studios returns the sorted IDs of the studios with persisted collections
+// that no repository has registered yet
+func (store *FileStore) studios() []string {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var studioIDs []string
+	for collection := range store.loaded {
+		studioID, _, _ := strings.Cut(collection, "/")
+		if !slices.Contains(studioIDs, studioID) {
+			studioIDs = append(studioIDs, studioID)
+		}
+	}
+	sort.Strings(studioIDs)
+	return studioIDs
+}
+
+// register attaches a repository to the store and replays its persisted state into it
+func (store *FileStore) register(collection string, j journal) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, exists := store.journals[collection]; exists {
+		return fmt.Errorf("collection %q is already registered", collection)
+	}
+	for _, record := range store.loaded[collection] {
+		if err := j.replay(record.Op, record.Data); err != nil {
+			return fmt.Errorf("replay %s record %d: %w", collection, record.Seq, err)
+		}
+	}
+	delete(store.loaded, collection)
+	store.journals[collection] = j
+	return nil
+}
+
+// append logs one mutation, it returns once the record is as durable as the configured mode
+func (store *FileStore) append(collection, op string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	record := walRecord{Seq: store.seq + 1, Collection: collection, Op: op, Data: data}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if _, err := store.wal.Write(line); err != nil {
+		// Drop any partial record so the next append starts on a clean line
+		store.wal.Truncate(store.walSize)
+		return fmt.Errorf("append to write-ahead log: %w", err)
+	}
+	if store.cfg.Durability == DurabilityAlways {
+		if err := store.wal.Sync(); err != nil {
+			store.wal.Truncate(store.walSize)
+			return fmt.Errorf("sync write-ahead log: %w", err)
+		}
+	}
+	store.walSize += int64(len(line))
+	store.seq = record.Seq
+	store.pending++
+
+	if store.cfg.SnapshotEvery > 0 && store.pending >= store.cfg.SnapshotEvery {
+		// Compaction needs the repository locks, so it runs outside of this call
+		select {
+		case store.compact <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// background runs the periodic fsync and the log compaction
+func (store *FileStore) background() {
+	defer store.wg.Done()
+
+	var tick <-chan time.Time
+	if store.cfg.Durability == DurabilityInterval {
+		ticker := time.NewTicker(store.cfg.SyncInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-tick:
+			store.mu.Lock()
+			if err := store.wal.Sync(); err != nil {
+				log.Printf("Failed to sync write-ahead log: %v", err)
+			}
+			store.mu.Unlock()
+		case <-store.compact:
+			if err := store.Snapshot(); err != nil {
+				log.Printf("Failed to compact write-ahead log: %v", err)
+			}
+		case <-store.done:
+			return
+		}
+	}
+}
+
+// Snapshot writes the state of every collection to the snapshot file and truncates the log
+func (store *FileStore) Snapshot() error {
+	// Lock the repositories in a fixed order before the store, the same order
+	// mutations take, so that the snapshot matches the log exactly
+	store.mu.Lock()
+	names := make([]string, 0, len(store.journals))
+	journals := make(map[string]journal, len(store.journals))
+	for name, j := range store.journals {
+		names = append(names, name)
+		journals[name] = j
+	}
+	store.mu.Unlock()
+	sort.Strings(names)
+	for _, name := range names {
+		journals[name].lock()
+		defer journals[name].unlock()
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	snapshot := snapshotFile{LastSeq: store.seq, Collections: make(map[string]json.RawMessage)}
+	for _, name := range names {
+		data, err := journals[name].snapshot()
+		if err != nil {
+			return fmt.Errorf("snapshot %s: %w", name, err)
+		}
+		snapshot.Collections[name] = data
+	}
+	// Keep the state of collections that no repository has claimed yet
+	for name, records := range store.loaded {
+		if len(records) != 1 || records[0].Op != opSnapshot {
+			return fmt.Errorf("cannot compact unregistered collection %q with pending log records", name)
+		}
+		snapshot.Collections[name] = records[0].Data
+	}
+
+	if err := writeFileAtomic(filepath.Join(store.cfg.Dir, snapshotFileName), snapshot); err != nil {
+		return err
+	}
+	if err := store.wal.Truncate(0); err != nil {
+		return fmt.Errorf("truncate write-ahead log: %w", err)
+	}
+	store.walSize, store.pending = 0, 0
+	return nil
+}
+
+// Close stops the background work and flushes the log
+func (store *FileStore) Close() error {
+	close(store.done)
+	store.wg.Wait()
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if err := store.wal.Sync(); err != nil {
+		return err
+	}
+	return store.wal.Close()
+}
+
+// writeFileAtomic replaces path with the JSON encoding of v so that readers
+// see either the old or the new content, never a partial file
+func writeFileAtomic(path string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Persist the rename itself
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"glofox/internal/models"
+	"sync"
+	"time"
+)
+
+type IdempotencyRepository interface {
+	// Reserve stores record unless an unexpired record holds its key, in which
+	// case that record is returned and reserved is false
+	Reserve(record models.IdempotencyRecord) (existing models.IdempotencyRecord, reserved bool, err error)
+	Complete(record models.IdempotencyRecord) error
+	Release(key string) error
+	Purge(now time.Time) error
+}
+
+// IdempotencyRepo manages the in-memory idempotency records
+type IdempotencyRepo struct {
+	// Key: idempotency key
+	records map[string]models.IdempotencyRecord
+	mu      sync.Mutex
+}
+
+// NewIdempotencyRepo creates a new IdempotencyRepo
+func NewIdempotencyRepo() *IdempotencyRepo {
+	return &IdempotencyRepo{
+		records: make(map[string]models.IdempotencyRecord),
+	}
+}
+
+// Reserve claims the key of record for a new request, the check and the
+// insert happen under the same lock so concurrent retries cannot both run
+func (idempotencyRepo *IdempotencyRepo) Reserve(record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	idempotencyRepo.mu.Lock()
+	defer idempotencyRepo.mu.Unlock()
+
+	if existing, held := idempotencyRepo.holder(record); held {
+		return existing, false, nil
+	}
+	idempotencyRepo.records[record.Key] = record
+	return record, true, nil
+}
+
+// Complete stores the response of a reserved key
+func (idempotencyRepo *IdempotencyRepo) Complete(record models.IdempotencyRecord) error {
+	idempotencyRepo.put(record)
+	return nil
+}
+
+// Release frees a reserved key so that the request can be retried
+func (idempotencyRepo *IdempotencyRepo) Release(key string) error {
+	idempotencyRepo.remove(key)
+	return nil
+}
+
+// Purge deletes the records that expired before now
+func (idempotencyRepo *IdempotencyRepo) Purge(now time.Time) error {
+	idempotencyRepo.mu.Lock()
+	defer idempotencyRepo.mu.Unlock()
+
+	for key, record := range idempotencyRepo.records {
+		if !record.ExpiresAt.After(now) {
+			delete(idempotencyRepo.records, key)
+		}
+	}
+	return nil
+}
+
+// holder returns the unexpired record holding the key of record, the caller
+// must hold the lock
+func (idempotencyRepo *IdempotencyRepo) holder(record models.IdempotencyRecord) (models.IdempotencyRecord, bool) {
+	existing, exists := idempotencyRepo.records[record.Key]
+	if !exists || !existing.ExpiresAt.After(record.CreatedAt) {
+		return models.IdempotencyRecord{}, false
+	}
+	return existing, true
+}
+
+// get fetches the record of a key
+func (idempotencyRepo *IdempotencyRepo) get(key string) (models.IdempotencyRecord, bool) {
+	idempotencyRepo.mu.Lock()
+	defer idempotencyRepo.mu.Unlock()
+
+	record, exists := idempotencyRepo.records[key]
+	return record, exists
+}
+
+// put inserts or replaces a record without validation, used to restore persisted state
+func (idempotencyRepo *IdempotencyRepo) put(record models.IdempotencyRecord) {
+	idempotencyRepo.mu.Lock()
+	defer idempotencyRepo.mu.Unlock()
+
+	idempotencyRepo.records[record.Key] = record
+}
+
+// remove deletes a record
+func (idempotencyRepo *IdempotencyRepo) remove(key string) {
+	idempotencyRepo.mu.Lock()
+	defer idempotencyRepo.mu.Unlock()
+
+	delete(idempotencyRepo.records, key)
+}
+
+// all returns every record, including expired ones
+func (idempotencyRepo *IdempotencyRepo) all() []models.IdempotencyRecord {
+	idempotencyRepo.mu.Lock()
+	defer idempotencyRepo.mu.Unlock()
+
+	records := make([]models.IdempotencyRecord, 0, len(idempotencyRepo.records))
+	for _, record := range idempotencyRepo.records {
+		records = append(records, record)
+	}
+	return records
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"glofox/internal/models"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyRepo(t *testing.T) {
+	testIdempotencyRepository(t, func(t *testing.T) IdempotencyRepository {
+		return NewIdempotencyRepo()
+	})
+}
+
+// testIdempotencyRepository runs the IdempotencyRepository test suite against
+// the implementation returned by newRepo
+func testIdempotencyRepository(t *testing.T, newRepo func(t *testing.T) IdempotencyRepository) {
+	t.Run("ReserveAndComplete", func(t *testing.T) { testIdempotencyReserveAndComplete(t, newRepo(t)) })
+	t.Run("Expiry", func(t *testing.T) { testIdempotencyExpiry(t, newRepo(t)) })
+	t.Run("ConcurrentReserve", func(t *testing.T) { testIdempotencyConcurrentReserve(t, newRepo(t)) })
+}
+
+// testRecord returns a pending record of key created at the given time
+func testRecord(key string, createdAt time.Time) models.IdempotencyRecord {
+	return models.IdempotencyRecord{Key: key, Fingerprint: "fp_" + key, CreatedAt: createdAt, ExpiresAt: createdAt.Add(time.Minute)}
+}
+
+func testIdempotencyReserveAndComplete(t *testing.T, repo IdempotencyRepository) {
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	pending := testRecord("key-1", now)
+
+	existing, reserved, err := repo.Reserve(pending)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Equal(t, pending, existing)
+
+	// A retry while the first request runs sees the pending record
+	existing, reserved, err = repo.Reserve(testRecord("key-1", now.Add(time.Second)))
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, pending, existing)
+
+	completed := pending
+	completed.StatusCode = 201
+	completed.ContentType = "application/json"
+	completed.Body = []byte(`{"status":"success"}`)
+	completed.ExpiresAt = now.Add(24 * time.Hour)
+	assert.NoError(t, repo.Complete(completed))
+	existing, reserved, err = repo.Reserve(testRecord("key-1", now.Add(time.Hour)))
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, completed, existing)
+
+	// A released key can be reserved again
+	assert.NoError(t, repo.Release("key-1"))
+	_, reserved, err = repo.Reserve(testRecord("key-1", now.Add(time.Hour)))
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	assert.NoError(t, repo.Release("missing"))
+}
+
+func testIdempotencyExpiry(t *testing.T, repo IdempotencyRepository) {
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	_, _, err := repo.Reserve(testRecord("stale", now))
+	assert.NoError(t, err)
+	_, _, err = repo.Reserve(testRecord("fresh", now.Add(time.Hour)))
+	assert.NoError(t, err)
+
+	// An expired record no longer holds its key
+	_, reserved, err := repo.Reserve(testRecord("stale", now.Add(time.Minute)))
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	// Purging drops expired records only
+	assert.NoError(t, repo.Purge(now.Add(2*time.Minute)))
+	_, reserved, err = repo.Reserve(testRecord("stale", now))
+	assert.NoError(t, err)
+	assert.True(t, reserved, "purged record should not hold its key")
+	_, reserved, err = repo.Reserve(testRecord("fresh", now.Add(time.Hour)))
+	assert.NoError(t, err)
+	assert.False(t, reserved, "unexpired record should survive the purge")
+}
+
+func testIdempotencyConcurrentReserve(t *testing.T, repo IdempotencyRepository) {
+	const retries = 50
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	var reservedCount int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < retries; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, reserved, err := repo.Reserve(testRecord("key-1", now))
+			assert.NoError(t, err)
+			if reserved {
+				atomic.AddInt32(&reservedCount, 1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), reservedCount, "Expected exactly one retry to reserve the key")
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"sort"
+	"sync"
+)
+
+type InstructorRepository interface {
+	Create(instructor models.Instructor) error
+	GetByID(id string) (models.Instructor, bool)
+	Update(instructor models.Instructor) error
+	Delete(id string) error
+	List(afterID string, limit int) []models.Instructor
+}
+
+// InstructorRepo manages the in-memory instructor data
+type InstructorRepo struct {
+	// Key: instructor ID
+	instructors map[string]models.Instructor
+	mu          sync.RWMutex
+}
+
+// NewInstructorRepo creates a new InstructorRepo
+func NewInstructorRepo() *InstructorRepo {
+	return &InstructorRepo{
+		instructors: make(map[string]models.Instructor),
+	}
+}
+
+// Create for creating a new instructor
+func (instructorRepo *InstructorRepo) Create(instructor models.Instructor) error {
+	instructorRepo.mu.Lock()
+	defer instructorRepo.mu.Unlock()
+
+	instructorRepo.instructors[instructor.ID] = instructor
+	return nil
+}
+
+// GetByID fetches instructor by given ID
+func (instructorRepo *InstructorRepo) GetByID(id string) (models.Instructor, bool) {
+	instructorRepo.mu.RLock()
+	defer instructorRepo.mu.RUnlock()
+
+	instructor, exists := instructorRepo.instructors[id]
+	return instructor, exists
+}
+
+// Update replaces an existing instructor
+func (instructorRepo *InstructorRepo) Update(instructor models.Instructor) error {
+	instructorRepo.mu.Lock()
+	defer instructorRepo.mu.Unlock()
+
+	if err := instructorRepo.found(instructor.ID); err != nil {
+		return err
+	}
+	instructorRepo.instructors[instructor.ID] = instructor
+	return nil
+}
+
+// Delete removes an instructor
+func (instructorRepo *InstructorRepo) Delete(id string) error {
+	instructorRepo.mu.Lock()
+	defer instructorRepo.mu.Unlock()
+
+	if err := instructorRepo.found(id); err != nil {
+		return err
+	}
+	delete(instructorRepo.instructors, id)
+	return nil
+}
+
+// List returns up to limit instructors sorted by ID, starting after afterID
+func (instructorRepo *InstructorRepo) List(afterID string, limit int) []models.Instructor {
+	instructorRepo.mu.RLock()
+	defer instructorRepo.mu.RUnlock()
+
+	instructors := make([]models.Instructor, 0, len(instructorRepo.instructors))
+	for id, instructor := range instructorRepo.instructors {
+		if id > afterID {
+			instructors = append(instructors, instructor)
+		}
+	}
+	sort.Slice(instructors, func(i, j int) bool {
+		return instructors[i].ID < instructors[j].ID
+	})
+	if len(instructors) > limit {
+		instructors = instructors[:limit]
+	}
+	return instructors
+}
+
+// found checks that an instructor exists, the caller must hold the lock
+func (instructorRepo *InstructorRepo) found(id string) error {
+	if _, exists := instructorRepo.instructors[id]; !exists {
+		return constants.ErrInstructorNotFound
+	}
+	return nil
+}
+
+// put inserts or replaces an instructor without validation, used to restore persisted state
+func (instructorRepo *InstructorRepo) put(instructor models.Instructor) {
+	instructorRepo.mu.Lock()
+	defer instructorRepo.mu.Unlock()
+
+	instructorRepo.instructors[instructor.ID] = instructor
+}
+
+// remove deletes an instructor without validation, used to replay deletions
+func (instructorRepo *InstructorRepo) remove(id string) {
+	instructorRepo.mu.Lock()
+	defer instructorRepo.mu.Unlock()
+
+	delete(instructorRepo.instructors, id)
+}
+
+// all returns every instructor
+func (instructorRepo *InstructorRepo) all() []models.Instructor {
+	instructorRepo.mu.RLock()
+	defer instructorRepo.mu.RUnlock()
+
+	instructors := make([]models.Instructor, 0, len(instructorRepo.instructors))
+	for _, instructor := range instructorRepo.instructors {
+		instructors = append(instructors, instructor)
+	}
+	return instructors
+}
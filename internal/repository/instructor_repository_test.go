@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstructorRepo(t *testing.T) {
+	testInstructorRepository(t, func(t *testing.T) InstructorRepository {
+		return NewInstructorRepo()
+	})
+}
+
+// testInstructorRepository runs the InstructorRepository test suite against
+// the implementation returned by newRepo
+func testInstructorRepository(t *testing.T, newRepo func(t *testing.T) InstructorRepository) {
+	t.Run("CRUD", func(t *testing.T) { testInstructorCRUD(t, newRepo(t)) })
+	t.Run("List", func(t *testing.T) { testInstructorList(t, newRepo(t)) })
+}
+
+// testInstructor returns a fully populated instructor
+func testInstructor(id, name string) models.Instructor {
+	created := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	return models.Instructor{ID: id, Name: name, Email: "instructor@example.com", CreatedAt: created, UpdatedAt: created}
+}
+
+func testInstructorCRUD(t *testing.T, repo InstructorRepository) {
+	instructor := testInstructor("in_1", "Maya")
+	assert.NoError(t, repo.Create(instructor))
+
+	stored, exists := repo.GetByID("in_1")
+	assert.True(t, exists)
+	assert.Equal(t, instructor, stored)
+
+	instructor.Name = "Maya Lee"
+	instructor.UpdatedAt = instructor.UpdatedAt.Add(time.Hour)
+	assert.NoError(t, repo.Update(instructor))
+	stored, _ = repo.GetByID("in_1")
+	assert.Equal(t, instructor, stored)
+
+	assert.NoError(t, repo.Delete("in_1"))
+	_, exists = repo.GetByID("in_1")
+	assert.False(t, exists)
+	assert.ErrorIs(t, repo.Delete("in_1"), constants.ErrInstructorNotFound)
+	assert.ErrorIs(t, repo.Update(instructor), constants.ErrInstructorNotFound)
+}
+
+func testInstructorList(t *testing.T, repo InstructorRepository) {
+	for _, id := range []string{"in_c", "in_a", "in_b"} {
+		assert.NoError(t, repo.Create(testInstructor(id, "Maya")))
+	}
+
+	ids := func(instructors []models.Instructor) []string {
+		ids := make([]string, 0, len(instructors))
+		for _, instructor := range instructors {
+			ids = append(ids, instructor.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"in_a", "in_b"}, ids(repo.List("", 2)))
+	assert.Equal(t, []string{"in_c"}, ids(repo.List("in_b", 2)))
+	assert.Empty(t, repo.List("in_c", 2))
+}
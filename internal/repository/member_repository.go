@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type MemberRepository interface {
+	Create(member models.Member) (models.Member, error)
+	GetByID(id string) (models.Member, bool)
+	FindByName(name string) []models.Member
+	// Update replaces the member when its stored version is member.Version
+	// and returns it with the next version
+	Update(member models.Member) (models.Member, error)
+	Delete(id string) error
+	List(afterID string, limit int) []models.Member
+}
+
+// MemberRepo manages the in-memory member data
+type MemberRepo struct {
+	// Key: member ID
+	members map[string]models.Member
+	mu      sync.RWMutex
+}
+
+// NewMemberRepo creates a new MemberRepo
+func NewMemberRepo() *MemberRepo {
+	return &MemberRepo{
+		members: make(map[string]models.Member),
+	}
+}
+
+// Create for creating a new member, the member starts at version 1
+func (memberRepo *MemberRepo) Create(member models.Member) (models.Member, error) {
+	memberRepo.mu.Lock()
+	defer memberRepo.mu.Unlock()
+
+	member = newMember(member)
+	memberRepo.members[member.ID] = member
+	return member, nil
+}
+
+// GetByID fetches member by given ID
+func (memberRepo *MemberRepo) GetByID(id string) (models.Member, bool) {
+	memberRepo.mu.RLock()
+	defer memberRepo.mu.RUnlock()
+
+	member, exists := memberRepo.members[id]
+	return member, exists
+}
+
+// FindByName returns the members whose name matches, ignoring case and
+// surrounding spaces, sorted by ID
+func (memberRepo *MemberRepo) FindByName(name string) []models.Member {
+	memberRepo.mu.RLock()
+	defer memberRepo.mu.RUnlock()
+
+	var members []models.Member
+	for _, member := range memberRepo.members {
+		if sameName(member.Name, name) {
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ID < members[j].ID
+	})
+	return members
+}
+
+// Update replaces an existing member, the version check and the write
+// happen under the same lock so concurrent updates cannot overwrite each other
+func (memberRepo *MemberRepo) Update(member models.Member) (models.Member, error) {
+	memberRepo.mu.Lock()
+	defer memberRepo.mu.Unlock()
+
+	member, err := memberRepo.checkUpdate(member)
+	if err != nil {
+		return models.Member{}, err
+	}
+	memberRepo.members[member.ID] = member
+	return member, nil
+}
+
+// Delete removes a member
+func (memberRepo *MemberRepo) Delete(id string) error {
+	memberRepo.mu.Lock()
+	defer memberRepo.mu.Unlock()
+
+	if err := memberRepo.found(id); err != nil {
+		return err
+	}
+	delete(memberRepo.members, id)
+	return nil
+}
+
+// List returns up to limit members sorted by ID, starting after afterID
+func (memberRepo *MemberRepo) List(afterID string, limit int) []models.Member {
+	memberRepo.mu.RLock()
+	defer memberRepo.mu.RUnlock()
+
+	members := make([]models.Member, 0, len(memberRepo.members))
+	for id, member := range memberRepo.members {
+		if id > afterID {
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ID < members[j].ID
+	})
+	if len(members) > limit {
+		members = members[:limit]
+	}
+	return members
+}
+
+// checkUpdate returns a member as Update stores it, the caller must hold the lock
+func (memberRepo *MemberRepo) checkUpdate(member models.Member) (models.Member, error) {
+	stored, exists := memberRepo.members[member.ID]
+	if !exists {
+		return models.Member{}, constants.ErrMemberNotFound
+	}
+	if stored.Version != member.Version {
+		return models.Member{}, constants.ErrVersionMismatch
+	}
+	member.Version++
+	return member, nil
+}
+
+// found checks that a member exists, the caller must hold the lock
+func (memberRepo *MemberRepo) found(id string) error {
+	if _, exists := memberRepo.members[id]; !exists {
+		return constants.ErrMemberNotFound
+	}
+	return nil
+}
+
+// newMember returns a new member as Create stores it, at version 1
+func newMember(member models.Member) models.Member {
+	member.Version = 1
+	return member
+}
+
+// put inserts or replaces a member without validation, used to restore persisted state
+func (memberRepo *MemberRepo) put(member models.Member) {
+	memberRepo.mu.Lock()
+	defer memberRepo.mu.Unlock()
+
+	// Members persisted before versions existed start at version 1
+	member.Version = max(member.Version, 1)
+	memberRepo.members[member.ID] = member
+}
+
+// remove deletes a member without validation, used to replay deletions
+func (memberRepo *MemberRepo) remove(id string) {
+	memberRepo.mu.Lock()
+	defer memberRepo.mu.Unlock()
+
+	delete(memberRepo.members, id)
+}
+
+// all returns every member
+func (memberRepo *MemberRepo) all() []models.Member {
+	memberRepo.mu.RLock()
+	defer memberRepo.mu.RUnlock()
+
+	members := make([]models.Member, 0, len(memberRepo.members))
+	for _, member := range memberRepo.members {
+		members = append(members, member)
+	}
+	return members
+}
+
+// sameName reports whether two member names are equal ignoring case and surrounding spaces
+func sameName(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
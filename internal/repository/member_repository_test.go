@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemberRepo(t *testing.T) {
+	testMemberRepository(t, func(t *testing.T) MemberRepository {
+		return NewMemberRepo()
+	})
+}
+
+// testMemberRepository runs the MemberRepository test suite against the
+// implementation returned by newRepo
+func testMemberRepository(t *testing.T, newRepo func(t *testing.T) MemberRepository) {
+	t.Run("CRUD", func(t *testing.T) { testMemberCRUD(t, newRepo(t)) })
+	t.Run("FindByName", func(t *testing.T) { testMemberFindByName(t, newRepo(t)) })
+	t.Run("List", func(t *testing.T) { testMemberList(t, newRepo(t)) })
+}
+
+// testMember returns a fully populated member
+func testMember(id, name string) models.Member {
+	created := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	return models.Member{
+		ID:        id,
+		Name:      name,
+		Email:     "member@example.com",
+		Phone:     "+353 1 234 5678",
+		Status:    constants.MemberStatusActive,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+}
+
+func testMemberCRUD(t *testing.T, repo MemberRepository) {
+	member, err := repo.Create(testMember("mb_1", "Alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, member.Version)
+
+	stored, exists := repo.GetByID("mb_1")
+	assert.True(t, exists)
+	assert.Equal(t, member, stored)
+
+	member.Status = constants.MemberStatusSuspended
+	member.UpdatedAt = member.UpdatedAt.Add(time.Hour)
+	updated, err := repo.Update(member)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+	stored, _ = repo.GetByID("mb_1")
+	assert.Equal(t, updated, stored)
+	_, err = repo.Update(testMember("mb_2", "Bob"))
+	assert.ErrorIs(t, err, constants.ErrMemberNotFound)
+
+	// An update of a version that was changed since is refused
+	member.Status = constants.MemberStatusActive
+	_, err = repo.Update(member)
+	assert.ErrorIs(t, err, constants.ErrVersionMismatch)
+	stored, _ = repo.GetByID("mb_1")
+	assert.Equal(t, updated, stored)
+
+	assert.NoError(t, repo.Delete("mb_1"))
+	_, exists = repo.GetByID("mb_1")
+	assert.False(t, exists)
+	assert.ErrorIs(t, repo.Delete("mb_1"), constants.ErrMemberNotFound)
+}
+
+func testMemberFindByName(t *testing.T, repo MemberRepository) {
+	for id, name := range map[string]string{"mb_2": "Amrit", "mb_1": "Amrit", "mb_3": "Bob"} {
+		_, err := repo.Create(testMember(id, name))
+		assert.NoError(t, err)
+	}
+
+	// Names match ignoring case and surrounding spaces, members may share a name
+	assert.Equal(t, []string{"mb_1", "mb_2"}, memberIDs(repo.FindByName("amrit ")))
+	assert.Equal(t, []string{"mb_3"}, memberIDs(repo.FindByName("BOB")))
+	assert.Empty(t, repo.FindByName("Carol"))
+}
+
+func testMemberList(t *testing.T, repo MemberRepository) {
+	for _, id := range []string{"mb_c", "mb_a", "mb_b"} {
+		_, err := repo.Create(testMember(id, "Alice"))
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"mb_a", "mb_b"}, memberIDs(repo.List("", 2)))
+	assert.Equal(t, []string{"mb_c"}, memberIDs(repo.List("mb_b", 2)))
+	assert.Empty(t, repo.List("mb_c", 2))
+}
+
+// memberIDs returns the IDs of the members in order
+func memberIDs(members []models.Member) []string {
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.ID)
+	}
+	return ids
+}
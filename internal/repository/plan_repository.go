@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"sort"
+	"sync"
+)
+
+type PlanRepository interface {
+	Create(plan models.Plan) error
+	GetByID(id string) (models.Plan, bool)
+	Update(plan models.Plan) error
+	Delete(id string) error
+	List(afterID string, limit int) []models.Plan
+}
+
+// PlanRepo manages the in-memory plan data
+type PlanRepo struct {
+	// Key: plan ID
+	plans map[string]models.Plan
+	mu    sync.RWMutex
+}
+
+// NewPlanRepo creates a new PlanRepo
+func NewPlanRepo() *PlanRepo {
+	return &PlanRepo{
+		plans: make(map[string]models.Plan),
+	}
+}
+
+// Create for creating a new plan
+func (planRepo *PlanRepo) Create(plan models.Plan) error {
+	planRepo.mu.Lock()
+	defer planRepo.mu.Unlock()
+
+	planRepo.plans[plan.ID] = plan
+	return nil
+}
+
+// GetByID fetches plan by given ID
+func (planRepo *PlanRepo) GetByID(id string) (models.Plan, bool) {
+	planRepo.mu.RLock()
+	defer planRepo.mu.RUnlock()
+
+	plan, exists := planRepo.plans[id]
+	return plan, exists
+}
+
+// Update replaces an existing plan
+func (planRepo *PlanRepo) Update(plan models.Plan) error {
+	planRepo.mu.Lock()
+	defer planRepo.mu.Unlock()
+
+	if err := planRepo.found(plan.ID); err != nil {
+		return err
+	}
+	planRepo.plans[plan.ID] = plan
+	return nil
+}
+
+// Delete removes a plan
+func (planRepo *PlanRepo) Delete(id string) error {
+	planRepo.mu.Lock()
+	defer planRepo.mu.Unlock()
+
+	if err := planRepo.found(id); err != nil {
+		return err
+	}
+	delete(planRepo.plans, id)
+	return nil
+}
+
+// List returns up to limit plans sorted by ID, starting after afterID
+func (planRepo *PlanRepo) List(afterID string, limit int) []models.Plan {
+	planRepo.mu.RLock()
+	defer planRepo.mu.RUnlock()
+
+	plans := make([]models.Plan, 0, len(planRepo.plans))
+	for id, plan := range planRepo.plans {
+		if id > afterID {
+			plans = append(plans, plan)
+		}
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].ID < plans[j].ID
+	})
+	if len(plans) > limit {
+		plans = plans[:limit]
+	}
+	return plans
+}
+
+// found checks that a plan exists, the caller must hold the lock
+func (planRepo *PlanRepo) found(id string) error {
+	if _, exists := planRepo.plans[id]; !exists {
+		return constants.ErrPlanNotFound
+	}
+	return nil
+}
+
+// put inserts or replaces a plan without validation, used to restore persisted state
+func (planRepo *PlanRepo) put(plan models.Plan) {
+	planRepo.mu.Lock()
+	defer planRepo.mu.Unlock()
+
+	planRepo.plans[plan.ID] = plan
+}
+
+// remove deletes a plan without validation, used to replay deletions
+func (planRepo *PlanRepo) remove(id string) {
+	planRepo.mu.Lock()
+	defer planRepo.mu.Unlock()
+
+	delete(planRepo.plans, id)
+}
+
+// all returns every plan
+func (planRepo *PlanRepo) all() []models.Plan {
+	planRepo.mu.RLock()
+	defer planRepo.mu.RUnlock()
+
+	plans := make([]models.Plan, 0, len(planRepo.plans))
+	for _, plan := range planRepo.plans {
+		plans = append(plans, plan)
+	}
+	return plans
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanRepo(t *testing.T) {
+	testPlanRepository(t, func(t *testing.T) PlanRepository {
+		return NewPlanRepo()
+	})
+}
+
+// testPlanRepository runs the PlanRepository test suite against
+// the implementation returned by newRepo
+func testPlanRepository(t *testing.T, newRepo func(t *testing.T) PlanRepository) {
+	t.Run("CRUD", func(t *testing.T) { testPlanCRUD(t, newRepo(t)) })
+	t.Run("List", func(t *testing.T) { testPlanList(t, newRepo(t)) })
+}
+
+// testPlan returns a fully populated plan
+func testPlan(id, name string) models.Plan {
+	created := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	return models.Plan{
+		ID:        id,
+		Name:      name,
+		Kind:      constants.PlanPack,
+		Classes:   10,
+		ValidDays: 90,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+}
+
+func testPlanCRUD(t *testing.T, repo PlanRepository) {
+	plan := testPlan("pl_1", "10 Class Pack")
+	assert.NoError(t, repo.Create(plan))
+
+	stored, exists := repo.GetByID("pl_1")
+	assert.True(t, exists)
+	assert.Equal(t, plan, stored)
+
+	plan.Name = "12 Class Pack"
+	plan.Classes = 12
+	plan.UpdatedAt = plan.UpdatedAt.Add(time.Hour)
+	assert.NoError(t, repo.Update(plan))
+	stored, _ = repo.GetByID("pl_1")
+	assert.Equal(t, plan, stored)
+
+	assert.NoError(t, repo.Delete("pl_1"))
+	_, exists = repo.GetByID("pl_1")
+	assert.False(t, exists)
+	assert.ErrorIs(t, repo.Delete("pl_1"), constants.ErrPlanNotFound)
+	assert.ErrorIs(t, repo.Update(plan), constants.ErrPlanNotFound)
+}
+
+func testPlanList(t *testing.T, repo PlanRepository) {
+	for _, id := range []string{"pl_c", "pl_a", "pl_b"} {
+		assert.NoError(t, repo.Create(testPlan(id, "Pack")))
+	}
+
+	ids := func(plans []models.Plan) []string {
+		ids := make([]string, 0, len(plans))
+		for _, plan := range plans {
+			ids = append(ids, plan.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"pl_a", "pl_b"}, ids(repo.List("", 2)))
+	assert.Equal(t, []string{"pl_c"}, ids(repo.List("pl_b", 2)))
+	assert.Empty(t, repo.List("pl_c", 2))
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"sort"
+	"sync"
+)
+
+type PromoRepository interface {
+	Create(promo models.PromoCode) error
+	GetByCode(code string) (models.PromoCode, bool)
+	Update(promo models.PromoCode) error
+	Delete(code string) error
+	List(afterCode string, limit int) []models.PromoCode
+}
+
+// PromoRepo manages the in-memory promo code data
+type PromoRepo struct {
+	// Key: code
+	promos map[string]models.PromoCode
+	mu     sync.RWMutex
+}
+
+// NewPromoRepo creates a new PromoRepo
+func NewPromoRepo() *PromoRepo {
+	return &PromoRepo{
+		promos: make(map[string]models.PromoCode),
+	}
+}
+
+// Create for creating a new promo code
+func (promoRepo *PromoRepo) Create(promo models.PromoCode) error {
+	promoRepo.mu.Lock()
+	defer promoRepo.mu.Unlock()
+
+	if err := promoRepo.checkCreate(promo.Code); err != nil {
+		return err
+	}
+	promoRepo.promos[promo.Code] = promo
+	return nil
+}
+
+// GetByCode fetches promo code by given code
+func (promoRepo *PromoRepo) GetByCode(code string) (models.PromoCode, bool) {
+	promoRepo.mu.RLock()
+	defer promoRepo.mu.RUnlock()
+
+	promo, exists := promoRepo.promos[code]
+	return promo, exists
+}
+
+// Update replaces an existing promo code
+func (promoRepo *PromoRepo) Update(promo models.PromoCode) error {
+	promoRepo.mu.Lock()
+	defer promoRepo.mu.Unlock()
+
+	if err := promoRepo.found(promo.Code); err != nil {
+		return err
+	}
+	promoRepo.promos[promo.Code] = promo
+	return nil
+}
+
+// Delete removes a promo code
+func (promoRepo *PromoRepo) Delete(code string) error {
+	promoRepo.mu.Lock()
+	defer promoRepo.mu.Unlock()
+
+	if err := promoRepo.found(code); err != nil {
+		return err
+	}
+	delete(promoRepo.promos, code)
+	return nil
+}
+
+// List returns up to limit promo codes sorted by code, starting after afterCode
+func (promoRepo *PromoRepo) List(afterCode string, limit int) []models.PromoCode {
+	promoRepo.mu.RLock()
+	defer promoRepo.mu.RUnlock()
+
+	promos := make([]models.PromoCode, 0, len(promoRepo.promos))
+	for code, promo := range promoRepo.promos {
+		if code > afterCode {
+			promos = append(promos, promo)
+		}
+	}
+	sort.Slice(promos, func(i, j int) bool {
+		return promos[i].Code < promos[j].Code
+	})
+	if len(promos) > limit {
+		promos = promos[:limit]
+	}
+	return promos
+}
+
+// checkCreate checks that no promo code uses code, the caller must hold the lock
+func (promoRepo *PromoRepo) checkCreate(code string) error {
+	if _, exists := promoRepo.promos[code]; exists {
+		return constants.ErrPromoExists
+	}
+	return nil
+}
+
+// found checks that a promo code exists, the caller must hold the lock
+func (promoRepo *PromoRepo) found(code string) error {
+	if _, exists := promoRepo.promos[code]; !exists {
+		return constants.ErrPromoNotFound
+	}
+	return nil
+}
+
+// put inserts or replaces a promo code without validation, used to restore persisted state
+func (promoRepo *PromoRepo) put(promo models.PromoCode) {
+	promoRepo.mu.Lock()
+	defer promoRepo.mu.Unlock()
+
+	promoRepo.promos[promo.Code] = promo
+}
+
+// remove deletes a promo code without validation, used to replay deletions
+func (promoRepo *PromoRepo) remove(code string) {
+	promoRepo.mu.Lock()
+	defer promoRepo.mu.Unlock()
+
+	delete(promoRepo.promos, code)
+}
+
+// all returns every promo code
+func (promoRepo *PromoRepo) all() []models.PromoCode {
+	promoRepo.mu.RLock()
+	defer promoRepo.mu.RUnlock()
+
+	promos := make([]models.PromoCode, 0, len(promoRepo.promos))
+	for _, promo := range promoRepo.promos {
+		promos = append(promos, promo)
+	}
+	return promos
+}
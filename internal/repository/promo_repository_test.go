@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromoRepo(t *testing.T) {
+	testPromoRepository(t, func(t *testing.T) PromoRepository {
+		return NewPromoRepo()
+	})
+}
+
+// testPromoRepository runs the PromoRepository test suite against
+// the implementation returned by newRepo
+func testPromoRepository(t *testing.T, newRepo func(t *testing.T) PromoRepository) {
+	t.Run("CRUD", func(t *testing.T) { testPromoCRUD(t, newRepo(t)) })
+	t.Run("List", func(t *testing.T) { testPromoList(t, newRepo(t)) })
+}
+
+// testPromo returns a fully populated promo code
+func testPromo(code string) models.PromoCode {
+	created := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	from, until := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	return models.PromoCode{
+		Code:           code,
+		Kind:           constants.PromoFixed,
+		Amount:         500,
+		Currency:       "EUR",
+		MaxRedemptions: 100,
+		ClassNames:     []string{"Yoga", "Spin"},
+		ValidFrom:      &from,
+		ValidUntil:     &until,
+		CreatedAt:      created,
+		UpdatedAt:      created,
+	}
+}
+
+func testPromoCRUD(t *testing.T, repo PromoRepository) {
+	promo := testPromo("SUMMER")
+	assert.NoError(t, repo.Create(promo))
+	assert.ErrorIs(t, repo.Create(promo), constants.ErrPromoExists)
+
+	stored, exists := repo.GetByCode("SUMMER")
+	assert.True(t, exists)
+	assert.Equal(t, promo, stored)
+
+	promo.Kind, promo.Percent, promo.Amount, promo.Currency = constants.PromoPercentage, 20, 0, ""
+	promo.ClassNames, promo.ValidFrom = nil, nil
+	promo.UpdatedAt = promo.UpdatedAt.Add(time.Hour)
+	assert.NoError(t, repo.Update(promo))
+	stored, _ = repo.GetByCode("SUMMER")
+	assert.Equal(t, promo, stored)
+
+	assert.NoError(t, repo.Delete("SUMMER"))
+	_, exists = repo.GetByCode("SUMMER")
+	assert.False(t, exists)
+	assert.ErrorIs(t, repo.Delete("SUMMER"), constants.ErrPromoNotFound)
+	assert.ErrorIs(t, repo.Update(promo), constants.ErrPromoNotFound)
+}
+
+func testPromoList(t *testing.T, repo PromoRepository) {
+	for _, code := range []string{"WINTER", "AUTUMN", "SPRING"} {
+		assert.NoError(t, repo.Create(testPromo(code)))
+	}
+
+	codes := func(promos []models.PromoCode) []string {
+		codes := make([]string, 0, len(promos))
+		for _, promo := range promos {
+			codes = append(codes, promo.Code)
+		}
+		return codes
+	}
+	assert.Equal(t, []string{"AUTUMN", "SPRING"}, codes(repo.List("", 2)))
+	assert.Equal(t, []string{"WINTER"}, codes(repo.List("SPRING", 2)))
+	assert.Empty(t, repo.List("WINTER", 2))
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"sort"
+	"sync"
+)
+
+type RoomRepository interface {
+	Create(room models.Room) error
+	GetByID(id string) (models.Room, bool)
+	Update(room models.Room) error
+	Delete(id string) error
+	List(afterID string, limit int) []models.Room
+}
+
+// RoomRepo manages the in-memory room data
+type RoomRepo struct {
+	// Key: room ID
+	rooms map[string]models.Room
+	mu    sync.RWMutex
+}
+
+// NewRoomRepo creates a new RoomRepo
+func NewRoomRepo() *RoomRepo {
+	return &RoomRepo{
+		rooms: make(map[string]models.Room),
+	}
+}
+
+// Create for creating a new room
+func (roomRepo *RoomRepo) Create(room models.Room) error {
+	roomRepo.mu.Lock()
+	defer roomRepo.mu.Unlock()
+
+	roomRepo.rooms[room.ID] = room
+	return nil
+}
+
+// GetByID fetches room by given ID
+func (roomRepo *RoomRepo) GetByID(id string) (models.Room, bool) {
+	roomRepo.mu.RLock()
+	defer roomRepo.mu.RUnlock()
+
+	room, exists := roomRepo.rooms[id]
+	return room, exists
+}
+
+// Update replaces an existing room
+func (roomRepo *RoomRepo) Update(room models.Room) error {
+	roomRepo.mu.Lock()
+	defer roomRepo.mu.Unlock()
+
+	if err := roomRepo.found(room.ID); err != nil {
+		return err
+	}
+	roomRepo.rooms[room.ID] = room
+	return nil
+}
+
+// Delete removes a room
+func (roomRepo *RoomRepo) Delete(id string) error {
+	roomRepo.mu.Lock()
+	defer roomRepo.mu.Unlock()
+
+	if err := roomRepo.found(id); err != nil {
+		return err
+	}
+	delete(roomRepo.rooms, id)
+	return nil
+}
+
+// List returns up to limit rooms sorted by ID, starting after afterID
+func (roomRepo *RoomRepo) List(afterID string, limit int) []models.Room {
+	roomRepo.mu.RLock()
+	defer roomRepo.mu.RUnlock()
+
+	rooms := make([]models.Room, 0, len(roomRepo.rooms))
+	for id, room := range roomRepo.rooms {
+		if id > afterID {
+			rooms = append(rooms, room)
+		}
+	}
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].ID < rooms[j].ID
+	})
+	if len(rooms) > limit {
+		rooms = rooms[:limit]
+	}
+	return rooms
+}
+
+// found checks that a room exists, the caller must hold the lock
+func (roomRepo *RoomRepo) found(id string) error {
+	if _, exists := roomRepo.rooms[id]; !exists {
+		return constants.ErrRoomNotFound
+	}
+	return nil
+}
+
+// put inserts or replaces a room without validation, used to restore persisted state
+func (roomRepo *RoomRepo) put(room models.Room) {
+	roomRepo.mu.Lock()
+	defer roomRepo.mu.Unlock()
+
+	roomRepo.rooms[room.ID] = room
+}
+
+// remove deletes a room without validation, used to replay deletions
+func (roomRepo *RoomRepo) remove(id string) {
+	roomRepo.mu.Lock()
+	defer roomRepo.mu.Unlock()
+
+	delete(roomRepo.rooms, id)
+}
+
+// all returns every room
+func (roomRepo *RoomRepo) all() []models.Room {
+	roomRepo.mu.RLock()
+	defer roomRepo.mu.RUnlock()
+
+	rooms := make([]models.Room, 0, len(roomRepo.rooms))
+	for _, room := range roomRepo.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoomRepo(t *testing.T) {
+	testRoomRepository(t, func(t *testing.T) RoomRepository {
+		return NewRoomRepo()
+	})
+}
+
+// testRoomRepository runs the RoomRepository test suite against
+// the implementation returned by newRepo
+func testRoomRepository(t *testing.T, newRepo func(t *testing.T) RoomRepository) {
+	t.Run("CRUD", func(t *testing.T) { testRoomCRUD(t, newRepo(t)) })
+	t.Run("List", func(t *testing.T) { testRoomList(t, newRepo(t)) })
+}
+
+// testRoom returns a fully populated room
+func testRoom(id, name string) models.Room {
+	created := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	return models.Room{
+		ID:        id,
+		Name:      name,
+		Capacity:  20,
+		Resources: []models.Resource{{Name: "Bikes", Quantity: 18}},
+		Spots:     []models.Spot{{Label: "1", Row: 1, Column: 1}, {Label: "2", Row: 1, Column: 2}},
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+}
+
+func testRoomCRUD(t *testing.T, repo RoomRepository) {
+	room := testRoom("rm_1", "Studio 1")
+	assert.NoError(t, repo.Create(room))
+
+	stored, exists := repo.GetByID("rm_1")
+	assert.True(t, exists)
+	assert.Equal(t, room, stored)
+
+	room.Name = "Spin Room"
+	room.Resources = append(room.Resources, models.Resource{Name: "Mats", Quantity: 20})
+	room.UpdatedAt = room.UpdatedAt.Add(time.Hour)
+	assert.NoError(t, repo.Update(room))
+	stored, _ = repo.GetByID("rm_1")
+	assert.Equal(t, room, stored)
+
+	assert.NoError(t, repo.Delete("rm_1"))
+	_, exists = repo.GetByID("rm_1")
+	assert.False(t, exists)
+	assert.ErrorIs(t, repo.Delete("rm_1"), constants.ErrRoomNotFound)
+	assert.ErrorIs(t, repo.Update(room), constants.ErrRoomNotFound)
+}
+
+func testRoomList(t *testing.T, repo RoomRepository) {
+	for _, id := range []string{"rm_c", "rm_a", "rm_b"} {
+		assert.NoError(t, repo.Create(testRoom(id, "Studio")))
+	}
+
+	ids := func(rooms []models.Room) []string {
+		ids := make([]string, 0, len(rooms))
+		for _, room := range rooms {
+			ids = append(ids, room.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"rm_a", "rm_b"}, ids(repo.List("", 2)))
+	assert.Equal(t, []string{"rm_c"}, ids(repo.List("rm_b", 2)))
+	assert.Empty(t, repo.List("rm_c", 2))
+}
@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// migrations are applied in order and never edited once released, schema
+// changes are made by appending a new migration
+var migrations = []string{
+	// 1: classes, bookings and waitlists
+	`CREATE TABLE classes (
+		name              TEXT PRIMARY KEY,
+		start_date        TEXT NOT NULL,
+		end_date          TEXT NOT NULL,
+		capacity          INTEGER NOT NULL,
+		free_cancel_hours INTEGER NOT NULL,
+		allow_late_cancel INTEGER NOT NULL
+	);
+	CREATE TABLE bookings (
+		id           TEXT PRIMARY KEY,
+		class_name   TEXT NOT NULL,
+		member_name  TEXT NOT NULL,
+		date         TEXT NOT NULL,
+		status       TEXT NOT NULL,
+		created_at   TEXT NOT NULL,
+		late_cancel  INTEGER NOT NULL DEFAULT 0,
+		cancelled_at TEXT
+	);
+	-- A member holds at most one active booking per class and date
+	CREATE UNIQUE INDEX bookings_active_member ON bookings (class_name, date, member_name) WHERE status = 'booked';
+	CREATE INDEX bookings_order ON bookings (date, class_name, id);
+	CREATE TABLE waitlist_entries (
+		seq         INTEGER PRIMARY KEY AUTOINCREMENT,
+		class_name  TEXT NOT NULL,
+		date        TEXT NOT NULL,
+		member_name TEXT NOT NULL,
+		UNIQUE (class_name, date, member_name)
+	);`,
+	// 2: class recurrence, stored as JSON
+	`ALTER TABLE classes ADD COLUMN recurrence TEXT NOT NULL DEFAULT '{}';`,
+	// 3: class session times, stored as JSON
+	`ALTER TABLE classes ADD COLUMN session_times TEXT NOT NULL DEFAULT 'null';`,
+	// 4: class time zone, empty for classes created in UTC before time zones existed
+	`ALTER TABLE classes ADD COLUMN time_zone TEXT NOT NULL DEFAULT '';`,
+	// 5: members, bookings reference them by ID and waitlists hold member IDs
+	`CREATE TABLE members (
+		id         TEXT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		email      TEXT NOT NULL DEFAULT '',
+		phone      TEXT NOT NULL DEFAULT '',
+		status     TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+	CREATE INDEX members_name ON members (name COLLATE NOCASE);
+	ALTER TABLE bookings ADD COLUMN member_id TEXT NOT NULL DEFAULT '';
+	-- Members may share a name, bookings made before members existed stay unique by name
+	DROP INDEX bookings_active_member;
+	CREATE UNIQUE INDEX bookings_active_member ON bookings (class_name, date, member_id, member_name) WHERE status = 'booked';
+	CREATE INDEX bookings_member ON bookings (member_id);`,
+	// 6: responses stored for Idempotency-Key replays
+	`CREATE TABLE idempotency_keys (
+		key          TEXT PRIMARY KEY,
+		fingerprint  TEXT NOT NULL,
+		status_code  INTEGER NOT NULL,
+		content_type TEXT NOT NULL DEFAULT '',
+		body         BLOB,
+		created_at   TEXT NOT NULL,
+		expires_at   TEXT NOT NULL
+	);
+	CREATE INDEX idempotency_keys_expiry ON idempotency_keys (expires_at);`,
+	// 7: class descriptions and the reason a booking was cancelled by the studio
+	`ALTER TABLE classes ADD COLUMN description TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bookings ADD COLUMN cancel_reason TEXT NOT NULL DEFAULT '';`,
+	// 8: versions of classes and bookings for optimistic concurrency
+	`ALTER TABLE classes ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE bookings ADD COLUMN version INTEGER NOT NULL DEFAULT 1;`,
+	// 9: instructors teaching classes
+	`ALTER TABLE classes ADD COLUMN instructor_id TEXT NOT NULL DEFAULT '';`,
+	// 10: studios, rows written before studios existed belong to the default studio
+	`CREATE TABLE classes_by_studio (
+		studio_id         TEXT NOT NULL,
+		name              TEXT NOT NULL,
+		start_date        TEXT NOT NULL,
+		end_date          TEXT NOT NULL,
+		capacity          INTEGER NOT NULL,
+		free_cancel_hours INTEGER NOT NULL,
+		allow_late_cancel INTEGER NOT NULL,
+		recurrence        TEXT NOT NULL DEFAULT '{}',
+		session_times     TEXT NOT NULL DEFAULT 'null',
+		time_zone         TEXT NOT NULL DEFAULT '',
+		description       TEXT NOT NULL DEFAULT '',
+		version           INTEGER NOT NULL DEFAULT 1,
+		instructor_id     TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (studio_id, name)
+	);
+	INSERT INTO classes_by_studio SELECT 'default', name, start_date, end_date, capacity, free_cancel_hours, allow_late_cancel,
+		recurrence, session_times, time_zone, description, version, instructor_id FROM classes;
+	DROP TABLE classes;
+	ALTER TABLE classes_by_studio RENAME TO classes;
+	CREATE TABLE bookings_by_studio (
+		studio_id     TEXT NOT NULL,
+		id            TEXT NOT NULL,
+		class_name    TEXT NOT NULL,
+		member_id     TEXT NOT NULL DEFAULT '',
+		member_name   TEXT NOT NULL,
+		date          TEXT NOT NULL,
+		status        TEXT NOT NULL,
+		created_at    TEXT NOT NULL,
+		late_cancel   INTEGER NOT NULL DEFAULT 0,
+		cancelled_at  TEXT,
+		cancel_reason TEXT NOT NULL DEFAULT '',
+		version       INTEGER NOT NULL DEFAULT 1,
+		PRIMARY KEY (studio_id, id)
+	);
+	INSERT INTO bookings_by_studio SELECT 'default', id, class_name, member_id, member_name, date, status, created_at,
+		late_cancel, cancelled_at, cancel_reason, version FROM bookings;
+	DROP TABLE bookings;
+	ALTER TABLE bookings_by_studio RENAME TO bookings;
+	CREATE UNIQUE INDEX bookings_active_member ON bookings (studio_id, class_name, date, member_id, member_name) WHERE status = 'booked';
+	CREATE INDEX bookings_order ON bookings (studio_id, date, class_name, id);
+	CREATE INDEX bookings_member ON bookings (studio_id, member_id);
+	-- Entries keep their seq so waitlists keep their order
+	CREATE TABLE waitlist_entries_by_studio (
+		seq         INTEGER PRIMARY KEY AUTOINCREMENT,
+		studio_id   TEXT NOT NULL,
+		class_name  TEXT NOT NULL,
+		date        TEXT NOT NULL,
+		member_name TEXT NOT NULL,
+		UNIQUE (studio_id, class_name, date, member_name)
+	);
+	INSERT INTO waitlist_entries_by_studio SELECT seq, 'default', class_name, date, member_name FROM waitlist_entries;
+	DROP TABLE waitlist_entries;
+	ALTER TABLE waitlist_entries_by_studio RENAME TO waitlist_entries;
+	CREATE TABLE members_by_studio (
+		studio_id  TEXT NOT NULL,
+		id         TEXT NOT NULL,
+		name       TEXT NOT NULL,
+		email      TEXT NOT NULL DEFAULT '',
+		phone      TEXT NOT NULL DEFAULT '',
+		status     TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		PRIMARY KEY (studio_id, id)
+	);
+	INSERT INTO members_by_studio SELECT 'default', id, name, email, phone, status, created_at, updated_at FROM members;
+	DROP TABLE members;
+	ALTER TABLE members_by_studio RENAME TO members;
+	CREATE INDEX members_name ON members (studio_id, name COLLATE NOCASE);
+	CREATE TABLE idempotency_keys_by_studio (
+		studio_id    TEXT NOT NULL,
+		key          TEXT NOT NULL,
+		fingerprint  TEXT NOT NULL,
+		status_code  INTEGER NOT NULL,
+		content_type TEXT NOT NULL DEFAULT '',
+		body         BLOB,
+		created_at   TEXT NOT NULL,
+		expires_at   TEXT NOT NULL,
+		PRIMARY KEY (studio_id, key)
+	);
+	INSERT INTO idempotency_keys_by_studio SELECT 'default', key, fingerprint, status_code, content_type, body, created_at, expires_at FROM idempotency_keys;
+	DROP TABLE idempotency_keys;
+	ALTER TABLE idempotency_keys_by_studio RENAME TO idempotency_keys;
+	CREATE INDEX idempotency_keys_expiry ON idempotency_keys (expires_at);`,
+	// 11: instructors and the instructors substituting for single sessions, stored as JSON
+	`CREATE TABLE instructors (
+		studio_id  TEXT NOT NULL,
+		id         TEXT NOT NULL,
+		name       TEXT NOT NULL,
+		email      TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		PRIMARY KEY (studio_id, id)
+	);
+	ALTER TABLE classes ADD COLUMN substitutions TEXT NOT NULL DEFAULT 'null';`,
+	// 12: rooms with their equipment stored as JSON, and the room and equipment of classes
+	`CREATE TABLE rooms (
+		studio_id  TEXT NOT NULL,
+		id         TEXT NOT NULL,
+		name       TEXT NOT NULL,
+		capacity   INTEGER NOT NULL,
+		resources  TEXT NOT NULL DEFAULT 'null',
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		PRIMARY KEY (studio_id, id)
+	);
+	ALTER TABLE classes ADD COLUMN room_id TEXT NOT NULL DEFAULT '';
+	ALTER TABLE classes ADD COLUMN resource TEXT NOT NULL DEFAULT '';`,
+	// 13: seat layouts of rooms stored as JSON, and the spot of bookings
+	`ALTER TABLE rooms ADD COLUMN spots TEXT NOT NULL DEFAULT 'null';
+	ALTER TABLE bookings ADD COLUMN spot TEXT NOT NULL DEFAULT '';
+	CREATE UNIQUE INDEX bookings_active_spot ON bookings (studio_id, class_name, date, spot) WHERE status = 'booked' AND spot <> '';`,
+	// 14: membership plans, the memberships of members and the credit ledger
+	`CREATE TABLE plans (
+		studio_id  TEXT NOT NULL,
+		id         TEXT NOT NULL,
+		name       TEXT NOT NULL,
+		kind       TEXT NOT NULL,
+		classes    INTEGER NOT NULL,
+		valid_days INTEGER NOT NULL,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		PRIMARY KEY (studio_id, id)
+	);
+	ALTER TABLE members ADD COLUMN memberships TEXT NOT NULL DEFAULT 'null';
+	ALTER TABLE bookings ADD COLUMN membership_id TEXT NOT NULL DEFAULT '';
+	CREATE TABLE credit_ledger (
+		seq           INTEGER PRIMARY KEY AUTOINCREMENT,
+		studio_id     TEXT NOT NULL,
+		id            TEXT NOT NULL,
+		member_id     TEXT NOT NULL,
+		membership_id TEXT NOT NULL,
+		amount        INTEGER NOT NULL,
+		reason        TEXT NOT NULL,
+		booking_id    TEXT NOT NULL DEFAULT '',
+		session       TEXT,
+		created_at    TEXT NOT NULL,
+		UNIQUE (studio_id, id)
+	);
+	CREATE INDEX credit_ledger_member ON credit_ledger (studio_id, member_id, membership_id);`,
+	// 15: drop-in prices of classes, payments of bookings stored as JSON, and
+	// pending bookings holding their member and spot like booked ones
+	`ALTER TABLE classes ADD COLUMN drop_in_amount INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE classes ADD COLUMN drop_in_currency TEXT NOT NULL DEFAULT '';
+	ALTER TABLE bookings ADD COLUMN payment TEXT;
+	CREATE INDEX bookings_payment_status ON bookings (studio_id, json_extract(payment, '$.status')) WHERE payment IS NOT NULL;
+	DROP INDEX bookings_active_member;
+	CREATE UNIQUE INDEX bookings_active_member ON bookings (studio_id, class_name, date, member_id, member_name) WHERE status <> 'cancelled';
+	DROP INDEX bookings_active_spot;
+	CREATE UNIQUE INDEX bookings_active_spot ON bookings (studio_id, class_name, date, spot) WHERE status <> 'cancelled' AND spot <> '';`,
+	// 16: promo codes, and the promo code redeemed by bookings stored as JSON
+	`CREATE TABLE promo_codes (
+		studio_id       TEXT NOT NULL,
+		code            TEXT NOT NULL,
+		kind            TEXT NOT NULL,
+		percent         INTEGER NOT NULL,
+		amount          INTEGER NOT NULL,
+		currency        TEXT NOT NULL,
+		max_redemptions INTEGER NOT NULL,
+		class_names     TEXT NOT NULL,
+		valid_from      TEXT,
+		valid_until     TEXT,
+		created_at      TEXT NOT NULL,
+		updated_at      TEXT NOT NULL,
+		PRIMARY KEY (studio_id, code)
+	);
+	ALTER TABLE bookings ADD COLUMN promo TEXT;
+	CREATE INDEX bookings_promo_code ON bookings (studio_id, json_extract(promo, '$.code')) WHERE promo IS NOT NULL;`,
+	// 17: studios, recorded on first use so that only existing studios are
+	// served to unauthenticated requests, starting with those holding data
+	`CREATE TABLE studios (
+		id TEXT PRIMARY KEY
+	);
+	INSERT INTO studios (id) SELECT studio_id FROM (
+		SELECT studio_id FROM classes UNION SELECT studio_id FROM bookings UNION SELECT studio_id FROM waitlist_entries
+		UNION SELECT studio_id FROM members UNION SELECT studio_id FROM instructors UNION SELECT studio_id FROM rooms
+		UNION SELECT studio_id FROM plans UNION SELECT studio_id FROM credit_ledger UNION SELECT studio_id FROM promo_codes
+		UNION SELECT studio_id FROM idempotency_keys
+	);`,
+	// 18: versions of members for optimistic concurrency
+	`ALTER TABLE members ADD COLUMN version INTEGER NOT NULL DEFAULT 1;`,
+}
+
+// Migrate applies the migrations that the database has not seen yet
+func Migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this build (%d)", current, len(migrations))
+	}
+
+	for version := current + 1; version <= len(migrations); version++ {
+		if err := applyMigration(db, version); err != nil {
+			return err
+		}
+		log.Printf("Applied database migration %d", version)
+	}
+	return nil
+}
+
+// applyMigration runs one migration and records it in the same transaction
+func applyMigration(db *sql.DB, version int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(migrations[version-1]); err != nil {
+		return fmt.Errorf("migration %d: %w", version, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, formatTime(time.Now())); err != nil {
+		return fmt.Errorf("record migration %d: %w", version, err)
+	}
+	return tx.Commit()
+}
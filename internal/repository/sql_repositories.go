@@ -0,0 +1,1482 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"log"
+	"strings"
+	"time"
+)
+
+// scanner is satisfied by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// SQLClassRepo stores classes in a SQL database
+type SQLClassRepo struct {
+	db       *sql.DB
+	studioID string
+}
+
+// NewSQLClassRepo creates the SQLClassRepo of a studio
+func NewSQLClassRepo(db *sql.DB, studioID string) *SQLClassRepo {
+	return &SQLClassRepo{db: db, studioID: studioID}
+}
+
+const classColumns = `name, start_date, end_date, capacity, free_cancel_hours, allow_late_cancel, recurrence, session_times, time_zone, description, instructor_id, substitutions, room_id, resource, drop_in_amount, drop_in_currency, version`
+
+// Create for creating a new class, the class starts at version 1
+func (classRepo *SQLClassRepo) Create(class models.Class) (models.Class, error) {
+	recurrence, err := json.Marshal(class.Recurrence)
+	if err != nil {
+		return models.Class{}, err
+	}
+	sessionTimes, err := json.Marshal(class.SessionTimes)
+	if err != nil {
+		return models.Class{}, err
+	}
+	substitutions, err := json.Marshal(class.Substitutions)
+	if err != nil {
+		return models.Class{}, err
+	}
+	dropInAmount, dropInCurrency := dropInColumns(class.DropIn)
+	class.Version = 1
+	_, err = classRepo.db.Exec(`INSERT INTO classes (studio_id, `+classColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		classRepo.studioID, class.Name, formatTime(class.StartDate), formatTime(class.EndDate), class.Capacity,
+		class.CancellationPolicy.FreeCancelHours, class.CancellationPolicy.AllowLateCancel, string(recurrence), string(sessionTimes), class.TimeZone,
+		class.Description, class.InstructorID, string(substitutions), class.RoomID, class.Resource, dropInAmount, dropInCurrency, class.Version)
+	if isUniqueViolation(err) {
+		return models.Class{}, constants.ErrClassAlreadyExists
+	}
+	if err != nil {
+		return models.Class{}, err
+	}
+	return class, nil
+}
+
+// GetByName fetches class by given name
+func (classRepo *SQLClassRepo) GetByName(name string) (models.Class, bool) {
+	class, err := scanClass(classRepo.db.QueryRow(`SELECT `+classColumns+` FROM classes WHERE studio_id = ? AND name = ?`, classRepo.studioID, name))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to get class %s: %v", name, err)
+		}
+		return models.Class{}, false
+	}
+	return class, true
+}
+
+// List returns up to limit classes sorted by name, starting after afterName
+func (classRepo *SQLClassRepo) List(afterName string, limit int) []models.Class {
+	rows, err := classRepo.db.Query(`SELECT `+classColumns+` FROM classes WHERE studio_id = ? AND name > ? ORDER BY name LIMIT ?`, classRepo.studioID, afterName, limit)
+	if err != nil {
+		log.Printf("Failed to list classes: %v", err)
+		return []models.Class{}
+	}
+	defer rows.Close()
+
+	classes := []models.Class{}
+	for rows.Next() {
+		class, err := scanClass(rows)
+		if err != nil {
+			log.Printf("Failed to list classes: %v", err)
+			return []models.Class{}
+		}
+		classes = append(classes, class)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to list classes: %v", err)
+	}
+	return classes
+}
+
+// Update replaces an existing class, the version is compared and
+// incremented by the same statement so concurrent updates cannot overwrite
+// each other
+func (classRepo *SQLClassRepo) Update(class models.Class) (models.Class, error) {
+	recurrence, err := json.Marshal(class.Recurrence)
+	if err != nil {
+		return models.Class{}, err
+	}
+	sessionTimes, err := json.Marshal(class.SessionTimes)
+	if err != nil {
+		return models.Class{}, err
+	}
+	substitutions, err := json.Marshal(class.Substitutions)
+	if err != nil {
+		return models.Class{}, err
+	}
+	dropInAmount, dropInCurrency := dropInColumns(class.DropIn)
+	result, err := classRepo.db.Exec(`UPDATE classes SET start_date = ?, end_date = ?, capacity = ?, free_cancel_hours = ?, allow_late_cancel = ?,
+		recurrence = ?, session_times = ?, time_zone = ?, description = ?, instructor_id = ?, substitutions = ?, room_id = ?, resource = ?,
+		drop_in_amount = ?, drop_in_currency = ?, version = version + 1 WHERE studio_id = ? AND name = ? AND version = ?`,
+		formatTime(class.StartDate), formatTime(class.EndDate), class.Capacity,
+		class.CancellationPolicy.FreeCancelHours, class.CancellationPolicy.AllowLateCancel, string(recurrence), string(sessionTimes), class.TimeZone,
+		class.Description, class.InstructorID, string(substitutions), class.RoomID, class.Resource, dropInAmount, dropInCurrency,
+		classRepo.studioID, class.Name, class.Version)
+	if err := classRepo.versionedOne(result, err, class.Name); err != nil {
+		return models.Class{}, err
+	}
+	class.Version++
+	return class, nil
+}
+
+// Delete removes a class, its bookings are kept
+func (classRepo *SQLClassRepo) Delete(name string, version int) error {
+	result, err := classRepo.db.Exec(`DELETE FROM classes WHERE studio_id = ? AND name = ? AND version = ?`, classRepo.studioID, name, version)
+	return classRepo.versionedOne(result, err, name)
+}
+
+// versionedOne checks that a statement matching the name and version of a
+// class changed one row, and tells a missing class from a stale version
+func (classRepo *SQLClassRepo) versionedOne(result sql.Result, err error, name string) error {
+	if err := affectedOne(result, err, constants.ErrVersionMismatch); !errors.Is(err, constants.ErrVersionMismatch) {
+		return err
+	}
+	if _, exists := classRepo.GetByName(name); !exists {
+		return constants.ErrClassNotFound
+	}
+	return constants.ErrVersionMismatch
+}
+
+// scanClass reads a row selected with classColumns
+func scanClass(row scanner) (models.Class, error) {
+	var class models.Class
+	var startDate, endDate, recurrence, sessionTimes, substitutions string
+	var dropIn models.Price
+	err := row.Scan(&class.Name, &startDate, &endDate, &class.Capacity,
+		&class.CancellationPolicy.FreeCancelHours, &class.CancellationPolicy.AllowLateCancel, &recurrence, &sessionTimes, &class.TimeZone,
+		&class.Description, &class.InstructorID, &substitutions, &class.RoomID, &class.Resource, &dropIn.Amount, &dropIn.Currency, &class.Version)
+	if err != nil {
+		return models.Class{}, err
+	}
+	if dropIn.Amount > 0 {
+		class.DropIn = &dropIn
+	}
+	if err := json.Unmarshal([]byte(recurrence), &class.Recurrence); err != nil {
+		return models.Class{}, err
+	}
+	if err := json.Unmarshal([]byte(sessionTimes), &class.SessionTimes); err != nil {
+		return models.Class{}, err
+	}
+	if err := json.Unmarshal([]byte(substitutions), &class.Substitutions); err != nil {
+		return models.Class{}, err
+	}
+	if class.StartDate, err = parseTime(startDate); err != nil {
+		return models.Class{}, err
+	}
+	if class.EndDate, err = parseTime(endDate); err != nil {
+		return models.Class{}, err
+	}
+	return class, nil
+}
+
+// dropInColumns returns the columns storing the drop-in price of a class,
+// an amount of 0 when it has none
+func dropInColumns(price *models.Price) (int64, string) {
+	if price == nil {
+		return 0, ""
+	}
+	return price.Amount, price.Currency
+}
+
+// SQLBookingRepo stores bookings in a SQL database
+type SQLBookingRepo struct {
+	db       *sql.DB
+	studioID string
+}
+
+// NewSQLBookingRepo creates the SQLBookingRepo of a studio
+func NewSQLBookingRepo(db *sql.DB, studioID string) *SQLBookingRepo {
+	return &SQLBookingRepo{db: db, studioID: studioID}
+}
+
+const bookingColumns = `id, class_name, member_id, member_name, date, status, created_at, late_cancel, cancelled_at, cancel_reason, spot, membership_id, payment, promo, version`
+
+// Create for creating a new booking of the class, member and date of
+// booking, the duplicate, daily limit, capacity, spot, credit and promo code
+// checks, the insert and the debit run in one transaction so concurrent
+// bookings cannot oversell a date, book a member or a spot twice, spend a
+// credit twice or redeem a promo code past its limits
+func (bookingRepo *SQLBookingRepo) Create(booking models.Booking, limits models.BookingLimits) (models.Booking, error) {
+	booking = newBooking(booking)
+
+	tx, err := bookingRepo.db.Begin()
+	if err != nil {
+		return models.Booking{}, err
+	}
+	defer tx.Rollback()
+
+	var duplicates int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM bookings WHERE studio_id = ? AND class_name = ? AND date = ? AND status <> ? AND `+sameMemberClause,
+		bookingRepo.studioID, booking.ClassName, formatTime(booking.Date), constants.BookingStatusCancelled,
+		booking.MemberID, booking.MemberID, booking.MemberName).Scan(&duplicates)
+	if err != nil {
+		return models.Booking{}, err
+	}
+	if duplicates > 0 {
+		return models.Booking{}, constants.ErrAlreadyBooked
+	}
+
+	if limits.PerDay > 0 {
+		var held int
+		err = tx.QueryRow(`SELECT COUNT(*) FROM bookings WHERE studio_id = ? AND date >= ? AND date < ? AND status <> ? AND `+sameMemberClause,
+			bookingRepo.studioID, formatTime(limits.DayStart), formatTime(limits.DayEnd), constants.BookingStatusCancelled,
+			booking.MemberID, booking.MemberID, booking.MemberName).Scan(&held)
+		if err != nil {
+			return models.Booking{}, err
+		}
+		if held >= limits.PerDay {
+			return models.Booking{}, constants.ErrDailyLimitReached
+		}
+	}
+
+	taken, err := bookingRepo.bookedSpots(tx, booking.ClassName, booking.Date)
+	if err != nil {
+		return models.Booking{}, err
+	}
+	if len(taken) >= limits.Capacity {
+		return models.Booking{}, constants.ErrClassFull
+	}
+	if booking.Spot, err = pickSpot(booking.Spot, limits.Spots, spotSet(taken)); err != nil {
+		return models.Booking{}, err
+	}
+	if limits.Charge != nil {
+		balance, err := bookingRepo.balance(tx, booking.MemberID, *limits.Charge)
+		if err != nil {
+			return models.Booking{}, err
+		}
+		if balance < 1 {
+			return models.Booking{}, constants.ErrNoEntitlement
+		}
+		booking.MembershipID = limits.Charge.MembershipID
+	}
+	if limits.Promo != nil {
+		if err := bookingRepo.redeem(tx, booking, *limits.Promo); err != nil {
+			return models.Booking{}, err
+		}
+	}
+
+	payment, err := paymentColumn(booking.Payment)
+	if err != nil {
+		return models.Booking{}, err
+	}
+	promo, err := promoColumn(booking.Promo)
+	if err != nil {
+		return models.Booking{}, err
+	}
+	_, err = tx.Exec(`INSERT INTO bookings (studio_id, `+bookingColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		bookingRepo.studioID, booking.ID, booking.ClassName, booking.MemberID, booking.MemberName, formatTime(booking.Date), booking.Status,
+		formatTime(booking.CreatedAt), false, nil, "", booking.Spot, booking.MembershipID, payment, promo, booking.Version)
+	if isUniqueViolation(err) {
+		return models.Booking{}, bookingConflict(err)
+	}
+	if err != nil {
+		return models.Booking{}, err
+	}
+	if booking.MembershipID != "" {
+		if err := bookingRepo.addCredit(tx, newCredit(booking, -1, constants.CreditBooking)); err != nil {
+			return models.Booking{}, err
+		}
+	}
+	return booking, tx.Commit()
+}
+
+// balance returns the credits a member has left with the membership of a
+// charge, read in db or in the transaction of a booking
+func (bookingRepo *SQLBookingRepo) balance(db queryRower, memberID string, charge models.Charge) (int, error) {
+	query := `SELECT COALESCE(SUM(amount), 0) FROM credit_ledger WHERE studio_id = ? AND member_id = ? AND membership_id = ?`
+	args := []interface{}{bookingRepo.studioID, memberID, charge.MembershipID}
+	if !charge.From.IsZero() {
+		query += ` AND session >= ? AND session < ?`
+		args = append(args, formatTime(charge.From), formatTime(charge.To))
+	}
+	var sum int
+	if err := db.QueryRow(query, args...).Scan(&sum); err != nil {
+		return 0, err
+	}
+	return charge.Allowance + sum, nil
+}
+
+// redeem checks that the member of booking may redeem a promo code
+func (bookingRepo *SQLBookingRepo) redeem(tx *sql.Tx, booking models.Booking, promo models.PromoLimit) error {
+	var redemptions, redeemed, held int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM bookings WHERE studio_id = ? AND status <> ? AND promo IS NOT NULL AND json_extract(promo, '$.code') = ?`,
+		bookingRepo.studioID, constants.BookingStatusCancelled, promo.Code).Scan(&redemptions)
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRow(`SELECT COUNT(*), COALESCE(SUM(promo IS NOT NULL AND json_extract(promo, '$.code') = ?), 0) FROM bookings
+		WHERE studio_id = ? AND status <> ? AND `+sameMemberClause,
+		promo.Code, bookingRepo.studioID, constants.BookingStatusCancelled,
+		booking.MemberID, booking.MemberID, booking.MemberName).Scan(&held, &redeemed)
+	if err != nil {
+		return err
+	}
+	if redeemed > 0 {
+		return constants.ErrPromoRedeemed
+	}
+	return promoError(redemptions, held > 0, promo)
+}
+
+// bookedSpots returns the spot of every active booking of a session, empty
+// for bookings without one
+func (bookingRepo *SQLBookingRepo) bookedSpots(tx *sql.Tx, className string, date time.Time) ([]string, error) {
+	rows, err := tx.Query(`SELECT spot FROM bookings WHERE studio_id = ? AND class_name = ? AND date = ? AND status <> ?`,
+		bookingRepo.studioID, className, formatTime(date), constants.BookingStatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var spots []string
+	for rows.Next() {
+		var spot string
+		if err := rows.Scan(&spot); err != nil {
+			return nil, err
+		}
+		spots = append(spots, spot)
+	}
+	return spots, rows.Err()
+}
+
+// bookingConflict maps a unique constraint failure of a booking insert to the
+// error of the index it failed, bookings_active_spot on the spot of a session
+// or bookings_active_member on its member
+func bookingConflict(err error) error {
+	if strings.Contains(err.Error(), "bookings.spot") {
+		return constants.ErrSpotTaken
+	}
+	return constants.ErrAlreadyBooked
+}
+
+// spotSet returns the set of the given spots
+func spotSet(spots []string) map[string]bool {
+	set := make(map[string]bool, len(spots))
+	for _, spot := range spots {
+		set[spot] = true
+	}
+	return set
+}
+
+// sameMemberClause matches the bookings of a member given their ID twice and
+// name, bookings made before members were registered are told apart by name
+const sameMemberClause = `member_id = ? AND (? <> '' OR member_name = ?)`
+
+// GetByID fetches booking by given ID
+func (bookingRepo *SQLBookingRepo) GetByID(id string) (models.Booking, bool) {
+	booking, err := scanBooking(bookingRepo.db.QueryRow(`SELECT `+bookingColumns+` FROM bookings WHERE studio_id = ? AND id = ?`, bookingRepo.studioID, id))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to get booking %s: %v", id, err)
+		}
+		return models.Booking{}, false
+	}
+	return booking, true
+}
+
+// Cancel marks a booking as cancelled and frees its seat
+func (bookingRepo *SQLBookingRepo) Cancel(id string, version int, cancelledAt time.Time, lateCancel bool, reason string) (models.Booking, error) {
+	tx, err := bookingRepo.db.Begin()
+	if err != nil {
+		return models.Booking{}, err
+	}
+	defer tx.Rollback()
+
+	booking, err := scanBooking(tx.QueryRow(`SELECT `+bookingColumns+` FROM bookings WHERE studio_id = ? AND id = ?`, bookingRepo.studioID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Booking{}, constants.ErrBookingNotFound
+	}
+	if err != nil {
+		return models.Booking{}, err
+	}
+	if booking.Version != version {
+		return models.Booking{}, constants.ErrVersionMismatch
+	}
+	if booking.Status == constants.BookingStatusCancelled {
+		return models.Booking{}, constants.ErrAlreadyCancelled
+	}
+
+	booking.Status = constants.BookingStatusCancelled
+	booking.LateCancel = lateCancel
+	booking.CancelledAt = &cancelledAt
+	booking.CancelReason = reason
+	booking.Payment = refundPayment(booking.Payment, lateCancel, cancelledAt)
+	booking.Version++
+	payment, err := paymentColumn(booking.Payment)
+	if err != nil {
+		return models.Booking{}, err
+	}
+	result, err := tx.Exec(`UPDATE bookings SET status = ?, late_cancel = ?, cancelled_at = ?, cancel_reason = ?, payment = ?, version = version + 1
+		WHERE studio_id = ? AND id = ? AND version = ?`,
+		booking.Status, lateCancel, formatTime(cancelledAt), reason, payment, bookingRepo.studioID, id, version)
+	if err := affectedOne(result, err, constants.ErrVersionMismatch); err != nil {
+		return models.Booking{}, err
+	}
+	if booking.MembershipID != "" && !lateCancel {
+		if err := bookingRepo.addCredit(tx, newCredit(booking, 1, constants.CreditRefund)); err != nil {
+			return models.Booking{}, err
+		}
+	}
+	return booking, tx.Commit()
+}
+
+// UpdatePayment stores the payment of a booking, see BookingRepo.UpdatePayment
+func (bookingRepo *SQLBookingRepo) UpdatePayment(id string, version int, payment models.Payment) (models.Booking, error) {
+	tx, err := bookingRepo.db.Begin()
+	if err != nil {
+		return models.Booking{}, err
+	}
+	defer tx.Rollback()
+
+	booking, err := scanBooking(tx.QueryRow(`SELECT `+bookingColumns+` FROM bookings WHERE studio_id = ? AND id = ?`, bookingRepo.studioID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Booking{}, constants.ErrBookingNotFound
+	}
+	if err != nil {
+		return models.Booking{}, err
+	}
+	if booking.Version != version {
+		return models.Booking{}, constants.ErrVersionMismatch
+	}
+	if booking.Payment == nil || booking.Payment.ID != payment.ID {
+		return models.Booking{}, constants.ErrPaymentNotFound
+	}
+
+	booking = settlePayment(booking, payment)
+	column, err := paymentColumn(booking.Payment)
+	if err != nil {
+		return models.Booking{}, err
+	}
+	var cancelledAt interface{}
+	if booking.CancelledAt != nil {
+		cancelledAt = formatTime(*booking.CancelledAt)
+	}
+	result, err := tx.Exec(`UPDATE bookings SET status = ?, cancelled_at = ?, cancel_reason = ?, payment = ?, version = version + 1
+		WHERE studio_id = ? AND id = ? AND version = ?`,
+		booking.Status, cancelledAt, booking.CancelReason, column, bookingRepo.studioID, id, version)
+	if err := affectedOne(result, err, constants.ErrVersionMismatch); err != nil {
+		return models.Booking{}, err
+	}
+	return booking, tx.Commit()
+}
+
+// DeleteByClass removes the bookings of a deleted class, see BookingRepo.DeleteByClass
+func (bookingRepo *SQLBookingRepo) DeleteByClass(className string) error {
+	_, err := bookingRepo.db.Exec(`DELETE FROM bookings WHERE studio_id = ? AND class_name = ?
+		AND (payment IS NULL OR json_extract(payment, '$.status') NOT IN (?, ?, ?))`,
+		bookingRepo.studioID, className, constants.PaymentPending, constants.PaymentAuthorized, constants.PaymentRefundPending)
+	return err
+}
+
+// paymentColumn returns the JSON stored in the payment column, NULL for
+// bookings without a payment
+func paymentColumn(payment *models.Payment) (interface{}, error) {
+	if payment == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(payment)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// promoColumn returns the promo column of a booking, NULL when it redeemed no code
+func promoColumn(promo *models.Promo) (interface{}, error) {
+	if promo == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(promo)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+const creditColumns = `id, member_id, membership_id, amount, reason, booking_id, session, created_at`
+
+// AddCredit appends an entry to the credit ledger of a member
+func (bookingRepo *SQLBookingRepo) AddCredit(entry models.CreditEntry) error {
+	tx, err := bookingRepo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := bookingRepo.addCredit(tx, entry); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addCredit inserts a ledger entry in the transaction of a booking change
+func (bookingRepo *SQLBookingRepo) addCredit(tx *sql.Tx, entry models.CreditEntry) error {
+	var session interface{}
+	if entry.Session != nil {
+		session = formatTime(*entry.Session)
+	}
+	_, err := tx.Exec(`INSERT INTO credit_ledger (studio_id, `+creditColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		bookingRepo.studioID, entry.ID, entry.MemberID, entry.MembershipID, entry.Amount, entry.Reason, entry.BookingID, session, formatTime(entry.CreatedAt))
+	return err
+}
+
+// Credits returns the credit ledger of a member, oldest entry first
+func (bookingRepo *SQLBookingRepo) Credits(memberID string) []models.CreditEntry {
+	entries := []models.CreditEntry{}
+	rows, err := bookingRepo.db.Query(`SELECT `+creditColumns+` FROM credit_ledger WHERE studio_id = ? AND member_id = ? ORDER BY seq`,
+		bookingRepo.studioID, memberID)
+	if err != nil {
+		log.Printf("Failed to list credits of %s: %v", memberID, err)
+		return entries
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanCredit(rows)
+		if err != nil {
+			log.Printf("Failed to list credits of %s: %v", memberID, err)
+			return []models.CreditEntry{}
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to list credits of %s: %v", memberID, err)
+	}
+	return entries
+}
+
+// Balance returns the credits a member has left with the membership of a
+// charge, failures are logged and leave no credits
+func (bookingRepo *SQLBookingRepo) Balance(memberID string, charge models.Charge) int {
+	balance, err := bookingRepo.balance(bookingRepo.db, memberID, charge)
+	if err != nil {
+		log.Printf("Failed to read the credits of %s: %v", memberID, err)
+		return 0
+	}
+	return balance
+}
+
+// scanCredit reads a row selected with creditColumns
+func scanCredit(row scanner) (models.CreditEntry, error) {
+	var entry models.CreditEntry
+	var session sql.NullString
+	var createdAt string
+	err := row.Scan(&entry.ID, &entry.MemberID, &entry.MembershipID, &entry.Amount, &entry.Reason, &entry.BookingID, &session, &createdAt)
+	if err != nil {
+		return models.CreditEntry{}, err
+	}
+	if session.Valid {
+		t, err := parseTime(session.String)
+		if err != nil {
+			return models.CreditEntry{}, err
+		}
+		entry.Session = &t
+	}
+	if entry.CreatedAt, err = parseTime(createdAt); err != nil {
+		return models.CreditEntry{}, err
+	}
+	return entry, nil
+}
+
+// Count returns the number of active bookings for a class on a date
+func (bookingRepo *SQLBookingRepo) Count(className string, date time.Time) int {
+	var booked int
+	err := bookingRepo.db.QueryRow(`SELECT COUNT(*) FROM bookings WHERE studio_id = ? AND class_name = ? AND date = ? AND status <> ?`,
+		bookingRepo.studioID, className, formatTime(date), constants.BookingStatusCancelled).Scan(&booked)
+	if err != nil {
+		log.Printf("Failed to count bookings of %s: %v", className, err)
+	}
+	return booked
+}
+
+// Query returns up to filter.Limit bookings matching the filter, sorted by
+// date, class name and ID so that pages are stable between calls
+func (bookingRepo *SQLBookingRepo) Query(filter models.BookingFilter) []models.Booking {
+	where := []string{`studio_id = ?`}
+	args := []interface{}{bookingRepo.studioID}
+	if filter.MemberID != "" {
+		where, args = append(where, `member_id = ?`), append(args, filter.MemberID)
+	}
+	if filter.MemberName != "" {
+		where, args = append(where, `member_name = ?`), append(args, filter.MemberName)
+	}
+	if filter.ClassName != "" {
+		where, args = append(where, `class_name = ?`), append(args, filter.ClassName)
+	}
+	if !filter.From.IsZero() {
+		where, args = append(where, `date >= ?`), append(args, formatTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		where, args = append(where, `date <= ?`), append(args, formatTime(filter.To))
+	}
+	if filter.PromoCode != "" {
+		where, args = append(where, `json_extract(promo, '$.code') = ?`), append(args, filter.PromoCode)
+	}
+	if len(filter.PaymentStatuses) > 0 {
+		where = append(where, `payment IS NOT NULL AND json_extract(payment, '$.status') IN (?`+strings.Repeat(`, ?`, len(filter.PaymentStatuses)-1)+`)`)
+		for _, status := range filter.PaymentStatuses {
+			args = append(args, status)
+		}
+	}
+	if filter.After != nil {
+		after := formatTime(filter.After.Date)
+		where = append(where, `(date > ? OR (date = ? AND (class_name > ? OR (class_name = ? AND id > ?))))`)
+		args = append(args, after, after, filter.After.ClassName, filter.After.ClassName, filter.After.ID)
+	}
+
+	query := `SELECT ` + bookingColumns + ` FROM bookings WHERE ` + strings.Join(where, ` AND `) + ` ORDER BY date, class_name, id`
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(` LIMIT %d`, filter.Limit)
+	}
+
+	rows, err := bookingRepo.db.Query(query, args...)
+	if err != nil {
+		log.Printf("Failed to query bookings: %v", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var bookings []models.Booking
+	for rows.Next() {
+		booking, err := scanBooking(rows)
+		if err != nil {
+			log.Printf("Failed to query bookings: %v", err)
+			return nil
+		}
+		bookings = append(bookings, booking)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to query bookings: %v", err)
+	}
+	return bookings
+}
+
+// scanBooking reads a row selected with bookingColumns
+func scanBooking(row scanner) (models.Booking, error) {
+	var booking models.Booking
+	var date, createdAt string
+	var cancelledAt, payment, promo sql.NullString
+	err := row.Scan(&booking.ID, &booking.ClassName, &booking.MemberID, &booking.MemberName, &date, &booking.Status,
+		&createdAt, &booking.LateCancel, &cancelledAt, &booking.CancelReason, &booking.Spot, &booking.MembershipID, &payment, &promo, &booking.Version)
+	if err != nil {
+		return models.Booking{}, err
+	}
+	if payment.Valid {
+		if err := json.Unmarshal([]byte(payment.String), &booking.Payment); err != nil {
+			return models.Booking{}, err
+		}
+	}
+	if promo.Valid {
+		if err := json.Unmarshal([]byte(promo.String), &booking.Promo); err != nil {
+			return models.Booking{}, err
+		}
+	}
+	if booking.Date, err = parseTime(date); err != nil {
+		return models.Booking{}, err
+	}
+	if booking.CreatedAt, err = parseTime(createdAt); err != nil {
+		return models.Booking{}, err
+	}
+	if cancelledAt.Valid {
+		t, err := parseTime(cancelledAt.String)
+		if err != nil {
+			return models.Booking{}, err
+		}
+		booking.CancelledAt = &t
+	}
+	return booking, nil
+}
+
+// SQLWaitlistRepo stores waitlists in a SQL database
+type SQLWaitlistRepo struct {
+	db       *sql.DB
+	studioID string
+}
+
+// NewSQLWaitlistRepo creates the SQLWaitlistRepo of a studio
+func NewSQLWaitlistRepo(db *sql.DB, studioID string) *SQLWaitlistRepo {
+	return &SQLWaitlistRepo{db: db, studioID: studioID}
+}
+
+// Join appends a member to the waitlist and returns their 1-based position
+func (waitlistRepo *SQLWaitlistRepo) Join(className, memberName string, date time.Time) (int, error) {
+	tx, err := waitlistRepo.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO waitlist_entries (studio_id, class_name, date, member_name) VALUES (?, ?, ?, ?)`,
+		waitlistRepo.studioID, className, formatTime(date), memberName)
+	if isUniqueViolation(err) {
+		return 0, constants.ErrAlreadyWaitlisted
+	}
+	if err != nil {
+		return 0, err
+	}
+	position, err := waitlistPosition(tx, waitlistRepo.studioID, className, memberName, date)
+	if err != nil {
+		return 0, err
+	}
+	return position, tx.Commit()
+}
+
+// Leave removes a member from the waitlist
+func (waitlistRepo *SQLWaitlistRepo) Leave(className, memberName string, date time.Time) error {
+	result, err := waitlistRepo.db.Exec(`DELETE FROM waitlist_entries WHERE studio_id = ? AND class_name = ? AND date = ? AND member_name = ?`,
+		waitlistRepo.studioID, className, formatTime(date), memberName)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return constants.ErrNotOnWaitlist
+	}
+	return nil
+}
+
+// Position returns the 1-based position of a member on the waitlist
+func (waitlistRepo *SQLWaitlistRepo) Position(className, memberName string, date time.Time) (int, error) {
+	position, err := waitlistPosition(waitlistRepo.db, waitlistRepo.studioID, className, memberName, date)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, constants.ErrNotOnWaitlist
+	}
+	return position, err
+}
+
+// Peek returns the member at the head of the waitlist without removing them
+func (waitlistRepo *SQLWaitlistRepo) Peek(className string, date time.Time) (string, bool) {
+	var memberName string
+	err := waitlistRepo.db.QueryRow(`SELECT member_name FROM waitlist_entries WHERE studio_id = ? AND class_name = ? AND date = ? ORDER BY seq LIMIT 1`,
+		waitlistRepo.studioID, className, formatTime(date)).Scan(&memberName)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to peek waitlist of %s: %v", className, err)
+		}
+		return "", false
+	}
+	return memberName, true
+}
+
+// queryRower is satisfied by *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// waitlistPosition counts the entries of the session up to and including the member's
+func waitlistPosition(q queryRower, studioID, className, memberName string, date time.Time) (int, error) {
+	var position int
+	err := q.QueryRow(`SELECT COUNT(*) FROM waitlist_entries w
+		JOIN waitlist_entries m ON m.studio_id = w.studio_id AND m.class_name = w.class_name AND m.date = w.date AND m.member_name = ?
+		WHERE w.studio_id = ? AND w.class_name = ? AND w.date = ? AND w.seq <= m.seq`,
+		memberName, studioID, className, formatTime(date)).Scan(&position)
+	if err != nil {
+		return 0, err
+	}
+	if position == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return position, nil
+}
+
+// SQLMemberRepo stores members in a SQL database
+type SQLMemberRepo struct {
+	db       *sql.DB
+	studioID string
+}
+
+// NewSQLMemberRepo creates the SQLMemberRepo of a studio
+func NewSQLMemberRepo(db *sql.DB, studioID string) *SQLMemberRepo {
+	return &SQLMemberRepo{db: db, studioID: studioID}
+}
+
+const memberColumns = `id, name, email, phone, status, memberships, created_at, updated_at, version`
+
+// Create for creating a new member, the member starts at version 1
+func (memberRepo *SQLMemberRepo) Create(member models.Member) (models.Member, error) {
+	memberships, err := json.Marshal(member.Memberships)
+	if err != nil {
+		return models.Member{}, err
+	}
+	member.Version = 1
+	_, err = memberRepo.db.Exec(`INSERT INTO members (studio_id, `+memberColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		memberRepo.studioID, member.ID, member.Name, member.Email, member.Phone, member.Status, string(memberships),
+		formatTime(member.CreatedAt), formatTime(member.UpdatedAt), member.Version)
+	if err != nil {
+		return models.Member{}, err
+	}
+	return member, nil
+}
+
+// GetByID fetches member by given ID
+func (memberRepo *SQLMemberRepo) GetByID(id string) (models.Member, bool) {
+	member, err := scanMember(memberRepo.db.QueryRow(`SELECT `+memberColumns+` FROM members WHERE studio_id = ? AND id = ?`, memberRepo.studioID, id))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to get member %s: %v", id, err)
+		}
+		return models.Member{}, false
+	}
+	return member, true
+}
+
+// FindByName returns the members whose name matches, ignoring case and
+// surrounding spaces, sorted by ID. Names are stored trimmed.
+func (memberRepo *SQLMemberRepo) FindByName(name string) []models.Member {
+	return memberRepo.query(`SELECT `+memberColumns+` FROM members WHERE studio_id = ? AND name = TRIM(?) COLLATE NOCASE ORDER BY id`, memberRepo.studioID, name)
+}
+
+// Update replaces an existing member, the version is compared and
+// incremented by the same statement so concurrent updates cannot overwrite
+// each other
+func (memberRepo *SQLMemberRepo) Update(member models.Member) (models.Member, error) {
+	memberships, err := json.Marshal(member.Memberships)
+	if err != nil {
+		return models.Member{}, err
+	}
+	result, err := memberRepo.db.Exec(`UPDATE members SET name = ?, email = ?, phone = ?, status = ?, memberships = ?, updated_at = ?,
+		version = version + 1 WHERE studio_id = ? AND id = ? AND version = ?`,
+		member.Name, member.Email, member.Phone, member.Status, string(memberships), formatTime(member.UpdatedAt),
+		memberRepo.studioID, member.ID, member.Version)
+	if err := memberRepo.versionedOne(result, err, member.ID); err != nil {
+		return models.Member{}, err
+	}
+	member.Version++
+	return member, nil
+}
+
+// versionedOne checks that a statement matching the ID and version of a
+// member changed one row, and tells a missing member from a stale version
+func (memberRepo *SQLMemberRepo) versionedOne(result sql.Result, err error, id string) error {
+	if err := affectedOne(result, err, constants.ErrVersionMismatch); !errors.Is(err, constants.ErrVersionMismatch) {
+		return err
+	}
+	if _, exists := memberRepo.GetByID(id); !exists {
+		return constants.ErrMemberNotFound
+	}
+	return constants.ErrVersionMismatch
+}
+
+// Delete removes a member
+func (memberRepo *SQLMemberRepo) Delete(id string) error {
+	result, err := memberRepo.db.Exec(`DELETE FROM members WHERE studio_id = ? AND id = ?`, memberRepo.studioID, id)
+	return affectedOne(result, err, constants.ErrMemberNotFound)
+}
+
+// List returns up to limit members sorted by ID, starting after afterID
+func (memberRepo *SQLMemberRepo) List(afterID string, limit int) []models.Member {
+	members := memberRepo.query(`SELECT `+memberColumns+` FROM members WHERE studio_id = ? AND id > ? ORDER BY id LIMIT ?`, memberRepo.studioID, afterID, limit)
+	if members == nil {
+		return []models.Member{}
+	}
+	return members
+}
+
+// query runs a select of memberColumns, failures are logged and return no members
+func (memberRepo *SQLMemberRepo) query(query string, args ...interface{}) []models.Member {
+	rows, err := memberRepo.db.Query(query, args...)
+	if err != nil {
+		log.Printf("Failed to query members: %v", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var members []models.Member
+	for rows.Next() {
+		member, err := scanMember(rows)
+		if err != nil {
+			log.Printf("Failed to query members: %v", err)
+			return nil
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to query members: %v", err)
+	}
+	return members
+}
+
+// scanMember reads a row selected with memberColumns
+func scanMember(row scanner) (models.Member, error) {
+	var member models.Member
+	var memberships, createdAt, updatedAt string
+	err := row.Scan(&member.ID, &member.Name, &member.Email, &member.Phone, &member.Status, &memberships, &createdAt, &updatedAt, &member.Version)
+	if err != nil {
+		return models.Member{}, err
+	}
+	if err := json.Unmarshal([]byte(memberships), &member.Memberships); err != nil {
+		return models.Member{}, err
+	}
+	if member.CreatedAt, err = parseTime(createdAt); err != nil {
+		return models.Member{}, err
+	}
+	if member.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return models.Member{}, err
+	}
+	return member, nil
+}
+
+// SQLInstructorRepo stores instructors in a SQL database
+type SQLInstructorRepo struct {
+	db       *sql.DB
+	studioID string
+}
+
+// NewSQLInstructorRepo creates the SQLInstructorRepo of a studio
+func NewSQLInstructorRepo(db *sql.DB, studioID string) *SQLInstructorRepo {
+	return &SQLInstructorRepo{db: db, studioID: studioID}
+}
+
+const instructorColumns = `id, name, email, created_at, updated_at`
+
+// Create for creating a new instructor
+func (instructorRepo *SQLInstructorRepo) Create(instructor models.Instructor) error {
+	_, err := instructorRepo.db.Exec(`INSERT INTO instructors (studio_id, `+instructorColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+		instructorRepo.studioID, instructor.ID, instructor.Name, instructor.Email, formatTime(instructor.CreatedAt), formatTime(instructor.UpdatedAt))
+	return err
+}
+
+// GetByID fetches instructor by given ID
+func (instructorRepo *SQLInstructorRepo) GetByID(id string) (models.Instructor, bool) {
+	instructor, err := scanInstructor(instructorRepo.db.QueryRow(`SELECT `+instructorColumns+` FROM instructors WHERE studio_id = ? AND id = ?`, instructorRepo.studioID, id))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to get instructor %s: %v", id, err)
+		}
+		return models.Instructor{}, false
+	}
+	return instructor, true
+}
+
+// Update replaces an existing instructor
+func (instructorRepo *SQLInstructorRepo) Update(instructor models.Instructor) error {
+	result, err := instructorRepo.db.Exec(`UPDATE instructors SET name = ?, email = ?, updated_at = ? WHERE studio_id = ? AND id = ?`,
+		instructor.Name, instructor.Email, formatTime(instructor.UpdatedAt), instructorRepo.studioID, instructor.ID)
+	return affectedOne(result, err, constants.ErrInstructorNotFound)
+}
+
+// Delete removes an instructor
+func (instructorRepo *SQLInstructorRepo) Delete(id string) error {
+	result, err := instructorRepo.db.Exec(`DELETE FROM instructors WHERE studio_id = ? AND id = ?`, instructorRepo.studioID, id)
+	return affectedOne(result, err, constants.ErrInstructorNotFound)
+}
+
+// List returns up to limit instructors sorted by ID, starting after afterID
+func (instructorRepo *SQLInstructorRepo) List(afterID string, limit int) []models.Instructor {
+	rows, err := instructorRepo.db.Query(`SELECT `+instructorColumns+` FROM instructors WHERE studio_id = ? AND id > ? ORDER BY id LIMIT ?`,
+		instructorRepo.studioID, afterID, limit)
+	if err != nil {
+		log.Printf("Failed to list instructors: %v", err)
+		return []models.Instructor{}
+	}
+	defer rows.Close()
+
+	instructors := []models.Instructor{}
+	for rows.Next() {
+		instructor, err := scanInstructor(rows)
+		if err != nil {
+			log.Printf("Failed to list instructors: %v", err)
+			return []models.Instructor{}
+		}
+		instructors = append(instructors, instructor)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to list instructors: %v", err)
+	}
+	return instructors
+}
+
+// scanInstructor reads a row selected with instructorColumns
+func scanInstructor(row scanner) (models.Instructor, error) {
+	var instructor models.Instructor
+	var createdAt, updatedAt string
+	err := row.Scan(&instructor.ID, &instructor.Name, &instructor.Email, &createdAt, &updatedAt)
+	if err != nil {
+		return models.Instructor{}, err
+	}
+	if instructor.CreatedAt, err = parseTime(createdAt); err != nil {
+		return models.Instructor{}, err
+	}
+	if instructor.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return models.Instructor{}, err
+	}
+	return instructor, nil
+}
+
+// SQLRoomRepo stores rooms in a SQL database
+type SQLRoomRepo struct {
+	db       *sql.DB
+	studioID string
+}
+
+// NewSQLRoomRepo creates the SQLRoomRepo of a studio
+func NewSQLRoomRepo(db *sql.DB, studioID string) *SQLRoomRepo {
+	return &SQLRoomRepo{db: db, studioID: studioID}
+}
+
+const roomColumns = `id, name, capacity, resources, spots, created_at, updated_at`
+
+// Create for creating a new room
+func (roomRepo *SQLRoomRepo) Create(room models.Room) error {
+	resources, err := json.Marshal(room.Resources)
+	if err != nil {
+		return err
+	}
+	spots, err := json.Marshal(room.Spots)
+	if err != nil {
+		return err
+	}
+	_, err = roomRepo.db.Exec(`INSERT INTO rooms (studio_id, `+roomColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		roomRepo.studioID, room.ID, room.Name, room.Capacity, string(resources), string(spots), formatTime(room.CreatedAt), formatTime(room.UpdatedAt))
+	return err
+}
+
+// GetByID fetches room by given ID
+func (roomRepo *SQLRoomRepo) GetByID(id string) (models.Room, bool) {
+	room, err := scanRoom(roomRepo.db.QueryRow(`SELECT `+roomColumns+` FROM rooms WHERE studio_id = ? AND id = ?`, roomRepo.studioID, id))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to get room %s: %v", id, err)
+		}
+		return models.Room{}, false
+	}
+	return room, true
+}
+
+// Update replaces an existing room
+func (roomRepo *SQLRoomRepo) Update(room models.Room) error {
+	resources, err := json.Marshal(room.Resources)
+	if err != nil {
+		return err
+	}
+	spots, err := json.Marshal(room.Spots)
+	if err != nil {
+		return err
+	}
+	result, err := roomRepo.db.Exec(`UPDATE rooms SET name = ?, capacity = ?, resources = ?, spots = ?, updated_at = ? WHERE studio_id = ? AND id = ?`,
+		room.Name, room.Capacity, string(resources), string(spots), formatTime(room.UpdatedAt), roomRepo.studioID, room.ID)
+	return affectedOne(result, err, constants.ErrRoomNotFound)
+}
+
+// Delete removes a room
+func (roomRepo *SQLRoomRepo) Delete(id string) error {
+	result, err := roomRepo.db.Exec(`DELETE FROM rooms WHERE studio_id = ? AND id = ?`, roomRepo.studioID, id)
+	return affectedOne(result, err, constants.ErrRoomNotFound)
+}
+
+// List returns up to limit rooms sorted by ID, starting after afterID
+func (roomRepo *SQLRoomRepo) List(afterID string, limit int) []models.Room {
+	rows, err := roomRepo.db.Query(`SELECT `+roomColumns+` FROM rooms WHERE studio_id = ? AND id > ? ORDER BY id LIMIT ?`,
+		roomRepo.studioID, afterID, limit)
+	if err != nil {
+		log.Printf("Failed to list rooms: %v", err)
+		return []models.Room{}
+	}
+	defer rows.Close()
+
+	rooms := []models.Room{}
+	for rows.Next() {
+		room, err := scanRoom(rows)
+		if err != nil {
+			log.Printf("Failed to list rooms: %v", err)
+			return []models.Room{}
+		}
+		rooms = append(rooms, room)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to list rooms: %v", err)
+	}
+	return rooms
+}
+
+// scanRoom reads a row selected with roomColumns
+func scanRoom(row scanner) (models.Room, error) {
+	var room models.Room
+	var resources, spots, createdAt, updatedAt string
+	err := row.Scan(&room.ID, &room.Name, &room.Capacity, &resources, &spots, &createdAt, &updatedAt)
+	if err != nil {
+		return models.Room{}, err
+	}
+	if err := json.Unmarshal([]byte(resources), &room.Resources); err != nil {
+		return models.Room{}, err
+	}
+	if err := json.Unmarshal([]byte(spots), &room.Spots); err != nil {
+		return models.Room{}, err
+	}
+	if room.CreatedAt, err = parseTime(createdAt); err != nil {
+		return models.Room{}, err
+	}
+	if room.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return models.Room{}, err
+	}
+	return room, nil
+}
+
+// SQLPlanRepo stores membership plans in a SQL database
+type SQLPlanRepo struct {
+	db       *sql.DB
+	studioID string
+}
+
+// NewSQLPlanRepo creates the SQLPlanRepo of a studio
+func NewSQLPlanRepo(db *sql.DB, studioID string) *SQLPlanRepo {
+	return &SQLPlanRepo{db: db, studioID: studioID}
+}
+
+const planColumns = `id, name, kind, classes, valid_days, created_at, updated_at`
+
+// Create for creating a new plan
+func (planRepo *SQLPlanRepo) Create(plan models.Plan) error {
+	_, err := planRepo.db.Exec(`INSERT INTO plans (studio_id, `+planColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		planRepo.studioID, plan.ID, plan.Name, plan.Kind, plan.Classes, plan.ValidDays, formatTime(plan.CreatedAt), formatTime(plan.UpdatedAt))
+	return err
+}
+
+// GetByID fetches plan by given ID
+func (planRepo *SQLPlanRepo) GetByID(id string) (models.Plan, bool) {
+	plan, err := scanPlan(planRepo.db.QueryRow(`SELECT `+planColumns+` FROM plans WHERE studio_id = ? AND id = ?`, planRepo.studioID, id))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to get plan %s: %v", id, err)
+		}
+		return models.Plan{}, false
+	}
+	return plan, true
+}
+
+// Update replaces an existing plan
+func (planRepo *SQLPlanRepo) Update(plan models.Plan) error {
+	result, err := planRepo.db.Exec(`UPDATE plans SET name = ?, kind = ?, classes = ?, valid_days = ?, updated_at = ? WHERE studio_id = ? AND id = ?`,
+		plan.Name, plan.Kind, plan.Classes, plan.ValidDays, formatTime(plan.UpdatedAt), planRepo.studioID, plan.ID)
+	return affectedOne(result, err, constants.ErrPlanNotFound)
+}
+
+// Delete removes a plan
+func (planRepo *SQLPlanRepo) Delete(id string) error {
+	result, err := planRepo.db.Exec(`DELETE FROM plans WHERE studio_id = ? AND id = ?`, planRepo.studioID, id)
+	return affectedOne(result, err, constants.ErrPlanNotFound)
+}
+
+// List returns up to limit plans sorted by ID, starting after afterID
+func (planRepo *SQLPlanRepo) List(afterID string, limit int) []models.Plan {
+	rows, err := planRepo.db.Query(`SELECT `+planColumns+` FROM plans WHERE studio_id = ? AND id > ? ORDER BY id LIMIT ?`,
+		planRepo.studioID, afterID, limit)
+	if err != nil {
+		log.Printf("Failed to list plans: %v", err)
+		return []models.Plan{}
+	}
+	defer rows.Close()
+
+	plans := []models.Plan{}
+	for rows.Next() {
+		plan, err := scanPlan(rows)
+		if err != nil {
+			log.Printf("Failed to list plans: %v", err)
+			return []models.Plan{}
+		}
+		plans = append(plans, plan)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to list plans: %v", err)
+	}
+	return plans
+}
+
+// scanPlan reads a row selected with planColumns
+func scanPlan(row scanner) (models.Plan, error) {
+	var plan models.Plan
+	var createdAt, updatedAt string
+	err := row.Scan(&plan.ID, &plan.Name, &plan.Kind, &plan.Classes, &plan.ValidDays, &createdAt, &updatedAt)
+	if err != nil {
+		return models.Plan{}, err
+	}
+	if plan.CreatedAt, err = parseTime(createdAt); err != nil {
+		return models.Plan{}, err
+	}
+	if plan.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return models.Plan{}, err
+	}
+	return plan, nil
+}
+
+// SQLPromoRepo stores promo codes in a SQL database
+type SQLPromoRepo struct {
+	db       *sql.DB
+	studioID string
+}
+
+// NewSQLPromoRepo creates the SQLPromoRepo of a studio
+func NewSQLPromoRepo(db *sql.DB, studioID string) *SQLPromoRepo {
+	return &SQLPromoRepo{db: db, studioID: studioID}
+}
+
+const promoColumns = `code, kind, percent, amount, currency, max_redemptions, class_names, valid_from, valid_until, created_at, updated_at`
+
+// Create for creating a new promo code
+func (promoRepo *SQLPromoRepo) Create(promo models.PromoCode) error {
+	classNames, err := json.Marshal(promo.ClassNames)
+	if err != nil {
+		return err
+	}
+	_, err = promoRepo.db.Exec(`INSERT INTO promo_codes (studio_id, `+promoColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		promoRepo.studioID, promo.Code, promo.Kind, promo.Percent, promo.Amount, promo.Currency, promo.MaxRedemptions, string(classNames),
+		optionalTime(promo.ValidFrom), optionalTime(promo.ValidUntil), formatTime(promo.CreatedAt), formatTime(promo.UpdatedAt))
+	if isUniqueViolation(err) {
+		return constants.ErrPromoExists
+	}
+	return err
+}
+
+// GetByCode fetches promo code by given code
+func (promoRepo *SQLPromoRepo) GetByCode(code string) (models.PromoCode, bool) {
+	promo, err := scanPromo(promoRepo.db.QueryRow(`SELECT `+promoColumns+` FROM promo_codes WHERE studio_id = ? AND code = ?`, promoRepo.studioID, code))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to get promo code %s: %v", code, err)
+		}
+		return models.PromoCode{}, false
+	}
+	return promo, true
+}
+
+// Update replaces an existing promo code
+func (promoRepo *SQLPromoRepo) Update(promo models.PromoCode) error {
+	classNames, err := json.Marshal(promo.ClassNames)
+	if err != nil {
+		return err
+	}
+	result, err := promoRepo.db.Exec(`UPDATE promo_codes SET kind = ?, percent = ?, amount = ?, currency = ?, max_redemptions = ?, class_names = ?,
+		valid_from = ?, valid_until = ?, updated_at = ? WHERE studio_id = ? AND code = ?`,
+		promo.Kind, promo.Percent, promo.Amount, promo.Currency, promo.MaxRedemptions, string(classNames),
+		optionalTime(promo.ValidFrom), optionalTime(promo.ValidUntil), formatTime(promo.UpdatedAt), promoRepo.studioID, promo.Code)
+	return affectedOne(result, err, constants.ErrPromoNotFound)
+}
+
+// Delete removes a promo code
+func (promoRepo *SQLPromoRepo) Delete(code string) error {
+	result, err := promoRepo.db.Exec(`DELETE FROM promo_codes WHERE studio_id = ? AND code = ?`, promoRepo.studioID, code)
+	return affectedOne(result, err, constants.ErrPromoNotFound)
+}
+
+// List returns up to limit promo codes sorted by code, starting after afterCode
+func (promoRepo *SQLPromoRepo) List(afterCode string, limit int) []models.PromoCode {
+	rows, err := promoRepo.db.Query(`SELECT `+promoColumns+` FROM promo_codes WHERE studio_id = ? AND code > ? ORDER BY code LIMIT ?`,
+		promoRepo.studioID, afterCode, limit)
+	if err != nil {
+		log.Printf("Failed to list promo codes: %v", err)
+		return []models.PromoCode{}
+	}
+	defer rows.Close()
+
+	promos := []models.PromoCode{}
+	for rows.Next() {
+		promo, err := scanPromo(rows)
+		if err != nil {
+			log.Printf("Failed to list promo codes: %v", err)
+			return []models.PromoCode{}
+		}
+		promos = append(promos, promo)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to list promo codes: %v", err)
+	}
+	return promos
+}
+
+// scanPromo reads a row selected with promoColumns
+func scanPromo(row scanner) (models.PromoCode, error) {
+	var promo models.PromoCode
+	var classNames, createdAt, updatedAt string
+	var validFrom, validUntil sql.NullString
+	err := row.Scan(&promo.Code, &promo.Kind, &promo.Percent, &promo.Amount, &promo.Currency, &promo.MaxRedemptions, &classNames,
+		&validFrom, &validUntil, &createdAt, &updatedAt)
+	if err != nil {
+		return models.PromoCode{}, err
+	}
+	if err := json.Unmarshal([]byte(classNames), &promo.ClassNames); err != nil {
+		return models.PromoCode{}, err
+	}
+	if promo.ValidFrom, err = parseOptionalTime(validFrom); err != nil {
+		return models.PromoCode{}, err
+	}
+	if promo.ValidUntil, err = parseOptionalTime(validUntil); err != nil {
+		return models.PromoCode{}, err
+	}
+	if promo.CreatedAt, err = parseTime(createdAt); err != nil {
+		return models.PromoCode{}, err
+	}
+	if promo.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return models.PromoCode{}, err
+	}
+	return promo, nil
+}
+
+// optionalTime returns the column value of a time that may be unset
+func optionalTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return formatTime(*t)
+}
+
+// parseOptionalTime reads a column written by optionalTime
+func parseOptionalTime(s sql.NullString) (*time.Time, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	t, err := parseTime(s.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// affectedOne returns notFound when a statement matched no row
+func affectedOne(result sql.Result, err error, notFound error) error {
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// SQLIdempotencyRepo stores idempotency records in a SQL database
+type SQLIdempotencyRepo struct {
+	db       *sql.DB
+	studioID string
+}
+
+// NewSQLIdempotencyRepo creates the SQLIdempotencyRepo of a studio
+func NewSQLIdempotencyRepo(db *sql.DB, studioID string) *SQLIdempotencyRepo {
+	return &SQLIdempotencyRepo{db: db, studioID: studioID}
+}
+
+const idempotencyColumns = `key, fingerprint, status_code, content_type, body, created_at, expires_at`
+
+// Reserve claims the key of record for a new request, the check and the
+// insert run in one transaction so concurrent retries cannot both run
+func (idempotencyRepo *SQLIdempotencyRepo) Reserve(record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	tx, err := idempotencyRepo.db.Begin()
+	if err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+	defer tx.Rollback()
+
+	existing, err := scanIdempotencyRecord(tx.QueryRow(`SELECT `+idempotencyColumns+` FROM idempotency_keys WHERE studio_id = ? AND key = ?`, idempotencyRepo.studioID, record.Key))
+	switch {
+	case err == nil && existing.ExpiresAt.After(record.CreatedAt):
+		return existing, false, nil
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return models.IdempotencyRecord{}, false, err
+	}
+
+	_, err = tx.Exec(`INSERT OR REPLACE INTO idempotency_keys (studio_id, `+idempotencyColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		idempotencyRepo.studioID, record.Key, record.Fingerprint, record.StatusCode, record.ContentType, record.Body,
+		formatTime(record.CreatedAt), formatTime(record.ExpiresAt))
+	if err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+	return record, true, tx.Commit()
+}
+
+// Complete stores the response of a reserved key
+func (idempotencyRepo *SQLIdempotencyRepo) Complete(record models.IdempotencyRecord) error {
+	_, err := idempotencyRepo.db.Exec(`UPDATE idempotency_keys SET status_code = ?, content_type = ?, body = ?, expires_at = ? WHERE studio_id = ? AND key = ?`,
+		record.StatusCode, record.ContentType, record.Body, formatTime(record.ExpiresAt), idempotencyRepo.studioID, record.Key)
+	return err
+}
+
+// Release frees a reserved key so that the request can be retried
+func (idempotencyRepo *SQLIdempotencyRepo) Release(key string) error {
+	_, err := idempotencyRepo.db.Exec(`DELETE FROM idempotency_keys WHERE studio_id = ? AND key = ?`, idempotencyRepo.studioID, key)
+	return err
+}
+
+// Purge deletes the records of the studio that expired before now
+func (idempotencyRepo *SQLIdempotencyRepo) Purge(now time.Time) error {
+	_, err := idempotencyRepo.db.Exec(`DELETE FROM idempotency_keys WHERE studio_id = ? AND expires_at <= ?`, idempotencyRepo.studioID, formatTime(now))
+	return err
+}
+
+// scanIdempotencyRecord reads a row selected with idempotencyColumns
+func scanIdempotencyRecord(row scanner) (models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	var createdAt, expiresAt string
+	err := row.Scan(&record.Key, &record.Fingerprint, &record.StatusCode, &record.ContentType, &record.Body, &createdAt, &expiresAt)
+	if err != nil {
+		return models.IdempotencyRecord{}, err
+	}
+	if record.CreatedAt, err = parseTime(createdAt); err != nil {
+		return models.IdempotencyRecord{}, err
+	}
+	if record.ExpiresAt, err = parseTime(expiresAt); err != nil {
+		return models.IdempotencyRecord{}, err
+	}
+	return record, nil
+}
+
+// SQLStudios keeps the repositories of every studio in one SQL database, each
+// repository filters its statements by the ID of its studio
+type SQLStudios struct {
+	db *sql.DB
+}
+
+// NewSQLStudios creates a new SQLStudios
+func NewSQLStudios(db *sql.DB) *SQLStudios {
+	return &SQLStudios{db: db}
+}
+
+// Studio records a studio on first use and returns its repositories
+func (sqlStudios *SQLStudios) Studio(studioID string) (Repositories, error) {
+	if _, err := sqlStudios.db.Exec(`INSERT INTO studios (id) VALUES (?) ON CONFLICT (id) DO NOTHING`, studioID); err != nil {
+		return Repositories{}, fmt.Errorf("record studio %s: %w", studioID, err)
+	}
+	return Repositories{
+		Classes:     NewSQLClassRepo(sqlStudios.db, studioID),
+		Bookings:    NewSQLBookingRepo(sqlStudios.db, studioID),
+		Waitlists:   NewSQLWaitlistRepo(sqlStudios.db, studioID),
+		Members:     NewSQLMemberRepo(sqlStudios.db, studioID),
+		Instructors: NewSQLInstructorRepo(sqlStudios.db, studioID),
+		Rooms:       NewSQLRoomRepo(sqlStudios.db, studioID),
+		Plans:       NewSQLPlanRepo(sqlStudios.db, studioID),
+		Promos:      NewSQLPromoRepo(sqlStudios.db, studioID),
+		Idempotency: NewSQLIdempotencyRepo(sqlStudios.db, studioID),
+	}, nil
+}
+
+// Exists reports whether a studio was recorded by Studio
+func (sqlStudios *SQLStudios) Exists(studioID string) (bool, error) {
+	var exists bool
+	err := sqlStudios.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM studios WHERE id = ?)`, studioID).Scan(&exists)
+	return exists, err
+}
+
+// IDs returns the ID of every studio recorded by Studio, sorted
+func (sqlStudios *SQLStudios) IDs() ([]string, error) {
+	rows, err := sqlStudios.db.Query(`SELECT id FROM studios ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var studioID string
+		if err := rows.Scan(&studioID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, studioID)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"glofox/internal/constants"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestDB opens a fresh SQLite database that is closed when the test ends
+func openTestDB(t *testing.T) *sql.DB {
+	return openTestDBAt(t, filepath.Join(t.TempDir(), "glofox.db"))
+}
+
+// openTestDBAt opens the SQLite database at path until the test ends
+func openTestDBAt(t *testing.T, path string) *sql.DB {
+	db, err := OpenSQLite(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLClassRepo(t *testing.T) {
+	testClassRepository(t, func(t *testing.T) ClassRepository {
+		return NewSQLClassRepo(openTestDB(t), constants.DefaultStudioID)
+	})
+}
+
+func TestSQLBookingRepo(t *testing.T) {
+	testBookingRepository(t, func(t *testing.T) BookingRepository {
+		return NewSQLBookingRepo(openTestDB(t), constants.DefaultStudioID)
+	})
+}
+
+// TestSQLBookingRepo_ConstraintErrors inserts bookings past the checks of
+// Create, so that the unique indexes of the table reject them
+func TestSQLBookingRepo_ConstraintErrors(t *testing.T) {
+	db := openTestDB(t)
+	insert := func(id, memberID, spot string) error {
+		_, err := db.Exec(`INSERT INTO bookings (studio_id, id, class_name, member_id, member_name, date, status, created_at, spot, version)
+			VALUES (?, ?, 'Yoga', ?, ?, '2025-06-10T07:00:00.000000000Z', ?, '2025-06-01T00:00:00.000000000Z', ?, 1)`,
+			constants.DefaultStudioID, id, memberID, memberID, constants.BookingStatusBooked, spot)
+		return err
+	}
+	require.NoError(t, insert("bk_1", "mb_alice", "2"))
+
+	tests := []struct {
+		name     string
+		memberID string
+		spot     string
+		expected error
+	}{
+		{name: "Spot Taken", memberID: "mb_bob", spot: "2", expected: constants.ErrSpotTaken},
+		{name: "Member Booked", memberID: "mb_alice", spot: "3", expected: constants.ErrAlreadyBooked},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := insert(fmt.Sprintf("bk_%d", i+2), tt.memberID, tt.spot)
+			require.True(t, isUniqueViolation(err), "Expected a unique constraint failure, got %v", err)
+			assert.Equal(t, tt.expected, bookingConflict(err))
+		})
+	}
+}
+
+func TestSQLWaitlistRepo(t *testing.T) {
+	testWaitlistRepository(t, func(t *testing.T) WaitlistRepository {
+		return NewSQLWaitlistRepo(openTestDB(t), constants.DefaultStudioID)
+	})
+}
+
+func TestSQLMemberRepo(t *testing.T) {
+	testMemberRepository(t, func(t *testing.T) MemberRepository {
+		return NewSQLMemberRepo(openTestDB(t), constants.DefaultStudioID)
+	})
+}
+
+func TestSQLInstructorRepo(t *testing.T) {
+	testInstructorRepository(t, func(t *testing.T) InstructorRepository {
+		return NewSQLInstructorRepo(openTestDB(t), constants.DefaultStudioID)
+	})
+}
+
+func TestSQLRoomRepo(t *testing.T) {
+	testRoomRepository(t, func(t *testing.T) RoomRepository {
+		return NewSQLRoomRepo(openTestDB(t), constants.DefaultStudioID)
+	})
+}
+
+func TestSQLPlanRepo(t *testing.T) {
+	testPlanRepository(t, func(t *testing.T) PlanRepository {
+		return NewSQLPlanRepo(openTestDB(t), constants.DefaultStudioID)
+	})
+}
+
+func TestSQLPromoRepo(t *testing.T) {
+	testPromoRepository(t, func(t *testing.T) PromoRepository {
+		return NewSQLPromoRepo(openTestDB(t), constants.DefaultStudioID)
+	})
+}
+
+func TestSQLIdempotencyRepo(t *testing.T) {
+	testIdempotencyRepository(t, func(t *testing.T) IdempotencyRepository {
+		return NewSQLIdempotencyRepo(openTestDB(t), constants.DefaultStudioID)
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glofox.db")
+	db, err := OpenSQLite(path)
+	require.NoError(t, err)
+	_, err = NewSQLClassRepo(db, constants.DefaultStudioID).Create(testClass())
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// Reopening an up to date database applies nothing and keeps the data
+	db, err = OpenSQLite(path)
+	require.NoError(t, err)
+	defer db.Close()
+	var version int
+	require.NoError(t, db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version))
+	assert.Equal(t, len(migrations), version)
+	_, exists := NewSQLClassRepo(db, constants.DefaultStudioID).GetByName("Yoga")
+	assert.True(t, exists)
+
+	// A database written by a newer build is refused
+	_, err = db.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, len(migrations)+1, formatTime(time.Now()))
+	require.NoError(t, err)
+	assert.Error(t, Migrate(db))
+}
+
+func TestMigrate_UpgradesExistingDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glofox.db")
+
+	// Create a database as the first release left it
+	released := migrations
+	migrations = released[:1]
+	db, err := OpenSQLite(path)
+	migrations = released
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO classes (name, start_date, end_date, capacity, free_cancel_hours, allow_late_cancel) VALUES (?, ?, ?, ?, ?, ?)`,
+		"Yoga", formatTime(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)), formatTime(time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC)), 10, 12, true)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// Classes created before recurrences existed run every day
+	db = openTestDBAt(t, path)
+	class, exists := NewSQLClassRepo(db, constants.DefaultStudioID).GetByName("Yoga")
+	assert.True(t, exists)
+	assert.Equal(t, 10, class.Capacity)
+	assert.Zero(t, class.Recurrence.Frequency)
+	assert.Nil(t, class.SessionTimes)
+	assert.Empty(t, class.TimeZone)
+}
+
+func TestSQLStudios(t *testing.T) {
+	testStudioIsolation(t, func(t *testing.T) Studios {
+		return NewSQLStudios(openTestDB(t))
+	})
+}
+
+func TestMigrate_MovesExistingRowsToDefaultStudio(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glofox.db")
+
+	// Create a database as the release before studios left it
+	released := migrations
+	migrations = released[:9]
+	db, err := OpenSQLite(path)
+	migrations = released
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO classes (name, start_date, end_date, capacity, free_cancel_hours, allow_late_cancel) VALUES (?, ?, ?, ?, ?, ?)`,
+		"Yoga", formatTime(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)), formatTime(time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC)), 10, 12, true)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO waitlist_entries (class_name, date, member_name) VALUES (?, ?, ?), (?, ?, ?)`,
+		"Yoga", formatTime(time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)), "mb_1", "Yoga", formatTime(time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)), "mb_2")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db = openTestDBAt(t, path)
+	// The studios holding data are recorded by the migration
+	exists, err := NewSQLStudios(db).Exists(constants.DefaultStudioID)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	repos := studio(t, NewSQLStudios(db), constants.DefaultStudioID)
+	_, exists = repos.Classes.GetByName("Yoga")
+	assert.True(t, exists)
+	position, err := repos.Waitlists.Position("Yoga", "mb_2", time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 2, position)
+	_, exists = studio(t, NewSQLStudios(db), "other").Classes.GetByName("Yoga")
+	assert.False(t, exists)
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqlTimeLayout stores instants as fixed width UTC text so they sort correctly
+const sqlTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// OpenSQLite opens the SQLite database at path, creating it if needed, and
+// brings its schema up to date
+func OpenSQLite(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create database dir: %w", err)
+	}
+
+	// Write transactions take the database lock up front so that a capacity
+	// check and the insert that depends on it cannot interleave with another
+	// writer, and concurrent writers wait for the lock instead of failing
+	query := url.Values{}
+	query.Add("_pragma", "busy_timeout(10000)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "synchronous(FULL)")
+	query.Set("_txlock", "immediate")
+	db, err := sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if err := Migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// isUniqueViolation reports whether err is a unique or primary key constraint failure
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+// formatTime converts an instant to its stored form
+func formatTime(t time.Time) string {
+	return t.UTC().Format(sqlTimeLayout)
+}
+
+// parseTime converts a stored instant back to a time.Time
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(sqlTimeLayout, s)
+}
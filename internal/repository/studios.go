@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+)
+
+// Repositories are the repositories holding the data of one studio
+type Repositories struct {
+	Classes     ClassRepository
+	Bookings    BookingRepository
+	Waitlists   WaitlistRepository
+	Members     MemberRepository
+	Instructors InstructorRepository
+	Rooms       RoomRepository
+	Plans       PlanRepository
+	Promos      PromoRepository
+	Idempotency IdempotencyRepository
+}
+
+// Studios returns the repositories of each studio. The repositories of
+// different studios never share data, so the same class name, booking ID or
+// Idempotency-Key can be used by every studio.
+type Studios interface {
+	// Studio returns the repositories of a studio, created on first use
+	Studio(studioID string) (Repositories, error)
+	// Exists reports whether a studio was created by Studio
+	Exists(studioID string) (bool, error)
+	// IDs returns the ID of every studio created by Studio, sorted
+	IDs() ([]string, error)
+}
+
+// MemoryStudios keeps the in-memory repositories of each studio
+type MemoryStudios struct {
+	// Key: studio ID
+	studios map[string]Repositories
+	mu      sync.Mutex
+}
+
+// NewMemoryStudios creates a new MemoryStudios
+func NewMemoryStudios() *MemoryStudios {
+	return &MemoryStudios{studios: make(map[string]Repositories)}
+}
+
+// Studio returns the repositories of a studio
+func (memoryStudios *MemoryStudios) Studio(studioID string) (Repositories, error) {
+	memoryStudios.mu.Lock()
+	defer memoryStudios.mu.Unlock()
+
+	repos, exists := memoryStudios.studios[studioID]
+	if !exists {
+		repos = Repositories{
+			Classes:     NewClassRepo(),
+			Bookings:    NewBookingRepo(),
+			Waitlists:   NewWaitlistRepo(),
+			Members:     NewMemberRepo(),
+			Instructors: NewInstructorRepo(),
+			Rooms:       NewRoomRepo(),
+			Plans:       NewPlanRepo(),
+			Promos:      NewPromoRepo(),
+			Idempotency: NewIdempotencyRepo(),
+		}
+		memoryStudios.studios[studioID] = repos
+	}
+	return repos, nil
+}
+
+// Exists reports whether a studio was created by Studio
+func (memoryStudios *MemoryStudios) Exists(studioID string) (bool, error) {
+	memoryStudios.mu.Lock()
+	defer memoryStudios.mu.Unlock()
+
+	_, exists := memoryStudios.studios[studioID]
+	return exists, nil
+}
+
+// IDs returns the ID of every studio created by Studio, sorted
+func (memoryStudios *MemoryStudios) IDs() ([]string, error) {
+	memoryStudios.mu.Lock()
+	defer memoryStudios.mu.Unlock()
+
+	return studioIDs(memoryStudios.studios), nil
+}
+
+// studioIDs returns the sorted keys of the repositories of studios
+func studioIDs(studios map[string]Repositories) []string {
+	ids := make([]string, 0, len(studios))
+	for studioID := range studios {
+		ids = append(ids, studioID)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStudios(t *testing.T) {
+	testStudioIsolation(t, func(t *testing.T) Studios {
+		return NewMemoryStudios()
+	})
+}
+
+// isolatedMethods are the repository methods checked by testStudioIsolation
+var isolatedMethods = map[reflect.Type][]string{
+	reflect.TypeOf((*ClassRepository)(nil)).Elem():       {"Create", "Delete", "GetByName", "List", "Update"},
+	reflect.TypeOf((*BookingRepository)(nil)).Elem():     {"AddCredit", "Balance", "Cancel", "Count", "Create", "Credits", "DeleteByClass", "GetByID", "Query", "UpdatePayment"},
+	reflect.TypeOf((*WaitlistRepository)(nil)).Elem():    {"Join", "Leave", "Peek", "Position"},
+	reflect.TypeOf((*MemberRepository)(nil)).Elem():      {"Create", "Delete", "FindByName", "GetByID", "List", "Update"},
+	reflect.TypeOf((*InstructorRepository)(nil)).Elem():  {"Create", "Delete", "GetByID", "List", "Update"},
+	reflect.TypeOf((*RoomRepository)(nil)).Elem():        {"Create", "Delete", "GetByID", "List", "Update"},
+	reflect.TypeOf((*PlanRepository)(nil)).Elem():        {"Create", "Delete", "GetByID", "List", "Update"},
+	reflect.TypeOf((*PromoRepository)(nil)).Elem():       {"Create", "Delete", "GetByCode", "List", "Update"},
+	reflect.TypeOf((*IdempotencyRepository)(nil)).Elem(): {"Complete", "Purge", "Release", "Reserve"},
+}
+
+func TestStudioIsolation_CoversEveryMethod(t *testing.T) {
+	// A method added to a repository fails here until the isolation suite checks it
+	for repoType, checked := range isolatedMethods {
+		var methods []string
+		for i := 0; i < repoType.NumMethod(); i++ {
+			methods = append(methods, repoType.Method(i).Name)
+		}
+		assert.Equal(t, methods, checked, repoType.Name())
+	}
+	assert.Equal(t, reflect.TypeOf(Repositories{}).NumField(), len(isolatedMethods))
+}
+
+// testStudioIsolation runs the studio isolation test suite against the
+// implementation returned by newStudios. Data written in one studio must be
+// invisible to, and unchangeable from, every other studio.
+func testStudioIsolation(t *testing.T, newStudios func(t *testing.T) Studios) {
+	t.Run("Classes", func(t *testing.T) { testStudioClasses(t, newStudios(t)) })
+	t.Run("Bookings", func(t *testing.T) { testStudioBookings(t, newStudios(t)) })
+	t.Run("Waitlists", func(t *testing.T) { testStudioWaitlists(t, newStudios(t)) })
+	t.Run("Members", func(t *testing.T) { testStudioMembers(t, newStudios(t)) })
+	t.Run("Instructors", func(t *testing.T) { testStudioInstructors(t, newStudios(t)) })
+	t.Run("Rooms", func(t *testing.T) { testStudioRooms(t, newStudios(t)) })
+	t.Run("Plans", func(t *testing.T) { testStudioPlans(t, newStudios(t)) })
+	t.Run("Promos", func(t *testing.T) { testStudioPromos(t, newStudios(t)) })
+	t.Run("Idempotency", func(t *testing.T) { testStudioIdempotency(t, newStudios(t)) })
+	t.Run("Exists", func(t *testing.T) { testStudioExists(t, newStudios(t)) })
+}
+
+// studio returns the repositories of a studio, failing the test when they cannot be opened
+func studio(t *testing.T, studios Studios, studioID string) Repositories {
+	t.Helper()
+	repos, err := studios.Studio(studioID)
+	require.NoError(t, err)
+	return repos
+}
+
+func testStudioExists(t *testing.T, studios Studios) {
+	exists, err := studios.Exists("studio_a")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// A studio exists once it is opened, and only that studio
+	studio(t, studios, "studio_a")
+	exists, err = studios.Exists("studio_a")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = studios.Exists("studio_b")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// Every studio opened is listed, in order
+	studio(t, studios, "studio_0")
+	ids, err := studios.IDs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"studio_0", "studio_a"}, ids)
+}
+
+func testStudioClasses(t *testing.T, studios Studios) {
+	a, b := studio(t, studios, "studio_a").Classes, studio(t, studios, "studio_b").Classes
+	yoga, err := a.Create(testClass())
+	require.NoError(t, err)
+
+	_, exists := b.GetByName("Yoga")
+	assert.False(t, exists)
+	assert.Empty(t, b.List("", 10))
+	_, err = b.Update(yoga)
+	assert.ErrorIs(t, err, constants.ErrClassNotFound)
+	assert.ErrorIs(t, b.Delete("Yoga", yoga.Version), constants.ErrClassNotFound)
+
+	// Both studios may run a class of the same name
+	other := testClass()
+	other.Capacity = 3
+	_, err = b.Create(other)
+	require.NoError(t, err)
+	stored, exists := a.GetByName("Yoga")
+	assert.True(t, exists)
+	assert.Equal(t, yoga, stored)
+	assert.Equal(t, []string{"Yoga"}, classNames(a.List("", 10)))
+}
+
+func testStudioBookings(t *testing.T, studios Studios) {
+	a, b := studio(t, studios, "studio_a").Bookings, studio(t, studios, "studio_b").Bookings
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	dayLimit := models.BookingLimits{Capacity: 1, PerDay: 1, DayStart: date, DayEnd: date.AddDate(0, 0, 1)}
+	booking, err := a.Create(testBooking("Alice", date), dayLimit)
+	require.NoError(t, err)
+
+	_, exists := b.GetByID(booking.ID)
+	assert.False(t, exists)
+	assert.Zero(t, b.Count("Yoga", date))
+	assert.Empty(t, b.Query(models.BookingFilter{}))
+	assert.Empty(t, b.Query(models.BookingFilter{MemberID: booking.MemberID, ClassName: "Yoga"}))
+	_, err = b.Cancel(booking.ID, booking.Version, date, false, "")
+	assert.ErrorIs(t, err, constants.ErrBookingNotFound)
+	require.NoError(t, a.AddCredit(testGrant("Alice", 1)))
+	assert.Empty(t, b.Credits("mb_Alice"))
+	assert.Zero(t, b.Balance("mb_Alice", models.Charge{MembershipID: "ms_pack"}))
+	paid, err := a.Create(testPaidBooking("Bob", date.AddDate(0, 0, 2)), models.BookingLimits{Capacity: 1})
+	require.NoError(t, err)
+	_, err = b.UpdatePayment(paid.ID, paid.Version, *paid.Payment)
+	assert.ErrorIs(t, err, constants.ErrBookingNotFound)
+	assert.Empty(t, b.Query(models.BookingFilter{PaymentStatuses: []string{constants.PaymentPending}}))
+	require.NoError(t, b.DeleteByClass("Yoga"))
+
+	// Seats, duplicates, daily limits and credits are counted per studio
+	_, err = b.Create(testBooking("Alice", date), dayLimit)
+	require.NoError(t, err)
+	_, err = b.Create(testBooking("Alice", date.AddDate(0, 0, 1)), models.BookingLimits{Capacity: 1, Charge: &models.Charge{MembershipID: "ms_pack"}})
+	assert.ErrorIs(t, err, constants.ErrNoEntitlement)
+	assert.Len(t, a.Credits("mb_Alice"), 1)
+	assert.Equal(t, 1, a.Balance("mb_Alice", models.Charge{MembershipID: "ms_pack"}))
+	stored, _ := a.GetByID(booking.ID)
+	assert.Equal(t, booking, stored)
+	assert.Equal(t, 1, a.Count("Yoga", date))
+	assert.Len(t, a.Query(models.BookingFilter{}), 2)
+}
+
+func testStudioWaitlists(t *testing.T, studios Studios) {
+	a, b := studio(t, studios, "studio_a").Waitlists, studio(t, studios, "studio_b").Waitlists
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	_, err := a.Join("Yoga", "mb_alice", date)
+	require.NoError(t, err)
+
+	_, err = b.Position("Yoga", "mb_alice", date)
+	assert.ErrorIs(t, err, constants.ErrNotOnWaitlist)
+	_, exists := b.Peek("Yoga", date)
+	assert.False(t, exists)
+	assert.ErrorIs(t, b.Leave("Yoga", "mb_alice", date), constants.ErrNotOnWaitlist)
+
+	// Each studio has its own queue for the same session
+	position, err := b.Join("Yoga", "mb_bob", date)
+	require.NoError(t, err)
+	assert.Equal(t, 1, position)
+	position, err = a.Position("Yoga", "mb_alice", date)
+	require.NoError(t, err)
+	assert.Equal(t, 1, position)
+	head, _ := a.Peek("Yoga", date)
+	assert.Equal(t, "mb_alice", head)
+}
+
+func testStudioMembers(t *testing.T, studios Studios) {
+	a, b := studio(t, studios, "studio_a").Members, studio(t, studios, "studio_b").Members
+	alice, err := a.Create(testMember("mb_1", "Alice"))
+	require.NoError(t, err)
+
+	_, exists := b.GetByID("mb_1")
+	assert.False(t, exists)
+	assert.Empty(t, b.FindByName("Alice"))
+	assert.Empty(t, b.List("", 10))
+	_, err = b.Update(alice)
+	assert.ErrorIs(t, err, constants.ErrMemberNotFound)
+	assert.ErrorIs(t, b.Delete("mb_1"), constants.ErrMemberNotFound)
+
+	// Member IDs are keyed per studio
+	_, err = b.Create(testMember("mb_1", "Bob"))
+	require.NoError(t, err)
+	stored, exists := a.GetByID("mb_1")
+	assert.True(t, exists)
+	assert.Equal(t, alice, stored)
+	assert.Equal(t, []string{"mb_1"}, memberIDs(a.FindByName("Alice")))
+	assert.Empty(t, a.FindByName("Bob"))
+}
+
+func testStudioInstructors(t *testing.T, studios Studios) {
+	a, b := studio(t, studios, "studio_a").Instructors, studio(t, studios, "studio_b").Instructors
+	maya := testInstructor("in_1", "Maya")
+	require.NoError(t, a.Create(maya))
+
+	_, exists := b.GetByID("in_1")
+	assert.False(t, exists)
+	assert.Empty(t, b.List("", 10))
+	assert.ErrorIs(t, b.Update(maya), constants.ErrInstructorNotFound)
+	assert.ErrorIs(t, b.Delete("in_1"), constants.ErrInstructorNotFound)
+
+	// Instructor IDs are keyed per studio
+	require.NoError(t, b.Create(testInstructor("in_1", "Sam")))
+	stored, exists := a.GetByID("in_1")
+	assert.True(t, exists)
+	assert.Equal(t, maya, stored)
+	assert.Len(t, a.List("", 10), 1)
+}
+
+func testStudioRooms(t *testing.T, studios Studios) {
+	a, b := studio(t, studios, "studio_a").Rooms, studio(t, studios, "studio_b").Rooms
+	room := testRoom("rm_1", "Studio 1")
+	require.NoError(t, a.Create(room))
+
+	_, exists := b.GetByID("rm_1")
+	assert.False(t, exists)
+	assert.Empty(t, b.List("", 10))
+	assert.ErrorIs(t, b.Update(room), constants.ErrRoomNotFound)
+	assert.ErrorIs(t, b.Delete("rm_1"), constants.ErrRoomNotFound)
+
+	// Room IDs are keyed per studio
+	require.NoError(t, b.Create(testRoom("rm_1", "Studio A")))
+	stored, exists := a.GetByID("rm_1")
+	assert.True(t, exists)
+	assert.Equal(t, room, stored)
+	assert.Len(t, a.List("", 10), 1)
+}
+
+func testStudioPlans(t *testing.T, studios Studios) {
+	a, b := studio(t, studios, "studio_a").Plans, studio(t, studios, "studio_b").Plans
+	plan := testPlan("pl_1", "10 Class Pack")
+	require.NoError(t, a.Create(plan))
+
+	_, exists := b.GetByID("pl_1")
+	assert.False(t, exists)
+	assert.Empty(t, b.List("", 10))
+	assert.ErrorIs(t, b.Update(plan), constants.ErrPlanNotFound)
+	assert.ErrorIs(t, b.Delete("pl_1"), constants.ErrPlanNotFound)
+
+	// Plan IDs are keyed per studio
+	require.NoError(t, b.Create(testPlan("pl_1", "Unlimited")))
+	stored, exists := a.GetByID("pl_1")
+	assert.True(t, exists)
+	assert.Equal(t, plan, stored)
+	assert.Len(t, a.List("", 10), 1)
+}
+
+func testStudioPromos(t *testing.T, studios Studios) {
+	a, b := studio(t, studios, "studio_a").Promos, studio(t, studios, "studio_b").Promos
+	promo := testPromo("SUMMER")
+	require.NoError(t, a.Create(promo))
+
+	_, exists := b.GetByCode("SUMMER")
+	assert.False(t, exists)
+	assert.Empty(t, b.List("", 10))
+	assert.ErrorIs(t, b.Update(promo), constants.ErrPromoNotFound)
+	assert.ErrorIs(t, b.Delete("SUMMER"), constants.ErrPromoNotFound)
+
+	// Codes are keyed per studio
+	other := testPromo("SUMMER")
+	other.Percent = 50
+	require.NoError(t, b.Create(other))
+	stored, exists := a.GetByCode("SUMMER")
+	assert.True(t, exists)
+	assert.Equal(t, promo, stored)
+	assert.Len(t, a.List("", 10), 1)
+}
+
+func testStudioIdempotency(t *testing.T, studios Studios) {
+	a, b := studio(t, studios, "studio_a").Idempotency, studio(t, studios, "studio_b").Idempotency
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	completed := testRecord("key-1", now)
+	_, reserved, err := a.Reserve(completed)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	completed.StatusCode, completed.Body = 201, []byte(`{"status":"success"}`)
+	require.NoError(t, a.Complete(completed))
+
+	// The same key is new to another studio, which cannot change or free it
+	other := testRecord("key-1", now)
+	other.Fingerprint = "fp_other"
+	existing, reserved, err := b.Reserve(other)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Equal(t, other, existing)
+	other.StatusCode = 500
+	require.NoError(t, b.Complete(other))
+	require.NoError(t, b.Release("key-1"))
+	require.NoError(t, b.Purge(now.Add(time.Hour)))
+
+	existing, reserved, err = a.Reserve(testRecord("key-1", now))
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, completed, existing)
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"glofox/internal/constants"
+	"sync"
+	"time"
+)
+
+type WaitlistRepository interface {
+	Join(className, memberName string, date time.Time) (int, error)
+	Leave(className, memberName string, date time.Time) error
+	Position(className, memberName string, date time.Time) (int, error)
+	Peek(className string, date time.Time) (string, bool)
+}
+
+// WaitlistRepo manages the in-memory waitlists
+type WaitlistRepo struct {
+	// Key: class name, Sub-key: date, Value: member names in waitlist order
+	waitlists map[string]map[time.Time][]string
+	mu        sync.RWMutex
+}
+
+// NewWaitlistRepo creates a new WaitlistRepo
+func NewWaitlistRepo() *WaitlistRepo {
+	return &WaitlistRepo{
+		waitlists: make(map[string]map[time.Time][]string),
+	}
+}
+
+// Join appends a member to the waitlist and returns their 1-based position
+func (waitlistRepo *WaitlistRepo) Join(className, memberName string, date time.Time) (int, error) {
+	waitlistRepo.mu.Lock()
+	defer waitlistRepo.mu.Unlock()
+
+	entry, err := waitlistRepo.joined(className, memberName, date)
+	if err != nil {
+		return 0, err
+	}
+	waitlistRepo.set(entry)
+	return len(entry.Members), nil
+}
+
+// Leave removes a member from the waitlist
+func (waitlistRepo *WaitlistRepo) Leave(className, memberName string, date time.Time) error {
+	waitlistRepo.mu.Lock()
+	defer waitlistRepo.mu.Unlock()
+
+	entry, err := waitlistRepo.left(className, memberName, date)
+	if err != nil {
+		return err
+	}
+	waitlistRepo.set(entry)
+	return nil
+}
+
+// Position returns the 1-based position of a member on the waitlist
+func (waitlistRepo *WaitlistRepo) Position(className, memberName string, date time.Time) (int, error) {
+	waitlistRepo.mu.RLock()
+	defer waitlistRepo.mu.RUnlock()
+
+	i := indexOf(waitlistRepo.waitlists[className][date.UTC()], memberName)
+	if i < 0 {
+		return 0, constants.ErrNotOnWaitlist
+	}
+	return i + 1, nil
+}
+
+// Peek returns the member at the head of the waitlist without removing them
+func (waitlistRepo *WaitlistRepo) Peek(className string, date time.Time) (string, bool) {
+	waitlistRepo.mu.RLock()
+	defer waitlistRepo.mu.RUnlock()
+
+	members := waitlistRepo.waitlists[className][date.UTC()]
+	if len(members) == 0 {
+		return "", false
+	}
+	return members[0], true
+}
+
+// waitlistEntry is the persisted form of one session's waitlist
+type waitlistEntry struct {
+	ClassName string    `json:"class_name"`
+	Date      time.Time `json:"date"`
+	Members   []string  `json:"members"`
+}
+
+// entries returns every non-empty waitlist
+func (waitlistRepo *WaitlistRepo) entries() []waitlistEntry {
+	waitlistRepo.mu.RLock()
+	defer waitlistRepo.mu.RUnlock()
+
+	var entries []waitlistEntry
+	for className, dates := range waitlistRepo.waitlists {
+		for date, members := range dates {
+			if len(members) > 0 {
+				entries = append(entries, waitlistEntry{ClassName: className, Date: date, Members: append([]string(nil), members...)})
+			}
+		}
+	}
+	return entries
+}
+
+// entry returns a copy of one session's waitlist, the caller must hold the lock
+func (waitlistRepo *WaitlistRepo) entry(className string, date time.Time) waitlistEntry {
+	date = date.UTC()
+	return waitlistEntry{ClassName: className, Date: date, Members: append([]string{}, waitlistRepo.waitlists[className][date]...)}
+}
+
+// joined returns one session's waitlist with a member appended, the caller
+// must hold the lock
+func (waitlistRepo *WaitlistRepo) joined(className, memberName string, date time.Time) (waitlistEntry, error) {
+	entry := waitlistRepo.entry(className, date)
+	if indexOf(entry.Members, memberName) >= 0 {
+		return waitlistEntry{}, constants.ErrAlreadyWaitlisted
+	}
+	entry.Members = append(entry.Members, memberName)
+	return entry, nil
+}
+
+// left returns one session's waitlist with a member removed, the caller must
+// hold the lock
+func (waitlistRepo *WaitlistRepo) left(className, memberName string, date time.Time) (waitlistEntry, error) {
+	entry := waitlistRepo.entry(className, date)
+	i := indexOf(entry.Members, memberName)
+	if i < 0 {
+		return waitlistEntry{}, constants.ErrNotOnWaitlist
+	}
+	entry.Members = append(entry.Members[:i], entry.Members[i+1:]...)
+	return entry, nil
+}
+
+// restore replaces one session's waitlist, used to restore persisted state
+func (waitlistRepo *WaitlistRepo) restore(entry waitlistEntry) {
+	waitlistRepo.mu.Lock()
+	defer waitlistRepo.mu.Unlock()
+
+	waitlistRepo.set(entry)
+}
+
+// set replaces one session's waitlist, the caller must hold the lock
+func (waitlistRepo *WaitlistRepo) set(entry waitlistEntry) {
+	if _, exists := waitlistRepo.waitlists[entry.ClassName]; !exists {
+		waitlistRepo.waitlists[entry.ClassName] = make(map[time.Time][]string)
+	}
+	waitlistRepo.waitlists[entry.ClassName][entry.Date.UTC()] = entry.Members
+}
+
+// indexOf returns the index of name in names or -1 if absent
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"glofox/internal/constants"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitlistRepo(t *testing.T) {
+	testWaitlistRepository(t, func(t *testing.T) WaitlistRepository {
+		return NewWaitlistRepo()
+	})
+}
+
+// testWaitlistRepository runs the WaitlistRepository test suite against the
+// implementation returned by newRepo
+func testWaitlistRepository(t *testing.T, newRepo func(t *testing.T) WaitlistRepository) {
+	t.Run("Order", func(t *testing.T) { testWaitlistOrder(t, newRepo(t)) })
+}
+
+func testWaitlistOrder(t *testing.T, repo WaitlistRepository) {
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	for i, member := range []string{"Alice", "Bob", "Carol"} {
+		position, err := repo.Join("Yoga", member, date)
+		assert.NoError(t, err)
+		assert.Equal(t, i+1, position)
+	}
+	_, err := repo.Join("Yoga", "Bob", date)
+	assert.ErrorIs(t, err, constants.ErrAlreadyWaitlisted)
+
+	// Leaving moves everyone behind one place up
+	assert.NoError(t, repo.Leave("Yoga", "Alice", date))
+	position, err := repo.Position("Yoga", "Carol", date)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, position)
+
+	head, ok := repo.Peek("Yoga", date)
+	assert.True(t, ok)
+	assert.Equal(t, "Bob", head)
+
+	assert.ErrorIs(t, repo.Leave("Yoga", "Alice", date), constants.ErrNotOnWaitlist)
+	_, ok = repo.Peek("Yoga", date.AddDate(0, 0, 1))
+	assert.False(t, ok)
+}
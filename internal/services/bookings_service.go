@@ -1,16 +1,23 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"glofox/internal/apierrors"
 	"glofox/internal/constants"
+	"glofox/internal/models"
 	"glofox/internal/utils"
 	"log"
 	"runtime/debug"
+	"strings"
 	"time"
 )
 
-// BookClass creates a booking
-func (service *ClassService) BookClass(className, memberName, dateStr string) (err error) {
+// BookClass creates a booking, or puts the member on the waitlist when the
+// class is full and JoinWaitlist is set. Members without a membership
+// covering the class may pay its drop-in price, their booking is pending
+// until the payment is authorized.
+func (service *ClassService) BookClass(req models.BookingRequest) (result models.BookingResult, err error) {
 	// Recover from panics
 	defer func() {
 		if r := recover(); r != nil {
@@ -19,23 +26,298 @@ func (service *ClassService) BookClass(className, memberName, dateStr string) (e
 		}
 	}()
 
-	// specific date format validation
-	date, err := time.Parse(constants.DateFormat, dateStr)
+	class, requested, booking, err := service.reserve(req)
+	member, date := requested.MemberID, requested.Date
+	if errors.Is(err, constants.ErrClassFull) && req.JoinWaitlist {
+		position, err := service.waitlistRepo.Join(class.Name, member, date)
+		if err != nil {
+			return models.BookingResult{}, err
+		}
+		return models.BookingResult{Status: constants.BookingStatusWaitlisted, Position: position}, nil
+	}
+	if err != nil {
+		return models.BookingResult{}, err
+	}
+	if payment := booking.Payment; payment != nil {
+		// The seat is held while the payment is authorized, a provider that
+		// does not answer leaves the booking pending until it is settled
+		if booking, err = service.settle(class, booking); err != nil {
+			log.Printf("Payment %s of booking %s is pending: %v", payment.ID, booking.ID, err)
+		}
+		if booking.Payment.Status == constants.PaymentDeclined {
+			return models.BookingResult{}, constants.ErrPaymentDeclined
+		}
+	}
+
+	// A member who got a seat no longer needs their waitlist spot
+	if err := service.waitlistRepo.Leave(class.Name, member, date); err != nil && !errors.Is(err, constants.ErrNotOnWaitlist) {
+		log.Printf("Failed to remove %s from waitlist of %s: %v", member, class.Name, err)
+	}
+	booking = localBooking(booking, utils.ClassLocation(class))
+	if booking.Status == constants.BookingStatusPending {
+		return models.BookingResult{Status: constants.BookingStatusPending, Booking: &booking}, nil
+	}
+	return models.BookingResult{Status: constants.BookingStatusBooked, Booking: &booking}, nil
+}
+
+// reserve creates the booking of a request against the class as stored and
+// returns the class, the booking requested and the booking created. The
+// class cannot change between being read and booked, see classMu.
+func (service *ClassService) reserve(req models.BookingRequest) (models.Class, models.Booking, models.Booking, error) {
+	service.classMu.RLock()
+	defer service.classMu.RUnlock()
+
+	class, date, err := service.resolveSession(req.ClassName, req.Date)
+	if err != nil {
+		return models.Class{}, models.Booking{}, models.Booking{}, err
+	}
+	member, err := service.activeMember(req.MemberID, req.MemberName)
+	if err != nil {
+		return models.Class{}, models.Booking{}, models.Booking{}, err
+	}
+	limits := service.bookingLimits(class, date)
+	spot, err := requestedSpot(class, limits, req.Spot)
+	if err != nil {
+		return models.Class{}, models.Booking{}, models.Booking{}, err
+	}
+	limits.Charge, err = service.entitlement(member, class, date)
+	var promo *models.Promo
+	if req.PromoCode != "" {
+		// A code that applies keeps err while part of the price is left to pay
+		if promo, limits.Promo, err = service.redeemPromo(req.PromoCode, class, date, err); promo == nil && err != nil {
+			return models.Class{}, models.Booking{}, models.Booking{}, err
+		}
+	}
+	var payment *models.Payment
+	if errors.Is(err, constants.ErrNoEntitlement) && class.DropIn != nil && service.payments != nil {
+		if payment, err = service.dropInPayment(class, promo, req.PaymentMethod, err); err != nil {
+			return models.Class{}, models.Booking{}, models.Booking{}, err
+		}
+	}
+	if err != nil {
+		return models.Class{}, models.Booking{}, models.Booking{}, err
+	}
+
+	// Create booking, capacity, duplicates, spots, the daily limit, credits and promo codes are enforced by the repository
+	requested := models.Booking{
+		ClassName:  class.Name,
+		MemberID:   member.ID,
+		MemberName: member.Name,
+		Date:       date,
+		Spot:       spot,
+		Payment:    payment,
+		Promo:      promo,
+	}
+	booking, err := service.bookingRepo.Create(requested, limits)
+	return class, requested, booking, err
+}
+
+// bookingLimits returns the limits the repository enforces when booking the
+// session of the class starting at date
+func (service *ClassService) bookingLimits(class models.Class, date time.Time) models.BookingLimits {
+	limits := models.BookingLimits{Capacity: class.Capacity, PerDay: service.dailyBookingLimit}
+	for _, spot := range service.spots(class) {
+		limits.Spots = append(limits.Spots, spot.Label)
+	}
+	if limits.PerDay > 0 {
+		// The day is the calendar day of the session in the time zone of the class
+		local := date.In(utils.ClassLocation(class))
+		limits.DayStart = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location()).UTC()
+		limits.DayEnd = time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, local.Location()).UTC()
+	}
+	return limits
+}
+
+// requestedSpot returns the label of the requested spot as written in the seat
+// layout of the session, ignoring case. An empty request lets the repository
+// assign a free spot.
+func requestedSpot(class models.Class, limits models.BookingLimits, requested string) (string, error) {
+	requested = strings.TrimSpace(requested)
+	if requested == "" {
+		return "", nil
+	}
+	for _, spot := range limits.Spots {
+		if strings.EqualFold(spot, requested) {
+			return spot, nil
+		}
+	}
+	return "", apierrors.Field(fmt.Errorf("%w: %s has no spot %s", constants.ErrUnknownSpot, class.Name, requested), "spot")
+}
+
+// CancelBooking cancels a booking according to the cancellation policy of its
+// class and promotes the head of the waitlist into the freed seat. The booking
+// must be at version, unless version is constants.AnyVersion.
+func (service *ClassService) CancelBooking(id string, version int) (cancelled models.Booking, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	booking, exists := service.bookingRepo.GetByID(id)
+	if !exists {
+		return models.Booking{}, constants.ErrBookingNotFound
+	}
+	if version != constants.AnyVersion && version != booking.Version {
+		return models.Booking{}, constants.ErrVersionMismatch
+	}
+	if booking.Status == constants.BookingStatusCancelled {
+		return models.Booking{}, constants.ErrAlreadyCancelled
+	}
+
+	class, exists := service.classRepo.GetByName(booking.ClassName)
+	if !exists {
+		return models.Booking{}, constants.ErrClassNotFound
+	}
+
+	// Apply the cancellation policy relative to the start of the class
+	now := service.now().UTC()
+	start := booking.Date
+	if !now.Before(start) {
+		return models.Booking{}, constants.ErrCancellationClosed
+	}
+	freeUntil := start.Add(-time.Duration(class.CancellationPolicy.FreeCancelHours) * time.Hour)
+	lateCancel := !now.Before(freeUntil)
+	if lateCancel && !class.CancellationPolicy.AllowLateCancel {
+		return models.Booking{}, constants.ErrLateCancelDenied
+	}
+
+	cancelled, err = service.bookingRepo.Cancel(id, booking.Version, now, lateCancel, "")
 	if err != nil {
-		return constants.ErrInvalidDate
+		return models.Booking{}, err
 	}
+	if cancelled.Payment != nil {
+		// A refund that fails is retried when payments are settled
+		if cancelled, err = service.settle(class, cancelled); err != nil {
+			log.Printf("Failed to settle payment %s of booking %s: %v", cancelled.Payment.ID, id, err)
+		}
+	}
+	service.promoteWaitlist(class.Name, booking.Date)
+	return localBooking(cancelled, utils.ClassLocation(class)), nil
+}
 
+// GetBooking fetches a booking by ID, rendered in the time zone of its class
+func (service *ClassService) GetBooking(id string) (models.Booking, error) {
+	booking, exists := service.bookingRepo.GetByID(id)
+	if !exists {
+		return models.Booking{}, constants.ErrBookingNotFound
+	}
+	loc := service.location
+	if class, exists := service.classRepo.GetByName(booking.ClassName); exists {
+		loc = utils.ClassLocation(class)
+	}
+	return localBooking(booking, loc), nil
+}
+
+// resolveSession parses the date and returns the start of the session of the
+// class it selects. A date without a time selects the only session of that day.
+func (service *ClassService) resolveSession(className, dateStr string) (models.Class, time.Time, error) {
 	// Check if class exists
 	class, exists := service.classRepo.GetByName(className)
 	if !exists {
-		return constants.ErrClassNotFound
+		return models.Class{}, time.Time{}, constants.ErrClassNotFound
+	}
+
+	// Dates are parsed in the time zone of the class
+	loc := utils.ClassLocation(class)
+	date, dateOnly, err := utils.ParseDateTime(dateStr, loc)
+	if err != nil {
+		return models.Class{}, time.Time{}, apierrors.Field(constants.ErrInvalidDate, "date")
+	}
+
+	// Check if the class runs on the date
+	sessions := utils.Sessions(class, utils.LocalDate(date, loc))
+	if len(sessions) == 0 {
+		return models.Class{}, time.Time{}, fmt.Errorf("%w: class %s does not run on %s", constants.ErrSessionNotFound, className, dateStr)
+	}
+	if dateOnly {
+		if len(sessions) > 1 {
+			return models.Class{}, time.Time{}, apierrors.Field(constants.ErrSessionRequired, "date")
+		}
+		return class, sessions[0].Date, nil
 	}
+	for _, session := range sessions {
+		if session.Date.Equal(date) {
+			return class, session.Date, nil
+		}
+	}
+	return models.Class{}, time.Time{}, fmt.Errorf("%w: no session of class %s starts at %s", constants.ErrSessionNotFound, className, dateStr)
+}
 
-	// Check if date is valid for the class
-	if !utils.IsDateInRange(date, class.StartDate, class.EndDate) {
-		return fmt.Errorf("date %s is not valid for class %s", dateStr, className)
+// ListBookings returns a page of bookings filtered by member, class and date range
+func (service *ClassService) ListBookings(req models.BookingListRequest) (models.Page[models.Booking], error) {
+	filter := models.BookingFilter{
+		MemberID:   req.MemberID,
+		MemberName: req.MemberName,
+		ClassName:  req.ClassName,
 	}
 
-	// Create booking
-	return service.bookingRepo.Create(className, memberName, date)
+	// Date bounds are days in the time zone of the studio
+	if req.From != "" {
+		from, _, err := utils.ParseDateTime(req.From, service.location)
+		if err != nil {
+			return models.Page[models.Booking]{}, apierrors.Field(constants.ErrInvalidStartDate, "from")
+		}
+		filter.From = from
+	}
+	if req.To != "" {
+		to, dateOnly, err := utils.ParseDateTime(req.To, service.location)
+		if err != nil {
+			return models.Page[models.Booking]{}, apierrors.Field(constants.ErrInvalidEndDate, "to")
+		}
+		// A date includes every session of that day
+		if dateOnly {
+			to = to.In(service.location).AddDate(0, 0, 1).Add(-time.Nanosecond).UTC()
+		}
+		filter.To = to
+		if !filter.From.IsZero() {
+			if err := utils.IsValidDate(filter.From, filter.To); err != nil {
+				return models.Page[models.Booking]{}, apierrors.Field(err, "from", "to")
+			}
+		}
+	}
+	if req.Cursor != "" {
+		filter.After = &models.BookingCursor{}
+		if err := utils.DecodeCursor(req.Cursor, filter.After); err != nil {
+			return models.Page[models.Booking]{}, err
+		}
+	}
+
+	// Fetch one extra booking to know whether there is a next page
+	limit := utils.PageLimit(req.Limit)
+	filter.Limit = limit + 1
+	bookings := service.bookingRepo.Query(filter)
+
+	page := models.Page[models.Booking]{Items: bookings}
+	if page.Items == nil {
+		page.Items = []models.Booking{}
+	}
+	if len(bookings) > limit {
+		last := bookings[limit-1]
+		page.Items = bookings[:limit]
+		page.NextCursor = utils.EncodeCursor(models.BookingCursor{Date: last.Date, ClassName: last.ClassName, ID: last.ID})
+	}
+
+	page.Items = service.localBookings(page.Items)
+	return page, nil
+}
+
+// localBookings renders every booking in the time zone of its class
+func (service *ClassService) localBookings(bookings []models.Booking) []models.Booking {
+	locations := make(map[string]*time.Location)
+	local := make([]models.Booking, len(bookings))
+	for i, booking := range bookings {
+		loc, ok := locations[booking.ClassName]
+		if !ok {
+			loc = service.location
+			if class, exists := service.classRepo.GetByName(booking.ClassName); exists {
+				loc = utils.ClassLocation(class)
+			}
+			locations[booking.ClassName] = loc
+		}
+		local[i] = localBooking(booking, loc)
+	}
+	return local
 }
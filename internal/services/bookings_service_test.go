@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"glofox/internal/apierrors"
 	"glofox/internal/constants"
 	"glofox/internal/models"
 	"glofox/internal/utils"
@@ -11,8 +13,67 @@ import (
 	"time"
 )
 
-func (m *MockBookingRepo) Create(className, memberName string, date time.Time) error {
-	args := m.Called(className, memberName, date)
+func (m *MockBookingRepo) Create(booking models.Booking, limits models.BookingLimits) (models.Booking, error) {
+	args := m.Called(booking, limits)
+	created, _ := args.Get(0).(models.Booking)
+	return created, args.Error(1)
+}
+
+// seats returns the limits of a session with the given capacity and no daily cap
+func seats(capacity int) models.BookingLimits {
+	return models.BookingLimits{Capacity: capacity}
+}
+
+func (m *MockBookingRepo) GetByID(id string) (models.Booking, bool) {
+	args := m.Called(id)
+	booking, _ := args.Get(0).(models.Booking)
+	return booking, args.Bool(1)
+}
+
+func (m *MockBookingRepo) Cancel(id string, version int, cancelledAt time.Time, lateCancel bool, reason string) (models.Booking, error) {
+	args := m.Called(id, version, cancelledAt, lateCancel, reason)
+	booking, _ := args.Get(0).(models.Booking)
+	return booking, args.Error(1)
+}
+
+func (m *MockBookingRepo) Count(className string, date time.Time) int {
+	args := m.Called(className, date)
+	return args.Int(0)
+}
+
+func (m *MockBookingRepo) Query(filter models.BookingFilter) []models.Booking {
+	args := m.Called(filter)
+	bookings, _ := args.Get(0).([]models.Booking)
+	return bookings
+}
+
+func (m *MockBookingRepo) AddCredit(entry models.CreditEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockBookingRepo) Credits(memberID string) []models.CreditEntry {
+	args := m.Called(memberID)
+	entries, _ := args.Get(0).([]models.CreditEntry)
+	return entries
+}
+
+func (m *MockBookingRepo) Balance(memberID string, charge models.Charge) int {
+	args := m.Called(memberID, charge)
+	if balance, ok := args.Get(0).(func(string, models.Charge) int); ok {
+		return balance(memberID, charge)
+	}
+	return args.Int(0)
+}
+
+func (m *MockBookingRepo) UpdatePayment(id string, version int, payment models.Payment) (models.Booking, error) {
+	args := m.Called(id, version, payment)
+	booking, _ := args.Get(0).(models.Booking)
+	return booking, args.Error(1)
+}
+
+func (m *MockBookingRepo) DeleteByClass(className string) error {
+	args := m.Called(className)
 	return args.Error(0)
 }
 
@@ -20,7 +81,8 @@ func TestClassService_BookClass(t *testing.T) {
 	// Setup mocks
 	mockClassRepo := new(MockClassRepo)
 	mockBookingRepo := new(MockBookingRepo)
-	service := NewClassService(mockClassRepo, mockBookingRepo)
+	mockWaitlistRepo := new(MockWaitlistRepo)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Waitlists: mockWaitlistRepo, Members: aliceMemberRepo()})
 
 	// Define test cases
 	tests := []struct {
@@ -28,12 +90,15 @@ func TestClassService_BookClass(t *testing.T) {
 		className       string
 		memberName      string
 		dateStr         string
+		joinWaitlist    bool
 		setupMock       func()
 		expectedErr     error
+		expectedResult  models.BookingResult
 		expectedBooking *struct {
 			className  string
 			memberName string
 			date       time.Time
+			capacity   int
 		}
 	}{
 		{
@@ -51,25 +116,30 @@ func TestClassService_BookClass(t *testing.T) {
 					EndDate:   endDate,
 					Capacity:  10,
 				}, true)
-				mockBookingRepo.On("Create", "Yoga", "Alice", utils.ToMidnightUTC(date)).Return(nil)
+				mockBookingRepo.On("Create", aliceBooking("Yoga", utils.ToMidnightUTC(date)), seats(10)).Return(models.Booking{}, nil)
 			},
-			expectedErr: nil,
+			expectedErr:    nil,
+			expectedResult: models.BookingResult{Status: constants.BookingStatusBooked},
 			expectedBooking: &struct {
 				className  string
 				memberName string
 				date       time.Time
+				capacity   int
 			}{
 				className:  "Yoga",
 				memberName: "Alice",
 				date:       time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC),
+				capacity:   10,
 			},
 		},
 		{
-			name:            "Invalid Date Format",
-			className:       "Yoga",
-			memberName:      "Alice",
-			dateStr:         "2025/06/10",
-			setupMock:       func() {},
+			name:       "Invalid Date Format",
+			className:  "Yoga",
+			memberName: "Alice",
+			dateStr:    "2025/06/10",
+			setupMock: func() {
+				mockClassRepo.On("GetByName", "Yoga").Return(models.Class{Name: "Yoga", Capacity: 10}, true)
+			},
 			expectedErr:     constants.ErrInvalidDate,
 			expectedBooking: nil,
 		},
@@ -99,7 +169,24 @@ func TestClassService_BookClass(t *testing.T) {
 					Capacity:  10,
 				}, true)
 			},
-			expectedErr:     fmt.Errorf("date 2025-05-31 is not valid for class Yoga"),
+			expectedErr:     fmt.Errorf("%w: class Yoga does not run on 2025-05-31", constants.ErrSessionNotFound),
+			expectedBooking: nil,
+		},
+		{
+			name:       "Date Not An Occurrence",
+			className:  "Yoga",
+			memberName: "Alice",
+			dateStr:    "2025-06-10",
+			setupMock: func() {
+				mockClassRepo.On("GetByName", "Yoga").Return(models.Class{
+					Name:       "Yoga",
+					StartDate:  time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+					EndDate:    time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC),
+					Capacity:   10,
+					Recurrence: models.Recurrence{Frequency: constants.FrequencyWeekly, Interval: 1, Weekdays: []string{"MO", "WE"}},
+				}, true)
+			},
+			expectedErr:     fmt.Errorf("%w: class Yoga does not run on 2025-06-10", constants.ErrSessionNotFound),
 			expectedBooking: nil,
 		},
 		{
@@ -117,7 +204,7 @@ func TestClassService_BookClass(t *testing.T) {
 					Capacity:  10,
 				}, true)
 			},
-			expectedErr:     fmt.Errorf("date 2025-06-21 is not valid for class Yoga"),
+			expectedErr:     fmt.Errorf("%w: class Yoga does not run on 2025-06-21", constants.ErrSessionNotFound),
 			expectedBooking: nil,
 		},
 		{
@@ -135,17 +222,20 @@ func TestClassService_BookClass(t *testing.T) {
 					EndDate:   endDate,
 					Capacity:  10,
 				}, true)
-				mockBookingRepo.On("Create", "Yoga", "Alice", utils.ToMidnightUTC(date)).Return(nil)
+				mockBookingRepo.On("Create", aliceBooking("Yoga", utils.ToMidnightUTC(date)), seats(10)).Return(models.Booking{}, nil)
 			},
-			expectedErr: nil,
+			expectedErr:    nil,
+			expectedResult: models.BookingResult{Status: constants.BookingStatusBooked},
 			expectedBooking: &struct {
 				className  string
 				memberName string
 				date       time.Time
+				capacity   int
 			}{
 				className:  "Yoga",
 				memberName: "Alice",
 				date:       time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+				capacity:   10,
 			},
 		},
 		{
@@ -163,18 +253,86 @@ func TestClassService_BookClass(t *testing.T) {
 					EndDate:   endDate,
 					Capacity:  10,
 				}, true)
-				mockBookingRepo.On("Create", "Yoga", "Alice", utils.ToMidnightUTC(date)).Return(nil)
+				mockBookingRepo.On("Create", aliceBooking("Yoga", utils.ToMidnightUTC(date)), seats(10)).Return(models.Booking{}, nil)
 			},
-			expectedErr: nil,
+			expectedErr:    nil,
+			expectedResult: models.BookingResult{Status: constants.BookingStatusBooked},
 			expectedBooking: &struct {
 				className  string
 				memberName string
 				date       time.Time
+				capacity   int
 			}{
 				className:  "Yoga",
 				memberName: "Alice",
 				date:       time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC),
+				capacity:   10,
+			},
+		},
+		{
+			name:       "Class Full",
+			className:  "Yoga",
+			memberName: "Alice",
+			dateStr:    "2025-06-10",
+			setupMock: func() {
+				startDate, _ := time.Parse(constants.DateFormat, "2025-06-01")
+				endDate, _ := time.Parse(constants.DateFormat, "2025-06-20")
+				date, _ := time.Parse(constants.DateFormat, "2025-06-10")
+				mockClassRepo.On("GetByName", "Yoga").Return(models.Class{
+					Name:      "Yoga",
+					StartDate: startDate,
+					EndDate:   endDate,
+					Capacity:  10,
+				}, true)
+				mockBookingRepo.On("Create", aliceBooking("Yoga", utils.ToMidnightUTC(date)), seats(10)).Return(models.Booking{}, constants.ErrClassFull)
 			},
+			expectedErr:     constants.ErrClassFull,
+			expectedBooking: nil,
+		},
+		{
+			name:         "Already Booked",
+			className:    "Yoga",
+			memberName:   "Alice",
+			dateStr:      "2025-06-10",
+			joinWaitlist: true,
+			setupMock: func() {
+				startDate, _ := time.Parse(constants.DateFormat, "2025-06-01")
+				endDate, _ := time.Parse(constants.DateFormat, "2025-06-20")
+				date, _ := time.Parse(constants.DateFormat, "2025-06-10")
+				mockClassRepo.On("GetByName", "Yoga").Return(models.Class{
+					Name:      "Yoga",
+					StartDate: startDate,
+					EndDate:   endDate,
+					Capacity:  10,
+				}, true)
+				// A member already holding a seat is not put on the waitlist
+				mockBookingRepo.On("Create", aliceBooking("Yoga", utils.ToMidnightUTC(date)), seats(10)).Return(models.Booking{}, constants.ErrAlreadyBooked)
+			},
+			expectedErr:     constants.ErrAlreadyBooked,
+			expectedBooking: nil,
+		},
+		{
+			name:         "Class Full Joins Waitlist",
+			className:    "Yoga",
+			memberName:   "Alice",
+			dateStr:      "2025-06-10",
+			joinWaitlist: true,
+			setupMock: func() {
+				startDate, _ := time.Parse(constants.DateFormat, "2025-06-01")
+				endDate, _ := time.Parse(constants.DateFormat, "2025-06-20")
+				date, _ := time.Parse(constants.DateFormat, "2025-06-10")
+				mockClassRepo.On("GetByName", "Yoga").Return(models.Class{
+					Name:      "Yoga",
+					StartDate: startDate,
+					EndDate:   endDate,
+					Capacity:  10,
+				}, true)
+				mockBookingRepo.On("Create", aliceBooking("Yoga", utils.ToMidnightUTC(date)), seats(10)).Return(models.Booking{}, constants.ErrClassFull)
+				mockWaitlistRepo.On("Join", "Yoga", "mb_alice", utils.ToMidnightUTC(date)).Return(3, nil)
+			},
+			expectedErr:     nil,
+			expectedResult:  models.BookingResult{Status: constants.BookingStatusWaitlisted, Position: 3},
+			expectedBooking: nil,
 		},
 	}
 
@@ -186,12 +344,15 @@ func TestClassService_BookClass(t *testing.T) {
 			mockClassRepo.ExpectedCalls = nil
 			mockBookingRepo.Calls = nil
 			mockBookingRepo.ExpectedCalls = nil
+			mockWaitlistRepo.Calls = nil
+			mockWaitlistRepo.ExpectedCalls = nil
 
 			// Setup mock
 			tt.setupMock()
+			mockWaitlistRepo.On("Leave", mock.Anything, mock.Anything, mock.Anything).Return(constants.ErrNotOnWaitlist)
 
 			// Call BookClass
-			err := service.BookClass(tt.className, tt.memberName, tt.dateStr)
+			result, err := service.BookClass(models.BookingRequest{ClassName: tt.className, MemberName: tt.memberName, Date: tt.dateStr, JoinWaitlist: tt.joinWaitlist})
 
 			// Assert error
 			if tt.expectedErr != nil {
@@ -199,11 +360,18 @@ func TestClassService_BookClass(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 			}
+			assert.Equal(t, tt.expectedResult.Status, result.Status)
+			assert.Equal(t, tt.expectedResult.Position, result.Position)
 
 			// Assert mock calls
 			if tt.expectedBooking != nil {
 				mockClassRepo.AssertCalled(t, "GetByName", tt.className)
-				mockBookingRepo.AssertCalled(t, "Create", tt.expectedBooking.className, tt.expectedBooking.memberName, tt.expectedBooking.date)
+				mockBookingRepo.AssertCalled(t, "Create", models.Booking{
+					ClassName:  tt.expectedBooking.className,
+					MemberID:   "mb_alice",
+					MemberName: tt.expectedBooking.memberName,
+					Date:       tt.expectedBooking.date,
+				}, seats(tt.expectedBooking.capacity))
 			} else {
 				if errors.Is(err, constants.ErrInvalidDate) || errors.Is(err, constants.ErrClassNotFound) {
 					mockBookingRepo.AssertNotCalled(t, "Create")
@@ -212,3 +380,274 @@ func TestClassService_BookClass(t *testing.T) {
 		})
 	}
 }
+
+func TestClassService_BookClass_SessionTimes(t *testing.T) {
+	mockClassRepo, mockBookingRepo, mockWaitlistRepo := new(MockClassRepo), new(MockBookingRepo), new(MockWaitlistRepo)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Waitlists: mockWaitlistRepo, Members: aliceMemberRepo()})
+	evening := time.Date(2025, 6, 10, 18, 0, 0, 0, time.UTC)
+
+	mockClassRepo.On("GetByName", "Yoga").Return(models.Class{
+		Name:         "Yoga",
+		StartDate:    time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:      time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC),
+		Capacity:     10,
+		SessionTimes: []models.SessionTime{{Start: "07:00", DurationMinutes: 60}, {Start: "18:00", DurationMinutes: 60}},
+	}, true)
+	mockClassRepo.On("GetByName", "Boxing").Return(models.Class{
+		Name:         "Boxing",
+		StartDate:    time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:      time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC),
+		Capacity:     10,
+		SessionTimes: []models.SessionTime{{Start: "18:00", DurationMinutes: 60}},
+	}, true)
+	mockBookingRepo.On("Create", mock.MatchedBy(func(booking models.Booking) bool { return booking.Date.Equal(evening) }), seats(10)).Return(models.Booking{}, nil)
+	mockWaitlistRepo.On("Leave", mock.Anything, "mb_alice", evening).Return(constants.ErrNotOnWaitlist)
+
+	// An RFC 3339 start selects the session
+	_, err := service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberID: "mb_alice", Date: "2025-06-10T18:00:00Z"})
+	assert.NoError(t, err)
+	// A date alone is enough when the class runs one session that day
+	_, err = service.BookClass(models.BookingRequest{ClassName: "Boxing", MemberID: "mb_alice", Date: "2025-06-10"})
+	assert.NoError(t, err)
+
+	_, err = service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberID: "mb_alice", Date: "2025-06-10"})
+	assert.Equal(t, apierrors.Field(constants.ErrSessionRequired, "date"), err)
+	_, err = service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberID: "mb_alice", Date: "2025-06-10T09:00:00Z"})
+	assert.Equal(t, fmt.Errorf("%w: no session of class Yoga starts at 2025-06-10T09:00:00Z", constants.ErrSessionNotFound), err)
+	mockBookingRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestClassService_BookClass_TimeZone(t *testing.T) {
+	mockClassRepo, mockBookingRepo, mockWaitlistRepo := new(MockClassRepo), new(MockBookingRepo), new(MockWaitlistRepo)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Waitlists: mockWaitlistRepo, Members: aliceMemberRepo()})
+
+	// 07:00 in Sydney on 10 June is 21:00 UTC on 9 June
+	start := time.Date(2025, 6, 9, 21, 0, 0, 0, time.UTC)
+	mockClassRepo.On("GetByName", "Yoga").Return(models.Class{
+		Name:         "Yoga",
+		StartDate:    time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC),
+		EndDate:      time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC),
+		Capacity:     10,
+		TimeZone:     "Australia/Sydney",
+		SessionTimes: []models.SessionTime{{Start: "07:00", DurationMinutes: 60}},
+	}, true)
+	mockBookingRepo.On("Create", aliceBooking("Yoga", start), seats(10)).Return(models.Booking{ID: "bk_1", Date: start}, nil)
+	mockWaitlistRepo.On("Leave", "Yoga", "mb_alice", start).Return(constants.ErrNotOnWaitlist)
+
+	for _, dateStr := range []string{"2025-06-10", "2025-06-10T07:00", "2025-06-10T07:00:00+10:00", "2025-06-09T21:00:00Z"} {
+		result, err := service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberID: "mb_alice", Date: dateStr})
+		assert.NoError(t, err, dateStr)
+		// The booking is rendered in local time
+		assert.Equal(t, "2025-06-10T07:00:00+10:00", result.Booking.Date.Format(time.RFC3339), dateStr)
+	}
+
+	// The UTC date of the session is not a local date of the class
+	_, err := service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberID: "mb_alice", Date: "2025-06-09"})
+	assert.Equal(t, fmt.Errorf("%w: class Yoga does not run on 2025-06-09", constants.ErrSessionNotFound), err)
+	mockBookingRepo.AssertNumberOfCalls(t, "Create", 4)
+}
+
+func TestClassService_BookClass_DailyLimit(t *testing.T) {
+	mockClassRepo, mockBookingRepo, mockWaitlistRepo := new(MockClassRepo), new(MockBookingRepo), new(MockWaitlistRepo)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Waitlists: mockWaitlistRepo, Members: aliceMemberRepo(), DailyBookingLimit: 2})
+
+	// 07:00 in Sydney on 10 June is 21:00 UTC on 9 June, the day runs from 14:00 UTC to 14:00 UTC
+	start := time.Date(2025, 6, 9, 21, 0, 0, 0, time.UTC)
+	mockClassRepo.On("GetByName", "Yoga").Return(models.Class{
+		Name:         "Yoga",
+		StartDate:    time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC),
+		EndDate:      time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC),
+		Capacity:     10,
+		TimeZone:     "Australia/Sydney",
+		SessionTimes: []models.SessionTime{{Start: "07:00", DurationMinutes: 60}},
+	}, true)
+	mockBookingRepo.On("Create", aliceBooking("Yoga", start), models.BookingLimits{
+		Capacity: 10,
+		PerDay:   2,
+		DayStart: time.Date(2025, 6, 9, 14, 0, 0, 0, time.UTC),
+		DayEnd:   time.Date(2025, 6, 10, 14, 0, 0, 0, time.UTC),
+	}).Return(models.Booking{}, constants.ErrDailyLimitReached)
+
+	_, err := service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberID: "mb_alice", Date: "2025-06-10", JoinWaitlist: true})
+	assert.Equal(t, constants.ErrDailyLimitReached, err)
+	mockWaitlistRepo.AssertNotCalled(t, "Join", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestClassService_CancelBooking(t *testing.T) {
+	start := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	booking := models.Booking{ID: "bk_1", ClassName: "Yoga", MemberName: "Alice", Date: start, Status: constants.BookingStatusBooked, Version: 2}
+	class := models.Class{
+		Name:               "Yoga",
+		StartDate:          time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:            time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC),
+		Capacity:           10,
+		CancellationPolicy: models.CancellationPolicy{FreeCancelHours: 12, AllowLateCancel: true},
+	}
+	strictClass := class
+	strictClass.CancellationPolicy.AllowLateCancel = false
+
+	tests := []struct {
+		name         string
+		now          time.Time
+		version      int
+		booking      models.Booking
+		found        bool
+		class        models.Class
+		expectedErr  error
+		expectedLate *bool // Nil if no Cancel call expected
+	}{
+		{
+			name:         "Free Cancellation",
+			now:          start.Add(-13 * time.Hour),
+			version:      2,
+			booking:      booking,
+			found:        true,
+			class:        class,
+			expectedLate: new(bool),
+		},
+		{
+			name:         "Late Cancellation",
+			now:          start.Add(-12 * time.Hour),
+			booking:      booking,
+			found:        true,
+			class:        class,
+			expectedLate: func() *bool { late := true; return &late }(),
+		},
+		{
+			name:        "Late Cancellation Not Allowed",
+			now:         start.Add(-time.Hour),
+			booking:     booking,
+			found:       true,
+			class:       strictClass,
+			expectedErr: constants.ErrLateCancelDenied,
+		},
+		{
+			name:        "After Start",
+			now:         start,
+			booking:     booking,
+			found:       true,
+			class:       class,
+			expectedErr: constants.ErrCancellationClosed,
+		},
+		{
+			name:        "Stale Version",
+			now:         start.Add(-24 * time.Hour),
+			version:     1,
+			booking:     booking,
+			found:       true,
+			class:       class,
+			expectedErr: constants.ErrVersionMismatch,
+		},
+		{
+			name:        "Booking Not Found",
+			now:         start.Add(-24 * time.Hour),
+			expectedErr: constants.ErrBookingNotFound,
+		},
+		{
+			name: "Already Cancelled",
+			now:  start.Add(-24 * time.Hour),
+			booking: models.Booking{
+				ID: "bk_1", ClassName: "Yoga", Date: start, Status: constants.BookingStatusCancelled,
+			},
+			found:       true,
+			expectedErr: constants.ErrAlreadyCancelled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClassRepo, mockBookingRepo, mockWaitlistRepo := new(MockClassRepo), new(MockBookingRepo), new(MockWaitlistRepo)
+			mockBookingRepo.On("GetByID", "bk_1").Return(tt.booking, tt.found)
+			mockClassRepo.On("GetByName", "Yoga").Return(tt.class, true)
+			if tt.expectedLate != nil {
+				cancelled := tt.booking
+				cancelled.Status = constants.BookingStatusCancelled
+				cancelled.LateCancel = *tt.expectedLate
+				mockBookingRepo.On("Cancel", "bk_1", 2, tt.now, *tt.expectedLate, "").Return(cancelled, nil)
+				mockWaitlistRepo.On("Peek", "Yoga", start).Return("", false)
+			}
+			service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Waitlists: mockWaitlistRepo})
+			service.now = func() time.Time { return tt.now }
+
+			cancelled, err := service.CancelBooking("bk_1", tt.version)
+
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedLate != nil {
+				assert.Equal(t, constants.BookingStatusCancelled, cancelled.Status)
+				assert.Equal(t, *tt.expectedLate, cancelled.LateCancel)
+			} else {
+				mockBookingRepo.AssertNotCalled(t, "Cancel", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestClassService_GetBooking(t *testing.T) {
+	start := time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC)
+	booking := models.Booking{ID: "bk_1", ClassName: "Yoga", MemberID: "mb_1", Date: start, Status: constants.BookingStatusBooked}
+	mockClassRepo, mockBookingRepo := new(MockClassRepo), new(MockBookingRepo)
+	mockBookingRepo.On("GetByID", "bk_1").Return(booking, true)
+	mockBookingRepo.On("GetByID", "bk_2").Return(models.Booking{}, false)
+	mockClassRepo.On("GetByName", "Yoga").Return(models.Class{Name: "Yoga", TimeZone: "Europe/Dublin"}, true)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo})
+
+	found, err := service.GetBooking("bk_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "mb_1", found.MemberID)
+	assert.Equal(t, "Europe/Dublin", found.Date.Location().String())
+
+	_, err = service.GetBooking("bk_2")
+	assert.Equal(t, constants.ErrBookingNotFound, err)
+}
+
+func TestClassService_ListBookings(t *testing.T) {
+	mockClassRepo, mockBookingRepo := new(MockClassRepo), new(MockBookingRepo)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo})
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	mockClassRepo.On("GetByName", "Yoga").Return(models.Class{Name: "Yoga"}, true)
+	first := models.Booking{ID: "bk_1", ClassName: "Yoga", MemberName: "Alice", Date: date}
+	second := models.Booking{ID: "bk_2", ClassName: "Yoga", MemberName: "Alice", Date: date}
+
+	// First page, the extra booking signals that there is a next page
+	mockBookingRepo.On("Query", models.BookingFilter{
+		MemberName: "Alice",
+		ClassName:  "Yoga",
+		From:       time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		// A date-only upper bound includes the sessions of that day
+		To:    time.Date(2025, 6, 20, 23, 59, 59, 999999999, time.UTC),
+		Limit: 2,
+	}).Return([]models.Booking{first, second})
+	req := models.BookingListRequest{
+		ListRequest: models.ListRequest{Limit: 1},
+		MemberName:  "Alice",
+		ClassName:   "Yoga",
+		From:        "2025-06-01",
+		To:          "2025-06-20",
+	}
+	page, err := service.ListBookings(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Booking{first}, page.Items)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// The cursor resumes after the last booking of the previous page
+	mockBookingRepo.On("Query", mock.MatchedBy(func(filter models.BookingFilter) bool {
+		return filter.After != nil && filter.After.ID == "bk_1" && filter.After.Date.Equal(date)
+	})).Return([]models.Booking{second})
+	req.Cursor = page.NextCursor
+	page, err = service.ListBookings(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Booking{second}, page.Items)
+	assert.Empty(t, page.NextCursor)
+
+	// Invalid inputs never reach the repository
+	for input, expectedErr := range map[models.BookingListRequest]error{
+		{From: "2025/06/01"}:                            apierrors.Field(constants.ErrInvalidStartDate, "from"),
+		{To: "2025/06/20"}:                              apierrors.Field(constants.ErrInvalidEndDate, "to"),
+		{From: "2025-06-20", To: "2025-06-01"}:          apierrors.Field(constants.ErrInvalidStartEndDate, "from", "to"),
+		{ListRequest: models.ListRequest{Cursor: "%%"}}: constants.ErrInvalidCursor,
+	} {
+		_, err := service.ListBookings(input)
+		assert.Equal(t, expectedErr, err)
+	}
+	mockBookingRepo.AssertNumberOfCalls(t, "Query", 2)
+}
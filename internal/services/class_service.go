@@ -1,29 +1,103 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"glofox/internal/apierrors"
 	"glofox/internal/constants"
 	"glofox/internal/models"
+	"glofox/internal/payments"
 	"glofox/internal/repository"
 	"glofox/internal/utils"
 	"log"
 	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
 type ClassService struct {
-	classRepo   repository.ClassRepository
-	bookingRepo repository.BookingRepository
+	classRepo      repository.ClassRepository
+	bookingRepo    repository.BookingRepository
+	waitlistRepo   repository.WaitlistRepository
+	memberRepo     repository.MemberRepository
+	instructorRepo repository.InstructorRepository
+	roomRepo       repository.RoomRepository
+	planRepo       repository.PlanRepository
+	promoRepo      repository.PromoRepository
+	// payments takes the drop-in price of bookings, nil when the studio
+	// takes no payments
+	payments payments.PaymentProvider
+	// paymentTimeout bounds the calls to the provider settling a booking
+	paymentTimeout time.Duration
+	promoteMu      sync.Mutex
+	// classMu is held for reading while a booking is made against a class as
+	// read from the repository, and for writing while a class is changed or
+	// deleted against its bookings, so that no booking lands in between
+	classMu sync.RWMutex
+	// scheduleMu is held while the room and instructors of a class are
+	// checked for conflicts and the class is stored
+	scheduleMu sync.Mutex
+	// location is the time zone of the studio, used by classes that do not set their own
+	location *time.Location
+	// dailyBookingLimit is the number of bookings a member may hold per day across classes, 0 means no limit
+	dailyBookingLimit int
+	// now returns the current time, replaced in tests
+	now func() time.Time
 }
 
-func NewClassService(classRepo repository.ClassRepository, bookingRepo repository.BookingRepository) *ClassService {
+// Dependencies are the repositories and settings of a ClassService.
+// Repositories a caller does not use may be left nil.
+type Dependencies struct {
+	Classes     repository.ClassRepository
+	Bookings    repository.BookingRepository
+	Waitlists   repository.WaitlistRepository
+	Members     repository.MemberRepository
+	Instructors repository.InstructorRepository
+	Rooms       repository.RoomRepository
+	Plans       repository.PlanRepository
+	Promos      repository.PromoRepository
+	// Payments takes the drop-in price of bookings, nil when the studio takes no payments
+	Payments payments.PaymentProvider
+	// PaymentTimeout bounds the calls to Payments settling a booking,
+	// DefaultPaymentsTimeout when 0
+	PaymentTimeout time.Duration
+	// Location is the time zone of the studio, UTC when nil
+	Location *time.Location
+	// DailyBookingLimit is the number of bookings a member may hold per day across classes, 0 means no limit
+	DailyBookingLimit int
+}
+
+func NewClassService(deps Dependencies) *ClassService {
+	location := deps.Location
+	if location == nil {
+		location = time.UTC
+	}
+	paymentTimeout := deps.PaymentTimeout
+	if paymentTimeout <= 0 {
+		paymentTimeout = constants.DefaultPaymentsTimeout
+	}
 	return &ClassService{
-		classRepo:   classRepo,
-		bookingRepo: bookingRepo,
+		classRepo:         deps.Classes,
+		bookingRepo:       deps.Bookings,
+		waitlistRepo:      deps.Waitlists,
+		memberRepo:        deps.Members,
+		instructorRepo:    deps.Instructors,
+		roomRepo:          deps.Rooms,
+		planRepo:          deps.Plans,
+		promoRepo:         deps.Promos,
+		payments:          deps.Payments,
+		paymentTimeout:    paymentTimeout,
+		location:          location,
+		dailyBookingLimit: deps.DailyBookingLimit,
+		now:               time.Now,
 	}
 }
 
-// CreateClass adds a new class
-func (service *ClassService) CreateClass(name, startDateStr, endDateStr string, capacity int) (err error) {
+// CreateClass adds a new class and returns it at its first version
+func (service *ClassService) CreateClass(req models.ClassRequest) (created models.Class, err error) {
 	// Recover from panics
 	defer func() {
 		if r := recover(); r != nil {
@@ -32,27 +106,674 @@ func (service *ClassService) CreateClass(name, startDateStr, endDateStr string,
 		}
 	}()
 
-	// specific date format validation
-	startDate, err := time.Parse(constants.DateFormat, startDateStr)
+	timeZone := req.TimeZone
+	if timeZone == "" {
+		timeZone = service.location.String()
+	}
+	loc, err := utils.LoadLocation(timeZone)
+	if err != nil {
+		return models.Class{}, apierrors.Field(err, "time_zone")
+	}
+
+	// Dates are parsed in the time zone of the class, the class runs on whole local days
+	start, startDateOnly, err := utils.ParseDateTime(req.StartDate, loc)
 	if err != nil {
-		return constants.ErrInvalidStartDate
+		return models.Class{}, apierrors.Field(constants.ErrInvalidStartDate, "start_date")
 	}
+	startDate := utils.LocalDate(start, loc)
 
-	endDate, err := time.Parse(constants.DateFormat, endDateStr)
+	end, _, err := utils.ParseDateTime(req.EndDate, loc)
 	if err != nil {
-		return constants.ErrInvalidEndDate
+		return models.Class{}, apierrors.Field(constants.ErrInvalidEndDate, "end_date")
 	}
+	endDate := utils.LocalDate(end, loc)
 
 	err = utils.IsValidDate(startDate, endDate)
 	if err != nil {
-		return err
+		return models.Class{}, apierrors.Field(err, "start_date", "end_date")
+	}
+
+	recurrence, err := parseRecurrence(req.Recurrence)
+	if err != nil {
+		return models.Class{}, err
+	}
+
+	// A start date with a time of day and no explicit session times runs one session at that time
+	sessionTimesReq := req.SessionTimes
+	if localStart := start.In(loc); len(sessionTimesReq) == 0 && !startDateOnly && !localStart.Equal(utils.LocalTime(startDate, 0, loc)) {
+		sessionTimesReq = []models.SessionTimeRequest{{Start: localStart.Format(constants.TimeFormat)}}
+	}
+	sessionTimes, err := parseSessionTimes(sessionTimesReq)
+	if err != nil {
+		return models.Class{}, err
 	}
 
 	class := models.Class{
-		Name:      name,
-		StartDate: startDate,
-		EndDate:   endDate,
-		Capacity:  capacity,
+		Name:               req.Name,
+		StartDate:          startDate,
+		EndDate:            endDate,
+		Capacity:           req.Capacity,
+		CancellationPolicy: cancellationPolicy(req.CancellationPolicy),
+		Recurrence:         recurrence,
+		TimeZone:           timeZone,
+		SessionTimes:       sessionTimes,
+		Description:        strings.TrimSpace(req.Description),
+		InstructorID:       strings.TrimSpace(req.InstructorID),
+	}
+	if _, ok := nextOccurrence(class, startDate); !ok {
+		return models.Class{}, constants.ErrNoOccurrences
+	}
+	if err := service.checkInstructor(class.InstructorID); err != nil {
+		return models.Class{}, err
+	}
+	class.Substitutions, err = service.parseSubstitutions(class, req.Substitutions)
+	if err != nil {
+		return models.Class{}, err
+	}
+	class.RoomID = strings.TrimSpace(req.RoomID)
+	class.Resource = strings.TrimSpace(req.Resource)
+	if class.DropIn, err = dropInPrice(req.DropIn); err != nil {
+		return models.Class{}, err
+	}
+	created, err = service.schedule(class, service.classRepo.Create)
+	if err != nil {
+		return models.Class{}, err
+	}
+	return localClass(created), nil
+}
+
+// parseRecurrence validates the requested recurrence, classes without one run every day
+func parseRecurrence(req *models.RecurrenceRequest) (models.Recurrence, error) {
+	recurrence := models.Recurrence{Frequency: constants.FrequencyDaily, Interval: 1}
+	if req == nil {
+		return recurrence, nil
+	}
+
+	if req.RRule != "" {
+		if req.Frequency != "" || req.Interval != 0 || len(req.Weekdays) > 0 {
+			return models.Recurrence{}, fmt.Errorf("%w: rrule cannot be combined with frequency, interval or weekdays", constants.ErrInvalidRecurrence)
+		}
+		rule, err := utils.ParseRRule(req.RRule)
+		if err != nil {
+			return models.Recurrence{}, err
+		}
+		recurrence.Frequency, recurrence.Weekdays = rule.Frequency, rule.Weekdays
+		if rule.Interval > 0 {
+			recurrence.Interval = rule.Interval
+		}
+	} else {
+		if req.Frequency != "" {
+			recurrence.Frequency = req.Frequency
+		}
+		if req.Interval > 0 {
+			recurrence.Interval = req.Interval
+		}
+		recurrence.Weekdays = req.Weekdays
+	}
+
+	if len(recurrence.Weekdays) > 0 && recurrence.Frequency != constants.FrequencyWeekly {
+		return models.Recurrence{}, fmt.Errorf("%w: weekdays are only supported by weekly classes", constants.ErrInvalidRecurrence)
+	}
+	// Store the canonical day codes so that matching is a plain comparison
+	weekdays := make([]string, 0, len(recurrence.Weekdays))
+	for _, code := range recurrence.Weekdays {
+		day, err := utils.ParseWeekday(code)
+		if err != nil {
+			return models.Recurrence{}, err
+		}
+		if code := utils.WeekdayCode(day); !slices.Contains(weekdays, code) {
+			weekdays = append(weekdays, code)
+		}
+	}
+	recurrence.Weekdays = nil
+	if len(weekdays) > 0 {
+		recurrence.Weekdays = weekdays
+	}
+
+	for _, dateStr := range req.Exclusions {
+		date, err := time.Parse(constants.DateFormat, dateStr)
+		if err != nil {
+			return models.Recurrence{}, fmt.Errorf("%w: invalid exclusion date %q, expected YYYY-MM-DD", constants.ErrInvalidRecurrence, dateStr)
+		}
+		recurrence.Exclusions = append(recurrence.Exclusions, date)
+	}
+	return recurrence, nil
+}
+
+// parseSessionTimes validates the requested session times and sorts them by start
+func parseSessionTimes(req []models.SessionTimeRequest) ([]models.SessionTime, error) {
+	if len(req) == 0 {
+		return nil, nil
+	}
+
+	offsets := make(map[time.Duration]bool, len(req))
+	sessionTimes := make([]models.SessionTime, 0, len(req))
+	for _, sessionTime := range req {
+		offset, err := utils.ParseTimeOfDay(sessionTime.Start)
+		if err != nil {
+			return nil, err
+		}
+		if offsets[offset] {
+			return nil, fmt.Errorf("%w: %s is listed more than once", constants.ErrInvalidSessionTime, sessionTime.Start)
+		}
+		offsets[offset] = true
+
+		duration := sessionTime.DurationMinutes
+		if duration == 0 {
+			duration = constants.DefaultSessionMinutes
+		}
+		sessionTimes = append(sessionTimes, models.SessionTime{
+			// Store the canonical form so that 7:00 and 07:00 compare equal
+			Start:           time.Time{}.Add(offset).Format(constants.TimeFormat),
+			DurationMinutes: duration,
+		})
+	}
+	sort.Slice(sessionTimes, func(i, j int) bool { return sessionTimes[i].Start < sessionTimes[j].Start })
+	return sessionTimes, nil
+}
+
+// nextOccurrence returns the first date on or after from on which the class runs
+func nextOccurrence(class models.Class, from time.Time) (time.Time, bool) {
+	date := utils.ToMidnightUTC(from)
+	if start := utils.ToMidnightUTC(class.StartDate); date.Before(start) {
+		date = start
+	}
+	for ; utils.IsDateInRange(date, class.StartDate, class.EndDate); date = date.AddDate(0, 0, 1) {
+		if utils.OccursOn(class, date) {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// cancellationPolicy fills the unset fields of the requested policy with the defaults
+func cancellationPolicy(req *models.CancellationPolicyRequest) models.CancellationPolicy {
+	policy := models.CancellationPolicy{
+		FreeCancelHours: constants.DefaultFreeCancelHours,
+		AllowLateCancel: constants.DefaultAllowLateCancel,
+	}
+	if req == nil {
+		return policy
+	}
+	if req.FreeCancelHours != nil {
+		policy.FreeCancelHours = *req.FreeCancelHours
+	}
+	if req.AllowLateCancel != nil {
+		policy.AllowLateCancel = *req.AllowLateCancel
+	}
+	return policy
+}
+
+// dropInPrice validates the requested drop-in price, nil when there is none
+func dropInPrice(req *models.PriceRequest) (*models.Price, error) {
+	if req == nil || req.Amount == 0 {
+		return nil, nil
+	}
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if req.Amount < 0 || len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return nil, apierrors.Field(constants.ErrInvalidPrice, "drop_in")
+	}
+	return &models.Price{Amount: req.Amount, Currency: currency}, nil
+}
+
+// GetClass fetches a class by name
+func (service *ClassService) GetClass(name string) (models.Class, error) {
+	class, exists := service.classRepo.GetByName(name)
+	if !exists {
+		return models.Class{}, constants.ErrClassNotFound
+	}
+	return localClass(class), nil
+}
+
+// ListClasses returns a page of classes sorted by name
+func (service *ClassService) ListClasses(req models.ListRequest) (models.Page[models.Class], error) {
+	var afterName string
+	if req.Cursor != "" {
+		if err := utils.DecodeCursor(req.Cursor, &afterName); err != nil {
+			return models.Page[models.Class]{}, err
+		}
+	}
+
+	// Fetch one extra class to know whether there is a next page
+	limit := utils.PageLimit(req.Limit)
+	classes := service.classRepo.List(afterName, limit+1)
+
+	page := models.Page[models.Class]{Items: classes}
+	if len(classes) > limit {
+		page.Items = classes[:limit]
+		page.NextCursor = utils.EncodeCursor(page.Items[limit-1].Name)
+	}
+	for i, class := range page.Items {
+		page.Items[i] = localClass(class)
+	}
+	return page, nil
+}
+
+// ListSessions returns a page of the sessions of a class with booked and
+// remaining seats, and the spot map of classes held in a room with a seat layout
+func (service *ClassService) ListSessions(className string, req models.ListRequest) (models.Page[models.Session], error) {
+	class, exists := service.classRepo.GetByName(className)
+	if !exists {
+		return models.Page[models.Session]{}, constants.ErrClassNotFound
+	}
+
+	// The cursor is the start of the last session of the previous page
+	loc := utils.ClassLocation(class)
+	from := class.StartDate
+	var after time.Time
+	if req.Cursor != "" {
+		if err := utils.DecodeCursor(req.Cursor, &after); err != nil {
+			return models.Page[models.Session]{}, err
+		}
+		from = utils.LocalDate(after, loc)
+	}
+
+	limit := utils.PageLimit(req.Limit)
+	spots := service.spots(class)
+	page := models.Page[models.Session]{Items: []models.Session{}}
+	for date, ok := nextOccurrence(class, from); ok; date, ok = nextOccurrence(class, date.AddDate(0, 0, 1)) {
+		for _, session := range utils.Sessions(class, date) {
+			if !session.Date.After(after) {
+				continue
+			}
+			if len(page.Items) == limit {
+				page.NextCursor = utils.EncodeCursor(page.Items[limit-1].Date)
+				return page, nil
+			}
+			session.Booked = service.bookingRepo.Count(className, session.Date)
+			session.Remaining = max(class.Capacity-session.Booked, 0)
+			if len(spots) > 0 {
+				session.Spots = service.sessionSpots(class, session.Date, spots)
+			}
+			page.Items = append(page.Items, localSession(session, loc))
+		}
+	}
+	return page, nil
+}
+
+// UpdateClass changes the dates, capacity or description of a class. Upcoming
+// bookings of sessions the new dates remove are cancelled with the given reason
+// when forced, and bookings above a reduced capacity are moved to the waitlist
+// when the overflow policy allows it. The class must be at version, unless
+// version is constants.AnyVersion.
+func (service *ClassService) UpdateClass(name string, version int, req models.ClassUpdateRequest, change models.ClassChangeRequest) (result models.ClassChangeResult, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	class, exists := service.classRepo.GetByName(name)
+	if !exists {
+		return models.ClassChangeResult{}, constants.ErrClassNotFound
+	}
+	if version != constants.AnyVersion && version != class.Version {
+		return models.ClassChangeResult{}, constants.ErrVersionMismatch
+	}
+	if change.Force && strings.TrimSpace(change.Reason) == "" {
+		return models.ClassChangeResult{}, constants.ErrCancelReasonMissing
+	}
+
+	// Dates are parsed in the time zone of the class, like in CreateClass
+	loc := utils.ClassLocation(class)
+	updated := class
+	if req.StartDate != nil {
+		start, _, err := utils.ParseDateTime(*req.StartDate, loc)
+		if err != nil {
+			return models.ClassChangeResult{}, apierrors.Field(constants.ErrInvalidStartDate, "start_date")
+		}
+		updated.StartDate = utils.LocalDate(start, loc)
+	}
+	if req.EndDate != nil {
+		end, _, err := utils.ParseDateTime(*req.EndDate, loc)
+		if err != nil {
+			return models.ClassChangeResult{}, apierrors.Field(constants.ErrInvalidEndDate, "end_date")
+		}
+		updated.EndDate = utils.LocalDate(end, loc)
+	}
+	if err := utils.IsValidDate(updated.StartDate, updated.EndDate); err != nil {
+		return models.ClassChangeResult{}, apierrors.Field(err, "start_date", "end_date")
+	}
+	if _, ok := nextOccurrence(updated, updated.StartDate); !ok {
+		return models.ClassChangeResult{}, constants.ErrNoOccurrences
+	}
+	if req.Capacity != nil {
+		updated.Capacity = *req.Capacity
+	}
+	if req.Description != nil {
+		updated.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.InstructorID != nil {
+		updated.InstructorID = strings.TrimSpace(*req.InstructorID)
+		if err := service.checkInstructor(updated.InstructorID); err != nil {
+			return models.ClassChangeResult{}, err
+		}
+	}
+	if req.Substitutions != nil {
+		substitutions, err := service.parseSubstitutions(updated, *req.Substitutions)
+		if err != nil {
+			return models.ClassChangeResult{}, err
+		}
+		updated.Substitutions = substitutions
+	} else {
+		// Substitutions of sessions the new dates remove go with them
+		updated.Substitutions = keptSubstitutions(updated)
+	}
+	if req.RoomID != nil {
+		updated.RoomID = strings.TrimSpace(*req.RoomID)
+	}
+	if req.Resource != nil {
+		updated.Resource = strings.TrimSpace(*req.Resource)
+	}
+	if req.DropIn != nil {
+		if updated.DropIn, err = dropInPrice(req.DropIn); err != nil {
+			return models.ClassChangeResult{}, err
+		}
+	}
+
+	result, dates, err := service.reschedule(updated, change)
+	if err != nil {
+		return models.ClassChangeResult{}, err
+	}
+	updated = *result.Class
+
+	// Seats added to booked sessions go to their waitlists
+	if updated.Capacity > class.Capacity {
+		for _, date := range dates {
+			service.promoteWaitlist(updated.Name, date)
+		}
+	}
+
+	updated = localClass(updated)
+	result.Class = &updated
+	return result, nil
+}
+
+// reschedule stores the change of a class and cancels the upcoming bookings
+// it leaves without a seat, returning the dates of the booked sessions it
+// keeps. The bookings are checked and the class stored under classMu.
+func (service *ClassService) reschedule(updated models.Class, change models.ClassChangeRequest) (models.ClassChangeResult, []time.Time, error) {
+	service.classMu.Lock()
+	defer service.classMu.Unlock()
+
+	name, loc := updated.Name, utils.ClassLocation(updated)
+	// Split the upcoming bookings into those of removed sessions and the rest by session
+	var orphaned []models.Booking
+	sessions := make(map[time.Time][]models.Booking)
+	for _, booking := range service.upcomingBookings(name) {
+		if hasSession(updated, booking.Date, loc) {
+			sessions[booking.Date] = append(sessions[booking.Date], booking)
+		} else {
+			orphaned = append(orphaned, booking)
+		}
+	}
+	if len(orphaned) > 0 && !change.Force {
+		return models.ClassChangeResult{}, nil, constants.ErrClassHasBookings
+	}
+
+	// The most recent bookings of a session give up their seats first
+	var overflow []models.Booking
+	for _, bookings := range sessions {
+		if len(bookings) <= updated.Capacity {
+			continue
+		}
+		if change.Overflow != constants.OverflowWaitlist {
+			return models.ClassChangeResult{}, nil, constants.ErrCapacityBelowBooked
+		}
+		sort.Slice(bookings, func(i, j int) bool { return bookings[i].CreatedAt.Before(bookings[j].CreatedAt) })
+		overflow = append(overflow, bookings[updated.Capacity:]...)
+	}
+
+	// The repository rejects the update when the class changed since it was read
+	updated, err := service.schedule(updated, service.classRepo.Update)
+	if err != nil {
+		return models.ClassChangeResult{}, nil, err
+	}
+
+	result := models.ClassChangeResult{Class: &updated, Cancelled: []models.Booking{}, Waitlisted: []models.Booking{}}
+	for _, booking := range orphaned {
+		if cancelled, ok := service.cancelForClass(booking, change.Reason); ok {
+			result.Cancelled = append(result.Cancelled, localBooking(cancelled, loc))
+		}
+	}
+	for _, booking := range overflow {
+		cancelled, ok := service.cancelForClass(booking, constants.CancelReasonCapacityReduced)
+		if !ok {
+			continue
+		}
+		// Bookings made before members were registered hold a member name
+		member := booking.MemberID
+		if member == "" {
+			member = booking.MemberName
+		}
+		if _, err := service.waitlistRepo.Join(name, member, booking.Date); err != nil && !errors.Is(err, constants.ErrAlreadyWaitlisted) {
+			log.Printf("Failed to add %s to waitlist of %s: %v", member, name, err)
+		}
+		result.Waitlisted = append(result.Waitlisted, localBooking(cancelled, loc))
+	}
+	dates := make([]time.Time, 0, len(sessions))
+	for date := range sessions {
+		dates = append(dates, date)
+	}
+	return result, dates, nil
+}
+
+// DeleteClass removes a class. Upcoming bookings are cancelled with the given
+// reason when forced, otherwise a class with upcoming bookings is kept. The
+// class must be at version, unless version is constants.AnyVersion.
+func (service *ClassService) DeleteClass(name string, version int, change models.ClassChangeRequest) (result models.ClassChangeResult, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	class, exists := service.classRepo.GetByName(name)
+	if !exists {
+		return models.ClassChangeResult{}, constants.ErrClassNotFound
+	}
+	if version != constants.AnyVersion && version != class.Version {
+		return models.ClassChangeResult{}, constants.ErrVersionMismatch
+	}
+	if change.Force && strings.TrimSpace(change.Reason) == "" {
+		return models.ClassChangeResult{}, constants.ErrCancelReasonMissing
+	}
+
+	// No booking may be made between the check and the delete
+	service.classMu.Lock()
+	defer service.classMu.Unlock()
+
+	bookings := service.upcomingBookings(name)
+	if len(bookings) > 0 && !change.Force {
+		return models.ClassChangeResult{}, constants.ErrClassHasBookings
+	}
+
+	if err := service.classRepo.Delete(name, class.Version); err != nil {
+		return models.ClassChangeResult{}, err
+	}
+
+	loc := utils.ClassLocation(class)
+	result = models.ClassChangeResult{Cancelled: []models.Booking{}, Waitlisted: []models.Booking{}}
+	for _, booking := range bookings {
+		if cancelled, ok := service.cancelForClass(booking, change.Reason); ok {
+			result.Cancelled = append(result.Cancelled, localBooking(cancelled, loc))
+		}
+		service.clearWaitlist(name, booking.Date)
+	}
+	// Past bookings go too, so that a class created with the same name does not inherit them
+	if err := service.bookingRepo.DeleteByClass(name); err != nil {
+		log.Printf("Failed to delete bookings of %s: %v", name, err)
+	}
+	return result, nil
+}
+
+// upcomingBookings returns the pending and booked bookings of sessions of the class that have not started
+func (service *ClassService) upcomingBookings(className string) []models.Booking {
+	var upcoming []models.Booking
+	for _, booking := range service.bookingRepo.Query(models.BookingFilter{ClassName: className, From: service.now().UTC()}) {
+		if booking.Status != constants.BookingStatusCancelled {
+			upcoming = append(upcoming, booking)
+		}
+	}
+	return upcoming
+}
+
+// hasSession reports whether a session of the class starts at date
+func hasSession(class models.Class, date time.Time, loc *time.Location) bool {
+	for _, session := range utils.Sessions(class, utils.LocalDate(date, loc)) {
+		if session.Date.Equal(date) {
+			return true
+		}
+	}
+	return false
+}
+
+// schedule checks the room and instructors of a class for conflicts and
+// stores the class with save, so that two classes cannot claim a room or an
+// instructor at once
+func (service *ClassService) schedule(class models.Class, save func(models.Class) (models.Class, error)) (models.Class, error) {
+	service.scheduleMu.Lock()
+	defer service.scheduleMu.Unlock()
+
+	if err := service.checkRoom(class); err != nil {
+		return models.Class{}, err
+	}
+	if err := service.checkRoomConflicts(class); err != nil {
+		return models.Class{}, err
+	}
+	if err := service.checkInstructorConflicts(class); err != nil {
+		return models.Class{}, err
+	}
+	return save(class)
+}
+
+// eachClass calls visit with every class in name order until visit returns false
+func (service *ClassService) eachClass(visit func(models.Class) bool) {
+	afterName := ""
+	for {
+		classes := service.classRepo.List(afterName, constants.MaxPageLimit)
+		for _, class := range classes {
+			if !visit(class) {
+				return
+			}
+		}
+		if len(classes) < constants.MaxPageLimit {
+			return
+		}
+		afterName = classes[len(classes)-1].Name
+	}
+}
+
+// forEachSession calls visit with every session of the class on the calendar
+// dates from first to last, until visit returns false. Sessions without an
+// end last DefaultSessionMinutes.
+func forEachSession(class models.Class, first, last time.Time, visit func(session models.Session) bool) {
+	if first.Before(class.StartDate) {
+		first = class.StartDate
+	}
+	if last.After(class.EndDate) {
+		last = class.EndDate
+	}
+	for date := utils.ToMidnightUTC(first); !date.After(last); date = date.AddDate(0, 0, 1) {
+		for _, session := range utils.Sessions(class, date) {
+			if session.End == nil {
+				end := session.Date.Add(constants.DefaultSessionMinutes * time.Minute)
+				session.End = &end
+			}
+			if !visit(session) {
+				return
+			}
+		}
+	}
+}
+
+// week returns the first calendar date, start and end of the week starting
+// on the date in from, or today when from is empty. The week is made of whole
+// days in the time zone of the studio.
+func (service *ClassService) week(from string) (first, start, end time.Time, err error) {
+	loc := service.location
+	first = utils.LocalDate(service.now(), loc)
+	if from != "" {
+		date, _, err := utils.ParseDateTime(from, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, time.Time{}, apierrors.Field(constants.ErrInvalidDate, "from")
+		}
+		first = utils.LocalDate(date, loc)
+	}
+	start = utils.LocalTime(first, 0, loc).In(loc)
+	end = utils.LocalTime(first.AddDate(0, 0, 7), 0, loc).In(loc)
+	return first, start, end, nil
+}
+
+// overlaps reports whether two sessions with an end overlap in time
+func overlaps(a, b models.Session) bool {
+	return a.Date.Before(*b.End) && b.Date.Before(*a.End)
+}
+
+// cancelForClass cancels a booking on behalf of the studio, ignoring the
+// cancellation policy. Bookings changed concurrently are skipped.
+func (service *ClassService) cancelForClass(booking models.Booking, reason string) (models.Booking, bool) {
+	cancelled, err := service.bookingRepo.Cancel(booking.ID, booking.Version, service.now().UTC(), false, strings.TrimSpace(reason))
+	if err != nil {
+		if !errors.Is(err, constants.ErrAlreadyCancelled) && !errors.Is(err, constants.ErrVersionMismatch) {
+			log.Printf("Failed to cancel booking %s of %s: %v", booking.ID, booking.ClassName, err)
+		}
+		return models.Booking{}, false
+	}
+	return cancelled, true
+}
+
+// clearWaitlist removes every member from the waitlist of a session
+func (service *ClassService) clearWaitlist(className string, date time.Time) {
+	for {
+		entry, ok := service.waitlistRepo.Peek(className, date)
+		if !ok {
+			return
+		}
+		if err := service.waitlistRepo.Leave(className, entry, date); err != nil {
+			log.Printf("Failed to remove %s from waitlist of %s: %v", entry, className, err)
+			return
+		}
+	}
+}
+
+// localClass renders the dates of a class as the start of the day in its time zone
+func localClass(class models.Class) models.Class {
+	loc := utils.ClassLocation(class)
+	class.StartDate = utils.LocalTime(class.StartDate, 0, loc).In(loc)
+	class.EndDate = utils.LocalTime(class.EndDate, 0, loc).In(loc)
+	if len(class.Substitutions) > 0 {
+		substitutions := make([]models.Substitution, len(class.Substitutions))
+		for i, substitution := range class.Substitutions {
+			substitution.Session = substitution.Session.In(loc)
+			substitutions[i] = substitution
+		}
+		class.Substitutions = substitutions
+	}
+	return class
+}
+
+// localSession renders the times of a session in loc
+func localSession(session models.Session, loc *time.Location) models.Session {
+	session.Date = session.Date.In(loc)
+	if session.End != nil {
+		end := session.End.In(loc)
+		session.End = &end
+	}
+	return session
+}
+
+// localBooking renders the times of a booking in loc
+func localBooking(booking models.Booking, loc *time.Location) models.Booking {
+	booking.Date = booking.Date.In(loc)
+	booking.CreatedAt = booking.CreatedAt.In(loc)
+	if booking.CancelledAt != nil {
+		cancelledAt := booking.CancelledAt.In(loc)
+		booking.CancelledAt = &cancelledAt
 	}
-	return service.classRepo.Create(class)
+	return booking
 }
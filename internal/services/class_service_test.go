@@ -1,13 +1,17 @@
 package services
 
 import (
+	"fmt"
+	"glofox/internal/apierrors"
 	"glofox/internal/constants"
 	"glofox/internal/models"
+	"glofox/internal/repository"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockClassRepo mocks the ClassRepo
@@ -15,9 +19,11 @@ type MockClassRepo struct {
 	mock.Mock
 }
 
-func (m *MockClassRepo) Create(class models.Class) error {
+// Create returns the class at its first version
+func (m *MockClassRepo) Create(class models.Class) (models.Class, error) {
 	args := m.Called(class)
-	return args.Error(0)
+	class.Version = 1
+	return class, args.Error(0)
 }
 
 func (m *MockClassRepo) GetByName(name string) (models.Class, bool) {
@@ -27,16 +33,39 @@ func (m *MockClassRepo) GetByName(name string) (models.Class, bool) {
 	return class, exists
 }
 
-// MockBookingRepo is just a placeholder, not used in CreateClass
+func (m *MockClassRepo) List(afterName string, limit int) []models.Class {
+	args := m.Called(afterName, limit)
+	classes, _ := args.Get(0).([]models.Class)
+	return classes
+}
+
+// Update returns the class at its next version
+func (m *MockClassRepo) Update(class models.Class) (models.Class, error) {
+	args := m.Called(class)
+	class.Version++
+	return class, args.Error(0)
+}
+
+func (m *MockClassRepo) Delete(name string, version int) error {
+	args := m.Called(name, version)
+	return args.Error(0)
+}
+
+// MockBookingRepo mocks the BookingRepo
 type MockBookingRepo struct {
 	mock.Mock
 }
 
+// MockWaitlistRepo mocks the WaitlistRepo
+type MockWaitlistRepo struct {
+	mock.Mock
+}
+
 func TestClassService_CreateClass(t *testing.T) {
 	// Setup mocks
 	mockClassRepo := new(MockClassRepo)
 	mockBookingRepo := new(MockBookingRepo)
-	service := NewClassService(mockClassRepo, mockBookingRepo)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo})
 
 	// Define test cases
 	tests := []struct {
@@ -46,6 +75,8 @@ func TestClassService_CreateClass(t *testing.T) {
 		endDateStr    string
 		capacity      int
 		setupMock     func()
+		policy        *models.CancellationPolicyRequest
+		recurrence    *models.RecurrenceRequest
 		expectedErr   error
 		expectedClass *models.Class // Nil if no Create call expected
 	}{
@@ -63,6 +94,12 @@ func TestClassService_CreateClass(t *testing.T) {
 					StartDate: startDate,
 					EndDate:   endDate,
 					Capacity:  10,
+					CancellationPolicy: models.CancellationPolicy{
+						FreeCancelHours: constants.DefaultFreeCancelHours,
+						AllowLateCancel: constants.DefaultAllowLateCancel,
+					},
+					Recurrence: models.Recurrence{Frequency: constants.FrequencyDaily, Interval: 1},
+					TimeZone:   "UTC",
 				}).Return(nil)
 			},
 			expectedErr: nil,
@@ -71,16 +108,42 @@ func TestClassService_CreateClass(t *testing.T) {
 				StartDate: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
 				EndDate:   time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC),
 				Capacity:  10,
+				CancellationPolicy: models.CancellationPolicy{
+					FreeCancelHours: constants.DefaultFreeCancelHours,
+					AllowLateCancel: constants.DefaultAllowLateCancel,
+				},
+			},
+		},
+		{
+			name:         "Custom Cancellation Policy",
+			inputName:    "Yoga",
+			startDateStr: "2025-06-01",
+			endDateStr:   "2025-06-20",
+			capacity:     10,
+			policy:       &models.CancellationPolicyRequest{AllowLateCancel: new(bool)},
+			setupMock: func() {
+				mockClassRepo.On("Create", mock.Anything).Return(nil)
+			},
+			expectedErr: nil,
+			expectedClass: &models.Class{
+				Name:      "Yoga",
+				StartDate: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:   time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC),
+				Capacity:  10,
+				CancellationPolicy: models.CancellationPolicy{
+					FreeCancelHours: constants.DefaultFreeCancelHours,
+					AllowLateCancel: false,
+				},
 			},
 		},
 		{
 			name:          "Invalid Start Date Format",
 			inputName:     "Yoga",
-			startDateStr:  "2025-06-01T00:00:00Z",
+			startDateStr:  "2025-06-01 07:00",
 			endDateStr:    "2025-06-20",
 			capacity:      10,
 			setupMock:     func() {},
-			expectedErr:   constants.ErrInvalidStartDate,
+			expectedErr:   apierrors.Field(constants.ErrInvalidStartDate, "start_date"),
 			expectedClass: nil,
 		},
 		{
@@ -90,7 +153,7 @@ func TestClassService_CreateClass(t *testing.T) {
 			endDateStr:    "2025/06/20",
 			capacity:      10,
 			setupMock:     func() {},
-			expectedErr:   constants.ErrInvalidEndDate,
+			expectedErr:   apierrors.Field(constants.ErrInvalidEndDate, "end_date"),
 			expectedClass: nil,
 		},
 		{
@@ -100,7 +163,7 @@ func TestClassService_CreateClass(t *testing.T) {
 			endDateStr:    "2025-06-01",
 			capacity:      10,
 			setupMock:     func() {},
-			expectedErr:   constants.ErrInvalidStartEndDate,
+			expectedErr:   apierrors.Field(constants.ErrInvalidStartEndDate, "start_date", "end_date"),
 			expectedClass: nil,
 		},
 		{
@@ -117,6 +180,12 @@ func TestClassService_CreateClass(t *testing.T) {
 					StartDate: startDate,
 					EndDate:   endDate,
 					Capacity:  10,
+					CancellationPolicy: models.CancellationPolicy{
+						FreeCancelHours: constants.DefaultFreeCancelHours,
+						AllowLateCancel: constants.DefaultAllowLateCancel,
+					},
+					Recurrence: models.Recurrence{Frequency: constants.FrequencyDaily, Interval: 1},
+					TimeZone:   "UTC",
 				}).Return(nil)
 			},
 			expectedErr: nil,
@@ -125,8 +194,87 @@ func TestClassService_CreateClass(t *testing.T) {
 				StartDate: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
 				EndDate:   time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
 				Capacity:  10,
+				CancellationPolicy: models.CancellationPolicy{
+					FreeCancelHours: constants.DefaultFreeCancelHours,
+					AllowLateCancel: constants.DefaultAllowLateCancel,
+				},
+			},
+		},
+		{
+			name:         "Weekly Recurrence From RRule",
+			inputName:    "Yoga",
+			startDateStr: "2025-06-02",
+			endDateStr:   "2025-08-31",
+			capacity:     10,
+			recurrence: &models.RecurrenceRequest{
+				RRule:      "FREQ=WEEKLY;INTERVAL=2;BYDAY=mo,WE,FR,MO",
+				Exclusions: []string{"2025-06-04"},
+			},
+			setupMock: func() {
+				mockClassRepo.On("Create", mock.Anything).Return(nil)
+			},
+			expectedErr: nil,
+			expectedClass: &models.Class{
+				Name:      "Yoga",
+				StartDate: time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC),
+				EndDate:   time.Date(2025, 8, 31, 0, 0, 0, 0, time.UTC),
+				Capacity:  10,
+				CancellationPolicy: models.CancellationPolicy{
+					FreeCancelHours: constants.DefaultFreeCancelHours,
+					AllowLateCancel: constants.DefaultAllowLateCancel,
+				},
+				Recurrence: models.Recurrence{
+					Frequency:  constants.FrequencyWeekly,
+					Interval:   2,
+					Weekdays:   []string{"MO", "WE", "FR"},
+					Exclusions: []time.Time{time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)},
+				},
 			},
 		},
+		{
+			name:          "Unknown Weekday",
+			inputName:     "Yoga",
+			startDateStr:  "2025-06-01",
+			endDateStr:    "2025-06-20",
+			capacity:      10,
+			recurrence:    &models.RecurrenceRequest{Frequency: constants.FrequencyWeekly, Weekdays: []string{"MO", "XX"}},
+			setupMock:     func() {},
+			expectedErr:   fmt.Errorf("%w: unknown weekday %q", constants.ErrInvalidRecurrence, "XX"),
+			expectedClass: nil,
+		},
+		{
+			name:          "Weekdays On Daily Class",
+			inputName:     "Yoga",
+			startDateStr:  "2025-06-01",
+			endDateStr:    "2025-06-20",
+			capacity:      10,
+			recurrence:    &models.RecurrenceRequest{Weekdays: []string{"MO"}},
+			setupMock:     func() {},
+			expectedErr:   fmt.Errorf("%w: weekdays are only supported by weekly classes", constants.ErrInvalidRecurrence),
+			expectedClass: nil,
+		},
+		{
+			name:          "Invalid Exclusion Date",
+			inputName:     "Yoga",
+			startDateStr:  "2025-06-01",
+			endDateStr:    "2025-06-20",
+			capacity:      10,
+			recurrence:    &models.RecurrenceRequest{Exclusions: []string{"June 4th"}},
+			setupMock:     func() {},
+			expectedErr:   fmt.Errorf("%w: invalid exclusion date %q, expected YYYY-MM-DD", constants.ErrInvalidRecurrence, "June 4th"),
+			expectedClass: nil,
+		},
+		{
+			name:          "No Occurrence In Range",
+			inputName:     "Yoga",
+			startDateStr:  "2025-06-02",
+			endDateStr:    "2025-06-06",
+			capacity:      10,
+			recurrence:    &models.RecurrenceRequest{RRule: "FREQ=WEEKLY;BYDAY=SA,SU"},
+			setupMock:     func() {},
+			expectedErr:   constants.ErrNoOccurrences,
+			expectedClass: nil,
+		},
 	}
 
 	// Run tests
@@ -140,7 +288,14 @@ func TestClassService_CreateClass(t *testing.T) {
 			tt.setupMock()
 
 			// Call CreateClass
-			err := service.CreateClass(tt.inputName, tt.startDateStr, tt.endDateStr, tt.capacity)
+			_, err := service.CreateClass(models.ClassRequest{
+				Name:               tt.inputName,
+				StartDate:          tt.startDateStr,
+				EndDate:            tt.endDateStr,
+				Capacity:           tt.capacity,
+				CancellationPolicy: tt.policy,
+				Recurrence:         tt.recurrence,
+			})
 
 			// Assert error
 			assert.Equal(t, tt.expectedErr, err, "Expected error %v, got %v", tt.expectedErr, err)
@@ -155,6 +310,10 @@ func TestClassService_CreateClass(t *testing.T) {
 					assert.True(t, tt.expectedClass.StartDate.Equal(class.StartDate), "StartDate mismatch: expected %v, got %v", tt.expectedClass.StartDate, class.StartDate)
 					assert.True(t, tt.expectedClass.EndDate.Equal(class.EndDate), "EndDate mismatch: expected %v, got %v", tt.expectedClass.EndDate, class.EndDate)
 					assert.Equal(t, tt.expectedClass.Capacity, class.Capacity)
+					assert.Equal(t, tt.expectedClass.CancellationPolicy, class.CancellationPolicy)
+					if tt.expectedClass.Recurrence.Frequency != "" {
+						assert.Equal(t, tt.expectedClass.Recurrence, class.Recurrence)
+					}
 				}
 			} else {
 				mockClassRepo.AssertNotCalled(t, "Create")
@@ -162,3 +321,529 @@ func TestClassService_CreateClass(t *testing.T) {
 		})
 	}
 }
+
+func TestClassService_ListClasses(t *testing.T) {
+	mockClassRepo := new(MockClassRepo)
+	service := NewClassService(Dependencies{Classes: mockClassRepo})
+
+	// First page, the extra class signals that there is a next page
+	mockClassRepo.On("List", "", 3).Return([]models.Class{{Name: "Boxing"}, {Name: "Pilates"}, {Name: "Yoga"}})
+	page, err := service.ListClasses(models.ListRequest{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Class{{Name: "Boxing"}, {Name: "Pilates"}}, page.Items)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// Second page resumes after the last class of the first page
+	mockClassRepo.On("List", "Pilates", 3).Return([]models.Class{{Name: "Yoga"}})
+	page, err = service.ListClasses(models.ListRequest{Limit: 2, Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Class{{Name: "Yoga"}}, page.Items)
+	assert.Empty(t, page.NextCursor)
+
+	_, err = service.ListClasses(models.ListRequest{Cursor: "not a cursor"})
+	assert.Equal(t, constants.ErrInvalidCursor, err)
+}
+
+func TestClassService_ListSessions(t *testing.T) {
+	mockClassRepo, mockBookingRepo := new(MockClassRepo), new(MockBookingRepo)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo})
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC) }
+
+	mockClassRepo.On("GetByName", "Yoga").Return(models.Class{Name: "Yoga", StartDate: day(1), EndDate: day(3), Capacity: 2}, true)
+	mockClassRepo.On("GetByName", "Boxing").Return(models.Class{}, false)
+	mockBookingRepo.On("Count", "Yoga", day(1)).Return(2)
+	mockBookingRepo.On("Count", "Yoga", day(2)).Return(1)
+	mockBookingRepo.On("Count", "Yoga", day(3)).Return(0)
+
+	page, err := service.ListSessions("Yoga", models.ListRequest{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Session{
+		{Date: day(1), Capacity: 2, Booked: 2, Remaining: 0},
+		{Date: day(2), Capacity: 2, Booked: 1, Remaining: 1},
+	}, page.Items)
+	assert.NotEmpty(t, page.NextCursor)
+
+	page, err = service.ListSessions("Yoga", models.ListRequest{Limit: 2, Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Session{{Date: day(3), Capacity: 2, Booked: 0, Remaining: 2}}, page.Items)
+	assert.Empty(t, page.NextCursor)
+
+	_, err = service.ListSessions("Boxing", models.ListRequest{})
+	assert.Equal(t, constants.ErrClassNotFound, err)
+}
+
+func TestClassService_ListSessions_Recurrence(t *testing.T) {
+	mockClassRepo, mockBookingRepo := new(MockClassRepo), new(MockBookingRepo)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo})
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC) }
+
+	// Mondays and Fridays of June 2025 except the 13th
+	mockClassRepo.On("GetByName", "Yoga").Return(models.Class{
+		Name:      "Yoga",
+		StartDate: day(1),
+		EndDate:   day(30),
+		Capacity:  2,
+		Recurrence: models.Recurrence{
+			Frequency:  constants.FrequencyWeekly,
+			Interval:   1,
+			Weekdays:   []string{"MO", "FR"},
+			Exclusions: []time.Time{day(13)},
+		},
+	}, true)
+	mockBookingRepo.On("Count", "Yoga", mock.Anything).Return(0)
+
+	var dates []time.Time
+	page, err := service.ListSessions("Yoga", models.ListRequest{Limit: 4})
+	assert.NoError(t, err)
+	for _, session := range page.Items {
+		dates = append(dates, session.Date)
+	}
+	page, err = service.ListSessions("Yoga", models.ListRequest{Limit: 4, Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	for _, session := range page.Items {
+		dates = append(dates, session.Date)
+	}
+	assert.Empty(t, page.NextCursor)
+	assert.Equal(t, []time.Time{day(2), day(6), day(9), day(16), day(20), day(23), day(27), day(30)}, dates)
+}
+
+func TestClassService_CreateClass_SessionTimes(t *testing.T) {
+	mockClassRepo := new(MockClassRepo)
+	service := NewClassService(Dependencies{Classes: mockClassRepo})
+	mockClassRepo.On("Create", mock.Anything).Return(nil)
+
+	// Session times are sorted, canonicalised and get the default duration
+	_, err := service.CreateClass(models.ClassRequest{
+		Name: "Yoga", StartDate: "2025-06-01", EndDate: "2025-06-20", Capacity: 10,
+		SessionTimes: []models.SessionTimeRequest{{Start: "18:00", DurationMinutes: 45}, {Start: "7:00"}},
+	})
+	assert.NoError(t, err)
+	class := mockClassRepo.Calls[0].Arguments[0].(models.Class)
+	assert.Equal(t, []models.SessionTime{
+		{Start: "07:00", DurationMinutes: constants.DefaultSessionMinutes},
+		{Start: "18:00", DurationMinutes: 45},
+	}, class.SessionTimes)
+
+	// An RFC 3339 start date runs one session at its time of day
+	_, err = service.CreateClass(models.ClassRequest{Name: "Boxing", StartDate: "2025-06-01T18:30:00Z", EndDate: "2025-06-20T00:00:00Z", Capacity: 10})
+	assert.NoError(t, err)
+	class = mockClassRepo.Calls[1].Arguments[0].(models.Class)
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), class.StartDate)
+	assert.Equal(t, []models.SessionTime{{Start: "18:30", DurationMinutes: constants.DefaultSessionMinutes}}, class.SessionTimes)
+
+	for _, sessionTimes := range [][]models.SessionTimeRequest{
+		{{Start: "7am"}},
+		{{Start: "25:00"}},
+		{{Start: "07:00"}, {Start: "7:00"}},
+	} {
+		_, err := service.CreateClass(models.ClassRequest{Name: "Pilates", StartDate: "2025-06-01", EndDate: "2025-06-20", Capacity: 10, SessionTimes: sessionTimes})
+		assert.ErrorIs(t, err, constants.ErrInvalidSessionTime)
+	}
+	mockClassRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestClassService_ListSessions_SessionTimes(t *testing.T) {
+	mockClassRepo, mockBookingRepo := new(MockClassRepo), new(MockBookingRepo)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo})
+	at := func(d, h int) time.Time { return time.Date(2025, 6, d, h, 0, 0, 0, time.UTC) }
+
+	mockClassRepo.On("GetByName", "Yoga").Return(models.Class{
+		Name:         "Yoga",
+		StartDate:    at(1, 0),
+		EndDate:      at(2, 0),
+		Capacity:     2,
+		SessionTimes: []models.SessionTime{{Start: "07:00", DurationMinutes: 60}, {Start: "18:00", DurationMinutes: 90}},
+	}, true)
+	mockBookingRepo.On("Count", "Yoga", at(1, 7)).Return(2)
+	mockBookingRepo.On("Count", "Yoga", mock.Anything).Return(0)
+
+	page, err := service.ListSessions("Yoga", models.ListRequest{Limit: 3})
+	assert.NoError(t, err)
+	end := func(d, h, m int) *time.Time { t := time.Date(2025, 6, d, h, m, 0, 0, time.UTC); return &t }
+	assert.Equal(t, []models.Session{
+		{Date: at(1, 7), End: end(1, 8, 0), Capacity: 2, Booked: 2, Remaining: 0},
+		{Date: at(1, 18), End: end(1, 19, 30), Capacity: 2, Booked: 0, Remaining: 2},
+		{Date: at(2, 7), End: end(2, 8, 0), Capacity: 2, Booked: 0, Remaining: 2},
+	}, page.Items)
+
+	// The next page resumes after the 07:00 session of the same day
+	page, err = service.ListSessions("Yoga", models.ListRequest{Limit: 3, Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Session{{Date: at(2, 18), End: end(2, 19, 30), Capacity: 2, Booked: 0, Remaining: 2}}, page.Items)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestClassService_CreateClass_TimeZone(t *testing.T) {
+	mockClassRepo := new(MockClassRepo)
+	sydney, _ := time.LoadLocation("Australia/Sydney")
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Location: sydney})
+	mockClassRepo.On("Create", mock.Anything).Return(nil)
+
+	// Classes default to the time zone of the studio and keep local dates
+	_, err := service.CreateClass(models.ClassRequest{Name: "Yoga", StartDate: "2025-06-01T07:00", EndDate: "2025-06-20", Capacity: 10})
+	assert.NoError(t, err)
+	class := mockClassRepo.Calls[0].Arguments[0].(models.Class)
+	assert.Equal(t, "Australia/Sydney", class.TimeZone)
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), class.StartDate)
+	assert.Equal(t, []models.SessionTime{{Start: "07:00", DurationMinutes: constants.DefaultSessionMinutes}}, class.SessionTimes)
+
+	// An RFC 3339 start is converted to the time zone of the class
+	_, err = service.CreateClass(models.ClassRequest{Name: "Boxing", StartDate: "2025-05-31T22:00:00Z", EndDate: "2025-06-20", Capacity: 10, TimeZone: "Europe/Dublin"})
+	assert.NoError(t, err)
+	class = mockClassRepo.Calls[1].Arguments[0].(models.Class)
+	assert.Equal(t, "Europe/Dublin", class.TimeZone)
+	assert.Equal(t, time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC), class.StartDate)
+	assert.Equal(t, []models.SessionTime{{Start: "23:00", DurationMinutes: constants.DefaultSessionMinutes}}, class.SessionTimes)
+
+	_, err = service.CreateClass(models.ClassRequest{Name: "Pilates", StartDate: "2025-06-01", EndDate: "2025-06-20", Capacity: 10, TimeZone: "Europe/Atlantis"})
+	assert.ErrorIs(t, err, constants.ErrInvalidTimeZone)
+	mockClassRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestClassService_GetClass_TimeZone(t *testing.T) {
+	mockClassRepo := new(MockClassRepo)
+	service := NewClassService(Dependencies{Classes: mockClassRepo})
+	mockClassRepo.On("GetByName", "Yoga").Return(models.Class{
+		Name:      "Yoga",
+		StartDate: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC),
+		TimeZone:  "Australia/Sydney",
+	}, true)
+
+	// Dates are rendered as the start of the local day
+	class, err := service.GetClass("Yoga")
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-06-01T00:00:00+10:00", class.StartDate.Format(time.RFC3339))
+	assert.Equal(t, "2025-06-20T00:00:00+10:00", class.EndDate.Format(time.RFC3339))
+}
+
+// upcomingYogaBookings returns the bookings of yogaClass that have not started on 2025-06-05
+func upcomingYogaBookings() []models.Booking {
+	booking := func(id, memberID, memberName string, day, createdDay int) models.Booking {
+		return models.Booking{
+			ID:         id,
+			ClassName:  "Yoga",
+			MemberID:   memberID,
+			MemberName: memberName,
+			Date:       time.Date(2025, 6, day, 0, 0, 0, 0, time.UTC),
+			Status:     constants.BookingStatusBooked,
+			CreatedAt:  time.Date(2025, 6, createdDay, 0, 0, 0, 0, time.UTC),
+			Version:    1,
+		}
+	}
+	return []models.Booking{
+		booking("bk_1", "mb_alice", "Alice", 10, 1),
+		booking("bk_2", "mb_bob", "Bob", 10, 2),
+		booking("bk_3", "mb_carol", "Carol", 18, 3),
+	}
+}
+
+func TestClassService_UpdateClass(t *testing.T) {
+	now := time.Date(2025, 6, 5, 9, 0, 0, 0, time.UTC)
+	session := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	upcoming := models.BookingFilter{ClassName: "Yoga", From: now}
+	updated := func(change func(*models.Class)) models.Class {
+		class := yogaClass()
+		change(&class)
+		return class
+	}
+	cancelled := func(id, reason string) models.Booking {
+		return models.Booking{ID: id, ClassName: "Yoga", Status: constants.BookingStatusCancelled, CancelReason: reason}
+	}
+	capacity := func(n int) *int { return &n }
+	text := func(s string) *string { return &s }
+
+	tests := []struct {
+		name               string
+		version            int
+		req                models.ClassUpdateRequest
+		change             models.ClassChangeRequest
+		setupMock          func(*MockClassRepo, *MockBookingRepo, *MockWaitlistRepo)
+		expectedErr        error
+		expectedCancelled  []string
+		expectedWaitlisted []string
+	}{
+		{
+			name:    "Description",
+			version: 3,
+			req:     models.ClassUpdateRequest{Description: text(" Slow flow ")},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				b.On("Query", upcoming).Return(upcomingYogaBookings())
+				c.On("Update", updated(func(class *models.Class) { class.Description = "Slow flow" })).Return(nil)
+			},
+		},
+		{
+			name: "Instructor",
+			req:  models.ClassUpdateRequest{InstructorID: text("in_2")},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				b.On("Query", upcoming).Return(upcomingYogaBookings())
+				// The instructor is checked for conflicts with the other classes
+				c.On("List", "", constants.MaxPageLimit).Return([]models.Class{yogaClass()})
+				c.On("Update", updated(func(class *models.Class) { class.InstructorID = "in_2" })).Return(nil)
+			},
+		},
+		{
+			name: "Capacity Below Bookings",
+			req:  models.ClassUpdateRequest{Capacity: capacity(1)},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				b.On("Query", upcoming).Return(upcomingYogaBookings())
+			},
+			expectedErr: constants.ErrCapacityBelowBooked,
+		},
+		{
+			name:   "Overflow Waitlisted",
+			req:    models.ClassUpdateRequest{Capacity: capacity(1)},
+			change: models.ClassChangeRequest{Overflow: constants.OverflowWaitlist},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				b.On("Query", upcoming).Return(upcomingYogaBookings())
+				c.On("Update", updated(func(class *models.Class) { class.Capacity = 1 })).Return(nil)
+				// The most recent booking of the session gives up its seat
+				b.On("Cancel", "bk_2", 1, now, false, constants.CancelReasonCapacityReduced).Return(cancelled("bk_2", constants.CancelReasonCapacityReduced), nil)
+				w.On("Join", "Yoga", "mb_bob", session).Return(1, nil)
+			},
+			expectedWaitlisted: []string{"bk_2"},
+		},
+		{
+			name: "Capacity Increase Promotes Waitlist",
+			req:  models.ClassUpdateRequest{Capacity: capacity(3)},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				b.On("Query", upcoming).Return(upcomingYogaBookings())
+				c.On("Update", updated(func(class *models.Class) { class.Capacity = 3 })).Return(nil)
+				w.On("Peek", "Yoga", session).Return("", false)
+				w.On("Peek", "Yoga", time.Date(2025, 6, 18, 0, 0, 0, 0, time.UTC)).Return("", false)
+			},
+		},
+		{
+			name: "Shortened Dates Orphan Bookings",
+			req:  models.ClassUpdateRequest{EndDate: text("2025-06-15")},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				b.On("Query", upcoming).Return(upcomingYogaBookings())
+			},
+			expectedErr: constants.ErrClassHasBookings,
+		},
+		{
+			name:   "Forced Shortening Cancels Bookings",
+			req:    models.ClassUpdateRequest{EndDate: text("2025-06-15")},
+			change: models.ClassChangeRequest{Force: true, Reason: "Studio closed"},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				b.On("Query", upcoming).Return(upcomingYogaBookings())
+				c.On("Update", updated(func(class *models.Class) { class.EndDate = time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC) })).Return(nil)
+				b.On("Cancel", "bk_3", 1, now, false, "Studio closed").Return(cancelled("bk_3", "Studio closed"), nil)
+			},
+			expectedCancelled: []string{"bk_3"},
+		},
+		{
+			name:        "Force Without Reason",
+			req:         models.ClassUpdateRequest{EndDate: text("2025-06-15")},
+			change:      models.ClassChangeRequest{Force: true},
+			setupMock:   func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {},
+			expectedErr: constants.ErrCancelReasonMissing,
+		},
+		{
+			name:        "Stale Version",
+			version:     2,
+			req:         models.ClassUpdateRequest{Capacity: capacity(5)},
+			setupMock:   func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {},
+			expectedErr: constants.ErrVersionMismatch,
+		},
+		{
+			name:        "Invalid Date Range",
+			req:         models.ClassUpdateRequest{StartDate: text("2025-06-21")},
+			setupMock:   func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {},
+			expectedErr: constants.ErrInvalidStartEndDate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClassRepo, mockBookingRepo, mockWaitlistRepo := new(MockClassRepo), new(MockBookingRepo), new(MockWaitlistRepo)
+			mockClassRepo.On("GetByName", "Yoga").Return(yogaClass(), true)
+			tt.setupMock(mockClassRepo, mockBookingRepo, mockWaitlistRepo)
+			service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Waitlists: mockWaitlistRepo, Members: waitlistMembers(), Instructors: newMockInstructorRepo()})
+			service.now = func() time.Time { return now }
+
+			result, err := service.UpdateClass("Yoga", tt.version, tt.req, tt.change)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				mockClassRepo.AssertNotCalled(t, "Update", mock.Anything)
+				mockBookingRepo.AssertNotCalled(t, "Cancel", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, result.Class)
+			assert.Equal(t, tt.expectedCancelled, bookingIDs(result.Cancelled))
+			assert.Equal(t, tt.expectedWaitlisted, bookingIDs(result.Waitlisted))
+			mockClassRepo.AssertExpectations(t)
+			mockBookingRepo.AssertExpectations(t)
+			mockWaitlistRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Class Not Found", func(t *testing.T) {
+		mockClassRepo := new(MockClassRepo)
+		mockClassRepo.On("GetByName", "Pilates").Return(models.Class{}, false)
+		service := NewClassService(Dependencies{Classes: mockClassRepo})
+
+		_, err := service.UpdateClass("Pilates", constants.AnyVersion, models.ClassUpdateRequest{Capacity: capacity(5)}, models.ClassChangeRequest{})
+		assert.ErrorIs(t, err, constants.ErrClassNotFound)
+	})
+}
+
+func TestClassService_DeleteClass(t *testing.T) {
+	now := time.Date(2025, 6, 5, 9, 0, 0, 0, time.UTC)
+	upcoming := models.BookingFilter{ClassName: "Yoga", From: now}
+
+	tests := []struct {
+		name              string
+		version           int
+		change            models.ClassChangeRequest
+		setupMock         func(*MockClassRepo, *MockBookingRepo, *MockWaitlistRepo)
+		expectedErr       error
+		expectedCancelled []string
+	}{
+		{
+			name:    "No Upcoming Bookings",
+			version: 3,
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				b.On("Query", upcoming).Return([]models.Booking(nil))
+				c.On("Delete", "Yoga", 3).Return(nil)
+				// Past bookings are deleted with the class
+				b.On("DeleteByClass", "Yoga").Return(nil)
+			},
+		},
+		{
+			name: "Upcoming Bookings",
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				b.On("Query", upcoming).Return(upcomingYogaBookings())
+			},
+			expectedErr: constants.ErrClassHasBookings,
+		},
+		{
+			name:        "Stale Version",
+			version:     2,
+			setupMock:   func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {},
+			expectedErr: constants.ErrVersionMismatch,
+		},
+		{
+			name:   "Forced Delete Cancels Bookings",
+			change: models.ClassChangeRequest{Force: true, Reason: "Class discontinued"},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				b.On("Query", upcoming).Return(upcomingYogaBookings())
+				c.On("Delete", "Yoga", 3).Return(nil)
+				b.On("DeleteByClass", "Yoga").Return(nil)
+				for _, booking := range upcomingYogaBookings() {
+					b.On("Cancel", booking.ID, booking.Version, now, false, "Class discontinued").Return(booking, nil)
+				}
+				// The waitlists of the cancelled sessions are cleared
+				w.On("Peek", "Yoga", time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)).Return("mb_dave", true).Once()
+				w.On("Leave", "Yoga", "mb_dave", time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)).Return(nil)
+				w.On("Peek", "Yoga", mock.Anything).Return("", false)
+			},
+			expectedCancelled: []string{"bk_1", "bk_2", "bk_3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClassRepo, mockBookingRepo, mockWaitlistRepo := new(MockClassRepo), new(MockBookingRepo), new(MockWaitlistRepo)
+			mockClassRepo.On("GetByName", "Yoga").Return(yogaClass(), true)
+			tt.setupMock(mockClassRepo, mockBookingRepo, mockWaitlistRepo)
+			service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Waitlists: mockWaitlistRepo})
+			service.now = func() time.Time { return now }
+
+			result, err := service.DeleteClass("Yoga", tt.version, tt.change)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				mockClassRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCancelled, bookingIDs(result.Cancelled))
+			mockClassRepo.AssertExpectations(t)
+			mockBookingRepo.AssertExpectations(t)
+			mockWaitlistRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// newStudioService returns the service of a studio stored in memory with a
+// Yoga class of capacity, and members named Member 0 to Member n-1 holding
+// an unlimited membership
+func newStudioService(t *testing.T, capacity, members int) *ClassService {
+	t.Helper()
+	service := studioService(t, NewStudios(repository.NewMemoryStudios(), nil, 0, time.UTC, 0), "studio_a")
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	_, err := service.CreateClass(models.ClassRequest{Name: "Yoga", StartDate: "2025-06-01", EndDate: "2025-06-30", Capacity: capacity})
+	require.NoError(t, err)
+	plan, err := service.CreatePlan(models.PlanRequest{Name: "Unlimited", Kind: constants.PlanUnlimited})
+	require.NoError(t, err)
+	for i := 0; i < members; i++ {
+		member, err := service.CreateMember(models.MemberRequest{Name: fmt.Sprintf("Member %d", i)})
+		require.NoError(t, err)
+		_, err = service.PurchaseMembership(member.ID, models.MembershipRequest{PlanID: plan.ID})
+		require.NoError(t, err)
+	}
+	return service
+}
+
+// updateHookRepo is a ClassRepository that calls beforeUpdate before storing an update
+type updateHookRepo struct {
+	repository.ClassRepository
+	beforeUpdate func()
+}
+
+func (repo updateHookRepo) Update(class models.Class) (models.Class, error) {
+	repo.beforeUpdate()
+	return repo.ClassRepository.Update(class)
+}
+
+func TestClassService_UpdateClass_ConcurrentBooking(t *testing.T) {
+	service := newStudioService(t, 3, 3)
+	session := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		_, err := service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberName: fmt.Sprintf("Member %d", i), Date: "2025-06-10"})
+		require.NoError(t, err)
+	}
+
+	// A booking made between the check of the bookings and the update waits for the update
+	booked := make(chan error, 1)
+	service.classRepo = updateHookRepo{ClassRepository: service.classRepo, beforeUpdate: func() {
+		go func() {
+			_, err := service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberName: "Member 2", Date: "2025-06-10"})
+			booked <- err
+		}()
+		time.Sleep(50 * time.Millisecond)
+	}}
+	capacity := 2
+	_, err := service.UpdateClass("Yoga", constants.AnyVersion, models.ClassUpdateRequest{Capacity: &capacity}, models.ClassChangeRequest{})
+	require.NoError(t, err)
+	assert.ErrorIs(t, <-booked, constants.ErrClassFull)
+	assert.Equal(t, 2, service.bookingRepo.Count("Yoga", session))
+}
+
+func TestClassService_DeleteClass_Bookings(t *testing.T) {
+	service := newStudioService(t, 10, 1)
+	_, err := service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberName: "Member 0", Date: "2025-06-10"})
+	require.NoError(t, err)
+	past := service.now()
+	service.now = func() time.Time { return past.AddDate(0, 0, 30) }
+
+	// The bookings of a deleted class are not inherited by a class of the same name
+	_, err = service.DeleteClass("Yoga", constants.AnyVersion, models.ClassChangeRequest{})
+	require.NoError(t, err)
+	_, err = service.CreateClass(models.ClassRequest{Name: "Yoga", StartDate: "2025-06-01", EndDate: "2025-06-30", Capacity: 10})
+	require.NoError(t, err)
+	assert.Empty(t, service.bookingRepo.Query(models.BookingFilter{ClassName: "Yoga"}))
+}
+
+// bookingIDs returns the IDs of the bookings, nil when there are none
+func bookingIDs(bookings []models.Booking) []string {
+	var ids []string
+	for _, booking := range bookings {
+		ids = append(ids, booking.ID)
+	}
+	return ids
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"fmt"
+	"glofox/internal/apierrors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/utils"
+	"log"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CreateInstructor registers a new instructor
+func (service *ClassService) CreateInstructor(req models.InstructorRequest) (instructor models.Instructor, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	now := service.now().UTC()
+	instructor = models.Instructor{ID: utils.NewID("in_"), CreatedAt: now}
+	instructor, err = applyInstructorRequest(instructor, req, now)
+	if err != nil {
+		return models.Instructor{}, err
+	}
+	if err := service.instructorRepo.Create(instructor); err != nil {
+		return models.Instructor{}, err
+	}
+	return instructor, nil
+}
+
+// GetInstructor fetches an instructor by ID
+func (service *ClassService) GetInstructor(id string) (models.Instructor, error) {
+	instructor, exists := service.instructorRepo.GetByID(id)
+	if !exists {
+		return models.Instructor{}, constants.ErrInstructorNotFound
+	}
+	return instructor, nil
+}
+
+// ListInstructors returns a page of instructors sorted by ID
+func (service *ClassService) ListInstructors(req models.ListRequest) (models.Page[models.Instructor], error) {
+	var afterID string
+	if req.Cursor != "" {
+		if err := utils.DecodeCursor(req.Cursor, &afterID); err != nil {
+			return models.Page[models.Instructor]{}, err
+		}
+	}
+
+	// Fetch one extra instructor to know whether there is a next page
+	limit := utils.PageLimit(req.Limit)
+	instructors := service.instructorRepo.List(afterID, limit+1)
+
+	page := models.Page[models.Instructor]{Items: instructors}
+	if len(instructors) > limit {
+		page.Items = instructors[:limit]
+		page.NextCursor = utils.EncodeCursor(page.Items[limit-1].ID)
+	}
+	return page, nil
+}
+
+// UpdateInstructor replaces the details of an instructor
+func (service *ClassService) UpdateInstructor(id string, req models.InstructorRequest) (instructor models.Instructor, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	instructor, exists := service.instructorRepo.GetByID(id)
+	if !exists {
+		return models.Instructor{}, constants.ErrInstructorNotFound
+	}
+	instructor, err = applyInstructorRequest(instructor, req, service.now().UTC())
+	if err != nil {
+		return models.Instructor{}, err
+	}
+	if err := service.instructorRepo.Update(instructor); err != nil {
+		return models.Instructor{}, err
+	}
+	return instructor, nil
+}
+
+// DeleteInstructor removes an instructor. Instructors who teach a class or a
+// session of one are kept until the class is reassigned.
+func (service *ClassService) DeleteInstructor(id string) (err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	// Classes are assigned under scheduleMu, so none can claim the instructor meanwhile
+	service.scheduleMu.Lock()
+	defer service.scheduleMu.Unlock()
+
+	var assigned string
+	service.eachClass(func(class models.Class) bool {
+		if teachers(class)[id] {
+			assigned = class.Name
+			return false
+		}
+		return true
+	})
+	if assigned != "" {
+		return fmt.Errorf("%w: %s teaches %s", constants.ErrInstructorAssigned, id, assigned)
+	}
+	return service.instructorRepo.Delete(id)
+}
+
+// InstructorSchedule returns the sessions an instructor teaches in the week
+// starting on the requested date, including the sessions they substitute
+func (service *ClassService) InstructorSchedule(id string, req models.ScheduleRequest) (schedule models.Schedule, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	if _, exists := service.instructorRepo.GetByID(id); !exists {
+		return models.Schedule{}, constants.ErrInstructorNotFound
+	}
+
+	first, from, to, err := service.week(req.From)
+	if err != nil {
+		return models.Schedule{}, err
+	}
+	schedule = models.Schedule{InstructorID: id, From: from, To: to, Sessions: []models.ScheduledSession{}}
+
+	service.eachClass(func(class models.Class) bool {
+		if !teachers(class)[id] {
+			return true
+		}
+		classLoc := utils.ClassLocation(class)
+		// Classes in other time zones may have sessions of the week on the dates around it
+		forEachTaughtSession(class, first.AddDate(0, 0, -1), first.AddDate(0, 0, 7), func(session models.Session, instructorID string, substitute bool) bool {
+			if instructorID == id && !session.Date.Before(schedule.From) && session.Date.Before(schedule.To) {
+				session = localSession(session, classLoc)
+				schedule.Sessions = append(schedule.Sessions, models.ScheduledSession{
+					ClassName:  class.Name,
+					Date:       session.Date,
+					End:        session.End,
+					Substitute: substitute,
+				})
+			}
+			return true
+		})
+		return true
+	})
+	sort.SliceStable(schedule.Sessions, func(i, j int) bool {
+		return schedule.Sessions[i].Date.Before(schedule.Sessions[j].Date)
+	})
+	return schedule, nil
+}
+
+// applyInstructorRequest copies the requested details onto an instructor
+func applyInstructorRequest(instructor models.Instructor, req models.InstructorRequest, now time.Time) (models.Instructor, error) {
+	instructor.Name = strings.TrimSpace(req.Name)
+	if instructor.Name == "" {
+		return models.Instructor{}, constants.ErrInvalidInstructorName
+	}
+	instructor.Email = strings.TrimSpace(req.Email)
+	instructor.UpdatedAt = now
+	return instructor, nil
+}
+
+// checkInstructor checks that an assigned instructor exists, an empty ID
+// leaves the class unassigned
+func (service *ClassService) checkInstructor(id string) error {
+	if id == "" {
+		return nil
+	}
+	if _, exists := service.instructorRepo.GetByID(id); !exists {
+		return apierrors.Field(constants.ErrInstructorNotFound, "instructor_id")
+	}
+	return nil
+}
+
+// parseSubstitutions validates the requested substitutions of a class. Each
+// names a session of the class, at most once, and an existing instructor.
+func (service *ClassService) parseSubstitutions(class models.Class, req []models.SubstitutionRequest) ([]models.Substitution, error) {
+	if len(req) == 0 {
+		return nil, nil
+	}
+	loc := utils.ClassLocation(class)
+	substitutions := make([]models.Substitution, 0, len(req))
+	seen := make(map[time.Time]bool)
+	for _, substitutionReq := range req {
+		instructorID := strings.TrimSpace(substitutionReq.InstructorID)
+		if instructorID == "" {
+			return nil, apierrors.Field(fmt.Errorf("%w: instructor_id is required", constants.ErrInvalidSubstitution), "substitutions")
+		}
+		if _, exists := service.instructorRepo.GetByID(instructorID); !exists {
+			return nil, apierrors.Field(fmt.Errorf("%w: instructor %s", constants.ErrInstructorNotFound, instructorID), "substitutions")
+		}
+
+		// Like bookings, a date alone selects the only session of that day
+		date, dateOnly, err := utils.ParseDateTime(substitutionReq.Session, loc)
+		if err != nil {
+			return nil, apierrors.Field(fmt.Errorf("%w: session %q is not a date", constants.ErrInvalidSubstitution, substitutionReq.Session), "substitutions")
+		}
+		sessions := utils.Sessions(class, utils.LocalDate(date, loc))
+		var session time.Time
+		switch {
+		case dateOnly && len(sessions) > 1:
+			return nil, apierrors.Field(fmt.Errorf("%w: session %s needs a start time, the class runs several sessions that day", constants.ErrInvalidSubstitution, substitutionReq.Session), "substitutions")
+		case dateOnly && len(sessions) == 1:
+			session = sessions[0].Date
+		default:
+			for _, candidate := range sessions {
+				if candidate.Date.Equal(date) {
+					session = candidate.Date
+				}
+			}
+		}
+		if session.IsZero() || seen[session] {
+			return nil, apierrors.Field(fmt.Errorf("%w: session %s is not a session of the class or is substituted twice", constants.ErrInvalidSubstitution, substitutionReq.Session), "substitutions")
+		}
+		seen[session] = true
+		substitutions = append(substitutions, models.Substitution{Session: session, InstructorID: instructorID})
+	}
+	sort.Slice(substitutions, func(i, j int) bool { return substitutions[i].Session.Before(substitutions[j].Session) })
+	return substitutions, nil
+}
+
+// keptSubstitutions returns the substitutions of sessions the class still runs
+func keptSubstitutions(class models.Class) []models.Substitution {
+	var kept []models.Substitution
+	for _, substitution := range class.Substitutions {
+		if hasSession(class, substitution.Session, utils.ClassLocation(class)) {
+			kept = append(kept, substitution)
+		}
+	}
+	return kept
+}
+
+// checkInstructorConflicts returns ErrInstructorConflict when an instructor
+// of a session of the class teaches a session of another class at the same time
+func (service *ClassService) checkInstructorConflicts(class models.Class) error {
+	// Key: instructor ID
+	taught := make(map[string][]models.Session)
+	forEachTaughtSession(class, class.StartDate, class.EndDate, func(session models.Session, instructorID string, _ bool) bool {
+		taught[instructorID] = append(taught[instructorID], session)
+		return true
+	})
+	if len(taught) == 0 {
+		return nil
+	}
+
+	var conflict error
+	service.eachClass(func(other models.Class) bool {
+		if other.Name == class.Name || !sharesInstructor(other, taught) {
+			return true
+		}
+		// The dates of classes in other time zones may be a day apart
+		first, last := class.StartDate.AddDate(0, 0, -1), class.EndDate.AddDate(0, 0, 1)
+		forEachTaughtSession(other, first, last, func(session models.Session, instructorID string, _ bool) bool {
+			for _, mine := range taught[instructorID] {
+				if overlaps(mine, session) {
+					conflict = fmt.Errorf("%w: %s teaches %s at %s", constants.ErrInstructorConflict, instructorID, other.Name,
+						session.Date.In(utils.ClassLocation(other)).Format(time.RFC3339))
+					return false
+				}
+			}
+			return true
+		})
+		return conflict == nil
+	})
+	return conflict
+}
+
+// forEachTaughtSession calls visit with every session of the class on the
+// calendar dates from first to last that has an instructor, until visit
+// returns false
+func forEachTaughtSession(class models.Class, first, last time.Time, visit func(session models.Session, instructorID string, substitute bool) bool) {
+	substitutes := make(map[time.Time]string, len(class.Substitutions))
+	for _, substitution := range class.Substitutions {
+		substitutes[substitution.Session.UTC()] = substitution.InstructorID
+	}
+	forEachSession(class, first, last, func(session models.Session) bool {
+		instructorID, substitute := class.InstructorID, false
+		if substituteID, ok := substitutes[session.Date]; ok {
+			instructorID, substitute = substituteID, true
+		}
+		return instructorID == "" || visit(session, instructorID, substitute)
+	})
+}
+
+// teachers returns the IDs of the instructors of the class and its substitutions
+func teachers(class models.Class) map[string]bool {
+	ids := make(map[string]bool)
+	if class.InstructorID != "" {
+		ids[class.InstructorID] = true
+	}
+	for _, substitution := range class.Substitutions {
+		ids[substitution.InstructorID] = true
+	}
+	return ids
+}
+
+// sharesInstructor reports whether any instructor of the class is in taught
+func sharesInstructor(class models.Class, taught map[string][]models.Session) bool {
+	for id := range teachers(class) {
+		if len(taught[id]) > 0 {
+			return true
+		}
+	}
+	return false
+}
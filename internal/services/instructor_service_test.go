@@ -0,0 +1,325 @@
+package services
+
+import (
+	"glofox/internal/apierrors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockInstructorRepo mocks the InstructorRepo
+type MockInstructorRepo struct {
+	mock.Mock
+}
+
+func (m *MockInstructorRepo) Create(instructor models.Instructor) error {
+	args := m.Called(instructor)
+	return args.Error(0)
+}
+
+func (m *MockInstructorRepo) GetByID(id string) (models.Instructor, bool) {
+	args := m.Called(id)
+	instructor, _ := args.Get(0).(models.Instructor)
+	return instructor, args.Bool(1)
+}
+
+func (m *MockInstructorRepo) Update(instructor models.Instructor) error {
+	args := m.Called(instructor)
+	return args.Error(0)
+}
+
+func (m *MockInstructorRepo) Delete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockInstructorRepo) List(afterID string, limit int) []models.Instructor {
+	args := m.Called(afterID, limit)
+	instructors, _ := args.Get(0).([]models.Instructor)
+	return instructors
+}
+
+// newMockInstructorRepo returns a MockInstructorRepo that knows Maya as in_1
+// and Sam as in_2, and nobody else
+func newMockInstructorRepo() *MockInstructorRepo {
+	repo := new(MockInstructorRepo)
+	repo.On("GetByID", "in_1").Return(models.Instructor{ID: "in_1", Name: "Maya"}, true)
+	repo.On("GetByID", "in_2").Return(models.Instructor{ID: "in_2", Name: "Sam"}, true)
+	repo.On("GetByID", mock.Anything).Return(models.Instructor{}, false)
+	return repo
+}
+
+// taughtClass returns a class running daily from 1 to 20 June 2025 with one
+// session a day at start, taught by an instructor
+func taughtClass(name, start, instructorID string) models.Class {
+	class := yogaClass()
+	class.Name = name
+	class.SessionTimes = []models.SessionTime{{Start: start, DurationMinutes: constants.DefaultSessionMinutes}}
+	class.InstructorID = instructorID
+	return class
+}
+
+func TestClassService_CreateInstructor(t *testing.T) {
+	mockInstructorRepo := new(MockInstructorRepo)
+	service := NewClassService(Dependencies{Instructors: mockInstructorRepo})
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	mockInstructorRepo.On("Create", mock.Anything).Return(nil)
+
+	instructor, err := service.CreateInstructor(models.InstructorRequest{Name: " Maya ", Email: "maya@example.com "})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(instructor.ID, "in_"))
+	assert.Equal(t, models.Instructor{
+		ID:        instructor.ID,
+		Name:      "Maya",
+		Email:     "maya@example.com",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, instructor)
+	mockInstructorRepo.AssertCalled(t, "Create", instructor)
+
+	_, err = service.CreateInstructor(models.InstructorRequest{Name: "  "})
+	assert.Equal(t, constants.ErrInvalidInstructorName, err)
+	mockInstructorRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestClassService_CreateClass_Instructors(t *testing.T) {
+	morningFlow := taughtClass("Morning Flow", "07:00", "in_1")
+	dublinFlow := morningFlow
+	dublinFlow.TimeZone = "Europe/Dublin"
+
+	tests := []struct {
+		name          string
+		existing      models.Class
+		req           models.ClassRequest
+		expectedErr   error
+		expectedField string
+	}{
+		{
+			name:        "Overlapping Session",
+			existing:    morningFlow,
+			req:         models.ClassRequest{InstructorID: "in_1", SessionTimes: []models.SessionTimeRequest{{Start: "07:30"}}},
+			expectedErr: constants.ErrInstructorConflict,
+		},
+		{
+			name:     "Session Starting As Another Ends",
+			existing: morningFlow,
+			req:      models.ClassRequest{InstructorID: "in_1", SessionTimes: []models.SessionTimeRequest{{Start: "08:00"}}},
+		},
+		{
+			name:     "Other Instructor",
+			existing: morningFlow,
+			req:      models.ClassRequest{InstructorID: "in_2", SessionTimes: []models.SessionTimeRequest{{Start: "07:00"}}},
+		},
+		{
+			name:     "Other Dates",
+			existing: morningFlow,
+			req: models.ClassRequest{
+				StartDate:    "2025-06-21",
+				InstructorID: "in_1",
+				SessionTimes: []models.SessionTimeRequest{{Start: "07:00"}},
+			},
+		},
+		{
+			name:     "Substitute Already Teaching",
+			existing: morningFlow,
+			req: models.ClassRequest{
+				InstructorID:  "in_2",
+				SessionTimes:  []models.SessionTimeRequest{{Start: "07:00"}},
+				Substitutions: []models.SubstitutionRequest{{Session: "2025-06-10", InstructorID: "in_1"}},
+			},
+			expectedErr: constants.ErrInstructorConflict,
+		},
+		{
+			name: "Instructor Substituted For The Session",
+			existing: func() models.Class {
+				class := morningFlow
+				class.Substitutions = []models.Substitution{{Session: time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC), InstructorID: "in_2"}}
+				return class
+			}(),
+			req: models.ClassRequest{
+				StartDate:    "2025-06-10",
+				EndDate:      "2025-06-10",
+				InstructorID: "in_1",
+				SessionTimes: []models.SessionTimeRequest{{Start: "07:00"}},
+			},
+		},
+		{
+			name:        "Overlap Across Time Zones",
+			existing:    dublinFlow,
+			req:         models.ClassRequest{InstructorID: "in_1", SessionTimes: []models.SessionTimeRequest{{Start: "06:00"}}},
+			expectedErr: constants.ErrInstructorConflict,
+		},
+		{
+			name:          "Unknown Instructor",
+			existing:      morningFlow,
+			req:           models.ClassRequest{InstructorID: "in_3"},
+			expectedErr:   constants.ErrInstructorNotFound,
+			expectedField: "instructor_id",
+		},
+		{
+			name:     "Substitution Of Missing Session",
+			existing: morningFlow,
+			req: models.ClassRequest{
+				InstructorID:  "in_2",
+				Substitutions: []models.SubstitutionRequest{{Session: "2025-07-01", InstructorID: "in_1"}},
+			},
+			expectedErr:   constants.ErrInvalidSubstitution,
+			expectedField: "substitutions",
+		},
+		{
+			name:     "Substitution Without Start Time",
+			existing: morningFlow,
+			req: models.ClassRequest{
+				InstructorID:  "in_2",
+				SessionTimes:  []models.SessionTimeRequest{{Start: "09:00"}, {Start: "18:00"}},
+				Substitutions: []models.SubstitutionRequest{{Session: "2025-06-10", InstructorID: "in_1"}},
+			},
+			expectedErr:   constants.ErrInvalidSubstitution,
+			expectedField: "substitutions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClassRepo := new(MockClassRepo)
+			mockClassRepo.On("List", "", constants.MaxPageLimit).Return([]models.Class{tt.existing})
+			mockClassRepo.On("Create", mock.Anything).Return(nil)
+			service := NewClassService(Dependencies{Classes: mockClassRepo, Instructors: newMockInstructorRepo()})
+
+			req := tt.req
+			req.Name, req.Capacity = "Yoga", 10
+			if req.StartDate == "" {
+				req.StartDate = "2025-06-01"
+			}
+			if req.EndDate == "" {
+				req.EndDate = "2025-06-30"
+			}
+			_, err := service.CreateClass(req)
+
+			if tt.expectedErr == nil {
+				assert.NoError(t, err)
+				mockClassRepo.AssertNumberOfCalls(t, "Create", 1)
+				return
+			}
+			assert.ErrorIs(t, err, tt.expectedErr)
+			if tt.expectedField != "" {
+				var apiErr *apierrors.Error
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, tt.expectedField, apiErr.Fields[0].Field)
+			}
+			mockClassRepo.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+}
+
+func TestClassService_CreateClass_Substitutions(t *testing.T) {
+	mockClassRepo := new(MockClassRepo)
+	mockClassRepo.On("List", "", constants.MaxPageLimit).Return([]models.Class{})
+	mockClassRepo.On("Create", mock.Anything).Return(nil)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Instructors: newMockInstructorRepo()})
+
+	class, err := service.CreateClass(models.ClassRequest{
+		Name:         "Yoga",
+		StartDate:    "2025-06-01",
+		EndDate:      "2025-06-20",
+		Capacity:     10,
+		TimeZone:     "Europe/Dublin",
+		InstructorID: "in_1",
+		SessionTimes: []models.SessionTimeRequest{{Start: "07:00"}, {Start: "18:00"}},
+		Substitutions: []models.SubstitutionRequest{
+			{Session: "2025-06-12T18:00:00+01:00", InstructorID: "in_2"},
+			{Session: "2025-06-10T07:00:00+01:00", InstructorID: "in_2"},
+		},
+	})
+	require.NoError(t, err)
+
+	// Substitutions are sorted by session and rendered in the time zone of the class
+	dublin, _ := time.LoadLocation("Europe/Dublin")
+	assert.Equal(t, []models.Substitution{
+		{Session: time.Date(2025, 6, 10, 7, 0, 0, 0, dublin), InstructorID: "in_2"},
+		{Session: time.Date(2025, 6, 12, 18, 0, 0, 0, dublin), InstructorID: "in_2"},
+	}, class.Substitutions)
+}
+
+func TestClassService_UpdateClass_Substitutions(t *testing.T) {
+	yoga := taughtClass("Yoga", "07:00", "in_1")
+	yoga.Substitutions = []models.Substitution{
+		{Session: time.Date(2025, 6, 5, 7, 0, 0, 0, time.UTC), InstructorID: "in_2"},
+		{Session: time.Date(2025, 6, 15, 7, 0, 0, 0, time.UTC), InstructorID: "in_2"},
+	}
+	mockClassRepo, mockBookingRepo := new(MockClassRepo), new(MockBookingRepo)
+	mockClassRepo.On("GetByName", "Yoga").Return(yoga, true)
+	boxing := taughtClass("Boxing", "07:30", "in_2")
+	boxing.StartDate, boxing.EndDate = time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC)
+	mockClassRepo.On("List", "", constants.MaxPageLimit).Return([]models.Class{boxing, yoga})
+	mockClassRepo.On("Update", mock.Anything).Return(nil)
+	mockBookingRepo.On("Query", mock.Anything).Return([]models.Booking{})
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Instructors: newMockInstructorRepo()})
+	service.now = func() time.Time { return time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC) }
+
+	// Substitutions of sessions the new dates remove are dropped with them
+	endDate := "2025-06-10"
+	result, err := service.UpdateClass("Yoga", constants.AnyVersion, models.ClassUpdateRequest{EndDate: &endDate}, models.ClassChangeRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, yoga.Substitutions[:1], result.Class.Substitutions)
+
+	// The class itself is not a conflict, Boxing on 8 June is
+	substitutions := []models.SubstitutionRequest{{Session: "2025-06-07", InstructorID: "in_2"}}
+	result, err = service.UpdateClass("Yoga", constants.AnyVersion, models.ClassUpdateRequest{Substitutions: &substitutions}, models.ClassChangeRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Substitution{{Session: time.Date(2025, 6, 7, 7, 0, 0, 0, time.UTC), InstructorID: "in_2"}}, result.Class.Substitutions)
+	substitutions = []models.SubstitutionRequest{{Session: "2025-06-08", InstructorID: "in_2"}}
+	_, err = service.UpdateClass("Yoga", constants.AnyVersion, models.ClassUpdateRequest{Substitutions: &substitutions}, models.ClassChangeRequest{})
+	assert.ErrorIs(t, err, constants.ErrInstructorConflict)
+	mockClassRepo.AssertNumberOfCalls(t, "Update", 2)
+}
+
+func TestClassService_DeleteInstructor(t *testing.T) {
+	pilates := taughtClass("Pilates", "18:00", "in_2")
+	pilates.Substitutions = []models.Substitution{{Session: time.Date(2025, 6, 11, 18, 0, 0, 0, time.UTC), InstructorID: "in_1"}}
+	mockClassRepo, mockInstructorRepo := new(MockClassRepo), new(MockInstructorRepo)
+	mockClassRepo.On("List", "", constants.MaxPageLimit).Return([]models.Class{pilates})
+	mockInstructorRepo.On("Delete", "in_3").Return(nil)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Instructors: mockInstructorRepo})
+
+	// Instructors of a class, or of one of its sessions, are kept
+	assert.ErrorIs(t, service.DeleteInstructor("in_1"), constants.ErrInstructorAssigned)
+	assert.ErrorIs(t, service.DeleteInstructor("in_2"), constants.ErrInstructorAssigned)
+	assert.NoError(t, service.DeleteInstructor("in_3"))
+	mockInstructorRepo.AssertNumberOfCalls(t, "Delete", 1)
+}
+
+func TestClassService_InstructorSchedule(t *testing.T) {
+	pilates := taughtClass("Pilates", "18:00", "in_2")
+	pilates.Substitutions = []models.Substitution{{Session: time.Date(2025, 6, 11, 18, 0, 0, 0, time.UTC), InstructorID: "in_1"}}
+	mockClassRepo := new(MockClassRepo)
+	mockClassRepo.On("List", "", constants.MaxPageLimit).Return([]models.Class{taughtClass("Boxing", "12:00", "in_2"), pilates, taughtClass("Yoga", "07:00", "in_1")})
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Instructors: newMockInstructorRepo()})
+	service.now = func() time.Time { return time.Date(2025, 6, 16, 9, 0, 0, 0, time.UTC) }
+
+	schedule, err := service.InstructorSchedule("in_1", models.ScheduleRequest{From: "2025-06-09"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC), schedule.From)
+	assert.Equal(t, time.Date(2025, 6, 16, 0, 0, 0, 0, time.UTC), schedule.To)
+	require.Len(t, schedule.Sessions, 8)
+	assert.Equal(t, "Yoga", schedule.Sessions[0].ClassName)
+	assert.Equal(t, time.Date(2025, 6, 9, 7, 0, 0, 0, time.UTC), schedule.Sessions[0].Date)
+	end := time.Date(2025, 6, 11, 19, 0, 0, 0, time.UTC)
+	assert.Equal(t, models.ScheduledSession{ClassName: "Pilates", Date: time.Date(2025, 6, 11, 18, 0, 0, 0, time.UTC), End: &end, Substitute: true}, schedule.Sessions[3])
+
+	// The week starts today by default, and ends with the last day of Yoga
+	schedule, err = service.InstructorSchedule("in_1", models.ScheduleRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 16, 0, 0, 0, 0, time.UTC), schedule.From)
+	assert.Len(t, schedule.Sessions, 5)
+
+	_, err = service.InstructorSchedule("in_3", models.ScheduleRequest{})
+	assert.Equal(t, constants.ErrInstructorNotFound, err)
+}
@@ -1,6 +1,58 @@
 package services
 
+import "glofox/internal/models"
+
 type IService interface {
-	CreateClass(name, startDateStr, endDateStr string, capacity int) error
-	BookClass(className, memberName, dateStr string) error
+	CreateClass(req models.ClassRequest) (models.Class, error)
+	GetClass(name string) (models.Class, error)
+	UpdateClass(name string, version int, req models.ClassUpdateRequest, change models.ClassChangeRequest) (models.ClassChangeResult, error)
+	DeleteClass(name string, version int, change models.ClassChangeRequest) (models.ClassChangeResult, error)
+	ListClasses(req models.ListRequest) (models.Page[models.Class], error)
+	ListSessions(className string, req models.ListRequest) (models.Page[models.Session], error)
+	BookClass(req models.BookingRequest) (models.BookingResult, error)
+	GetBooking(id string) (models.Booking, error)
+	CancelBooking(id string, version int) (models.Booking, error)
+	ListBookings(req models.BookingListRequest) (models.Page[models.Booking], error)
+	JoinWaitlist(className, dateStr string, req models.WaitlistRequest) (models.WaitlistPosition, error)
+	LeaveWaitlist(className, member, dateStr string) error
+	WaitlistPosition(className, member, dateStr string) (models.WaitlistPosition, error)
+	CreateMember(req models.MemberRequest) (models.Member, error)
+	GetMember(id string) (models.Member, error)
+	ListMembers(req models.ListRequest) (models.Page[models.Member], error)
+	UpdateMember(id string, req models.MemberRequest) (models.Member, error)
+	DeleteMember(id string) error
+	CreateInstructor(req models.InstructorRequest) (models.Instructor, error)
+	GetInstructor(id string) (models.Instructor, error)
+	ListInstructors(req models.ListRequest) (models.Page[models.Instructor], error)
+	UpdateInstructor(id string, req models.InstructorRequest) (models.Instructor, error)
+	DeleteInstructor(id string) error
+	InstructorSchedule(id string, req models.ScheduleRequest) (models.Schedule, error)
+	CreateRoom(req models.RoomRequest) (models.Room, error)
+	GetRoom(id string) (models.Room, error)
+	ListRooms(req models.ListRequest) (models.Page[models.Room], error)
+	UpdateRoom(id string, req models.RoomRequest) (models.Room, error)
+	DeleteRoom(id string) error
+	RoomUtilization(id string, req models.ScheduleRequest) (models.RoomUtilization, error)
+	CreatePlan(req models.PlanRequest) (models.Plan, error)
+	GetPlan(id string) (models.Plan, error)
+	ListPlans(req models.ListRequest) (models.Page[models.Plan], error)
+	UpdatePlan(id string, req models.PlanRequest) (models.Plan, error)
+	DeletePlan(id string) error
+	CreatePromoCode(req models.PromoCodeRequest) (models.PromoCode, error)
+	GetPromoCode(code string) (models.PromoCode, error)
+	ListPromoCodes(req models.ListRequest) (models.Page[models.PromoCode], error)
+	UpdatePromoCode(code string, req models.PromoCodeRequest) (models.PromoCode, error)
+	DeletePromoCode(code string) error
+	PromoRedemptions(code string) (models.PromoReport, error)
+	PurchaseMembership(memberID string, req models.MembershipRequest) (models.Membership, error)
+	MemberCredits(id string) (models.Credits, error)
+	ApplyPaymentEvent(event models.PaymentEvent) (models.Booking, error)
+}
+
+// IStudios returns the service of each studio
+type IStudios interface {
+	// Service returns the service of a studio, creating the studio on first use
+	Service(studioID string) (IService, error)
+	// Exists reports whether a studio was created before
+	Exists(studioID string) (bool, error)
 }
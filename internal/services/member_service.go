@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/utils"
+	"log"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// CreateMember registers a new member, members are active unless told otherwise
+func (service *ClassService) CreateMember(req models.MemberRequest) (member models.Member, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	now := service.now().UTC()
+	member = models.Member{
+		ID:        utils.NewID("mb_"),
+		Status:    constants.MemberStatusActive,
+		CreatedAt: now,
+	}
+	member, err = applyMemberRequest(member, req, now)
+	if err != nil {
+		return models.Member{}, err
+	}
+	return service.memberRepo.Create(member)
+}
+
+// GetMember fetches a member by ID
+func (service *ClassService) GetMember(id string) (models.Member, error) {
+	member, exists := service.memberRepo.GetByID(id)
+	if !exists {
+		return models.Member{}, constants.ErrMemberNotFound
+	}
+	return member, nil
+}
+
+// ListMembers returns a page of members sorted by ID
+func (service *ClassService) ListMembers(req models.ListRequest) (models.Page[models.Member], error) {
+	var afterID string
+	if req.Cursor != "" {
+		if err := utils.DecodeCursor(req.Cursor, &afterID); err != nil {
+			return models.Page[models.Member]{}, err
+		}
+	}
+
+	// Fetch one extra member to know whether there is a next page
+	limit := utils.PageLimit(req.Limit)
+	members := service.memberRepo.List(afterID, limit+1)
+
+	page := models.Page[models.Member]{Items: members}
+	if len(members) > limit {
+		page.Items = members[:limit]
+		page.NextCursor = utils.EncodeCursor(page.Items[limit-1].ID)
+	}
+	return page, nil
+}
+
+// UpdateMember replaces the details of a member, an empty status keeps the current one
+func (service *ClassService) UpdateMember(id string, req models.MemberRequest) (member models.Member, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	now := service.now().UTC()
+	return service.updateMember(id, func(member models.Member) (models.Member, error) {
+		return applyMemberRequest(member, req, now)
+	})
+}
+
+// DeleteMember removes a member, their past bookings keep the member ID
+func (service *ClassService) DeleteMember(id string) error {
+	return service.memberRepo.Delete(id)
+}
+
+// updateMember applies change to the stored member and writes it back. A
+// member changed concurrently is read again and changed anew, so that no
+// update overwrites another.
+func (service *ClassService) updateMember(id string, change func(models.Member) (models.Member, error)) (models.Member, error) {
+	for {
+		member, exists := service.memberRepo.GetByID(id)
+		if !exists {
+			return models.Member{}, constants.ErrMemberNotFound
+		}
+		member, err := change(member)
+		if err != nil {
+			return models.Member{}, err
+		}
+		updated, err := service.memberRepo.Update(member)
+		if !errors.Is(err, constants.ErrVersionMismatch) {
+			return updated, err
+		}
+	}
+}
+
+// applyMemberRequest copies the requested details onto a member
+func applyMemberRequest(member models.Member, req models.MemberRequest, now time.Time) (models.Member, error) {
+	// Names are stored trimmed so that lookups by name are exact
+	member.Name = strings.TrimSpace(req.Name)
+	if member.Name == "" {
+		return models.Member{}, constants.ErrInvalidMemberName
+	}
+	member.Email = strings.TrimSpace(req.Email)
+	member.Phone = strings.TrimSpace(req.Phone)
+	if req.Status != "" {
+		member.Status = req.Status
+	}
+	member.UpdatedAt = now
+	return member, nil
+}
+
+// resolveMember returns the member with the given ID, or the only member
+// with the given name when no ID is given
+func (service *ClassService) resolveMember(memberID, memberName string) (models.Member, error) {
+	if memberID != "" {
+		member, exists := service.memberRepo.GetByID(memberID)
+		if !exists {
+			return models.Member{}, constants.ErrMemberNotFound
+		}
+		return member, nil
+	}
+
+	members := service.memberRepo.FindByName(memberName)
+	switch len(members) {
+	case 0:
+		return models.Member{}, constants.ErrMemberNotFound
+	case 1:
+		return members[0], nil
+	}
+	return models.Member{}, constants.ErrAmbiguousMember
+}
+
+// findMember resolves a path parameter that holds a member ID or, for older
+// clients, a member name
+func (service *ClassService) findMember(member string) (models.Member, error) {
+	if found, exists := service.memberRepo.GetByID(member); exists {
+		return found, nil
+	}
+	return service.resolveMember("", member)
+}
+
+// activeMember resolves a member who may book classes
+func (service *ClassService) activeMember(memberID, memberName string) (models.Member, error) {
+	member, err := service.resolveMember(memberID, memberName)
+	if err != nil {
+		return models.Member{}, err
+	}
+	if member.Status == constants.MemberStatusSuspended {
+		return models.Member{}, constants.ErrMemberSuspended
+	}
+	return member, nil
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMemberRepo mocks the MemberRepo
+type MockMemberRepo struct {
+	mock.Mock
+}
+
+func (m *MockMemberRepo) Create(member models.Member) (models.Member, error) {
+	args := m.Called(member)
+	if create, ok := args.Get(0).(func(models.Member) (models.Member, error)); ok {
+		return create(member)
+	}
+	created, _ := args.Get(0).(models.Member)
+	return created, args.Error(1)
+}
+
+func (m *MockMemberRepo) GetByID(id string) (models.Member, bool) {
+	args := m.Called(id)
+	member, _ := args.Get(0).(models.Member)
+	return member, args.Bool(1)
+}
+
+func (m *MockMemberRepo) FindByName(name string) []models.Member {
+	args := m.Called(name)
+	if find, ok := args.Get(0).(func(string) []models.Member); ok {
+		return find(name)
+	}
+	members, _ := args.Get(0).([]models.Member)
+	return members
+}
+
+func (m *MockMemberRepo) Update(member models.Member) (models.Member, error) {
+	args := m.Called(member)
+	if update, ok := args.Get(0).(func(models.Member) (models.Member, error)); ok {
+		return update(member)
+	}
+	updated, _ := args.Get(0).(models.Member)
+	return updated, args.Error(1)
+}
+
+func (m *MockMemberRepo) Delete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockMemberRepo) List(afterID string, limit int) []models.Member {
+	args := m.Called(afterID, limit)
+	members, _ := args.Get(0).([]models.Member)
+	return members
+}
+
+// newMockMemberRepo returns a MockMemberRepo that looks up the given members
+// by ID and by name, and finds nobody else
+func newMockMemberRepo(members ...models.Member) *MockMemberRepo {
+	repo := new(MockMemberRepo)
+	byName := make(map[string][]models.Member)
+	for _, member := range members {
+		repo.On("GetByID", member.ID).Return(member, true)
+		key := strings.ToLower(member.Name)
+		byName[key] = append(byName[key], member)
+	}
+	repo.On("GetByID", mock.Anything).Return(models.Member{}, false)
+	repo.On("FindByName", mock.Anything).Return(func(name string) []models.Member {
+		return byName[strings.ToLower(strings.TrimSpace(name))]
+	})
+	return repo
+}
+
+// testMember returns an active member with an unlimited membership
+func testMember(id, name string) models.Member {
+	return models.Member{ID: id, Name: name, Status: constants.MemberStatusActive, Memberships: []models.Membership{unlimitedMembership()}}
+}
+
+// nextVersion stands in for a repository storing a member at its next version
+func nextVersion(member models.Member) (models.Member, error) {
+	member.Version++
+	return member, nil
+}
+
+// aliceMemberRepo returns a MockMemberRepo that knows Alice as mb_alice
+func aliceMemberRepo() *MockMemberRepo {
+	return newMockMemberRepo(testMember("mb_alice", "Alice"))
+}
+
+// aliceBooking returns the booking Alice makes for a session
+func aliceBooking(className string, date time.Time) models.Booking {
+	return models.Booking{ClassName: className, MemberID: "mb_alice", MemberName: "Alice", Date: date}
+}
+
+func TestClassService_CreateMember(t *testing.T) {
+	mockMemberRepo := new(MockMemberRepo)
+	service := NewClassService(Dependencies{Members: mockMemberRepo})
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	mockMemberRepo.On("Create", mock.Anything).Return(nextVersion)
+
+	// Names and contact details are trimmed, new members are active
+	member, err := service.CreateMember(models.MemberRequest{Name: " Amrit ", Email: "amrit@example.com ", Phone: "+353 1 234 5678"})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(member.ID, "mb_"))
+	expected := models.Member{
+		ID:        member.ID,
+		Name:      "Amrit",
+		Email:     "amrit@example.com",
+		Phone:     "+353 1 234 5678",
+		Status:    constants.MemberStatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	mockMemberRepo.AssertCalled(t, "Create", expected)
+	expected.Version = 1
+	assert.Equal(t, expected, member)
+
+	// Every member gets their own ID, even with the same name
+	other, err := service.CreateMember(models.MemberRequest{Name: "Amrit"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, member.ID, other.ID)
+
+	_, err = service.CreateMember(models.MemberRequest{Name: "  "})
+	assert.Equal(t, constants.ErrInvalidMemberName, err)
+	mockMemberRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestClassService_UpdateMember(t *testing.T) {
+	mockMemberRepo := newMockMemberRepo(testMember("mb_alice", "Alice"))
+	service := NewClassService(Dependencies{Members: mockMemberRepo})
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	mockMemberRepo.On("Update", mock.Anything).Return(nextVersion)
+
+	member, err := service.UpdateMember("mb_alice", models.MemberRequest{Name: "Alice", Status: constants.MemberStatusSuspended})
+	assert.NoError(t, err)
+	assert.Equal(t, constants.MemberStatusSuspended, member.Status)
+	assert.Equal(t, now, member.UpdatedAt)
+
+	// An empty status keeps the current one
+	member, err = service.UpdateMember("mb_alice", models.MemberRequest{Name: "Alice Smith"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice Smith", member.Name)
+	assert.Equal(t, constants.MemberStatusActive, member.Status)
+
+	_, err = service.UpdateMember("mb_bob", models.MemberRequest{Name: "Bob"})
+	assert.Equal(t, constants.ErrMemberNotFound, err)
+}
+
+func TestClassService_ListMembers(t *testing.T) {
+	mockMemberRepo := new(MockMemberRepo)
+	service := NewClassService(Dependencies{Members: mockMemberRepo})
+	alice, bob := testMember("mb_1", "Alice"), testMember("mb_2", "Bob")
+	mockMemberRepo.On("List", "", 2).Return([]models.Member{alice, bob})
+	mockMemberRepo.On("List", "mb_1", 2).Return([]models.Member{bob})
+
+	page, err := service.ListMembers(models.ListRequest{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Member{alice}, page.Items)
+
+	page, err = service.ListMembers(models.ListRequest{Limit: 1, Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Member{bob}, page.Items)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestClassService_BookClass_Members(t *testing.T) {
+	mockClassRepo, mockBookingRepo, mockWaitlistRepo := new(MockClassRepo), new(MockBookingRepo), new(MockWaitlistRepo)
+	suspended := testMember("mb_sam", "Sam")
+	suspended.Status = constants.MemberStatusSuspended
+	mockMemberRepo := newMockMemberRepo(testMember("mb_alice", "Alice"), testMember("mb_amrit_1", "Amrit"), testMember("mb_amrit_2", "Amrit"), suspended)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Waitlists: mockWaitlistRepo, Members: mockMemberRepo})
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	mockClassRepo.On("GetByName", "Yoga").Return(yogaClass(), true)
+	mockBookingRepo.On("Create", mock.Anything, seats(2)).Return(models.Booking{}, nil)
+	mockWaitlistRepo.On("Leave", "Yoga", mock.Anything, date).Return(constants.ErrNotOnWaitlist)
+
+	// Members sharing a name are told apart by their ID
+	_, err := service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberID: "mb_amrit_2", Date: "2025-06-10"})
+	assert.NoError(t, err)
+	mockBookingRepo.AssertCalled(t, "Create", models.Booking{ClassName: "Yoga", MemberID: "mb_amrit_2", MemberName: "Amrit", Date: date}, seats(2))
+
+	// The deprecated name lookup ignores case and surrounding spaces
+	_, err = service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberName: " alice ", Date: "2025-06-10"})
+	assert.NoError(t, err)
+	mockBookingRepo.AssertCalled(t, "Create", aliceBooking("Yoga", date), seats(2))
+
+	for req, expectedErr := range map[models.BookingRequest]error{
+		{ClassName: "Yoga", MemberName: "Amrit", Date: "2025-06-10"}:  constants.ErrAmbiguousMember,
+		{ClassName: "Yoga", MemberName: "Carol", Date: "2025-06-10"}:  constants.ErrMemberNotFound,
+		{ClassName: "Yoga", MemberID: "mb_carol", Date: "2025-06-10"}: constants.ErrMemberNotFound,
+		{ClassName: "Yoga", MemberID: "mb_sam", Date: "2025-06-10"}:   constants.ErrMemberSuspended,
+	} {
+		_, err := service.BookClass(req)
+		assert.Equal(t, expectedErr, err, "request %+v", req)
+	}
+	mockBookingRepo.AssertNumberOfCalls(t, "Create", 2)
+}
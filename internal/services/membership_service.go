@@ -0,0 +1,299 @@
+package services
+
+import (
+	"fmt"
+	"glofox/internal/apierrors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/utils"
+	"log"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CreatePlan adds a new membership plan
+func (service *ClassService) CreatePlan(req models.PlanRequest) (plan models.Plan, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	now := service.now().UTC()
+	plan = models.Plan{ID: utils.NewID("pl_"), CreatedAt: now}
+	plan, err = applyPlanRequest(plan, req, now)
+	if err != nil {
+		return models.Plan{}, err
+	}
+	if err := service.planRepo.Create(plan); err != nil {
+		return models.Plan{}, err
+	}
+	return plan, nil
+}
+
+// GetPlan fetches a plan by ID
+func (service *ClassService) GetPlan(id string) (models.Plan, error) {
+	plan, exists := service.planRepo.GetByID(id)
+	if !exists {
+		return models.Plan{}, constants.ErrPlanNotFound
+	}
+	return plan, nil
+}
+
+// ListPlans returns a page of plans sorted by ID
+func (service *ClassService) ListPlans(req models.ListRequest) (models.Page[models.Plan], error) {
+	var afterID string
+	if req.Cursor != "" {
+		if err := utils.DecodeCursor(req.Cursor, &afterID); err != nil {
+			return models.Page[models.Plan]{}, err
+		}
+	}
+
+	// Fetch one extra plan to know whether there is a next page
+	limit := utils.PageLimit(req.Limit)
+	plans := service.planRepo.List(afterID, limit+1)
+
+	page := models.Page[models.Plan]{Items: plans}
+	if len(plans) > limit {
+		page.Items = plans[:limit]
+		page.NextCursor = utils.EncodeCursor(page.Items[limit-1].ID)
+	}
+	return page, nil
+}
+
+// UpdatePlan replaces the terms of a plan. Memberships sold before keep the
+// terms they were bought with.
+func (service *ClassService) UpdatePlan(id string, req models.PlanRequest) (plan models.Plan, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	plan, exists := service.planRepo.GetByID(id)
+	if !exists {
+		return models.Plan{}, constants.ErrPlanNotFound
+	}
+	plan, err = applyPlanRequest(plan, req, service.now().UTC())
+	if err != nil {
+		return models.Plan{}, err
+	}
+	if err := service.planRepo.Update(plan); err != nil {
+		return models.Plan{}, err
+	}
+	return plan, nil
+}
+
+// DeletePlan stops selling a plan, memberships bought before stay valid
+func (service *ClassService) DeletePlan(id string) error {
+	return service.planRepo.Delete(id)
+}
+
+// PurchaseMembership sells a plan to a member. Packs are granted their
+// classes as credits in the ledger of the member.
+func (service *ClassService) PurchaseMembership(memberID string, req models.MembershipRequest) (membership models.Membership, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	if _, exists := service.memberRepo.GetByID(memberID); !exists {
+		return models.Membership{}, constants.ErrMemberNotFound
+	}
+	plan, exists := service.planRepo.GetByID(strings.TrimSpace(req.PlanID))
+	if !exists {
+		return models.Membership{}, apierrors.Field(constants.ErrPlanNotFound, "plan_id")
+	}
+
+	now := service.now().UTC()
+	startsAt := now
+	if req.StartsAt != "" {
+		// A date alone starts the membership at midnight in the studio time zone
+		if startsAt, _, err = utils.ParseDateTime(req.StartsAt, service.location); err != nil {
+			return models.Membership{}, apierrors.Field(constants.ErrInvalidDate, "starts_at")
+		}
+	}
+	membership = models.Membership{
+		ID:        utils.NewID("ms_"),
+		PlanID:    plan.ID,
+		PlanName:  plan.Name,
+		Kind:      plan.Kind,
+		Classes:   plan.Classes,
+		StartsAt:  startsAt,
+		CreatedAt: now,
+	}
+	if plan.ValidDays > 0 {
+		expiresAt := startsAt.AddDate(0, 0, plan.ValidDays)
+		membership.ExpiresAt = &expiresAt
+	}
+
+	_, err = service.updateMember(memberID, func(member models.Member) (models.Member, error) {
+		member.Memberships = append(slices.Clone(member.Memberships), membership)
+		member.UpdatedAt = now
+		return member, nil
+	})
+	if err != nil {
+		return models.Membership{}, err
+	}
+	if membership.Kind == constants.PlanPack {
+		err := service.bookingRepo.AddCredit(models.CreditEntry{
+			ID:           utils.NewID("cr_"),
+			MemberID:     memberID,
+			MembershipID: membership.ID,
+			Amount:       membership.Classes,
+			Reason:       constants.CreditGrant,
+			CreatedAt:    now,
+		})
+		if err != nil {
+			// A pack without its credits is not sold, changes made to the
+			// member in the meantime are kept
+			revert := func(member models.Member) (models.Member, error) {
+				member.Memberships = slices.DeleteFunc(slices.Clone(member.Memberships), func(sold models.Membership) bool {
+					return sold.ID == membership.ID
+				})
+				return member, nil
+			}
+			if _, err := service.updateMember(memberID, revert); err != nil {
+				log.Printf("Failed to revert membership %s of %s: %v", membership.ID, memberID, err)
+			}
+			return models.Membership{}, err
+		}
+	}
+	return membership, nil
+}
+
+// MemberCredits returns what is left of the active memberships of a member
+// and their credit ledger
+func (service *ClassService) MemberCredits(id string) (credits models.Credits, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	member, exists := service.memberRepo.GetByID(id)
+	if !exists {
+		return models.Credits{}, constants.ErrMemberNotFound
+	}
+	credits = models.Credits{MemberID: id, Balances: []models.CreditBalance{}, Entries: service.bookingRepo.Credits(id)}
+
+	now := service.now().UTC()
+	for _, membership := range activeMemberships(member, now) {
+		balance := models.CreditBalance{Membership: membership}
+		if membership.Kind == constants.PlanUnlimited {
+			balance.Unlimited = true
+		} else {
+			balance.Remaining = service.bookingRepo.Balance(id, membershipCharge(membership, now, service.location))
+		}
+		credits.Balances = append(credits.Balances, balance)
+	}
+	return credits, nil
+}
+
+// entitlement returns the charge of a booking of the member for the session
+// of the class starting at date, nil when an unlimited plan covers it. Weekly
+// and monthly plans are used before packs, and packs that expire first
+// before the others.
+func (service *ClassService) entitlement(member models.Member, class models.Class, date time.Time) (*models.Charge, error) {
+	memberships := activeMemberships(member, date)
+	if len(memberships) == 0 {
+		return nil, fmt.Errorf("%w: %s has no membership active on the date of the class", constants.ErrNoEntitlement, member.Name)
+	}
+	for _, membership := range memberships {
+		if membership.Kind == constants.PlanUnlimited {
+			return nil, nil
+		}
+	}
+
+	sort.SliceStable(memberships, func(i, j int) bool {
+		a, b := memberships[i], memberships[j]
+		if (a.Kind == constants.PlanPack) != (b.Kind == constants.PlanPack) {
+			return b.Kind == constants.PlanPack
+		}
+		return a.ExpiresAt != nil && (b.ExpiresAt == nil || a.ExpiresAt.Before(*b.ExpiresAt))
+	})
+	loc := utils.ClassLocation(class)
+	for _, membership := range memberships {
+		// The repository checks the balance again when it debits a credit
+		charge := membershipCharge(membership, date, loc)
+		if service.bookingRepo.Balance(member.ID, charge) > 0 {
+			return &charge, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s has no classes left on their memberships", constants.ErrNoEntitlement, member.Name)
+}
+
+// applyPlanRequest copies the requested terms onto a plan
+func applyPlanRequest(plan models.Plan, req models.PlanRequest, now time.Time) (models.Plan, error) {
+	plan.Name = strings.TrimSpace(req.Name)
+	if plan.Name == "" {
+		return models.Plan{}, apierrors.Field(fmt.Errorf("%w: name cannot be blank", constants.ErrInvalidPlan), "name")
+	}
+	switch req.Kind {
+	case constants.PlanUnlimited:
+		if req.Classes != 0 {
+			return models.Plan{}, apierrors.Field(fmt.Errorf("%w: unlimited plans have no number of classes", constants.ErrInvalidPlan), "classes")
+		}
+	case constants.PlanWeekly, constants.PlanMonthly, constants.PlanPack:
+		if req.Classes < 1 {
+			return models.Plan{}, apierrors.Field(fmt.Errorf("%w: %s plans need at least 1 class", constants.ErrInvalidPlan, req.Kind), "classes")
+		}
+	default:
+		return models.Plan{}, apierrors.Field(fmt.Errorf("%w: unknown kind %q", constants.ErrInvalidPlan, req.Kind), "kind")
+	}
+	if req.Kind == constants.PlanPack && req.ValidDays < 1 {
+		return models.Plan{}, apierrors.Field(fmt.Errorf("%w: packs need to expire after at least 1 day", constants.ErrInvalidPlan), "valid_days")
+	}
+	if req.ValidDays < 0 {
+		return models.Plan{}, apierrors.Field(fmt.Errorf("%w: valid_days cannot be negative", constants.ErrInvalidPlan), "valid_days")
+	}
+	plan.Kind, plan.Classes, plan.ValidDays = req.Kind, req.Classes, req.ValidDays
+	plan.UpdatedAt = now
+	return plan, nil
+}
+
+// activeMemberships returns the memberships of a member that have started
+// and not expired at t
+func activeMemberships(member models.Member, t time.Time) []models.Membership {
+	var active []models.Membership
+	for _, membership := range member.Memberships {
+		if !t.Before(membership.StartsAt) && (membership.ExpiresAt == nil || t.Before(*membership.ExpiresAt)) {
+			active = append(active, membership)
+		}
+	}
+	return active
+}
+
+// membershipCharge returns the charge of a booking at date to a membership.
+// Weekly plans count the calendar week from Monday and monthly plans the
+// calendar month of the date in loc.
+func membershipCharge(membership models.Membership, date time.Time, loc *time.Location) models.Charge {
+	charge := models.Charge{MembershipID: membership.ID}
+	day := utils.LocalDate(date, loc)
+	var first time.Time
+	switch membership.Kind {
+	case constants.PlanWeekly:
+		first = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		charge.To = utils.LocalTime(first.AddDate(0, 0, 7), 0, loc).UTC()
+	case constants.PlanMonthly:
+		first = day.AddDate(0, 0, 1-day.Day())
+		charge.To = utils.LocalTime(first.AddDate(0, 1, 0), 0, loc).UTC()
+	default:
+		return charge
+	}
+	charge.Allowance = membership.Classes
+	charge.From = utils.LocalTime(first, 0, loc).UTC()
+	return charge
+}
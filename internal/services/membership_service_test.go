@@ -0,0 +1,300 @@
+package services
+
+import (
+	"errors"
+	"glofox/internal/apierrors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockPlanRepo mocks the PlanRepo
+type MockPlanRepo struct {
+	mock.Mock
+}
+
+func (m *MockPlanRepo) Create(plan models.Plan) error {
+	args := m.Called(plan)
+	return args.Error(0)
+}
+
+func (m *MockPlanRepo) GetByID(id string) (models.Plan, bool) {
+	args := m.Called(id)
+	plan, _ := args.Get(0).(models.Plan)
+	return plan, args.Bool(1)
+}
+
+func (m *MockPlanRepo) Update(plan models.Plan) error {
+	args := m.Called(plan)
+	return args.Error(0)
+}
+
+func (m *MockPlanRepo) Delete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockPlanRepo) List(afterID string, limit int) []models.Plan {
+	args := m.Called(afterID, limit)
+	plans, _ := args.Get(0).([]models.Plan)
+	return plans
+}
+
+// unlimitedMembership returns a membership of an unlimited plan that does not expire
+func unlimitedMembership() models.Membership {
+	return models.Membership{ID: "ms_unlimited", PlanID: "pl_unlimited", PlanName: "Unlimited", Kind: constants.PlanUnlimited}
+}
+
+// packPlan returns a pack of 10 classes valid for 90 days
+func packPlan() models.Plan {
+	return models.Plan{ID: "pl_pack", Name: "10 Class Pack", Kind: constants.PlanPack, Classes: 10, ValidDays: 90}
+}
+
+// session returns the start of a session charged to a membership
+func session(date time.Time) *time.Time {
+	return &date
+}
+
+func TestClassService_CreatePlan(t *testing.T) {
+	mockPlanRepo := new(MockPlanRepo)
+	service := NewClassService(Dependencies{Plans: mockPlanRepo})
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	mockPlanRepo.On("Create", mock.Anything).Return(nil)
+
+	plan, err := service.CreatePlan(models.PlanRequest{Name: " 10 Class Pack ", Kind: constants.PlanPack, Classes: 10, ValidDays: 90})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plan.ID, "pl_"))
+	expected := packPlan()
+	expected.ID, expected.CreatedAt, expected.UpdatedAt = plan.ID, now, now
+	assert.Equal(t, expected, plan)
+	mockPlanRepo.AssertCalled(t, "Create", plan)
+
+	for field, req := range map[string]models.PlanRequest{
+		"name":       {Name: " ", Kind: constants.PlanUnlimited},
+		"classes":    {Name: "Unlimited", Kind: constants.PlanUnlimited, Classes: 4},
+		"kind":       {Name: "Daily", Kind: "daily", Classes: 1},
+		"valid_days": {Name: "5 Class Pack", Kind: constants.PlanPack, Classes: 5},
+	} {
+		_, err := service.CreatePlan(req)
+		assert.ErrorIs(t, err, constants.ErrInvalidPlan, field)
+		var apiErr *apierrors.Error
+		require.True(t, errors.As(err, &apiErr), field)
+		assert.Equal(t, field, apiErr.Fields[0].Field)
+	}
+	_, err = service.CreatePlan(models.PlanRequest{Name: "Twice a Week", Kind: constants.PlanWeekly})
+	assert.ErrorIs(t, err, constants.ErrInvalidPlan)
+	mockPlanRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestClassService_PurchaseMembership(t *testing.T) {
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	newService := func(bookingRepo *MockBookingRepo, memberRepo *MockMemberRepo) *ClassService {
+		planRepo := new(MockPlanRepo)
+		planRepo.On("GetByID", "pl_pack").Return(packPlan(), true)
+		planRepo.On("GetByID", "pl_weekly").Return(models.Plan{ID: "pl_weekly", Name: "Twice a Week", Kind: constants.PlanWeekly, Classes: 2}, true)
+		planRepo.On("GetByID", mock.Anything).Return(models.Plan{}, false)
+		service := NewClassService(Dependencies{Bookings: bookingRepo, Members: memberRepo, Plans: planRepo})
+		service.now = func() time.Time { return now }
+		return service
+	}
+
+	t.Run("Pack", func(t *testing.T) {
+		mockBookingRepo, mockMemberRepo := new(MockBookingRepo), newMockMemberRepo(testMember("mb_alice", "Alice"))
+		mockMemberRepo.On("Update", mock.Anything).Return(nextVersion)
+		mockBookingRepo.On("AddCredit", mock.Anything).Return(nil)
+		service := newService(mockBookingRepo, mockMemberRepo)
+
+		membership, err := service.PurchaseMembership("mb_alice", models.MembershipRequest{PlanID: "pl_pack"})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(membership.ID, "ms_"))
+		assert.Equal(t, constants.PlanPack, membership.Kind)
+		assert.Equal(t, now, membership.StartsAt)
+		require.NotNil(t, membership.ExpiresAt)
+		assert.Equal(t, now.AddDate(0, 0, 90), *membership.ExpiresAt)
+
+		// The membership is added to the member and the pack granted its classes
+		member := testMember("mb_alice", "Alice")
+		member.Memberships = append(member.Memberships, membership)
+		member.UpdatedAt = now
+		mockMemberRepo.AssertCalled(t, "Update", member)
+		mockBookingRepo.AssertCalled(t, "AddCredit", mock.MatchedBy(func(entry models.CreditEntry) bool {
+			return entry.MemberID == "mb_alice" && entry.MembershipID == membership.ID && entry.Amount == 10 && entry.Reason == constants.CreditGrant
+		}))
+	})
+
+	t.Run("Weekly From A Date", func(t *testing.T) {
+		mockBookingRepo, mockMemberRepo := new(MockBookingRepo), newMockMemberRepo(testMember("mb_alice", "Alice"))
+		mockMemberRepo.On("Update", mock.Anything).Return(nextVersion)
+		service := newService(mockBookingRepo, mockMemberRepo)
+
+		membership, err := service.PurchaseMembership("mb_alice", models.MembershipRequest{PlanID: "pl_weekly", StartsAt: "2025-06-09"})
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC), membership.StartsAt)
+		assert.Nil(t, membership.ExpiresAt)
+		mockBookingRepo.AssertNotCalled(t, "AddCredit", mock.Anything)
+	})
+
+	t.Run("Credits Not Granted", func(t *testing.T) {
+		mockBookingRepo, mockMemberRepo := new(MockBookingRepo), newMockMemberRepo(testMember("mb_alice", "Alice"))
+		mockMemberRepo.On("Update", mock.Anything).Return(nextVersion)
+		mockBookingRepo.On("AddCredit", mock.Anything).Return(constants.ErrInternalServer)
+		service := newService(mockBookingRepo, mockMemberRepo)
+
+		_, err := service.PurchaseMembership("mb_alice", models.MembershipRequest{PlanID: "pl_pack"})
+		assert.ErrorIs(t, err, constants.ErrInternalServer)
+		// The member is restored without the membership
+		mockMemberRepo.AssertCalled(t, "Update", testMember("mb_alice", "Alice"))
+	})
+
+	t.Run("Member Changed Concurrently", func(t *testing.T) {
+		alice, renamed := testMember("mb_alice", "Alice"), testMember("mb_alice", "Alice Smith")
+		alice.Version, renamed.Version = 1, 2
+		mockBookingRepo, mockMemberRepo := new(MockBookingRepo), new(MockMemberRepo)
+		mockMemberRepo.On("GetByID", "mb_alice").Return(alice, true).Twice()
+		mockMemberRepo.On("GetByID", "mb_alice").Return(renamed, true)
+		mockMemberRepo.On("Update", mock.Anything).Return(models.Member{}, constants.ErrVersionMismatch).Once()
+		mockMemberRepo.On("Update", mock.Anything).Return(nextVersion)
+		service := newService(mockBookingRepo, mockMemberRepo)
+
+		membership, err := service.PurchaseMembership("mb_alice", models.MembershipRequest{PlanID: "pl_weekly"})
+		require.NoError(t, err)
+
+		// The membership is added again to the member as changed in the meantime
+		renamed.Memberships = append(renamed.Memberships, membership)
+		renamed.UpdatedAt = now
+		mockMemberRepo.AssertNumberOfCalls(t, "Update", 2)
+		mockMemberRepo.AssertCalled(t, "Update", renamed)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		service := newService(new(MockBookingRepo), newMockMemberRepo(testMember("mb_alice", "Alice")))
+		_, err := service.PurchaseMembership("mb_bob", models.MembershipRequest{PlanID: "pl_pack"})
+		assert.Equal(t, constants.ErrMemberNotFound, err)
+		_, err = service.PurchaseMembership("mb_alice", models.MembershipRequest{PlanID: "pl_9"})
+		assert.ErrorIs(t, err, constants.ErrPlanNotFound)
+	})
+}
+
+func TestClassService_MemberCredits(t *testing.T) {
+	now := time.Date(2025, 6, 11, 9, 0, 0, 0, time.UTC)
+	expires := now.AddDate(0, 0, 30)
+	weekly := models.Membership{ID: "ms_weekly", Kind: constants.PlanWeekly, Classes: 2}
+	pack := models.Membership{ID: "ms_pack", Kind: constants.PlanPack, Classes: 5, ExpiresAt: &expires}
+	expired := models.Membership{ID: "ms_old", Kind: constants.PlanPack, Classes: 5, ExpiresAt: &now}
+	alice := testMember("mb_alice", "Alice")
+	alice.Memberships = append(alice.Memberships, weekly, pack, expired)
+	entries := []models.CreditEntry{
+		{ID: "cr_1", MemberID: "mb_alice", MembershipID: "ms_pack", Amount: 5, Reason: constants.CreditGrant},
+		{ID: "cr_2", MemberID: "mb_alice", MembershipID: "ms_pack", Amount: -1, Reason: constants.CreditBooking, Session: session(now)},
+		{ID: "cr_3", MemberID: "mb_alice", MembershipID: "ms_weekly", Amount: -1, Reason: constants.CreditBooking, Session: session(now.AddDate(0, 0, -7))},
+		{ID: "cr_4", MemberID: "mb_alice", MembershipID: "ms_weekly", Amount: -1, Reason: constants.CreditBooking, Session: session(now.AddDate(0, 0, 2))},
+	}
+	mockBookingRepo := new(MockBookingRepo)
+	mockBookingRepo.On("Credits", "mb_alice").Return(entries)
+	// This week runs from Monday 9 June
+	week := time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC)
+	mockBookingRepo.On("Balance", "mb_alice", models.Charge{MembershipID: "ms_weekly", Allowance: 2, From: week, To: week.AddDate(0, 0, 7)}).Return(1)
+	mockBookingRepo.On("Balance", "mb_alice", models.Charge{MembershipID: "ms_pack"}).Return(4)
+	service := NewClassService(Dependencies{Bookings: mockBookingRepo, Members: newMockMemberRepo(alice)})
+	service.now = func() time.Time { return now }
+
+	credits, err := service.MemberCredits("mb_alice")
+	require.NoError(t, err)
+	assert.Equal(t, models.Credits{
+		MemberID: "mb_alice",
+		Balances: []models.CreditBalance{
+			{Membership: unlimitedMembership(), Unlimited: true},
+			{Membership: weekly, Remaining: 1},
+			{Membership: pack, Remaining: 4},
+		},
+		Entries: entries,
+	}, credits)
+
+	_, err = service.MemberCredits("mb_bob")
+	assert.Equal(t, constants.ErrMemberNotFound, err)
+}
+
+func TestClassService_BookClass_Entitlement(t *testing.T) {
+	// Tuesday 10 June 2025, the week runs from Monday 9 June
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	week := time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC)
+	expiresSoon, expiresLater := date.AddDate(0, 0, 10), date.AddDate(0, 0, 60)
+	weekly := models.Membership{ID: "ms_weekly", Kind: constants.PlanWeekly, Classes: 1}
+	soon := models.Membership{ID: "ms_soon", Kind: constants.PlanPack, Classes: 5, ExpiresAt: &expiresSoon}
+	later := models.Membership{ID: "ms_later", Kind: constants.PlanPack, Classes: 5, ExpiresAt: &expiresLater}
+	packsLeft := map[string]int{"ms_soon": 5, "ms_later": 5}
+
+	tests := []struct {
+		name        string
+		memberships []models.Membership
+		// balances are the credits left by membership ID
+		balances      map[string]int
+		expectedLimit models.BookingLimits
+		expectedErr   error
+	}{
+		{
+			name:          "Unlimited Is Not Charged",
+			memberships:   []models.Membership{unlimitedMembership(), weekly},
+			expectedLimit: seats(2),
+		},
+		{
+			name:          "Weekly Before Packs",
+			memberships:   []models.Membership{later, weekly, soon},
+			balances:      map[string]int{"ms_weekly": 1, "ms_soon": 5, "ms_later": 5},
+			expectedLimit: models.BookingLimits{Capacity: 2, Charge: &models.Charge{MembershipID: "ms_weekly", Allowance: 1, From: week, To: week.AddDate(0, 0, 7)}},
+		},
+		{
+			name:          "Pack Expiring First",
+			memberships:   []models.Membership{later, weekly, soon},
+			balances:      packsLeft,
+			expectedLimit: models.BookingLimits{Capacity: 2, Charge: &models.Charge{MembershipID: "ms_soon"}},
+		},
+		{
+			name:        "Week Used Up",
+			memberships: []models.Membership{weekly},
+			balances:    packsLeft,
+			expectedErr: constants.ErrNoEntitlement,
+		},
+		{
+			name:        "Expired",
+			memberships: []models.Membership{{ID: "ms_old", Kind: constants.PlanUnlimited, ExpiresAt: &date}},
+			expectedErr: constants.ErrNoEntitlement,
+		},
+		{
+			name:        "No Membership",
+			expectedErr: constants.ErrNoEntitlement,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alice := testMember("mb_alice", "Alice")
+			alice.Memberships = tt.memberships
+			mockClassRepo, mockBookingRepo, mockWaitlistRepo := new(MockClassRepo), new(MockBookingRepo), new(MockWaitlistRepo)
+			mockClassRepo.On("GetByName", "Yoga").Return(yogaClass(), true)
+			mockBookingRepo.On("Balance", "mb_alice", mock.Anything).Return(func(memberID string, charge models.Charge) int {
+				return tt.balances[charge.MembershipID]
+			})
+			mockBookingRepo.On("Create", aliceBooking("Yoga", date), tt.expectedLimit).Return(models.Booking{}, nil)
+			mockWaitlistRepo.On("Leave", "Yoga", "mb_alice", date).Return(constants.ErrNotOnWaitlist)
+			service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Waitlists: mockWaitlistRepo, Members: newMockMemberRepo(alice)})
+
+			_, err := service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberID: "mb_alice", Date: "2025-06-10"})
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				mockBookingRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			mockBookingRepo.AssertCalled(t, "Create", aliceBooking("Yoga", date), tt.expectedLimit)
+		})
+	}
+}
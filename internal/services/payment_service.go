@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"glofox/internal/apierrors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/payments"
+	"glofox/internal/utils"
+	"log"
+	"runtime/debug"
+)
+
+// dropInPayment returns the payment of a member booking a class at its
+// drop-in price less the discount of promo, nil when no code was redeemed,
+// with method. err is the reason the member has no entitlement.
+func (service *ClassService) dropInPayment(class models.Class, promo *models.Promo, method string, err error) (*models.Payment, error) {
+	if method == "" {
+		return nil, apierrors.Field(err, "payment_method")
+	}
+	amount := class.DropIn.Amount
+	if promo != nil {
+		amount -= promo.Discount
+	}
+	return &models.Payment{
+		ID:        utils.NewID("pay_"),
+		Amount:    amount,
+		Currency:  class.DropIn.Currency,
+		Method:    method,
+		Status:    constants.PaymentPending,
+		UpdatedAt: service.now().UTC(),
+	}, nil
+}
+
+// SettlePayments moves every unfinished payment forward, authorizing pending
+// payments, capturing authorized ones and refunding cancelled bookings. It
+// is run periodically so that calls the provider did not answer are retried.
+func (service *ClassService) SettlePayments() {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+		}
+	}()
+
+	if service.payments == nil {
+		return
+	}
+	bookings := service.bookingRepo.Query(models.BookingFilter{
+		PaymentStatuses: []string{constants.PaymentPending, constants.PaymentAuthorized, constants.PaymentRefundPending},
+	})
+	for _, booking := range bookings {
+		class, exists := service.classRepo.GetByName(booking.ClassName)
+		if !exists {
+			// Refunds of the bookings of a deleted class are still due
+			class = models.Class{Name: booking.ClassName}
+		}
+		if _, err := service.settle(class, booking); err != nil {
+			log.Printf("Failed to settle payment %s of booking %s: %v", booking.Payment.ID, booking.ID, err)
+		}
+	}
+}
+
+// ApplyPaymentEvent records what the provider reported about the payment of
+// a booking in a webhook. Events may arrive late, twice or out of order, an
+// event the payment already went past is ignored.
+func (service *ClassService) ApplyPaymentEvent(event models.PaymentEvent) (settled models.Booking, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	booking, exists := service.bookingRepo.GetByID(event.BookingID)
+	if !exists || booking.Payment == nil || booking.Payment.ID != event.PaymentID {
+		return models.Booking{}, constants.ErrPaymentNotFound
+	}
+	class, exists := service.classRepo.GetByName(booking.ClassName)
+	if !exists {
+		// The unsettled bookings of a deleted class are kept until settled
+		class = models.Class{Name: booking.ClassName}
+	}
+
+	payment := *booking.Payment
+	if payment.AuthorizationID == "" {
+		payment.AuthorizationID = event.AuthorizationID
+	}
+	switch {
+	case event.Type == constants.PaymentEventAuthorized && payment.Status == constants.PaymentPending:
+		payment.Status = constants.PaymentAuthorized
+	case event.Type == constants.PaymentEventCaptured && (payment.Status == constants.PaymentPending || payment.Status == constants.PaymentAuthorized):
+		payment.Status = constants.PaymentCaptured
+	case event.Type == constants.PaymentEventDeclined && (payment.Status == constants.PaymentPending || payment.Status == constants.PaymentRefundPending):
+		payment.Status = constants.PaymentDeclined
+	case event.Type == constants.PaymentEventRefunded && (payment.Status == constants.PaymentAuthorized || payment.Status == constants.PaymentCaptured || payment.Status == constants.PaymentRefundPending):
+		payment.Status = constants.PaymentRefunded
+	}
+	if payment != *booking.Payment {
+		payment.UpdatedAt = service.now().UTC()
+		if booking, err = service.updatePayment(class, booking, payment); err != nil {
+			return models.Booking{}, err
+		}
+	}
+
+	// A refund or capture that is still due is taken now
+	if service.payments != nil {
+		if booking, err = service.settle(class, booking); err != nil {
+			log.Printf("Failed to settle payment %s of booking %s: %v", payment.ID, booking.ID, err)
+		}
+	}
+	return localBooking(booking, utils.ClassLocation(class)), nil
+}
+
+// settle calls the provider until the payment of a booking is captured,
+// declined or refunded, storing each step so that a failed call is retried
+// from where it stopped. A booking still pending when its session starts is
+// cancelled and its payment refunded. It returns the booking as stored.
+// The calls share paymentTimeout, so that the request settling a booking
+// waits for an unresponsive provider no longer than that.
+func (service *ClassService) settle(class models.Class, booking models.Booking) (models.Booking, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.paymentTimeout)
+	defer cancel()
+	for booking.Payment != nil {
+		payment := *booking.Payment
+		switch payment.Status {
+		case constants.PaymentPending:
+			if booking.Status == constants.BookingStatusPending && !service.now().Before(booking.Date) {
+				cancelled, err := service.bookingRepo.Cancel(booking.ID, booking.Version, service.now().UTC(), false, constants.CancelReasonPaymentExpired)
+				if stored, ok := service.changed(booking, err); ok {
+					booking = stored
+					continue
+				}
+				if err != nil {
+					return booking, err
+				}
+				service.promoteWaitlist(class.Name, cancelled.Date)
+				booking = cancelled
+				continue
+			}
+			id, err := service.payments.Authorize(ctx, authorizeRequest(booking))
+			switch {
+			case errors.Is(err, constants.ErrPaymentDeclined):
+				payment.Status = constants.PaymentDeclined
+			case err != nil:
+				return booking, err
+			default:
+				payment.AuthorizationID = id
+				payment.Status = constants.PaymentAuthorized
+			}
+		case constants.PaymentAuthorized:
+			if payment.AuthorizationID == "" {
+				// Events may report an authorization without its ID, authorizing again returns it
+				id, err := service.payments.Authorize(ctx, authorizeRequest(booking))
+				if err != nil {
+					return booking, err
+				}
+				payment.AuthorizationID = id
+			}
+			if err := service.payments.Capture(ctx, payment.AuthorizationID); err != nil {
+				return booking, err
+			}
+			payment.Status = constants.PaymentCaptured
+		case constants.PaymentRefundPending:
+			if payment.AuthorizationID == "" {
+				// The outcome of the authorization is unknown, ask again before refunding
+				id, err := service.payments.Authorize(ctx, authorizeRequest(booking))
+				if err != nil && !errors.Is(err, constants.ErrPaymentDeclined) {
+					return booking, err
+				}
+				payment.AuthorizationID = id
+			}
+			if payment.AuthorizationID == "" {
+				payment.Status = constants.PaymentDeclined
+			} else if err := service.payments.Refund(ctx, payment.AuthorizationID); err != nil {
+				return booking, err
+			} else {
+				payment.Status = constants.PaymentRefunded
+			}
+		default:
+			return booking, nil
+		}
+
+		payment.UpdatedAt = service.now().UTC()
+		updated, err := service.updatePayment(class, booking, payment)
+		if stored, ok := service.changed(booking, err); ok {
+			booking = stored
+			continue
+		}
+		if err != nil {
+			return booking, err
+		}
+		booking = updated
+	}
+	return booking, nil
+}
+
+// updatePayment stores the payment of a booking and offers the seat of a
+// booking cancelled by a declined payment to the waitlist
+func (service *ClassService) updatePayment(class models.Class, booking models.Booking, payment models.Payment) (models.Booking, error) {
+	updated, err := service.bookingRepo.UpdatePayment(booking.ID, booking.Version, payment)
+	if err != nil {
+		return models.Booking{}, err
+	}
+	if booking.Status != constants.BookingStatusCancelled && updated.Status == constants.BookingStatusCancelled {
+		service.promoteWaitlist(class.Name, updated.Date)
+	}
+	return updated, nil
+}
+
+// changed returns the stored booking when err reports that it was changed
+// concurrently, so that settling continues from its latest payment
+func (service *ClassService) changed(booking models.Booking, err error) (models.Booking, bool) {
+	if !errors.Is(err, constants.ErrVersionMismatch) {
+		return booking, false
+	}
+	return service.bookingRepo.GetByID(booking.ID)
+}
+
+// authorizeRequest returns the request authorizing the payment of a booking
+func authorizeRequest(booking models.Booking) payments.AuthorizeRequest {
+	return payments.AuthorizeRequest{
+		PaymentID: booking.Payment.ID,
+		Amount:    booking.Payment.Amount,
+		Currency:  booking.Payment.Currency,
+		Method:    booking.Payment.Method,
+		BookingID: booking.ID,
+	}
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"glofox/internal/apierrors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/payments"
+	"glofox/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPaymentService returns the service of a studio taking payments with a
+// fake provider, where Yoga has a drop-in price and Alice no membership
+func newPaymentService(t *testing.T) (*ClassService, *payments.Fake) {
+	t.Helper()
+	fake := payments.NewFake("whsec_test")
+	service := studioService(t, NewStudios(repository.NewMemoryStudios(), fake, 0, time.UTC, 0), "studio_a")
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	_, err := service.CreateClass(models.ClassRequest{Name: "Yoga", StartDate: "2025-06-01", EndDate: "2025-06-20", Capacity: 1,
+		DropIn: &models.PriceRequest{Amount: 1500, Currency: "eur"}})
+	require.NoError(t, err)
+	_, err = service.CreateMember(models.MemberRequest{Name: "Alice"})
+	require.NoError(t, err)
+	return service, fake
+}
+
+// bookDropIn books Yoga for Alice on 10 June paying with method
+func bookDropIn(service *ClassService, method string) (models.BookingResult, error) {
+	return service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2025-06-10", PaymentMethod: method})
+}
+
+func TestClassService_BookClass_DropIn(t *testing.T) {
+	t.Run("Captured", func(t *testing.T) {
+		service, fake := newPaymentService(t)
+		result, err := bookDropIn(service, "pm_card")
+		require.NoError(t, err)
+		assert.Equal(t, constants.BookingStatusBooked, result.Status)
+		assert.Equal(t, constants.BookingStatusBooked, result.Booking.Status)
+		assert.Equal(t, int64(1500), result.Booking.Payment.Amount)
+		assert.Equal(t, "EUR", result.Booking.Payment.Currency)
+		assert.Equal(t, constants.PaymentCaptured, result.Booking.Payment.Status)
+
+		payment, _ := fake.Payment(result.Booking.Payment.ID)
+		assert.Equal(t, constants.PaymentCaptured, payment.Status)
+		assert.Equal(t, "studio_a", payment.StudioID)
+		assert.Equal(t, result.Booking.ID, payment.BookingID)
+	})
+
+	t.Run("Payment Method Required", func(t *testing.T) {
+		service, _ := newPaymentService(t)
+		_, err := bookDropIn(service, "")
+		assert.ErrorIs(t, err, constants.ErrNoEntitlement)
+		var apiErr *apierrors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "payment_method", apiErr.Fields[0].Field)
+	})
+
+	t.Run("Declined Frees The Seat", func(t *testing.T) {
+		service, _ := newPaymentService(t)
+		_, err := bookDropIn(service, payments.MethodDeclined)
+		assert.ErrorIs(t, err, constants.ErrPaymentDeclined)
+
+		bookings := service.bookingRepo.Query(models.BookingFilter{})
+		require.Len(t, bookings, 1)
+		assert.Equal(t, constants.BookingStatusCancelled, bookings[0].Status)
+		assert.Equal(t, constants.CancelReasonPaymentDeclined, bookings[0].CancelReason)
+		assert.Equal(t, 0, service.bookingRepo.Count("Yoga", bookings[0].Date))
+	})
+
+	t.Run("Timeout Leaves The Booking Pending", func(t *testing.T) {
+		service, fake := newPaymentService(t)
+		result, err := bookDropIn(service, payments.MethodTimeout)
+		require.NoError(t, err)
+		assert.Equal(t, constants.BookingStatusPending, result.Status)
+		assert.Equal(t, constants.PaymentPending, result.Booking.Payment.Status)
+		// The pending booking holds the seat
+		_, err = service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2025-06-10", PaymentMethod: "pm_card"})
+		assert.ErrorIs(t, err, constants.ErrAlreadyBooked)
+
+		// Settling learns that the payment was authorized and captures it once
+		service.SettlePayments()
+		booking, err := service.GetBooking(result.Booking.ID)
+		require.NoError(t, err)
+		assert.Equal(t, constants.BookingStatusBooked, booking.Status)
+		assert.Equal(t, constants.PaymentCaptured, booking.Payment.Status)
+		payment, _ := fake.Payment(booking.Payment.ID)
+		assert.Equal(t, constants.PaymentCaptured, payment.Status)
+		assert.Equal(t, 2, payment.Attempts)
+	})
+
+	t.Run("Expired Before The Class", func(t *testing.T) {
+		service, fake := newPaymentService(t)
+		fake.SetUnavailable(true)
+		result, err := bookDropIn(service, "pm_card")
+		require.NoError(t, err)
+		assert.Equal(t, constants.BookingStatusPending, result.Status)
+
+		// The provider answers again once the class has started
+		fake.SetUnavailable(false)
+		started := time.Date(2025, 6, 10, 0, 30, 0, 0, time.UTC)
+		service.now = func() time.Time { return started }
+		service.SettlePayments()
+
+		booking, err := service.GetBooking(result.Booking.ID)
+		require.NoError(t, err)
+		assert.Equal(t, constants.BookingStatusCancelled, booking.Status)
+		assert.Equal(t, constants.CancelReasonPaymentExpired, booking.CancelReason)
+		assert.Equal(t, constants.PaymentRefunded, booking.Payment.Status)
+		payment, _ := fake.Payment(booking.Payment.ID)
+		assert.Equal(t, constants.PaymentRefunded, payment.Status)
+	})
+}
+
+// slowProvider is a provider that never answers an authorization before its
+// caller gives up
+type slowProvider struct {
+	*payments.Fake
+}
+
+// Authorize waits until ctx is done
+func (slowProvider) Authorize(ctx context.Context, _ payments.AuthorizeRequest) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestClassService_BookClass_PaymentTimeout(t *testing.T) {
+	service, fake := newPaymentService(t)
+	service.payments = slowProvider{fake}
+	service.paymentTimeout = 10 * time.Millisecond
+
+	// The booking is answered once the timeout passed and stays pending
+	done := make(chan struct{})
+	var result models.BookingResult
+	var err error
+	go func() {
+		defer close(done)
+		result, err = bookDropIn(service, "pm_card")
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the booking to stop waiting for the provider")
+	}
+	require.NoError(t, err)
+	assert.Equal(t, constants.BookingStatusPending, result.Status)
+	assert.Equal(t, constants.PaymentPending, result.Booking.Payment.Status)
+}
+
+func TestClassService_CancelBooking_Refund(t *testing.T) {
+	service, fake := newPaymentService(t)
+	result, err := bookDropIn(service, "pm_card")
+	require.NoError(t, err)
+
+	// A refund the provider does not answer is retried when payments are settled
+	fake.SetUnavailable(true)
+	cancelled, err := service.CancelBooking(result.Booking.ID, result.Booking.Version)
+	require.NoError(t, err)
+	assert.Equal(t, constants.PaymentRefundPending, cancelled.Payment.Status)
+
+	fake.SetUnavailable(false)
+	service.SettlePayments()
+	booking, err := service.GetBooking(result.Booking.ID)
+	require.NoError(t, err)
+	assert.Equal(t, constants.PaymentRefunded, booking.Payment.Status)
+	payment, _ := fake.Payment(booking.Payment.ID)
+	assert.Equal(t, constants.PaymentRefunded, payment.Status)
+}
+
+func TestClassService_ApplyPaymentEvent(t *testing.T) {
+	service, fake := newPaymentService(t)
+	result, err := bookDropIn(service, payments.MethodTimeout)
+	require.NoError(t, err)
+	paymentID := result.Booking.Payment.ID
+
+	// The provider reports the authorization whose response was lost
+	payload, signature, err := fake.Webhook(paymentID, constants.PaymentEventAuthorized)
+	require.NoError(t, err)
+	event, err := fake.VerifyWebhook(payload, signature)
+	require.NoError(t, err)
+	booking, err := service.ApplyPaymentEvent(event)
+	require.NoError(t, err)
+	assert.Equal(t, constants.BookingStatusBooked, booking.Status)
+	assert.Equal(t, constants.PaymentCaptured, booking.Payment.Status)
+
+	// Events are delivered again or after the payment went past them
+	again, err := service.ApplyPaymentEvent(event)
+	require.NoError(t, err)
+	assert.Equal(t, booking.Version, again.Version)
+
+	event.PaymentID = "pay_other"
+	_, err = service.ApplyPaymentEvent(event)
+	assert.ErrorIs(t, err, constants.ErrPaymentNotFound)
+}
+
+func TestClassService_ApplyPaymentEvent_RefundBeforeAuthorization(t *testing.T) {
+	service, fake := newPaymentService(t)
+	result, err := bookDropIn(service, payments.MethodTimeout)
+	require.NoError(t, err)
+	require.Equal(t, constants.PaymentPending, result.Booking.Payment.Status)
+	paymentID := result.Booking.Payment.ID
+
+	// A refund reported while the payment is still pending overtook the
+	// authorization it refunds, it is ignored and the payment is settled
+	payload, signature, err := fake.Webhook(paymentID, constants.PaymentEventRefunded)
+	require.NoError(t, err)
+	refunded, err := fake.VerifyWebhook(payload, signature)
+	require.NoError(t, err)
+	booking, err := service.ApplyPaymentEvent(refunded)
+	require.NoError(t, err)
+	assert.Equal(t, constants.BookingStatusBooked, booking.Status)
+	assert.Equal(t, constants.PaymentCaptured, booking.Payment.Status)
+
+	// Delivered again once the payment was captured, it is applied
+	booking, err = service.ApplyPaymentEvent(refunded)
+	require.NoError(t, err)
+	assert.Equal(t, constants.PaymentRefunded, booking.Payment.Status)
+}
+
+func TestClassService_ApplyPaymentEvent_DeletedClass(t *testing.T) {
+	service, fake := newPaymentService(t)
+	result, err := bookDropIn(service, payments.MethodTimeout)
+	require.NoError(t, err)
+	paymentID := result.Booking.Payment.ID
+
+	// Deleting the class keeps the booking until its refund is settled
+	fake.SetUnavailable(true)
+	_, err = service.DeleteClass("Yoga", constants.AnyVersion, models.ClassChangeRequest{Force: true, Reason: "Studio closed"})
+	require.NoError(t, err)
+	booking, err := service.GetBooking(result.Booking.ID)
+	require.NoError(t, err)
+	require.Equal(t, constants.PaymentRefundPending, booking.Payment.Status)
+
+	// The provider reports the refund of the deleted class
+	fake.SetUnavailable(false)
+	payload, signature, err := fake.Webhook(paymentID, constants.PaymentEventRefunded)
+	require.NoError(t, err)
+	event, err := fake.VerifyWebhook(payload, signature)
+	require.NoError(t, err)
+	booking, err = service.ApplyPaymentEvent(event)
+	require.NoError(t, err)
+	assert.Equal(t, constants.BookingStatusCancelled, booking.Status)
+	assert.Equal(t, constants.PaymentRefunded, booking.Payment.Status)
+}
+
+func TestDropInPrice(t *testing.T) {
+	price, err := dropInPrice(&models.PriceRequest{Amount: 1500, Currency: " usd "})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Price{Amount: 1500, Currency: "USD"}, price)
+
+	price, err = dropInPrice(&models.PriceRequest{Amount: 0})
+	assert.NoError(t, err)
+	assert.Nil(t, price)
+
+	_, err = dropInPrice(&models.PriceRequest{Amount: 1500, Currency: "euro"})
+	assert.ErrorIs(t, err, constants.ErrInvalidPrice)
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"glofox/internal/apierrors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/utils"
+	"log"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CreatePromoCode adds a new promo code
+func (service *ClassService) CreatePromoCode(req models.PromoCodeRequest) (promo models.PromoCode, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	now := service.now().UTC()
+	promo = models.PromoCode{Code: promoCode(req.Code), CreatedAt: now}
+	if promo.Code == "" || strings.Trim(promo.Code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-") != "" {
+		return models.PromoCode{}, apierrors.Field(fmt.Errorf("%w: codes are made of letters, digits, - and _", constants.ErrInvalidPromo), "code")
+	}
+	promo, err = service.applyPromoRequest(promo, req, now)
+	if err != nil {
+		return models.PromoCode{}, err
+	}
+	if err := service.promoRepo.Create(promo); err != nil {
+		return models.PromoCode{}, err
+	}
+	return promo, nil
+}
+
+// GetPromoCode fetches a promo code, ignoring case
+func (service *ClassService) GetPromoCode(code string) (models.PromoCode, error) {
+	promo, exists := service.promoRepo.GetByCode(promoCode(code))
+	if !exists {
+		return models.PromoCode{}, constants.ErrPromoNotFound
+	}
+	return promo, nil
+}
+
+// ListPromoCodes returns a page of promo codes sorted by code
+func (service *ClassService) ListPromoCodes(req models.ListRequest) (models.Page[models.PromoCode], error) {
+	var afterCode string
+	if req.Cursor != "" {
+		if err := utils.DecodeCursor(req.Cursor, &afterCode); err != nil {
+			return models.Page[models.PromoCode]{}, err
+		}
+	}
+
+	// Fetch one extra promo code to know whether there is a next page
+	limit := utils.PageLimit(req.Limit)
+	promos := service.promoRepo.List(afterCode, limit+1)
+
+	page := models.Page[models.PromoCode]{Items: promos}
+	if len(promos) > limit {
+		page.Items = promos[:limit]
+		page.NextCursor = utils.EncodeCursor(page.Items[limit-1].Code)
+	}
+	return page, nil
+}
+
+// UpdatePromoCode replaces the terms of a promo code. Bookings that redeemed
+// it before keep their discount.
+func (service *ClassService) UpdatePromoCode(code string, req models.PromoCodeRequest) (promo models.PromoCode, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	promo, exists := service.promoRepo.GetByCode(promoCode(code))
+	if !exists {
+		return models.PromoCode{}, constants.ErrPromoNotFound
+	}
+	if promoCode(req.Code) != promo.Code {
+		return models.PromoCode{}, apierrors.Field(fmt.Errorf("%w: the code cannot be changed", constants.ErrInvalidPromo), "code")
+	}
+	promo, err = service.applyPromoRequest(promo, req, service.now().UTC())
+	if err != nil {
+		return models.PromoCode{}, err
+	}
+	if err := service.promoRepo.Update(promo); err != nil {
+		return models.PromoCode{}, err
+	}
+	return promo, nil
+}
+
+// DeletePromoCode stops accepting a promo code, bookings that redeemed it keep their discount
+func (service *ClassService) DeletePromoCode(code string) error {
+	return service.promoRepo.Delete(promoCode(code))
+}
+
+// PromoRedemptions returns the bookings that redeemed a promo code, the
+// discount they got and how many redemptions are left
+func (service *ClassService) PromoRedemptions(code string) (report models.PromoReport, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	promo, exists := service.promoRepo.GetByCode(promoCode(code))
+	if !exists {
+		return models.PromoReport{}, constants.ErrPromoNotFound
+	}
+	bookings := service.bookingRepo.Query(models.BookingFilter{PromoCode: promo.Code})
+	report = models.PromoReport{PromoCode: promo, Discounts: map[string]int64{}, Bookings: service.localBookings(bookings)}
+	for _, booking := range bookings {
+		// Cancelled bookings gave their redemption back
+		if booking.Status == constants.BookingStatusCancelled {
+			continue
+		}
+		report.Redemptions++
+		if booking.Promo.Currency != "" {
+			report.Discounts[booking.Promo.Currency] += booking.Promo.Discount
+		}
+	}
+	if promo.MaxRedemptions > 0 {
+		remaining := max(promo.MaxRedemptions-report.Redemptions, 0)
+		report.Remaining = &remaining
+	}
+	return report, nil
+}
+
+// redeemPromo returns the discount a booking for the session of the class
+// starting at date gets with a promo code, and the limits the repository
+// checks when it is redeemed. err is the reason the member booking has no
+// entitlement, it is returned while part of the drop-in price is left to pay
+// and nil once the code makes the booking free. A member whose membership
+// covers the class books without redeeming the code.
+func (service *ClassService) redeemPromo(code string, class models.Class, date time.Time, err error) (*models.Promo, *models.PromoLimit, error) {
+	if err == nil {
+		return nil, nil, nil
+	}
+	if !errors.Is(err, constants.ErrNoEntitlement) {
+		return nil, nil, err
+	}
+	promo, exists := service.promoRepo.GetByCode(promoCode(code))
+	if !exists {
+		return nil, nil, apierrors.Field(constants.ErrPromoNotFound, "promo_code")
+	}
+	if len(promo.ClassNames) > 0 && !slices.Contains(promo.ClassNames, class.Name) {
+		return nil, nil, apierrors.Field(fmt.Errorf("%w: %s does not apply to %s", constants.ErrPromoNotApplicable, promo.Code, class.Name), "promo_code")
+	}
+	// The validity of a code is in calendar days of the time zone of the class
+	day := utils.LocalDate(date, utils.ClassLocation(class))
+	if (promo.ValidFrom != nil && day.Before(*promo.ValidFrom)) || (promo.ValidUntil != nil && day.After(*promo.ValidUntil)) {
+		return nil, nil, apierrors.Field(fmt.Errorf("%w: %s is not valid on %s", constants.ErrPromoNotApplicable, promo.Code, day.Format(constants.DateFormat)), "promo_code")
+	}
+
+	// Codes discount the drop-in price, classes without one are for members only
+	if class.DropIn == nil {
+		return nil, nil, apierrors.Field(fmt.Errorf("%w: %s has no drop-in price", constants.ErrPromoNotApplicable, class.Name), "promo_code")
+	}
+	price := *class.DropIn
+	redeemed := &models.Promo{Code: promo.Code, Currency: price.Currency}
+	switch promo.Kind {
+	case constants.PromoFirstClass:
+		redeemed.Discount = price.Amount
+	case constants.PromoPercentage, constants.PromoFixed:
+		if promo.Kind == constants.PromoFixed && promo.Currency != price.Currency {
+			return nil, nil, apierrors.Field(fmt.Errorf("%w: %s is priced in %s", constants.ErrPromoNotApplicable, class.Name, price.Currency), "promo_code")
+		}
+		redeemed.Discount = min(price.Amount*int64(promo.Percent)/100, price.Amount)
+		if promo.Kind == constants.PromoFixed {
+			redeemed.Discount = min(promo.Amount, price.Amount)
+		}
+	}
+	limit := &models.PromoLimit{Code: promo.Code, MaxRedemptions: promo.MaxRedemptions, FirstClass: promo.Kind == constants.PromoFirstClass}
+	if redeemed.Discount < price.Amount {
+		return redeemed, limit, err
+	}
+	return redeemed, limit, nil
+}
+
+// applyPromoRequest copies the requested terms onto a promo code. Its
+// validity dates are calendar days in the time zone of the studio.
+func (service *ClassService) applyPromoRequest(promo models.PromoCode, req models.PromoCodeRequest, now time.Time) (models.PromoCode, error) {
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	switch req.Kind {
+	case constants.PromoPercentage:
+		if req.Percent < 1 || req.Percent > 100 {
+			return models.PromoCode{}, apierrors.Field(fmt.Errorf("%w: percentage codes take 1 to 100 percent off", constants.ErrInvalidPromo), "percent")
+		}
+		if req.Amount != 0 || currency != "" {
+			return models.PromoCode{}, apierrors.Field(fmt.Errorf("%w: percentage codes have no amount", constants.ErrInvalidPromo), "amount")
+		}
+	case constants.PromoFixed:
+		if req.Amount < 1 {
+			return models.PromoCode{}, apierrors.Field(fmt.Errorf("%w: fixed codes take an amount off", constants.ErrInvalidPromo), "amount")
+		}
+		if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return models.PromoCode{}, apierrors.Field(fmt.Errorf("%w: fixed codes need a 3 letter currency", constants.ErrInvalidPromo), "currency")
+		}
+		if req.Percent != 0 {
+			return models.PromoCode{}, apierrors.Field(fmt.Errorf("%w: fixed codes have no percent", constants.ErrInvalidPromo), "percent")
+		}
+	case constants.PromoFirstClass:
+		if req.Percent != 0 || req.Amount != 0 || currency != "" {
+			return models.PromoCode{}, apierrors.Field(fmt.Errorf("%w: first class codes make the class free", constants.ErrInvalidPromo), "kind")
+		}
+	default:
+		return models.PromoCode{}, apierrors.Field(fmt.Errorf("%w: unknown kind %q", constants.ErrInvalidPromo, req.Kind), "kind")
+	}
+	if req.MaxRedemptions < 0 {
+		return models.PromoCode{}, apierrors.Field(fmt.Errorf("%w: max_redemptions cannot be negative", constants.ErrInvalidPromo), "max_redemptions")
+	}
+
+	var classNames []string
+	for _, name := range req.ClassNames {
+		name = strings.TrimSpace(name)
+		if _, exists := service.classRepo.GetByName(name); !exists {
+			return models.PromoCode{}, apierrors.Field(fmt.Errorf("%w: unknown class %q", constants.ErrInvalidPromo, name), "class_names")
+		}
+		if !slices.Contains(classNames, name) {
+			classNames = append(classNames, name)
+		}
+	}
+	validFrom, err := service.promoDate(req.ValidFrom, "valid_from")
+	if err != nil {
+		return models.PromoCode{}, err
+	}
+	validUntil, err := service.promoDate(req.ValidUntil, "valid_until")
+	if err != nil {
+		return models.PromoCode{}, err
+	}
+	if validFrom != nil && validUntil != nil && validFrom.After(*validUntil) {
+		return models.PromoCode{}, apierrors.Field(fmt.Errorf("%w: valid_from is after valid_until", constants.ErrInvalidPromo), "valid_until")
+	}
+
+	promo.Kind, promo.Percent, promo.Amount, promo.Currency = req.Kind, req.Percent, req.Amount, currency
+	promo.MaxRedemptions, promo.ClassNames = req.MaxRedemptions, classNames
+	promo.ValidFrom, promo.ValidUntil = validFrom, validUntil
+	promo.UpdatedAt = now
+	return promo, nil
+}
+
+// promoDate returns the calendar day of a validity date of a promo code in
+// the time zone of the studio, nil when it is empty
+func (service *ClassService) promoDate(s, field string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, _, err := utils.ParseDateTime(s, service.location)
+	if err != nil {
+		return nil, apierrors.Field(constants.ErrInvalidDate, field)
+	}
+	day := utils.LocalDate(t, service.location)
+	return &day, nil
+}
+
+// promoCode returns a promo code as it is stored
+func promoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"errors"
+	"glofox/internal/apierrors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/payments"
+	"glofox/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockPromoRepo is a mock implementation of the PromoRepository interface
+type MockPromoRepo struct {
+	mock.Mock
+}
+
+func (m *MockPromoRepo) Create(promo models.PromoCode) error {
+	args := m.Called(promo)
+	return args.Error(0)
+}
+
+func (m *MockPromoRepo) GetByCode(code string) (models.PromoCode, bool) {
+	args := m.Called(code)
+	promo, _ := args.Get(0).(models.PromoCode)
+	return promo, args.Bool(1)
+}
+
+func (m *MockPromoRepo) Update(promo models.PromoCode) error {
+	args := m.Called(promo)
+	return args.Error(0)
+}
+
+func (m *MockPromoRepo) Delete(code string) error {
+	args := m.Called(code)
+	return args.Error(0)
+}
+
+func (m *MockPromoRepo) List(afterCode string, limit int) []models.PromoCode {
+	args := m.Called(afterCode, limit)
+	promos, _ := args.Get(0).([]models.PromoCode)
+	return promos
+}
+
+// newPromoService returns the service of a studio taking payments with a
+// fake provider, where Yoga has a drop-in price, Pilates is free and Alice,
+// Bob and Carol have no membership
+func newPromoService(t *testing.T) *ClassService {
+	t.Helper()
+	service := studioService(t, NewStudios(repository.NewMemoryStudios(), payments.NewFake("whsec_test"), 0, time.UTC, 0), "studio_a")
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	_, err := service.CreateClass(models.ClassRequest{Name: "Yoga", StartDate: "2025-06-01", EndDate: "2025-06-30", Capacity: 10,
+		DropIn: &models.PriceRequest{Amount: 1500, Currency: "EUR"}})
+	require.NoError(t, err)
+	_, err = service.CreateClass(models.ClassRequest{Name: "Pilates", StartDate: "2025-06-01", EndDate: "2025-06-30", Capacity: 10})
+	require.NoError(t, err)
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		_, err = service.CreateMember(models.MemberRequest{Name: name})
+		require.NoError(t, err)
+	}
+	return service
+}
+
+// bookWithPromo books a class for a member on 10 June redeeming code
+func bookWithPromo(service *ClassService, className, memberName, code string) (models.BookingResult, error) {
+	return service.BookClass(models.BookingRequest{ClassName: className, MemberName: memberName, Date: "2025-06-10", PaymentMethod: "pm_card", PromoCode: code})
+}
+
+func TestClassService_CreatePromoCode(t *testing.T) {
+	service := newPromoService(t)
+
+	promo, err := service.CreatePromoCode(models.PromoCodeRequest{Code: " summer ", Kind: constants.PromoFixed, Amount: 500, Currency: "eur",
+		MaxRedemptions: 10, ClassNames: []string{"Yoga", "Yoga"}, ValidFrom: "2025-06-01", ValidUntil: "2025-06-30"})
+	require.NoError(t, err)
+	from, until := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "SUMMER", promo.Code)
+	assert.Equal(t, "EUR", promo.Currency)
+	assert.Equal(t, []string{"Yoga"}, promo.ClassNames)
+	assert.Equal(t, &from, promo.ValidFrom)
+	assert.Equal(t, &until, promo.ValidUntil)
+	stored, err := service.GetPromoCode("Summer")
+	require.NoError(t, err)
+	assert.Equal(t, promo, stored)
+
+	_, err = service.CreatePromoCode(models.PromoCodeRequest{Code: "SUMMER", Kind: constants.PromoFirstClass})
+	assert.ErrorIs(t, err, constants.ErrPromoExists)
+
+	for field, req := range map[string]models.PromoCodeRequest{
+		"code":        {Code: "SUMMER SALE", Kind: constants.PromoFirstClass},
+		"percent":     {Code: "HALF", Kind: constants.PromoPercentage},
+		"amount":      {Code: "FIVE", Kind: constants.PromoFixed, Currency: "EUR"},
+		"currency":    {Code: "FIVE", Kind: constants.PromoFixed, Amount: 500, Currency: "euro"},
+		"kind":        {Code: "FREE", Kind: constants.PromoFirstClass, Percent: 100},
+		"class_names": {Code: "BOX", Kind: constants.PromoFirstClass, ClassNames: []string{"Boxing"}},
+		"valid_until": {Code: "LATE", Kind: constants.PromoFirstClass, ValidFrom: "2025-07-01", ValidUntil: "2025-06-01"},
+	} {
+		_, err := service.CreatePromoCode(req)
+		assert.ErrorIs(t, err, constants.ErrInvalidPromo, field)
+		var apiErr *apierrors.Error
+		require.True(t, errors.As(err, &apiErr), field)
+		assert.Equal(t, field, apiErr.Fields[0].Field)
+	}
+
+	// The code of a promo cannot be changed
+	_, err = service.UpdatePromoCode("summer", models.PromoCodeRequest{Code: "WINTER", Kind: constants.PromoFirstClass})
+	assert.ErrorIs(t, err, constants.ErrInvalidPromo)
+	updated, err := service.UpdatePromoCode("summer", models.PromoCodeRequest{Code: "summer", Kind: constants.PromoPercentage, Percent: 20})
+	require.NoError(t, err)
+	assert.Equal(t, 20, updated.Percent)
+	assert.Nil(t, updated.ValidFrom)
+	assert.Equal(t, promo.CreatedAt, updated.CreatedAt)
+
+	require.NoError(t, service.DeletePromoCode("summer"))
+	_, err = service.GetPromoCode("SUMMER")
+	assert.ErrorIs(t, err, constants.ErrPromoNotFound)
+}
+
+func TestClassService_BookClass_Promo(t *testing.T) {
+	t.Run("Discounted Payment", func(t *testing.T) {
+		service := newPromoService(t)
+		_, err := service.CreatePromoCode(models.PromoCodeRequest{Code: "SUMMER", Kind: constants.PromoPercentage, Percent: 20})
+		require.NoError(t, err)
+
+		result, err := bookWithPromo(service, "Yoga", "Alice", "summer")
+		require.NoError(t, err)
+		assert.Equal(t, constants.BookingStatusBooked, result.Status)
+		assert.Equal(t, &models.Promo{Code: "SUMMER", Discount: 300, Currency: "EUR"}, result.Booking.Promo)
+		assert.Equal(t, int64(1200), result.Booking.Payment.Amount)
+
+		// A member redeems a code once until their booking is cancelled
+		_, err = service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2025-06-11", PaymentMethod: "pm_card", PromoCode: "SUMMER"})
+		assert.ErrorIs(t, err, constants.ErrPromoRedeemed)
+		_, err = service.CancelBooking(result.Booking.ID, result.Booking.Version)
+		require.NoError(t, err)
+		_, err = service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2025-06-11", PaymentMethod: "pm_card", PromoCode: "SUMMER"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Free Booking", func(t *testing.T) {
+		service := newPromoService(t)
+		_, err := service.CreatePromoCode(models.PromoCodeRequest{Code: "WELCOME", Kind: constants.PromoFirstClass})
+		require.NoError(t, err)
+
+		// A booking the code pays for in full needs no payment method
+		result, err := service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2025-06-10", PromoCode: "WELCOME"})
+		require.NoError(t, err)
+		assert.Equal(t, constants.BookingStatusBooked, result.Status)
+		assert.Nil(t, result.Booking.Payment)
+		assert.Equal(t, &models.Promo{Code: "WELCOME", Discount: 1500, Currency: "EUR"}, result.Booking.Promo)
+
+		// First class codes are for members holding no other booking
+		_, err = bookWithPromo(service, "Yoga", "Bob", "")
+		require.NoError(t, err)
+		_, err = service.BookClass(models.BookingRequest{ClassName: "Pilates", MemberName: "Bob", Date: "2025-06-10", PromoCode: "WELCOME"})
+		assert.ErrorIs(t, err, constants.ErrPromoNotApplicable)
+	})
+
+	t.Run("Not Applicable", func(t *testing.T) {
+		service := newPromoService(t)
+		_, err := service.CreatePromoCode(models.PromoCodeRequest{Code: "YOGA", Kind: constants.PromoFixed, Amount: 500, Currency: "EUR",
+			ClassNames: []string{"Yoga"}, ValidUntil: "2025-06-09", MaxRedemptions: 1})
+		require.NoError(t, err)
+		_, err = service.CreatePromoCode(models.PromoCodeRequest{Code: "HALF", Kind: constants.PromoPercentage, Percent: 50})
+		require.NoError(t, err)
+		_, err = service.CreatePromoCode(models.PromoCodeRequest{Code: "USD", Kind: constants.PromoFixed, Amount: 500, Currency: "USD"})
+		require.NoError(t, err)
+		_, err = service.CreatePromoCode(models.PromoCodeRequest{Code: "WELCOME", Kind: constants.PromoFirstClass})
+		require.NoError(t, err)
+
+		for name, tt := range map[string]struct {
+			className, memberName, code string
+			expected                    error
+		}{
+			"Unknown Code":       {"Yoga", "Alice", "WINTER", constants.ErrPromoNotFound},
+			"Other Class":        {"Pilates", "Alice", "YOGA", constants.ErrPromoNotApplicable},
+			"Expired":            {"Yoga", "Alice", "YOGA", constants.ErrPromoNotApplicable},
+			"Free Class":         {"Pilates", "Alice", "HALF", constants.ErrPromoNotApplicable},
+			"Members Only Class": {"Pilates", "Alice", "WELCOME", constants.ErrPromoNotApplicable},
+			"Other Currency":     {"Yoga", "Alice", "USD", constants.ErrPromoNotApplicable},
+		} {
+			_, err := bookWithPromo(service, tt.className, tt.memberName, tt.code)
+			assert.ErrorIs(t, err, tt.expected, name)
+			var apiErr *apierrors.Error
+			require.True(t, errors.As(err, &apiErr), name)
+			assert.Equal(t, "promo_code", apiErr.Fields[0].Field, name)
+		}
+		assert.Empty(t, service.bookingRepo.Query(models.BookingFilter{}))
+	})
+
+	t.Run("Covered Membership", func(t *testing.T) {
+		service := newPromoService(t)
+		_, err := service.CreatePromoCode(models.PromoCodeRequest{Code: "HALF", Kind: constants.PromoPercentage, Percent: 50, MaxRedemptions: 1})
+		require.NoError(t, err)
+		plan, err := service.CreatePlan(models.PlanRequest{Name: "Unlimited", Kind: constants.PlanUnlimited})
+		require.NoError(t, err)
+		carol := service.memberRepo.FindByName("Carol")[0]
+		_, err = service.PurchaseMembership(carol.ID, models.MembershipRequest{PlanID: plan.ID})
+		require.NoError(t, err)
+
+		// A member whose membership covers the class books without redeeming the code
+		result, err := bookWithPromo(service, "Yoga", "Carol", "HALF")
+		require.NoError(t, err)
+		assert.Equal(t, constants.BookingStatusBooked, result.Status)
+		assert.Nil(t, result.Booking.Promo)
+		assert.Nil(t, result.Booking.Payment)
+		_, err = bookWithPromo(service, "Yoga", "Alice", "HALF")
+		assert.NoError(t, err)
+	})
+}
+
+func TestClassService_PromoRedemptions(t *testing.T) {
+	service := newPromoService(t)
+	_, err := service.CreatePromoCode(models.PromoCodeRequest{Code: "SUMMER", Kind: constants.PromoFixed, Amount: 500, Currency: "EUR", MaxRedemptions: 2})
+	require.NoError(t, err)
+
+	alice, err := bookWithPromo(service, "Yoga", "Alice", "SUMMER")
+	require.NoError(t, err)
+	_, err = bookWithPromo(service, "Yoga", "Bob", "SUMMER")
+	require.NoError(t, err)
+	_, err = bookWithPromo(service, "Yoga", "Carol", "SUMMER")
+	assert.ErrorIs(t, err, constants.ErrPromoExhausted)
+	_, err = service.CancelBooking(alice.Booking.ID, alice.Booking.Version)
+	require.NoError(t, err)
+
+	// Cancelled bookings are listed but gave their redemption back
+	report, err := service.PromoRedemptions("summer")
+	require.NoError(t, err)
+	assert.Equal(t, "SUMMER", report.PromoCode.Code)
+	assert.Equal(t, 1, report.Redemptions)
+	assert.Equal(t, 1, *report.Remaining)
+	assert.Equal(t, map[string]int64{"EUR": 500}, report.Discounts)
+	assert.Len(t, report.Bookings, 2)
+
+	_, err = service.PromoRedemptions("WINTER")
+	assert.ErrorIs(t, err, constants.ErrPromoNotFound)
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"fmt"
+	"glofox/internal/apierrors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/utils"
+	"log"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CreateRoom registers a new room
+func (service *ClassService) CreateRoom(req models.RoomRequest) (room models.Room, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	now := service.now().UTC()
+	room = models.Room{ID: utils.NewID("rm_"), CreatedAt: now}
+	room, err = applyRoomRequest(room, req, now)
+	if err != nil {
+		return models.Room{}, err
+	}
+	if err := service.roomRepo.Create(room); err != nil {
+		return models.Room{}, err
+	}
+	return room, nil
+}
+
+// GetRoom fetches a room by ID
+func (service *ClassService) GetRoom(id string) (models.Room, error) {
+	room, exists := service.roomRepo.GetByID(id)
+	if !exists {
+		return models.Room{}, constants.ErrRoomNotFound
+	}
+	return room, nil
+}
+
+// ListRooms returns a page of rooms sorted by ID
+func (service *ClassService) ListRooms(req models.ListRequest) (models.Page[models.Room], error) {
+	var afterID string
+	if req.Cursor != "" {
+		if err := utils.DecodeCursor(req.Cursor, &afterID); err != nil {
+			return models.Page[models.Room]{}, err
+		}
+	}
+
+	// Fetch one extra room to know whether there is a next page
+	limit := utils.PageLimit(req.Limit)
+	rooms := service.roomRepo.List(afterID, limit+1)
+
+	page := models.Page[models.Room]{Items: rooms}
+	if len(rooms) > limit {
+		page.Items = rooms[:limit]
+		page.NextCursor = utils.EncodeCursor(page.Items[limit-1].ID)
+	}
+	return page, nil
+}
+
+// UpdateRoom replaces the details of a room. The classes of the room must
+// still fit its capacity and find their equipment in it.
+func (service *ClassService) UpdateRoom(id string, req models.RoomRequest) (room models.Room, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	// Classes are checked against their room under scheduleMu
+	service.scheduleMu.Lock()
+	defer service.scheduleMu.Unlock()
+
+	room, exists := service.roomRepo.GetByID(id)
+	if !exists {
+		return models.Room{}, constants.ErrRoomNotFound
+	}
+	room, err = applyRoomRequest(room, req, service.now().UTC())
+	if err != nil {
+		return models.Room{}, err
+	}
+	service.eachClass(func(class models.Class) bool {
+		if class.RoomID == id {
+			err = fitsRoom(class, room)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return models.Room{}, err
+	}
+	if err := service.roomRepo.Update(room); err != nil {
+		return models.Room{}, err
+	}
+	return room, nil
+}
+
+// DeleteRoom removes a room that no class runs in
+func (service *ClassService) DeleteRoom(id string) (err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	service.scheduleMu.Lock()
+	defer service.scheduleMu.Unlock()
+
+	var used string
+	service.eachClass(func(class models.Class) bool {
+		if class.RoomID == id {
+			used = class.Name
+		}
+		return used == ""
+	})
+	if used != "" {
+		return fmt.Errorf("%w: %s runs in room %s", constants.ErrRoomInUse, used, id)
+	}
+	return service.roomRepo.Delete(id)
+}
+
+// RoomUtilization returns the sessions held in a room in the week starting on
+// the requested date, and how many of the seats of the room they booked
+func (service *ClassService) RoomUtilization(id string, req models.ScheduleRequest) (utilization models.RoomUtilization, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	room, exists := service.roomRepo.GetByID(id)
+	if !exists {
+		return models.RoomUtilization{}, constants.ErrRoomNotFound
+	}
+	first, from, to, err := service.week(req.From)
+	if err != nil {
+		return models.RoomUtilization{}, err
+	}
+	utilization = models.RoomUtilization{RoomID: id, From: from, To: to, Sessions: []models.RoomSession{}}
+
+	service.eachClass(func(class models.Class) bool {
+		if class.RoomID != id {
+			return true
+		}
+		loc := utils.ClassLocation(class)
+		// Classes in other time zones may have sessions of the week on the dates around it
+		forEachSession(class, first.AddDate(0, 0, -1), first.AddDate(0, 0, 7), func(session models.Session) bool {
+			if session.Date.Before(from) || !session.Date.Before(to) {
+				return true
+			}
+			booked := service.bookingRepo.Count(class.Name, session.Date)
+			utilization.ScheduledMinutes += int(session.End.Sub(session.Date) / time.Minute)
+			utilization.Seats += room.Capacity
+			utilization.Booked += booked
+			session = localSession(session, loc)
+			utilization.Sessions = append(utilization.Sessions, models.RoomSession{
+				ClassName: class.Name,
+				Date:      session.Date,
+				End:       session.End,
+				Capacity:  class.Capacity,
+				Booked:    booked,
+			})
+			return true
+		})
+		return true
+	})
+	sort.SliceStable(utilization.Sessions, func(i, j int) bool {
+		return utilization.Sessions[i].Date.Before(utilization.Sessions[j].Date)
+	})
+	if utilization.Seats > 0 {
+		utilization.Occupancy = float64(utilization.Booked) / float64(utilization.Seats)
+	}
+	return utilization, nil
+}
+
+// applyRoomRequest copies the requested details onto a room. Equipment names
+// and spot labels are unique within a room, ignoring case, and no two spots
+// share a place in the layout.
+func applyRoomRequest(room models.Room, req models.RoomRequest, now time.Time) (models.Room, error) {
+	room.Name = strings.TrimSpace(req.Name)
+	if room.Name == "" {
+		return models.Room{}, apierrors.Field(fmt.Errorf("%w: name cannot be blank", constants.ErrInvalidRoom), "name")
+	}
+	if req.Capacity < 1 {
+		return models.Room{}, apierrors.Field(fmt.Errorf("%w: capacity must be at least 1", constants.ErrInvalidRoom), "capacity")
+	}
+	room.Capacity = req.Capacity
+
+	room.Resources = nil
+	seen := make(map[string]bool)
+	for _, resourceReq := range req.Resources {
+		name := strings.TrimSpace(resourceReq.Name)
+		if name == "" || resourceReq.Quantity < 1 || seen[strings.ToLower(name)] {
+			return models.Room{}, apierrors.Field(fmt.Errorf("%w: equipment needs a unique name and a quantity of at least 1", constants.ErrInvalidRoom), "resources")
+		}
+		seen[strings.ToLower(name)] = true
+		room.Resources = append(room.Resources, models.Resource{Name: name, Quantity: resourceReq.Quantity})
+	}
+
+	room.Spots = nil
+	labels := make(map[string]bool)
+	places := make(map[[2]int]bool)
+	for _, spotReq := range req.Spots {
+		label, place := strings.TrimSpace(spotReq.Label), [2]int{spotReq.Row, spotReq.Column}
+		if label == "" || spotReq.Row < 1 || spotReq.Column < 1 || labels[strings.ToLower(label)] || places[place] {
+			return models.Room{}, apierrors.Field(fmt.Errorf("%w: spots need a unique label and a free row and column of at least 1", constants.ErrInvalidRoom), "spots")
+		}
+		labels[strings.ToLower(label)], places[place] = true, true
+		room.Spots = append(room.Spots, models.Spot{Label: label, Row: spotReq.Row, Column: spotReq.Column})
+	}
+	room.UpdatedAt = now
+	return room, nil
+}
+
+// checkRoom checks that the room of a class exists and holds the class. A
+// class without a room uses no equipment.
+func (service *ClassService) checkRoom(class models.Class) error {
+	if class.RoomID == "" {
+		if class.Resource != "" {
+			return apierrors.Field(fmt.Errorf("%w: %s, the class has no room", constants.ErrUnknownResource, class.Resource), "resource")
+		}
+		return nil
+	}
+	room, exists := service.roomRepo.GetByID(class.RoomID)
+	if !exists {
+		return apierrors.Field(constants.ErrRoomNotFound, "room_id")
+	}
+	return fitsRoom(class, room)
+}
+
+// fitsRoom checks that the capacity of a class fits its room, seat layout and equipment
+func fitsRoom(class models.Class, room models.Room) error {
+	if class.Capacity > room.Capacity {
+		return apierrors.Field(fmt.Errorf("%w: %s holds %d, %s needs %d", constants.ErrCapacityExceedsRoom, room.Name, room.Capacity, class.Name, class.Capacity), "capacity")
+	}
+	if len(room.Spots) > 0 && class.Capacity > len(room.Spots) {
+		return apierrors.Field(fmt.Errorf("%w: %s has %d spots, %s needs %d", constants.ErrCapacityExceedsRoom, room.Name, len(room.Spots), class.Name, class.Capacity), "capacity")
+	}
+	if class.Resource == "" {
+		return nil
+	}
+	resource, ok := findResource(room, class.Resource)
+	if !ok {
+		return apierrors.Field(fmt.Errorf("%w: %s has no %s", constants.ErrUnknownResource, room.Name, class.Resource), "resource")
+	}
+	if class.Capacity > resource.Quantity {
+		return apierrors.Field(fmt.Errorf("%w: %s has %d %s, %s needs %d", constants.ErrCapacityExceedsRoom, room.Name, resource.Quantity, resource.Name, class.Name, class.Capacity), "capacity")
+	}
+	return nil
+}
+
+// findResource returns the equipment of a room with the given name, ignoring case
+func findResource(room models.Room, name string) (models.Resource, bool) {
+	for _, resource := range room.Resources {
+		if strings.EqualFold(resource.Name, name) {
+			return resource, true
+		}
+	}
+	return models.Resource{}, false
+}
+
+// spots returns the seat layout of the room of a class, empty when the class
+// has no room or its room no layout
+func (service *ClassService) spots(class models.Class) []models.Spot {
+	if class.RoomID == "" {
+		return nil
+	}
+	room, _ := service.roomRepo.GetByID(class.RoomID)
+	return room.Spots
+}
+
+// sessionSpots returns the seat layout of a session with the spots its
+// active bookings hold
+func (service *ClassService) sessionSpots(class models.Class, date time.Time, spots []models.Spot) []models.SessionSpot {
+	taken := make(map[string]bool)
+	for _, booking := range service.bookingRepo.Query(models.BookingFilter{ClassName: class.Name, From: date, To: date}) {
+		if booking.Status != constants.BookingStatusCancelled {
+			taken[booking.Spot] = true
+		}
+	}
+	sessionSpots := make([]models.SessionSpot, len(spots))
+	for i, spot := range spots {
+		sessionSpots[i] = models.SessionSpot{Spot: spot, Taken: taken[spot.Label]}
+	}
+	return sessionSpots
+}
+
+// checkRoomConflicts returns ErrRoomConflict when a session of the class
+// overlaps a session of another class in the same room
+func (service *ClassService) checkRoomConflicts(class models.Class) error {
+	if class.RoomID == "" {
+		return nil
+	}
+	var sessions []models.Session
+	forEachSession(class, class.StartDate, class.EndDate, func(session models.Session) bool {
+		sessions = append(sessions, session)
+		return true
+	})
+
+	var conflict error
+	service.eachClass(func(other models.Class) bool {
+		if other.Name == class.Name || other.RoomID != class.RoomID {
+			return true
+		}
+		// The dates of classes in other time zones may be a day apart
+		forEachSession(other, class.StartDate.AddDate(0, 0, -1), class.EndDate.AddDate(0, 0, 1), func(session models.Session) bool {
+			for _, mine := range sessions {
+				if overlaps(mine, session) {
+					conflict = fmt.Errorf("%w: %s uses room %s at %s", constants.ErrRoomConflict, other.Name, class.RoomID,
+						session.Date.In(utils.ClassLocation(other)).Format(time.RFC3339))
+					return false
+				}
+			}
+			return true
+		})
+		return conflict == nil
+	})
+	return conflict
+}
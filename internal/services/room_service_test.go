@@ -0,0 +1,365 @@
+package services
+
+import (
+	"glofox/internal/apierrors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockRoomRepo mocks the RoomRepo
+type MockRoomRepo struct {
+	mock.Mock
+}
+
+func (m *MockRoomRepo) Create(room models.Room) error {
+	args := m.Called(room)
+	return args.Error(0)
+}
+
+func (m *MockRoomRepo) GetByID(id string) (models.Room, bool) {
+	args := m.Called(id)
+	room, _ := args.Get(0).(models.Room)
+	return room, args.Bool(1)
+}
+
+func (m *MockRoomRepo) Update(room models.Room) error {
+	args := m.Called(room)
+	return args.Error(0)
+}
+
+func (m *MockRoomRepo) Delete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockRoomRepo) List(afterID string, limit int) []models.Room {
+	args := m.Called(afterID, limit)
+	rooms, _ := args.Get(0).([]models.Room)
+	return rooms
+}
+
+// spinRoom returns a room for 20 with 12 bikes and 20 mats
+func spinRoom() models.Room {
+	return models.Room{
+		ID:        "rm_1",
+		Name:      "Studio 1",
+		Capacity:  20,
+		Resources: []models.Resource{{Name: "Bikes", Quantity: 12}, {Name: "Mats", Quantity: 20}},
+	}
+}
+
+// reformerRoom returns a room for 10 with a layout of three reformers
+func reformerRoom() models.Room {
+	return models.Room{
+		ID:       "rm_3",
+		Name:     "Reformer Studio",
+		Capacity: 10,
+		Spots:    []models.Spot{{Label: "A1", Row: 1, Column: 1}, {Label: "A2", Row: 1, Column: 2}, {Label: "B1", Row: 2, Column: 1}},
+	}
+}
+
+// newMockRoomRepo returns a MockRoomRepo that knows the spin room as rm_1,
+// an empty room for 8 as rm_2 and the reformer room as rm_3, and no other room
+func newMockRoomRepo() *MockRoomRepo {
+	repo := new(MockRoomRepo)
+	repo.On("GetByID", "rm_1").Return(spinRoom(), true)
+	repo.On("GetByID", "rm_2").Return(models.Room{ID: "rm_2", Name: "Studio 2", Capacity: 8}, true)
+	repo.On("GetByID", "rm_3").Return(reformerRoom(), true)
+	repo.On("GetByID", mock.Anything).Return(models.Room{}, false)
+	return repo
+}
+
+// roomClass returns a class running daily from 1 to 20 June 2025 with one
+// session a day at start, held in a room
+func roomClass(name, start, roomID string) models.Class {
+	class := taughtClass(name, start, "")
+	class.RoomID = roomID
+	return class
+}
+
+func TestClassService_CreateRoom(t *testing.T) {
+	mockRoomRepo := new(MockRoomRepo)
+	service := NewClassService(Dependencies{Rooms: mockRoomRepo})
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	mockRoomRepo.On("Create", mock.Anything).Return(nil)
+
+	room, err := service.CreateRoom(models.RoomRequest{
+		Name:      " Studio 1 ",
+		Capacity:  20,
+		Resources: []models.ResourceRequest{{Name: " Bikes", Quantity: 12}},
+	})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(room.ID, "rm_"))
+	assert.Equal(t, models.Room{
+		ID:        room.ID,
+		Name:      "Studio 1",
+		Capacity:  20,
+		Resources: []models.Resource{{Name: "Bikes", Quantity: 12}},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, room)
+	mockRoomRepo.AssertCalled(t, "Create", room)
+
+	// Equipment names are unique, ignoring case
+	_, err = service.CreateRoom(models.RoomRequest{
+		Name:      "Studio 2",
+		Capacity:  20,
+		Resources: []models.ResourceRequest{{Name: "Mats", Quantity: 5}, {Name: "mats", Quantity: 5}},
+	})
+	assert.ErrorIs(t, err, constants.ErrInvalidRoom)
+
+	// Two spots cannot share a place in the layout
+	_, err = service.CreateRoom(models.RoomRequest{
+		Name:     "Studio 3",
+		Capacity: 20,
+		Spots:    []models.SpotRequest{{Label: "1", Row: 1, Column: 1}, {Label: "2", Row: 1, Column: 1}},
+	})
+	assert.ErrorIs(t, err, constants.ErrInvalidRoom)
+	mockRoomRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestClassService_CreateClass_Rooms(t *testing.T) {
+	spin := roomClass("Spin", "07:00", "rm_1")
+	dublinSpin := spin
+	dublinSpin.TimeZone = "Europe/Dublin"
+
+	tests := []struct {
+		name          string
+		existing      models.Class
+		req           models.ClassRequest
+		expectedErr   error
+		expectedField string
+	}{
+		{
+			name:     "Fits Room And Equipment",
+			existing: spin,
+			req:      models.ClassRequest{Capacity: 12, RoomID: "rm_1", Resource: "bikes", SessionTimes: []models.SessionTimeRequest{{Start: "08:00"}}},
+		},
+		{
+			name:          "Larger Than Room",
+			existing:      spin,
+			req:           models.ClassRequest{Capacity: 10, RoomID: "rm_2", SessionTimes: []models.SessionTimeRequest{{Start: "07:00"}}},
+			expectedErr:   constants.ErrCapacityExceedsRoom,
+			expectedField: "capacity",
+		},
+		{
+			name:          "More Seats Than Equipment",
+			existing:      spin,
+			req:           models.ClassRequest{Capacity: 15, RoomID: "rm_1", Resource: "Bikes", SessionTimes: []models.SessionTimeRequest{{Start: "08:00"}}},
+			expectedErr:   constants.ErrCapacityExceedsRoom,
+			expectedField: "capacity",
+		},
+		{
+			name:          "Equipment Missing From Room",
+			existing:      spin,
+			req:           models.ClassRequest{Capacity: 5, RoomID: "rm_2", Resource: "Bikes", SessionTimes: []models.SessionTimeRequest{{Start: "07:00"}}},
+			expectedErr:   constants.ErrUnknownResource,
+			expectedField: "resource",
+		},
+		{
+			name:          "Equipment Without Room",
+			existing:      spin,
+			req:           models.ClassRequest{Capacity: 5, Resource: "Bikes", SessionTimes: []models.SessionTimeRequest{{Start: "07:00"}}},
+			expectedErr:   constants.ErrUnknownResource,
+			expectedField: "resource",
+		},
+		{
+			name:          "More Seats Than Spots",
+			existing:      spin,
+			req:           models.ClassRequest{Capacity: 4, RoomID: "rm_3", SessionTimes: []models.SessionTimeRequest{{Start: "07:00"}}},
+			expectedErr:   constants.ErrCapacityExceedsRoom,
+			expectedField: "capacity",
+		},
+		{
+			name:          "Unknown Room",
+			existing:      spin,
+			req:           models.ClassRequest{Capacity: 5, RoomID: "rm_9"},
+			expectedErr:   constants.ErrRoomNotFound,
+			expectedField: "room_id",
+		},
+		{
+			name:        "Overlapping Session",
+			existing:    spin,
+			req:         models.ClassRequest{Capacity: 5, RoomID: "rm_1", SessionTimes: []models.SessionTimeRequest{{Start: "07:30"}}},
+			expectedErr: constants.ErrRoomConflict,
+		},
+		{
+			name:     "Other Room",
+			existing: spin,
+			req:      models.ClassRequest{Capacity: 5, RoomID: "rm_2", SessionTimes: []models.SessionTimeRequest{{Start: "07:00"}}},
+		},
+		{
+			name:        "Overlap Across Time Zones",
+			existing:    dublinSpin,
+			req:         models.ClassRequest{Capacity: 5, RoomID: "rm_1", SessionTimes: []models.SessionTimeRequest{{Start: "06:00"}}},
+			expectedErr: constants.ErrRoomConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClassRepo := new(MockClassRepo)
+			mockClassRepo.On("List", "", constants.MaxPageLimit).Return([]models.Class{tt.existing})
+			mockClassRepo.On("Create", mock.Anything).Return(nil)
+			service := NewClassService(Dependencies{Classes: mockClassRepo, Instructors: newMockInstructorRepo(), Rooms: newMockRoomRepo()})
+
+			req := tt.req
+			req.Name, req.StartDate, req.EndDate = "Yoga", "2025-06-01", "2025-06-30"
+			_, err := service.CreateClass(req)
+
+			if tt.expectedErr == nil {
+				assert.NoError(t, err)
+				mockClassRepo.AssertNumberOfCalls(t, "Create", 1)
+				return
+			}
+			assert.ErrorIs(t, err, tt.expectedErr)
+			if tt.expectedField != "" {
+				var apiErr *apierrors.Error
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, tt.expectedField, apiErr.Fields[0].Field)
+			}
+			mockClassRepo.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+}
+
+func TestClassService_UpdateRoom(t *testing.T) {
+	spin := roomClass("Spin", "07:00", "rm_1")
+	spin.Capacity, spin.Resource = 12, "Bikes"
+	mockClassRepo, mockRoomRepo := new(MockClassRepo), newMockRoomRepo()
+	mockClassRepo.On("List", "", constants.MaxPageLimit).Return([]models.Class{spin})
+	mockRoomRepo.On("Update", mock.Anything).Return(nil)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Rooms: mockRoomRepo})
+
+	// The classes of the room must still fit it
+	tests := []struct {
+		req         models.RoomRequest
+		expectedErr error
+	}{
+		{req: models.RoomRequest{Name: "Studio 1", Capacity: 10, Resources: []models.ResourceRequest{{Name: "Bikes", Quantity: 12}}}, expectedErr: constants.ErrCapacityExceedsRoom},
+		{req: models.RoomRequest{Name: "Studio 1", Capacity: 20, Resources: []models.ResourceRequest{{Name: "Bikes", Quantity: 10}}}, expectedErr: constants.ErrCapacityExceedsRoom},
+		{req: models.RoomRequest{Name: "Studio 1", Capacity: 20, Resources: []models.ResourceRequest{{Name: "Mats", Quantity: 20}}}, expectedErr: constants.ErrUnknownResource},
+		{req: models.RoomRequest{Name: "Spin Studio", Capacity: 12, Resources: []models.ResourceRequest{{Name: "Bikes", Quantity: 12}}}},
+	}
+	for _, tt := range tests {
+		room, err := service.UpdateRoom("rm_1", tt.req)
+		if tt.expectedErr != nil {
+			assert.ErrorIs(t, err, tt.expectedErr)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, "Spin Studio", room.Name)
+	}
+	mockRoomRepo.AssertNumberOfCalls(t, "Update", 1)
+
+	_, err := service.UpdateRoom("rm_9", tests[3].req)
+	assert.Equal(t, constants.ErrRoomNotFound, err)
+}
+
+func TestClassService_DeleteRoom(t *testing.T) {
+	mockClassRepo, mockRoomRepo := new(MockClassRepo), new(MockRoomRepo)
+	mockClassRepo.On("List", "", constants.MaxPageLimit).Return([]models.Class{roomClass("Spin", "07:00", "rm_1")})
+	mockRoomRepo.On("Delete", "rm_2").Return(nil)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Rooms: mockRoomRepo})
+
+	assert.ErrorIs(t, service.DeleteRoom("rm_1"), constants.ErrRoomInUse)
+	assert.NoError(t, service.DeleteRoom("rm_2"))
+	mockRoomRepo.AssertNumberOfCalls(t, "Delete", 1)
+}
+
+func TestClassService_RoomUtilization(t *testing.T) {
+	mockClassRepo, mockBookingRepo := new(MockClassRepo), new(MockBookingRepo)
+	mockClassRepo.On("List", "", constants.MaxPageLimit).Return([]models.Class{
+		roomClass("Spin", "07:00", "rm_1"),
+		roomClass("Boxing", "07:00", "rm_2"),
+		roomClass("Pilates", "18:00", "rm_1"),
+	})
+	mockBookingRepo.On("Count", "Spin", time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC)).Return(2)
+	mockBookingRepo.On("Count", "Pilates", time.Date(2025, 6, 10, 18, 0, 0, 0, time.UTC)).Return(1)
+	mockBookingRepo.On("Count", mock.Anything, mock.Anything).Return(0)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Rooms: newMockRoomRepo()})
+
+	utilization, err := service.RoomUtilization("rm_1", models.ScheduleRequest{From: "2025-06-09"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC), utilization.From)
+	assert.Equal(t, time.Date(2025, 6, 16, 0, 0, 0, 0, time.UTC), utilization.To)
+
+	// Two classes a day for a week, each seating the 20 of the room
+	require.Len(t, utilization.Sessions, 14)
+	assert.Equal(t, 14*constants.DefaultSessionMinutes, utilization.ScheduledMinutes)
+	assert.Equal(t, 14*20, utilization.Seats)
+	assert.Equal(t, 3, utilization.Booked)
+	assert.InDelta(t, 3.0/280, utilization.Occupancy, 1e-9)
+	end := time.Date(2025, 6, 10, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, models.RoomSession{ClassName: "Spin", Date: time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC), End: &end, Capacity: 2, Booked: 2}, utilization.Sessions[2])
+	assert.Equal(t, "Pilates", utilization.Sessions[3].ClassName)
+
+	_, err = service.RoomUtilization("rm_9", models.ScheduleRequest{})
+	assert.Equal(t, constants.ErrRoomNotFound, err)
+}
+
+func TestClassService_BookClass_Spots(t *testing.T) {
+	reformer := roomClass("Reformer", "07:00", "rm_3")
+	session := time.Date(2025, 6, 10, 7, 0, 0, 0, time.UTC)
+	layout := models.BookingLimits{Capacity: 2, Spots: []string{"A1", "A2", "B1"}}
+	mockClassRepo, mockBookingRepo := new(MockClassRepo), new(MockBookingRepo)
+	mockClassRepo.On("GetByName", "Reformer").Return(reformer, true)
+	mockWaitlistRepo := new(MockWaitlistRepo)
+	mockWaitlistRepo.On("Leave", "Reformer", "mb_alice", session).Return(constants.ErrNotOnWaitlist)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Waitlists: mockWaitlistRepo, Members: aliceMemberRepo(), Rooms: newMockRoomRepo()})
+
+	// The spot is matched to the layout ignoring case
+	requested := aliceBooking("Reformer", session)
+	requested.Spot = "A2"
+	mockBookingRepo.On("Create", requested, layout).Return(requested, nil).Once()
+	result, err := service.BookClass(models.BookingRequest{ClassName: "Reformer", MemberID: "mb_alice", Date: "2025-06-10", Spot: " a2"})
+	require.NoError(t, err)
+	assert.Equal(t, "A2", result.Booking.Spot)
+
+	// The repository assigns a spot when none is requested
+	assigned := aliceBooking("Reformer", session)
+	assigned.Spot = "A1"
+	mockBookingRepo.On("Create", aliceBooking("Reformer", session), layout).Return(assigned, nil).Once()
+	result, err = service.BookClass(models.BookingRequest{ClassName: "Reformer", MemberID: "mb_alice", Date: "2025-06-10"})
+	require.NoError(t, err)
+	assert.Equal(t, "A1", result.Booking.Spot)
+
+	_, err = service.BookClass(models.BookingRequest{ClassName: "Reformer", MemberID: "mb_alice", Date: "2025-06-10", Spot: "C9"})
+	assert.ErrorIs(t, err, constants.ErrUnknownSpot)
+	var apiErr *apierrors.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "spot", apiErr.Fields[0].Field)
+	mockBookingRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestClassService_ListSessions_Spots(t *testing.T) {
+	reformer := roomClass("Reformer", "07:00", "rm_3")
+	reformer.EndDate = reformer.StartDate
+	session := time.Date(2025, 6, 1, 7, 0, 0, 0, time.UTC)
+	mockClassRepo, mockBookingRepo := new(MockClassRepo), new(MockBookingRepo)
+	mockClassRepo.On("GetByName", "Reformer").Return(reformer, true)
+	mockBookingRepo.On("Count", "Reformer", session).Return(1)
+	mockBookingRepo.On("Query", models.BookingFilter{ClassName: "Reformer", From: session, To: session}).Return([]models.Booking{
+		{ClassName: "Reformer", Date: session, Status: constants.BookingStatusCancelled, Spot: "A1"},
+		{ClassName: "Reformer", Date: session, Status: constants.BookingStatusBooked, Spot: "B1"},
+	})
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Rooms: newMockRoomRepo()})
+
+	page, err := service.ListSessions("Reformer", models.ListRequest{})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	room := reformerRoom()
+	assert.Equal(t, []models.SessionSpot{
+		{Spot: room.Spots[0], Taken: false},
+		{Spot: room.Spots[1], Taken: false},
+		{Spot: room.Spots[2], Taken: true},
+	}, page.Items[0].Spots)
+}
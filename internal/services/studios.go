@@ -0,0 +1,98 @@
+package services
+
+import (
+	"glofox/internal/payments"
+	"glofox/internal/repository"
+	"log"
+	"sync"
+	"time"
+)
+
+// Studios creates the ClassService of each studio on first use and keeps it,
+// so that every request of a studio shares the locks of one service
+type Studios struct {
+	repos             repository.Studios
+	payments          payments.PaymentProvider
+	paymentTimeout    time.Duration
+	location          *time.Location
+	dailyBookingLimit int
+	// Key: studio ID
+	services map[string]*ClassService
+	mu       sync.Mutex
+}
+
+// NewStudios creates the services of studios stored in repos, all studios use
+// the same payment provider and timeout, default time zone and daily booking limit
+func NewStudios(repos repository.Studios, provider payments.PaymentProvider, paymentTimeout time.Duration, location *time.Location, dailyBookingLimit int) *Studios {
+	return &Studios{
+		repos:             repos,
+		payments:          provider,
+		paymentTimeout:    paymentTimeout,
+		location:          location,
+		dailyBookingLimit: dailyBookingLimit,
+		services:          make(map[string]*ClassService),
+	}
+}
+
+// Service returns the service of a studio
+func (studios *Studios) Service(studioID string) (IService, error) {
+	service, err := studios.service(studioID)
+	if err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+// Exists reports whether the repositories of a studio were created before
+func (studios *Studios) Exists(studioID string) (bool, error) {
+	return studios.repos.Exists(studioID)
+}
+
+// SettlePayments retries the unfinished payments of every stored studio,
+// including those not served since the server started, see
+// ClassService.SettlePayments
+func (studios *Studios) SettlePayments() {
+	ids, err := studios.repos.IDs()
+	if err != nil {
+		log.Printf("Failed to list studios to settle payments: %v", err)
+		return
+	}
+	for _, studioID := range ids {
+		service, err := studios.service(studioID)
+		if err != nil {
+			log.Printf("Failed to open studio %s to settle payments: %v", studioID, err)
+			continue
+		}
+		service.SettlePayments()
+	}
+}
+
+// service returns the service of a studio, created on first use
+func (studios *Studios) service(studioID string) (*ClassService, error) {
+	studios.mu.Lock()
+	defer studios.mu.Unlock()
+
+	service, exists := studios.services[studioID]
+	if !exists {
+		repos, err := studios.repos.Studio(studioID)
+		if err != nil {
+			return nil, err
+		}
+		service = NewClassService(Dependencies{
+			Classes:           repos.Classes,
+			Bookings:          repos.Bookings,
+			Waitlists:         repos.Waitlists,
+			Members:           repos.Members,
+			Instructors:       repos.Instructors,
+			Rooms:             repos.Rooms,
+			Plans:             repos.Plans,
+			Promos:            repos.Promos,
+			Payments:          payments.ForStudio(studios.payments, studioID),
+			PaymentTimeout:    studios.paymentTimeout,
+			Location:          studios.location,
+			DailyBookingLimit: studios.dailyBookingLimit,
+		})
+		studios.services[studioID] = service
+	}
+	return service, nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/payments"
+	"glofox/internal/repository"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// studioService returns the service of a studio, failing the test when it cannot be created
+func studioService(t *testing.T, studios *Studios, studioID string) *ClassService {
+	t.Helper()
+	service, err := studios.service(studioID)
+	require.NoError(t, err)
+	return service
+}
+
+// brokenStudios is a repository.Studios whose studios cannot be opened
+type brokenStudios struct{}
+
+func (brokenStudios) Studio(string) (repository.Repositories, error) {
+	return repository.Repositories{}, errors.New("disk full")
+}
+
+func (brokenStudios) Exists(string) (bool, error) { return false, nil }
+
+func (brokenStudios) IDs() ([]string, error) { return []string{"studio_a"}, nil }
+
+func TestStudios_Service(t *testing.T) {
+	studios := NewStudios(repository.NewMemoryStudios(), nil, 0, time.UTC, 0)
+
+	// Each studio keeps one service
+	exists, err := studios.Exists("studio_a")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.Same(t, studioService(t, studios, "studio_a"), studioService(t, studios, "studio_a"))
+	assert.NotSame(t, studioService(t, studios, "studio_a"), studioService(t, studios, "studio_b"))
+	exists, err = studios.Exists("studio_a")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	_, err = studioService(t, studios, "studio_a").CreateClass(models.ClassRequest{Name: "Yoga", StartDate: "2025-06-01", EndDate: "2025-06-20", Capacity: 10})
+	require.NoError(t, err)
+	_, err = studioService(t, studios, "studio_a").GetClass("Yoga")
+	assert.NoError(t, err)
+	_, err = studioService(t, studios, "studio_b").GetClass("Yoga")
+	assert.ErrorIs(t, err, constants.ErrClassNotFound)
+
+	// A studio whose storage cannot be opened is reported, and retried by the next request
+	studios = NewStudios(brokenStudios{}, nil, 0, time.UTC, 0)
+	service, err := studios.Service("studio_a")
+	assert.Error(t, err)
+	assert.Nil(t, service)
+	assert.Empty(t, studios.services)
+}
+
+func TestStudios_SettlePayments_AfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glofox.db")
+	fake := payments.NewFake("whsec_test")
+	open := func() *Studios {
+		db, err := repository.OpenSQLite(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return NewStudios(repository.NewSQLStudios(db), fake, 0, time.UTC, 0)
+	}
+
+	// A payment the provider did not answer is left pending in a studio, the
+	// class is far in the future as the restarted service runs on the real clock
+	service := studioService(t, open(), "studio_a")
+	_, err := service.CreateClass(models.ClassRequest{Name: "Yoga", StartDate: "2099-06-01", EndDate: "2099-06-20", Capacity: 1,
+		DropIn: &models.PriceRequest{Amount: 1500, Currency: "eur"}})
+	require.NoError(t, err)
+	_, err = service.CreateMember(models.MemberRequest{Name: "Alice"})
+	require.NoError(t, err)
+	fake.SetUnavailable(true)
+	result, err := service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberName: "Alice", Date: "2099-06-10", PaymentMethod: "pm_card"})
+	require.NoError(t, err)
+	require.Equal(t, constants.PaymentPending, result.Booking.Payment.Status)
+
+	// After a restart the studio is settled before any request reaches it
+	fake.SetUnavailable(false)
+	studios := open()
+	studios.SettlePayments()
+	booking, err := studioService(t, studios, "studio_a").GetBooking(result.Booking.ID)
+	require.NoError(t, err)
+	assert.Equal(t, constants.BookingStatusBooked, booking.Status)
+	assert.Equal(t, constants.PaymentCaptured, booking.Payment.Status)
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+// JoinWaitlist puts a member on the waitlist of a full class session
+func (service *ClassService) JoinWaitlist(className, dateStr string, req models.WaitlistRequest) (position models.WaitlistPosition, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	// The checks and the join happen under promoteMu, so that a seat freed
+	// in between is offered to the member once joined, and under classMu,
+	// so that the class cannot change in between. The locks are taken in
+	// the order of promoteWaitlist.
+	service.promoteMu.Lock()
+	defer service.promoteMu.Unlock()
+	service.classMu.RLock()
+	defer service.classMu.RUnlock()
+
+	class, date, err := service.resolveSession(className, dateStr)
+	if err != nil {
+		return models.WaitlistPosition{}, err
+	}
+	member, err := service.activeMember(req.MemberID, req.MemberName)
+	if err != nil {
+		return models.WaitlistPosition{}, err
+	}
+
+	// A member holding a seat would only be turned away once promoted
+	for _, booking := range service.bookingRepo.Query(models.BookingFilter{MemberID: member.ID, ClassName: class.Name, From: date, To: date}) {
+		if booking.Status != constants.BookingStatusCancelled {
+			return models.WaitlistPosition{}, constants.ErrAlreadyBooked
+		}
+	}
+	// Members should book directly while there are seats left
+	if service.bookingRepo.Count(class.Name, date) < class.Capacity {
+		return models.WaitlistPosition{}, constants.ErrSeatsAvailable
+	}
+	// Waitlists hold member IDs
+	place, err := service.waitlistRepo.Join(class.Name, member.ID, date)
+	if err != nil {
+		return models.WaitlistPosition{}, err
+	}
+	return models.WaitlistPosition{MemberID: member.ID, MemberName: member.Name, Position: place}, nil
+}
+
+// LeaveWaitlist removes a member, given by ID or name, from the waitlist of a class session
+func (service *ClassService) LeaveWaitlist(className, member, dateStr string) (err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	class, date, err := service.resolveSession(className, dateStr)
+	if err != nil {
+		return err
+	}
+	found, err := service.findMember(member)
+	if err != nil {
+		return err
+	}
+	return service.waitlistRepo.Leave(class.Name, found.ID, date)
+}
+
+// WaitlistPosition returns the position of a member, given by ID or name, on
+// the waitlist of a class session
+func (service *ClassService) WaitlistPosition(className, member, dateStr string) (position models.WaitlistPosition, err error) {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic recovered: %v\nStack trace:\n%s", r, debug.Stack())
+			err = constants.ErrInternalServer
+		}
+	}()
+
+	class, date, err := service.resolveSession(className, dateStr)
+	if err != nil {
+		return models.WaitlistPosition{}, err
+	}
+	found, err := service.findMember(member)
+	if err != nil {
+		return models.WaitlistPosition{}, err
+	}
+	place, err := service.waitlistRepo.Position(class.Name, found.ID, date)
+	if err != nil {
+		return models.WaitlistPosition{}, err
+	}
+	return models.WaitlistPosition{MemberID: found.ID, MemberName: found.Name, Position: place}, nil
+}
+
+// promoteWaitlist books members from the head of the waitlist while seats are free
+func (service *ClassService) promoteWaitlist(className string, date time.Time) {
+	// Serialize promotions so two cancellations cannot promote the same member twice
+	service.promoteMu.Lock()
+	defer service.promoteMu.Unlock()
+
+	for {
+		entry, ok := service.waitlistRepo.Peek(className, date)
+		if !ok {
+			return
+		}
+		// Entries written before members were registered hold a member name
+		member, err := service.findMember(entry)
+		if err == nil && member.Status == constants.MemberStatusSuspended {
+			err = constants.ErrMemberSuspended
+		}
+		if err == nil {
+			err = service.promote(className, member, date)
+			if errors.Is(err, constants.ErrClassFull) {
+				// The seat was taken by a concurrent booking, keep the member at the head
+				return
+			}
+			if errors.Is(err, constants.ErrClassNotFound) {
+				// The class was deleted, its waitlists go with it
+				return
+			}
+			if err != nil && !errors.Is(err, constants.ErrAlreadyBooked) && !errors.Is(err, constants.ErrDailyLimitReached) && !errors.Is(err, constants.ErrNoEntitlement) {
+				log.Printf("Failed to promote %s from waitlist of %s: %v", entry, className, err)
+				return
+			}
+			if err == nil {
+				if err := service.waitlistRepo.Leave(className, entry, date); err != nil {
+					log.Printf("Failed to remove promoted member %s from waitlist of %s: %v", entry, className, err)
+				}
+				log.Printf("Promoted %s from waitlist of %s on %s", member.ID, className, date.Format(time.RFC3339))
+				continue
+			}
+		}
+
+		// The member cannot take the seat, offer it to the next one
+		log.Printf("Dropping %s from waitlist of %s: %v", entry, className, err)
+		if err := service.waitlistRepo.Leave(className, entry, date); err != nil {
+			log.Printf("Failed to remove %s from waitlist of %s: %v", entry, className, err)
+			return
+		}
+	}
+}
+
+// promote books a member from the waitlist into the session of a class
+// starting at date. The class is read under classMu, so that the seats
+// follow its capacity as stored.
+func (service *ClassService) promote(className string, member models.Member, date time.Time) error {
+	service.classMu.RLock()
+	defer service.classMu.RUnlock()
+
+	class, exists := service.classRepo.GetByName(className)
+	if !exists {
+		return constants.ErrClassNotFound
+	}
+	limits := service.bookingLimits(class, date)
+	var err error
+	if limits.Charge, err = service.entitlement(member, class, date); err != nil {
+		return err
+	}
+	_, err = service.bookingRepo.Create(models.Booking{
+		ClassName:  class.Name,
+		MemberID:   member.ID,
+		MemberName: member.Name,
+		Date:       date,
+	}, limits)
+	return err
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"glofox/internal/apierrors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"glofox/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (m *MockWaitlistRepo) Join(className, memberName string, date time.Time) (int, error) {
+	args := m.Called(className, memberName, date)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockWaitlistRepo) Leave(className, memberName string, date time.Time) error {
+	args := m.Called(className, memberName, date)
+	return args.Error(0)
+}
+
+func (m *MockWaitlistRepo) Position(className, memberName string, date time.Time) (int, error) {
+	args := m.Called(className, memberName, date)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockWaitlistRepo) Peek(className string, date time.Time) (string, bool) {
+	args := m.Called(className, date)
+	return args.String(0), args.Bool(1)
+}
+
+// yogaClass is the class used by the waitlist tests
+func yogaClass() models.Class {
+	return models.Class{
+		Name:      "Yoga",
+		StartDate: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC),
+		Capacity:  2,
+		Version:   3,
+	}
+}
+
+func TestClassService_JoinWaitlist(t *testing.T) {
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		dateStr          string
+		req              models.WaitlistRequest
+		setupMock        func(*MockClassRepo, *MockBookingRepo, *MockWaitlistRepo)
+		expectedPosition models.WaitlistPosition
+		expectedErr      error
+	}{
+		{
+			name:    "Happy Path",
+			dateStr: "2025-06-10",
+			req:     models.WaitlistRequest{MemberID: "mb_alice"},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				c.On("GetByName", "Yoga").Return(yogaClass(), true)
+				b.On("Query", models.BookingFilter{MemberID: "mb_alice", ClassName: "Yoga", From: date, To: date}).Return([]models.Booking{})
+				b.On("Count", "Yoga", date).Return(2)
+				w.On("Join", "Yoga", "mb_alice", date).Return(1, nil)
+			},
+			expectedPosition: models.WaitlistPosition{MemberID: "mb_alice", MemberName: "Alice", Position: 1},
+		},
+		{
+			name:    "By Member Name",
+			dateStr: "2025-06-10",
+			req:     models.WaitlistRequest{MemberName: "alice"},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				c.On("GetByName", "Yoga").Return(yogaClass(), true)
+				b.On("Query", models.BookingFilter{MemberID: "mb_alice", ClassName: "Yoga", From: date, To: date}).Return([]models.Booking{})
+				b.On("Count", "Yoga", date).Return(2)
+				w.On("Join", "Yoga", "mb_alice", date).Return(2, nil)
+			},
+			expectedPosition: models.WaitlistPosition{MemberID: "mb_alice", MemberName: "Alice", Position: 2},
+		},
+		{
+			name:    "Seats Available",
+			dateStr: "2025-06-10",
+			req:     models.WaitlistRequest{MemberID: "mb_alice"},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				c.On("GetByName", "Yoga").Return(yogaClass(), true)
+				b.On("Query", models.BookingFilter{MemberID: "mb_alice", ClassName: "Yoga", From: date, To: date}).Return([]models.Booking{})
+				b.On("Count", "Yoga", date).Return(1)
+			},
+			expectedErr: constants.ErrSeatsAvailable,
+		},
+		{
+			name:    "Already Waitlisted",
+			dateStr: "2025-06-10",
+			req:     models.WaitlistRequest{MemberID: "mb_alice"},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				c.On("GetByName", "Yoga").Return(yogaClass(), true)
+				b.On("Query", models.BookingFilter{MemberID: "mb_alice", ClassName: "Yoga", From: date, To: date}).Return([]models.Booking{})
+				b.On("Count", "Yoga", date).Return(2)
+				w.On("Join", "Yoga", "mb_alice", date).Return(0, constants.ErrAlreadyWaitlisted)
+			},
+			expectedErr: constants.ErrAlreadyWaitlisted,
+		},
+		{
+			name:    "Already Booked",
+			dateStr: "2025-06-10",
+			req:     models.WaitlistRequest{MemberID: "mb_alice"},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				c.On("GetByName", "Yoga").Return(yogaClass(), true)
+				b.On("Query", models.BookingFilter{MemberID: "mb_alice", ClassName: "Yoga", From: date, To: date}).Return([]models.Booking{
+					{ID: "bk_1", Status: constants.BookingStatusCancelled},
+					{ID: "bk_2", Status: constants.BookingStatusBooked},
+				})
+			},
+			expectedErr: constants.ErrAlreadyBooked,
+		},
+		{
+			name:    "Class Name As Stored",
+			dateStr: "2025-06-10",
+			req:     models.WaitlistRequest{MemberID: "mb_alice"},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				// The waitlist is keyed by the name of the class as bookings are
+				stored := yogaClass()
+				stored.Name = "Yoga Flow"
+				c.On("GetByName", "Yoga").Return(stored, true)
+				b.On("Query", models.BookingFilter{MemberID: "mb_alice", ClassName: "Yoga Flow", From: date, To: date}).Return([]models.Booking{})
+				b.On("Count", "Yoga Flow", date).Return(2)
+				w.On("Join", "Yoga Flow", "mb_alice", date).Return(1, nil)
+			},
+			expectedPosition: models.WaitlistPosition{MemberID: "mb_alice", MemberName: "Alice", Position: 1},
+		},
+		{
+			name:    "Suspended Member",
+			dateStr: "2025-06-10",
+			req:     models.WaitlistRequest{MemberID: "mb_dave"},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				c.On("GetByName", "Yoga").Return(yogaClass(), true)
+			},
+			expectedErr: constants.ErrMemberSuspended,
+		},
+		{
+			name:    "Class Not Found",
+			dateStr: "2025-06-10",
+			req:     models.WaitlistRequest{MemberID: "mb_alice"},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				c.On("GetByName", "Yoga").Return(models.Class{}, false)
+			},
+			expectedErr: constants.ErrClassNotFound,
+		},
+		{
+			name:    "Invalid Date Format",
+			dateStr: "2025/06/10",
+			req:     models.WaitlistRequest{MemberID: "mb_alice"},
+			setupMock: func(c *MockClassRepo, b *MockBookingRepo, w *MockWaitlistRepo) {
+				c.On("GetByName", "Yoga").Return(yogaClass(), true)
+			},
+			expectedErr: apierrors.Field(constants.ErrInvalidDate, "date"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClassRepo, mockBookingRepo, mockWaitlistRepo := new(MockClassRepo), new(MockBookingRepo), new(MockWaitlistRepo)
+			tt.setupMock(mockClassRepo, mockBookingRepo, mockWaitlistRepo)
+			service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Waitlists: mockWaitlistRepo, Members: waitlistMembers()})
+
+			position, err := service.JoinWaitlist("Yoga", tt.dateStr, tt.req)
+
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedPosition, position)
+			mockBookingRepo.AssertExpectations(t)
+			mockWaitlistRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// joinHookRepo is a WaitlistRepository that calls beforeJoin before joining a waitlist
+type joinHookRepo struct {
+	repository.WaitlistRepository
+	beforeJoin func()
+}
+
+func (repo joinHookRepo) Join(className, memberName string, date time.Time) (int, error) {
+	repo.beforeJoin()
+	return repo.WaitlistRepository.Join(className, memberName, date)
+}
+
+func TestClassService_JoinWaitlist_ConcurrentCancel(t *testing.T) {
+	service := newStudioService(t, 1, 2)
+	session := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	result, err := service.BookClass(models.BookingRequest{ClassName: "Yoga", MemberName: "Member 0", Date: "2025-06-10"})
+	require.NoError(t, err)
+
+	// A seat freed between the checks and the join is offered to the member once joined
+	cancelled := make(chan error, 1)
+	service.waitlistRepo = joinHookRepo{WaitlistRepository: service.waitlistRepo, beforeJoin: func() {
+		go func() {
+			_, err := service.CancelBooking(result.Booking.ID, result.Booking.Version)
+			cancelled <- err
+		}()
+		time.Sleep(50 * time.Millisecond)
+	}}
+	position, err := service.JoinWaitlist("Yoga", "2025-06-10", models.WaitlistRequest{MemberName: "Member 1"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, position.Position)
+	require.NoError(t, <-cancelled)
+	bookings := service.bookingRepo.Query(models.BookingFilter{MemberID: position.MemberID, ClassName: "Yoga"})
+	require.Len(t, bookings, 1)
+	assert.Equal(t, constants.BookingStatusBooked, bookings[0].Status)
+	_, waiting := service.waitlistRepo.Peek("Yoga", session)
+	assert.False(t, waiting)
+}
+
+// waitlistMembers returns the members of the waitlist tests, Dave is suspended
+func waitlistMembers() *MockMemberRepo {
+	dave := testMember("mb_dave", "Dave")
+	dave.Status = constants.MemberStatusSuspended
+	frank := testMember("mb_frank", "Frank")
+	frank.Memberships = nil
+	return newMockMemberRepo(testMember("mb_alice", "Alice"), testMember("mb_bob", "Bob"), testMember("mb_carol", "Carol"), dave, frank)
+}
+
+func TestClassService_CancelBooking_PromotesWaitlist(t *testing.T) {
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	booking := models.Booking{ID: "bk_1", ClassName: "Yoga", MemberID: "mb_alice", MemberName: "Alice", Date: date, Status: constants.BookingStatusBooked, Version: 1}
+	promoted := func(id, name string) models.Booking {
+		return models.Booking{ClassName: "Yoga", MemberID: id, MemberName: name, Date: date}
+	}
+
+	tests := []struct {
+		name            string
+		setupMock       func(*MockWaitlistRepo, *MockBookingRepo)
+		expectedPromote []string
+	}{
+		{
+			name: "Head Of Waitlist Promoted",
+			setupMock: func(w *MockWaitlistRepo, b *MockBookingRepo) {
+				w.On("Peek", "Yoga", date).Return("mb_bob", true).Once()
+				b.On("Create", promoted("mb_bob", "Bob"), seats(2)).Return(models.Booking{}, nil).Once()
+				w.On("Leave", "Yoga", "mb_bob", date).Return(nil)
+				w.On("Peek", "Yoga", date).Return("mb_carol", true).Once()
+				b.On("Create", promoted("mb_carol", "Carol"), seats(2)).Return(models.Booking{}, constants.ErrClassFull).Once()
+			},
+			expectedPromote: []string{"mb_bob"},
+		},
+		{
+			name: "Suspended Member Skipped",
+			setupMock: func(w *MockWaitlistRepo, b *MockBookingRepo) {
+				w.On("Peek", "Yoga", date).Return("mb_dave", true).Once()
+				w.On("Leave", "Yoga", "mb_dave", date).Return(nil)
+				w.On("Peek", "Yoga", date).Return("mb_bob", true).Once()
+				b.On("Create", promoted("mb_bob", "Bob"), seats(2)).Return(models.Booking{}, nil).Once()
+				w.On("Leave", "Yoga", "mb_bob", date).Return(nil)
+				w.On("Peek", "Yoga", date).Return("", false)
+			},
+			expectedPromote: []string{"mb_bob"},
+		},
+		{
+			name: "Member Without Entitlement Skipped",
+			setupMock: func(w *MockWaitlistRepo, b *MockBookingRepo) {
+				w.On("Peek", "Yoga", date).Return("mb_frank", true).Once()
+				w.On("Leave", "Yoga", "mb_frank", date).Return(nil)
+				w.On("Peek", "Yoga", date).Return("mb_bob", true).Once()
+				b.On("Create", promoted("mb_bob", "Bob"), seats(2)).Return(models.Booking{}, nil).Once()
+				w.On("Leave", "Yoga", "mb_bob", date).Return(nil)
+				w.On("Peek", "Yoga", date).Return("", false)
+			},
+			expectedPromote: []string{"mb_bob"},
+		},
+		{
+			name: "Already Booked Member Skipped",
+			setupMock: func(w *MockWaitlistRepo, b *MockBookingRepo) {
+				w.On("Peek", "Yoga", date).Return("mb_carol", true).Once()
+				b.On("Create", promoted("mb_carol", "Carol"), seats(2)).Return(models.Booking{}, constants.ErrAlreadyBooked).Once()
+				w.On("Leave", "Yoga", "mb_carol", date).Return(nil)
+				w.On("Peek", "Yoga", date).Return("mb_bob", true).Once()
+				b.On("Create", promoted("mb_bob", "Bob"), seats(2)).Return(models.Booking{}, nil).Once()
+				w.On("Leave", "Yoga", "mb_bob", date).Return(nil)
+				w.On("Peek", "Yoga", date).Return("", false)
+			},
+			expectedPromote: []string{"mb_bob"},
+		},
+		{
+			name: "Entry From Before Members",
+			setupMock: func(w *MockWaitlistRepo, b *MockBookingRepo) {
+				// The entry holds the member name and leaves the waitlist under it
+				w.On("Peek", "Yoga", date).Return("Bob", true).Once()
+				b.On("Create", promoted("mb_bob", "Bob"), seats(2)).Return(models.Booking{}, nil).Once()
+				w.On("Leave", "Yoga", "Bob", date).Return(nil)
+				w.On("Peek", "Yoga", date).Return("", false)
+			},
+			expectedPromote: []string{"Bob"},
+		},
+		{
+			name: "Empty Waitlist",
+			setupMock: func(w *MockWaitlistRepo, b *MockBookingRepo) {
+				w.On("Peek", "Yoga", date).Return("", false)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClassRepo, mockBookingRepo, mockWaitlistRepo := new(MockClassRepo), new(MockBookingRepo), new(MockWaitlistRepo)
+			mockClassRepo.On("GetByName", "Yoga").Return(yogaClass(), true)
+			mockBookingRepo.On("GetByID", "bk_1").Return(booking, true)
+			mockBookingRepo.On("Cancel", "bk_1", 1, mock.Anything, false, "").Return(booking, nil)
+			tt.setupMock(mockWaitlistRepo, mockBookingRepo)
+			service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Waitlists: mockWaitlistRepo, Members: waitlistMembers()})
+			service.now = func() time.Time { return date.AddDate(0, 0, -2) }
+
+			_, err := service.CancelBooking("bk_1", constants.AnyVersion)
+
+			assert.NoError(t, err)
+			for _, member := range tt.expectedPromote {
+				mockWaitlistRepo.AssertCalled(t, "Leave", "Yoga", member, date)
+			}
+			mockWaitlistRepo.AssertExpectations(t)
+			mockBookingRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestClassService_WaitlistPosition(t *testing.T) {
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	mockClassRepo, mockBookingRepo, mockWaitlistRepo := new(MockClassRepo), new(MockBookingRepo), new(MockWaitlistRepo)
+	mockClassRepo.On("GetByName", "Yoga").Return(yogaClass(), true)
+	mockWaitlistRepo.On("Position", "Yoga", "mb_alice", date).Return(2, nil)
+	mockWaitlistRepo.On("Position", "Yoga", "mb_bob", date).Return(0, constants.ErrNotOnWaitlist)
+	mockWaitlistRepo.On("Leave", "Yoga", "mb_alice", date).Return(nil)
+	service := NewClassService(Dependencies{Classes: mockClassRepo, Bookings: mockBookingRepo, Waitlists: mockWaitlistRepo, Members: waitlistMembers()})
+
+	// Members are given by ID, or by name for older clients
+	for _, member := range []string{"mb_alice", "Alice"} {
+		position, err := service.WaitlistPosition("Yoga", member, "2025-06-10")
+		assert.NoError(t, err)
+		assert.Equal(t, models.WaitlistPosition{MemberID: "mb_alice", MemberName: "Alice", Position: 2}, position)
+	}
+
+	_, err := service.WaitlistPosition("Yoga", "mb_bob", "2025-06-10")
+	assert.Equal(t, constants.ErrNotOnWaitlist, err)
+	_, err = service.WaitlistPosition("Yoga", "mb_erin", "2025-06-10")
+	assert.Equal(t, constants.ErrMemberNotFound, err)
+
+	assert.NoError(t, service.LeaveWaitlist("Yoga", "mb_alice", "2025-06-10"))
+}
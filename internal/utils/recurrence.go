@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"fmt"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// weekdayCodes maps RRULE day codes to weekdays
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WeekdayCode returns the RRULE day code of a weekday
+func WeekdayCode(day time.Weekday) string {
+	return strings.ToUpper(day.String()[:2])
+}
+
+// ParseWeekday parses an RRULE day code such as MO, case insensitively
+func ParseWeekday(code string) (time.Weekday, error) {
+	day, ok := weekdayCodes[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown weekday %q", constants.ErrInvalidRecurrence, code)
+	}
+	return day, nil
+}
+
+// ParseRRule parses the FREQ, INTERVAL and BYDAY parts of an RRULE such as
+// FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE,FR
+func ParseRRule(rule string) (models.Recurrence, error) {
+	var recurrence models.Recurrence
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return models.Recurrence{}, fmt.Errorf("%w: malformed rrule part %q", constants.ErrInvalidRecurrence, part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			recurrence.Frequency = strings.ToLower(value)
+			switch recurrence.Frequency {
+			case constants.FrequencyDaily, constants.FrequencyWeekly, constants.FrequencyMonthly:
+			default:
+				return models.Recurrence{}, fmt.Errorf("%w: unsupported frequency %q", constants.ErrInvalidRecurrence, value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return models.Recurrence{}, fmt.Errorf("%w: invalid interval %q", constants.ErrInvalidRecurrence, value)
+			}
+			recurrence.Interval = interval
+		case "BYDAY":
+			recurrence.Weekdays = strings.Split(value, ",")
+		default:
+			return models.Recurrence{}, fmt.Errorf("%w: unsupported rrule part %q", constants.ErrInvalidRecurrence, key)
+		}
+	}
+	if recurrence.Frequency == "" {
+		return models.Recurrence{}, fmt.Errorf("%w: rrule needs a FREQ", constants.ErrInvalidRecurrence)
+	}
+	return recurrence, nil
+}
+
+// OccursOn reports whether the class runs on the given calendar date
+func OccursOn(class models.Class, date time.Time) bool {
+	date = ToMidnightUTC(date)
+	start := ToMidnightUTC(class.StartDate)
+	if !IsDateInRange(date, start, class.EndDate) {
+		return false
+	}
+
+	recurrence := class.Recurrence
+	for _, excluded := range recurrence.Exclusions {
+		if ToMidnightUTC(excluded).Equal(date) {
+			return false
+		}
+	}
+
+	interval := max(recurrence.Interval, 1)
+	switch recurrence.Frequency {
+	case constants.FrequencyWeekly:
+		weekdays := recurrence.Weekdays
+		if len(weekdays) == 0 {
+			weekdays = []string{WeekdayCode(start.Weekday())}
+		}
+		if !slices.Contains(weekdays, WeekdayCode(date.Weekday())) {
+			return false
+		}
+		// Weeks start on Monday, like the RRULE default
+		weeks := daysBetween(weekStart(start), weekStart(date)) / 7
+		return weeks%interval == 0
+	case constants.FrequencyMonthly:
+		months := (date.Year()-start.Year())*12 + int(date.Month()-start.Month())
+		return date.Day() == start.Day() && months%interval == 0
+	default:
+		return daysBetween(start, date)%interval == 0
+	}
+}
+
+// daysBetween returns the number of days from one midnight to a later one
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// weekStart returns the Monday of the week of date
+func weekStart(date time.Time) time.Time {
+	return date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+}
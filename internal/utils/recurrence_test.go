@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOccursOn(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2025, m, d, 0, 0, 0, 0, time.UTC) }
+	class := func(recurrence models.Recurrence) models.Class {
+		// Monday 2 June to Sunday 31 August 2025
+		return models.Class{Name: "Yoga", StartDate: day(6, 2), EndDate: day(8, 31), Recurrence: recurrence}
+	}
+
+	tests := []struct {
+		name       string
+		recurrence models.Recurrence
+		occurs     []time.Time
+		skips      []time.Time
+	}{
+		{
+			name:   "Zero Value Runs Daily",
+			occurs: []time.Time{day(6, 2), day(6, 3), day(8, 31)},
+			skips:  []time.Time{day(6, 1), day(9, 1)},
+		},
+		{
+			name:       "Every Other Day",
+			recurrence: models.Recurrence{Frequency: constants.FrequencyDaily, Interval: 2},
+			occurs:     []time.Time{day(6, 2), day(6, 4), day(7, 2)},
+			skips:      []time.Time{day(6, 3), day(7, 1)},
+		},
+		{
+			name:       "Weekly On Start Weekday",
+			recurrence: models.Recurrence{Frequency: constants.FrequencyWeekly, Interval: 1},
+			occurs:     []time.Time{day(6, 2), day(6, 9), day(8, 25)},
+			skips:      []time.Time{day(6, 3), day(6, 8)},
+		},
+		{
+			name:       "Fortnightly Mon Wed Fri",
+			recurrence: models.Recurrence{Frequency: constants.FrequencyWeekly, Interval: 2, Weekdays: []string{"MO", "WE", "FR"}},
+			occurs:     []time.Time{day(6, 2), day(6, 4), day(6, 6), day(6, 16), day(6, 20)},
+			skips:      []time.Time{day(6, 3), day(6, 9), day(6, 11), day(6, 13), day(6, 21)},
+		},
+		{
+			name:       "Monthly On Start Day",
+			recurrence: models.Recurrence{Frequency: constants.FrequencyMonthly, Interval: 1},
+			occurs:     []time.Time{day(6, 2), day(7, 2), day(8, 2)},
+			skips:      []time.Time{day(6, 3), day(7, 1)},
+		},
+		{
+			name:       "Exclusions",
+			recurrence: models.Recurrence{Frequency: constants.FrequencyDaily, Interval: 1, Exclusions: []time.Time{day(6, 9)}},
+			occurs:     []time.Time{day(6, 8), day(6, 10)},
+			skips:      []time.Time{day(6, 9), day(6, 9).Add(18 * time.Hour)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, date := range tt.occurs {
+				assert.True(t, OccursOn(class(tt.recurrence), date), "expected an occurrence on %s", date.Format(constants.DateFormat))
+			}
+			for _, date := range tt.skips {
+				assert.False(t, OccursOn(class(tt.recurrence), date), "expected no occurrence on %s", date.Format(constants.DateFormat))
+			}
+		})
+	}
+}
+
+func TestParseRRule(t *testing.T) {
+	recurrence, err := ParseRRule("RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE")
+	assert.NoError(t, err)
+	assert.Equal(t, models.Recurrence{Frequency: constants.FrequencyWeekly, Interval: 2, Weekdays: []string{"MO", "WE"}}, recurrence)
+
+	for _, rule := range []string{"", "INTERVAL=2", "FREQ=HOURLY", "FREQ=DAILY;INTERVAL=0", "FREQ=DAILY;COUNT=10", "FREQ"} {
+		_, err := ParseRRule(rule)
+		assert.ErrorIs(t, err, constants.ErrInvalidRecurrence, "rule %q", rule)
+	}
+}
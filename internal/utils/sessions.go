@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"fmt"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"time"
+)
+
+// ParseTimeOfDay parses a HH:MM time of day into its offset from midnight
+func ParseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse(constants.TimeFormat, s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q, expected HH:MM", constants.ErrInvalidSessionTime, s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Sessions returns the sessions the class runs on the calendar date in start
+// order, with their start and end in UTC and their capacity set. Session times
+// are wall clock times in the time zone of the class.
+func Sessions(class models.Class, date time.Time) []models.Session {
+	if !OccursOn(class, date) {
+		return nil
+	}
+	date = ToMidnightUTC(date)
+	loc := ClassLocation(class)
+	if len(class.SessionTimes) == 0 {
+		return []models.Session{{Date: LocalTime(date, 0, loc), Capacity: class.Capacity}}
+	}
+
+	sessions := make([]models.Session, 0, len(class.SessionTimes))
+	for _, sessionTime := range class.SessionTimes {
+		offset, err := ParseTimeOfDay(sessionTime.Start)
+		if err != nil {
+			// Session times are validated when the class is created
+			continue
+		}
+		start := LocalTime(date, offset, loc)
+		end := start.Add(time.Duration(sessionTime.DurationMinutes) * time.Minute)
+		sessions = append(sessions, models.Session{Date: start, End: &end, Capacity: class.Capacity})
+	}
+	return sessions
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessions(t *testing.T) {
+	date := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	class := models.Class{Name: "Yoga", StartDate: date, EndDate: date, Capacity: 5}
+
+	// Classes without session times run one session starting at midnight
+	assert.Equal(t, []models.Session{{Date: date, Capacity: 5}}, Sessions(class, date.Add(9*time.Hour)))
+
+	class.SessionTimes = []models.SessionTime{{Start: "07:00", DurationMinutes: 60}, {Start: "18:30", DurationMinutes: 45}}
+	morningEnd, eveningEnd := date.Add(8*time.Hour), date.Add(19*time.Hour+15*time.Minute)
+	assert.Equal(t, []models.Session{
+		{Date: date.Add(7 * time.Hour), End: &morningEnd, Capacity: 5},
+		{Date: date.Add(18*time.Hour + 30*time.Minute), End: &eveningEnd, Capacity: 5},
+	}, Sessions(class, date))
+
+	assert.Empty(t, Sessions(class, date.AddDate(0, 0, 1)))
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	offset, err := ParseTimeOfDay("07:45")
+	assert.NoError(t, err)
+	assert.Equal(t, 7*time.Hour+45*time.Minute, offset)
+
+	for _, s := range []string{"", "7am", "24:00", "07:60", "07:00:00"} {
+		_, err := ParseTimeOfDay(s)
+		assert.ErrorIs(t, err, constants.ErrInvalidSessionTime, "time %q", s)
+	}
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"fmt"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"sync"
+	"time"
+)
+
+// locations caches loaded time zones by name
+var locations sync.Map
+
+// LoadLocation returns the IANA time zone with the given name, an empty name is UTC
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	// Local depends on the host, studios must name their zone
+	if name == "Local" {
+		return nil, fmt.Errorf("%w: %q", constants.ErrInvalidTimeZone, name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", constants.ErrInvalidTimeZone, name)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// ClassLocation returns the time zone of a class
+func ClassLocation(class models.Class) *time.Location {
+	loc, err := LoadLocation(class.TimeZone)
+	if err != nil {
+		// Time zones are validated when the class is created
+		return time.UTC
+	}
+	return loc
+}
+
+// LocalDate returns the calendar date of t in loc. Calendar dates are
+// represented as midnight UTC so that they compare and step by whole days.
+func LocalDate(t time.Time, loc *time.Location) time.Time {
+	return ToMidnightUTC(t.In(loc))
+}
+
+// LocalTime returns the instant at which the wall clock in loc shows offset
+// past midnight on the calendar date. A time skipped by a daylight saving
+// transition moves forward by the length of the gap, 02:30 becomes 03:30.
+func LocalTime(date time.Time, offset time.Duration, loc *time.Location) time.Time {
+	hours, minutes := int(offset/time.Hour), int(offset%time.Hour/time.Minute)
+	t := time.Date(date.Year(), date.Month(), date.Day(), hours, minutes, 0, 0, loc)
+	if local := t.In(loc); local.Hour() != hours || local.Minute() != minutes {
+		// Keep the UTC offset in effect before the gap
+		_, before := t.Add(-24 * time.Hour).In(loc).Zone()
+		t = time.Date(date.Year(), date.Month(), date.Day(), hours, minutes, 0, 0, time.UTC).Add(-time.Duration(before) * time.Second)
+	}
+	return t.UTC()
+}
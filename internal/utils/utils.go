@@ -1,20 +1,36 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"glofox/internal/apierrors"
 	"glofox/internal/constants"
 	"glofox/internal/models"
 	"log"
+	"net/http"
 	"time"
 )
 
-// HandleErrorResp logs and writes error on the context
-func HandleErrorResp(ctx *gin.Context, statusCode int, err error, errMsg string) {
-	log.Println(errMsg, err)
-	ctx.JSON(statusCode, models.Response{
-		Status:  "error",
-		Message: errMsg + err.Error(),
-	})
+// WriteProblem logs err and writes it on the context as an RFC 7807 problem
+// with the code and HTTP status of the error registry
+func WriteProblem(ctx *gin.Context, err error) {
+	log.Println(err)
+	apiErr := apierrors.From(err)
+	problem := models.Problem{
+		Type:     constants.ProblemTypePrefix + apiErr.Code,
+		Title:    http.StatusText(apiErr.Status),
+		Status:   apiErr.Status,
+		Detail:   apiErr.Error(),
+		Instance: ctx.Request.URL.Path,
+		Code:     apiErr.Code,
+		Errors:   apiErr.Fields,
+	}
+	ctx.Header("Content-Type", constants.ContentTypeProblem)
+	ctx.JSON(apiErr.Status, problem)
 }
 
 // ToMidnightUTC normalizes a time.Time to midnight UTC (00:00:00.000).
@@ -22,7 +38,28 @@ func ToMidnightUTC(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
 
-// IsDateInRange validates if the date is in specified range
+// localDateTimeFormats are the accepted forms of a wall clock time without an offset
+var localDateTimeFormats = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// ParseDateTime parses a date (YYYY-MM-DD), an RFC 3339 instant or a wall clock
+// time without an offset in loc, and returns the instant in UTC. A date is the
+// instant its day starts in loc, dateOnly reports that form.
+func ParseDateTime(s string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.ParseInLocation(constants.DateFormat, s, loc); err == nil {
+		return t.UTC(), true, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), false, nil
+	}
+	for _, format := range localDateTimeFormats {
+		if t, err := time.ParseInLocation(format, s, loc); err == nil {
+			return t.UTC(), false, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("cannot parse %q as a date or time", s)
+}
+
+// IsDateInRange validates if the calendar date is in specified range
 func IsDateInRange(date, start, end time.Time) bool {
 	date = ToMidnightUTC(date)
 	start = ToMidnightUTC(start)
@@ -37,3 +74,44 @@ func IsValidDate(startDate, endDate time.Time) error {
 	}
 	return nil
 }
+
+// NewID returns a random identifier prefixed with prefix
+func NewID(prefix string) string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return prefix + hex.EncodeToString(b)
+}
+
+// EncodeCursor serializes a pagination position into an opaque cursor
+func EncodeCursor(position interface{}) string {
+	b, err := json.Marshal(position)
+	if err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a cursor produced by EncodeCursor into position
+func DecodeCursor(cursor string, position interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return constants.ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, position); err != nil {
+		return constants.ErrInvalidCursor
+	}
+	return nil
+}
+
+// PageLimit applies the default and maximum page size to a requested limit
+func PageLimit(limit int) int {
+	if limit <= 0 {
+		return constants.DefaultPageLimit
+	}
+	if limit > constants.MaxPageLimit {
+		return constants.MaxPageLimit
+	}
+	return limit
+}
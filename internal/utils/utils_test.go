@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"glofox/internal/apierrors"
+	"glofox/internal/constants"
+	"glofox/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mustLoad loads a time zone or fails the test
+func mustLoad(t *testing.T, name string) *time.Location {
+	loc, err := LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
+
+func TestParseDateTime(t *testing.T) {
+	sydney := mustLoad(t, "Australia/Sydney")
+
+	date, dateOnly, err := ParseDateTime("2025-06-10", time.UTC)
+	assert.NoError(t, err)
+	assert.True(t, dateOnly)
+	assert.Equal(t, time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC), date)
+
+	// A date is the instant the day starts in the given zone
+	date, dateOnly, err = ParseDateTime("2025-06-10", sydney)
+	assert.NoError(t, err)
+	assert.True(t, dateOnly)
+	assert.Equal(t, time.Date(2025, 6, 9, 14, 0, 0, 0, time.UTC), date)
+
+	// Instants with an offset ignore the zone
+	instant, dateOnly, err := ParseDateTime("2025-06-10T07:00:00+02:00", sydney)
+	assert.NoError(t, err)
+	assert.False(t, dateOnly)
+	assert.Equal(t, time.Date(2025, 6, 10, 5, 0, 0, 0, time.UTC), instant)
+
+	// Wall clock times without an offset are in the given zone
+	instant, dateOnly, err = ParseDateTime("2025-06-10T07:00", sydney)
+	assert.NoError(t, err)
+	assert.False(t, dateOnly)
+	assert.Equal(t, time.Date(2025, 6, 9, 21, 0, 0, 0, time.UTC), instant)
+
+	for _, s := range []string{"", "2025/06/10", "2025-06-10 07:00", "2025-06-10T07"} {
+		_, _, err := ParseDateTime(s, time.UTC)
+		assert.Error(t, err, "date %q", s)
+	}
+}
+
+func TestLoadLocation(t *testing.T) {
+	loc, err := LoadLocation("")
+	assert.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+
+	for _, name := range []string{"Mars/Olympus_Mons", "Local", "+10:00"} {
+		_, err := LoadLocation(name)
+		assert.Error(t, err, "zone %q", name)
+	}
+}
+
+func TestIsDateInRange_DSTTransitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		zone    string
+		instant string
+		start   time.Time
+		end     time.Time
+		inRange bool
+	}{
+		{
+			// Sydney leaves daylight saving on 6 April 2025, 00:30 local is still 5 April in UTC
+			name:    "Sydney After End Of DST",
+			zone:    "Australia/Sydney",
+			instant: "2025-04-06T00:30:00+11:00",
+			start:   time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+			end:     time.Date(2025, 4, 5, 0, 0, 0, 0, time.UTC),
+			inRange: false,
+		},
+		{
+			name:    "Sydney Last Local Day Of Range",
+			zone:    "Australia/Sydney",
+			instant: "2025-04-05T23:30:00+11:00",
+			start:   time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+			end:     time.Date(2025, 4, 5, 0, 0, 0, 0, time.UTC),
+			inRange: true,
+		},
+		{
+			// Sydney enters daylight saving on 5 October 2025, the day is 23 hours long
+			name:    "Sydney Start Of DST",
+			zone:    "Australia/Sydney",
+			instant: "2025-10-05T23:59:00+11:00",
+			start:   time.Date(2025, 10, 5, 0, 0, 0, 0, time.UTC),
+			end:     time.Date(2025, 10, 5, 0, 0, 0, 0, time.UTC),
+			inRange: true,
+		},
+		{
+			// New York enters daylight saving on 9 March 2025, 23:30 local is 10 March in UTC
+			name:    "New York Start Of DST",
+			zone:    "America/New_York",
+			instant: "2025-03-09T23:30:00-04:00",
+			start:   time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC),
+			end:     time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC),
+			inRange: true,
+		},
+		{
+			name:    "New York Before Start Of Range",
+			zone:    "America/New_York",
+			instant: "2025-11-01T22:00:00-04:00",
+			start:   time.Date(2025, 11, 2, 0, 0, 0, 0, time.UTC),
+			end:     time.Date(2025, 11, 3, 0, 0, 0, 0, time.UTC),
+			inRange: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := mustLoad(t, tt.zone)
+			instant, _, err := ParseDateTime(tt.instant, loc)
+			require.NoError(t, err)
+			assert.Equal(t, tt.inRange, IsDateInRange(LocalDate(instant, loc), tt.start, tt.end))
+		})
+	}
+}
+
+func TestSessions_DSTTransitions(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	class := models.Class{
+		Name:         "Yoga",
+		StartDate:    time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC),
+		EndDate:      time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		Capacity:     10,
+		TimeZone:     "America/New_York",
+		SessionTimes: []models.SessionTime{{Start: "02:30", DurationMinutes: 60}, {Start: "07:00", DurationMinutes: 60}},
+	}
+
+	// 07:00 stays 07:00 on the wall clock, so the UTC instant moves by an hour
+	before := Sessions(class, time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC))
+	after := Sessions(class, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC), before[1].Date)
+	assert.Equal(t, time.Date(2025, 3, 10, 11, 0, 0, 0, time.UTC), after[1].Date)
+
+	// 02:30 does not exist on 9 March, the session moves to 03:30 daylight time
+	transition := Sessions(class, time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, "03:30", transition[0].Date.In(newYork).Format("15:04"))
+	assert.Equal(t, time.Date(2025, 3, 9, 11, 0, 0, 0, time.UTC), transition[1].Date)
+
+	// Classes without session times start at local midnight
+	class.SessionTimes = nil
+	sessions := Sessions(class, time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2025, 3, 9, 5, 0, 0, 0, time.UTC), sessions[0].Date)
+}
+
+func TestWriteProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/classes", nil)
+
+	WriteProblem(ctx, apierrors.Field(constants.ErrInvalidEndDate, "end_date"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, constants.ContentTypeProblem, w.Header().Get("Content-Type"))
+	var problem models.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, models.Problem{
+		Type:     constants.ProblemTypePrefix + "invalid_date",
+		Title:    "Bad Request",
+		Status:   http.StatusBadRequest,
+		Detail:   constants.ErrInvalidEndDate.Error(),
+		Instance: "/classes",
+		Code:     "invalid_date",
+		Errors:   []models.ProblemField{{Field: "end_date", Message: constants.ErrInvalidEndDate.Error()}},
+	}, problem)
+}